@@ -4,11 +4,11 @@ set -euo pipefail
 
 # ===== 配置项 =====
 LOCAL_ARCHIVE_DIR="/home/mc/server/archive"
-OSS_BUCKET="{{ .OSSRoot }}"
+OSS_ARCHIVE="{{ .ArchiveOSSPath }}"
 
-ARCHIVE="archive"
-ARCHIVE_NEW="archive-new"
-ARCHIVE_OLD="archive-old"
+ARCHIVE="${OSS_ARCHIVE}"
+ARCHIVE_NEW="${OSS_ARCHIVE}-new"
+ARCHIVE_OLD="${OSS_ARCHIVE}-old"
 
 has_objects() {
     ossutil ls "$1" | awk '/Object Number is:/ {print $4}' | grep -qv '^0$'
@@ -25,23 +25,23 @@ echo "上传新归档 -> ${ARCHIVE_NEW}"
 
 ossutil cp -r -f \
     "${LOCAL_ARCHIVE_DIR}" \
-    "${OSS_BUCKET}/${ARCHIVE_NEW}/"
+    "${ARCHIVE_NEW}/"
 
 # ===== Step 2: 删除已有 archive-old =====
-if has_objects "${OSS_BUCKET}/${ARCHIVE_OLD}/"; then
+if has_objects "${ARCHIVE_OLD}/"; then
     echo "删除旧的 ${ARCHIVE_OLD}"
-    ossutil rm -rf "${OSS_BUCKET}/${ARCHIVE_OLD}/"
+    ossutil rm -rf "${ARCHIVE_OLD}/"
 fi
 
 # ===== Step 3: archive -> archive-old（复制）=====
-if has_objects "${OSS_BUCKET}/${ARCHIVE}/"; then
+if has_objects "${ARCHIVE}/"; then
     echo "复制 ${ARCHIVE} -> ${ARCHIVE_OLD}"
     ossutil cp -r -f \
-        "${OSS_BUCKET}/${ARCHIVE}/" \
-        "${OSS_BUCKET}/${ARCHIVE_OLD}/"
+        "${ARCHIVE}/" \
+        "${ARCHIVE_OLD}/"
 
     echo "删除原 ${ARCHIVE}"
-    ossutil rm -rf "${OSS_BUCKET}/${ARCHIVE}/"
+    ossutil rm -rf "${ARCHIVE}/"
 else
     echo "未发现 ${ARCHIVE}，跳过 archive-old 生成"
 fi
@@ -50,10 +50,10 @@ fi
 echo "复制 ${ARCHIVE_NEW} -> ${ARCHIVE}"
 
 ossutil cp -r -f \
-    "${OSS_BUCKET}/${ARCHIVE_NEW}/" \
-    "${OSS_BUCKET}/${ARCHIVE}/"
+    "${ARCHIVE_NEW}/" \
+    "${ARCHIVE}/"
 
 echo "删除 ${ARCHIVE_NEW}"
-ossutil rm -rf "${OSS_BUCKET}/${ARCHIVE_NEW}/"
+ossutil rm -rf "${ARCHIVE_NEW}/"
 
 echo "归档轮转完成"
\ No newline at end of file
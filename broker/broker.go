@@ -1,6 +1,11 @@
 // Package broker 提供一个简单的 PubSub 结构以方便进程之间的一对多通讯，实现比一般 channel 更广泛的用途。Broker 尤其用于监控器（monitors）进程和其它进程的信息交流中。Broker 的源代码来自 https://stackoverflow.com/a/49877632
 package broker
 
+import "sync/atomic"
+
+// Dropped 统计所有 Broker 因订阅者的缓冲区已满而丢弃的消息数量
+var Dropped atomic.Int64
+
 type Broker[T any] struct {
 	stopCh    chan struct{}
 	publishCh chan T
@@ -34,6 +39,7 @@ func (b *Broker[T]) Start() {
 				select {
 				case msgCh <- msg:
 				default:
+					Dropped.Add(1)
 				}
 			}
 		}
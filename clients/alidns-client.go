@@ -0,0 +1,15 @@
+package clients
+
+import (
+	"github.com/Subilan/go-aliyunmc/config"
+	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// ShouldCreateAlidnsClient 根据凭据创建一个用于调用云解析（Alidns）接口的通用客户端。云解析没有引入专门的 SDK，接口通过 openapi.Client.CallApiWithCtx 调用
+func ShouldCreateAlidnsClient() (*openapi.Client, error) {
+	return openapi.NewClient(&openapi.Config{
+		Credential: MustGetAKCredential(),
+		Endpoint:   tea.String(config.Cfg.Aliyun.AlidnsEndpoint()),
+	})
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // BssClient 是系统全局费用服务客户端
-var BssClient *bss20171214.Client
+var BssClient BssProvider
 
 // ShouldCreateBssClient 根据凭据创建一个费用服务客户端
 func ShouldCreateBssClient() (*bss20171214.Client, error) {
@@ -4,6 +4,9 @@
 //   - 云服务器（ECS），用于对实例进行管理、查询等，是系统使用的核心阿里云服务。
 //   - 专有网络（VPC），用于查询和创建实例的默认交换机，以顺利完成实例的创建。
 //   - 费用（BSS），用于查询与系统运转相关的账单信息，实现统计和展示。
+//
+// 全局客户端均以接口类型暴露（见 EcsProvider 等），当配置中 aliyun.provider 为 fake 时，会被替换为 fake 包中的内存模拟实现，
+// 以便在本地完整运行创建、部署、删除等流程。
 package clients
 
 import (
@@ -14,7 +17,7 @@ import (
 )
 
 // EcsClient 是系统全局云服务器服务客户端
-var EcsClient *ecs20140526.Client
+var EcsClient EcsProvider
 
 // ShouldCreateEcsClient 根据凭据创建一个云服务器服务客户端
 func ShouldCreateEcsClient() (*ecs20140526.Client, error) {
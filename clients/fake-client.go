@@ -0,0 +1,23 @@
+package clients
+
+import (
+	"github.com/Subilan/go-aliyunmc/clients/fake"
+	"github.com/Subilan/go-aliyunmc/config"
+)
+
+var (
+	_ EcsProvider = (*fake.Provider)(nil)
+	_ VpcProvider = (*fake.Provider)(nil)
+	_ BssProvider = (*fake.Provider)(nil)
+	_ OssProvider = (*fake.Provider)(nil)
+)
+
+// UseFakeProvider 将全部全局客户端替换为同一个内存模拟实现，不需要任何阿里云凭据
+func UseFakeProvider() {
+	p := fake.New(config.Cfg.Aliyun.RegionId, config.Cfg.Aliyun.FakePublicIp)
+
+	EcsClient = p
+	VpcClient = p
+	BssClient = p
+	OssClient = p
+}
@@ -0,0 +1,40 @@
+package fake
+
+import (
+	"context"
+
+	bss20171214 "github.com/alibabacloud-go/bssopenapi-20171214/v6/client"
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+func (p *Provider) QueryAccountBalanceWithOptions(_ *dara.RuntimeOptions) (*bss20171214.QueryAccountBalanceResponse, error) {
+	return &bss20171214.QueryAccountBalanceResponse{
+		Body: &bss20171214.QueryAccountBalanceResponseBody{
+			RequestId: requestId(),
+			Success:   tea.Bool(true),
+			Data: &bss20171214.QueryAccountBalanceResponseBodyData{
+				AvailableAmount:     tea.String("100.00"),
+				AvailableCashAmount: tea.String("100.00"),
+				Currency:            tea.String("CNY"),
+			},
+		},
+	}, nil
+}
+
+func (p *Provider) QueryAccountTransactionDetailsWithContext(_ context.Context, _ *bss20171214.QueryAccountTransactionDetailsRequest, _ *dara.RuntimeOptions) (*bss20171214.QueryAccountTransactionDetailsResponse, error) {
+	// 模拟实现不产生任何费用，因此没有交易记录
+	return &bss20171214.QueryAccountTransactionDetailsResponse{
+		Body: &bss20171214.QueryAccountTransactionDetailsResponseBody{
+			RequestId: requestId(),
+			Success:   tea.Bool(true),
+			Data: &bss20171214.QueryAccountTransactionDetailsResponseBodyData{
+				TotalCount: tea.Int32(0),
+				NextToken:  tea.String(""),
+				AccountTransactionsList: &bss20171214.QueryAccountTransactionDetailsResponseBodyDataAccountTransactionsList{
+					AccountTransactionsList: []*bss20171214.QueryAccountTransactionDetailsResponseBodyDataAccountTransactionsListAccountTransactionsList{},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,535 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// instanceTypeOf 返回 cores 核 mem GiB 的模拟实例类型名称
+func instanceTypeOf(cores int32, mem float32) string {
+	return fmt.Sprintf("ecs.fake.c%dm%d", cores, int(mem))
+}
+
+// priceOf 返回模拟实例类型 instanceType 每小时的价格
+func priceOf(instanceType string) float32 {
+	var cores, mem int
+
+	if _, err := fmt.Sscanf(instanceType, "ecs.fake.c%dm%d", &cores, &mem); err != nil {
+		return 1
+	}
+
+	return 0.02*float32(cores) + 0.01*float32(mem)
+}
+
+func (p *Provider) DescribeAvailableResourceWithContext(_ context.Context, request *ecs20140526.DescribeAvailableResourceRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribeAvailableResourceResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var zones []*ecs20140526.DescribeAvailableResourceResponseBodyAvailableZonesAvailableZone
+
+	for _, zoneId := range p.zones {
+		instanceType := instanceTypeOf(tea.Int32Value(request.Cores), tea.Float32Value(request.Memory))
+		status, statusCategory := "Available", "WithStock"
+
+		if p.noStock[stockKey(zoneId, instanceType)] {
+			status, statusCategory = "SoldOut", "WithoutStock"
+		}
+
+		zones = append(zones, &ecs20140526.DescribeAvailableResourceResponseBodyAvailableZonesAvailableZone{
+			ZoneId:         tea.String(zoneId),
+			RegionId:       tea.String(p.regionId),
+			Status:         tea.String("Available"),
+			StatusCategory: tea.String("WithStock"),
+			AvailableResources: &ecs20140526.DescribeAvailableResourceResponseBodyAvailableZonesAvailableZoneAvailableResources{
+				AvailableResource: []*ecs20140526.DescribeAvailableResourceResponseBodyAvailableZonesAvailableZoneAvailableResourcesAvailableResource{
+					{
+						Type: tea.String("InstanceType"),
+						SupportedResources: &ecs20140526.DescribeAvailableResourceResponseBodyAvailableZonesAvailableZoneAvailableResourcesAvailableResourceSupportedResources{
+							SupportedResource: []*ecs20140526.DescribeAvailableResourceResponseBodyAvailableZonesAvailableZoneAvailableResourcesAvailableResourceSupportedResourcesSupportedResource{
+								{
+									Value:          tea.String(instanceType),
+									Status:         tea.String(status),
+									StatusCategory: tea.String(statusCategory),
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return &ecs20140526.DescribeAvailableResourceResponse{
+		Body: &ecs20140526.DescribeAvailableResourceResponseBody{
+			RequestId:      requestId(),
+			AvailableZones: &ecs20140526.DescribeAvailableResourceResponseBodyAvailableZones{AvailableZone: zones},
+		},
+	}, nil
+}
+
+func (p *Provider) DescribePriceWithContext(_ context.Context, request *ecs20140526.DescribePriceRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribePriceResponse, error) {
+	price := priceOf(tea.StringValue(request.InstanceType))
+
+	return &ecs20140526.DescribePriceResponse{
+		Body: &ecs20140526.DescribePriceResponseBody{
+			RequestId: requestId(),
+			PriceInfo: &ecs20140526.DescribePriceResponseBodyPriceInfo{
+				Price: &ecs20140526.DescribePriceResponseBodyPriceInfoPrice{
+					Currency:      tea.String("CNY"),
+					OriginalPrice: tea.Float32(price * 10),
+					DiscountPrice: tea.Float32(price * 9),
+					TradePrice:    tea.Float32(price),
+				},
+			},
+		},
+	}, nil
+}
+
+func (p *Provider) CreateInstanceWithContext(_ context.Context, request *ecs20140526.CreateInstanceRequest, _ *dara.RuntimeOptions) (*ecs20140526.CreateInstanceResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	zoneId := tea.StringValue(request.ZoneId)
+
+	if !slices.Contains(p.zones, zoneId) {
+		return nil, sdkError("InvalidZoneId.NotFound", "The specified zone does not exist.")
+	}
+
+	if tea.StringValue(request.VSwitchId) == "" || p.vswitches[zoneId] != tea.StringValue(request.VSwitchId) {
+		return nil, sdkError("InvalidVSwitchId.NotFound", "The specified vswitch does not exist in the zone.")
+	}
+
+	if p.noStock[stockKey(zoneId, tea.StringValue(request.InstanceType))] {
+		return nil, sdkError("OperationDenied.NoStock", "The requested resource is sold out in the specified zone.")
+	}
+
+	imageId := tea.StringValue(request.ImageId)
+
+	if imageId == "" {
+		return nil, sdkError("InvalidImageId.NotFound", "The specified image does not exist.")
+	}
+
+	for _, d := range request.DataDisk {
+		if s, ok := p.snapshots[tea.StringValue(d.SnapshotId)]; ok && s.diskSize > int(tea.Int32Value(d.Size)) {
+			return nil, sdkError("InvalidDataDiskSize.ValueNotSupported", "The data disk size cannot be smaller than the snapshot.")
+		}
+	}
+
+	inst := &instance{
+		id:           p.nextId("i"),
+		instanceType: tea.StringValue(request.InstanceType),
+		zoneId:       zoneId,
+		vswitchId:    tea.StringValue(request.VSwitchId),
+		imageId:      imageId,
+		hostName:     tea.StringValue(request.HostName),
+		status:       "Stopped",
+		createdAt:    time.Now(),
+	}
+
+	p.instances[inst.id] = inst
+
+	systemDisk := &disk{id: p.nextId("d"), instanceId: inst.id, diskType: "system", size: 20}
+
+	if request.SystemDisk != nil {
+		systemDisk.category = tea.StringValue(request.SystemDisk.Category)
+		systemDisk.size = int(tea.Int32Value(request.SystemDisk.Size))
+	}
+
+	p.disks[systemDisk.id] = systemDisk
+
+	for _, d := range request.DataDisk {
+		dataDisk := &disk{
+			id:               p.nextId("d"),
+			instanceId:       inst.id,
+			diskType:         "data",
+			category:         tea.StringValue(d.Category),
+			size:             int(tea.Int32Value(d.Size)),
+			sourceSnapshotId: tea.StringValue(d.SnapshotId),
+		}
+
+		p.disks[dataDisk.id] = dataDisk
+	}
+
+	return &ecs20140526.CreateInstanceResponse{
+		Body: &ecs20140526.CreateInstanceResponseBody{
+			RequestId:  requestId(),
+			InstanceId: tea.String(inst.id),
+			TradePrice: tea.Float32(priceOf(inst.instanceType)),
+		},
+	}, nil
+}
+
+// getInstance 返回实例 instanceId。调用者需要持有锁
+func (p *Provider) getInstance(instanceId *string) (*instance, error) {
+	inst, ok := p.instances[tea.StringValue(instanceId)]
+
+	if !ok {
+		return nil, sdkError("InvalidInstanceId.NotFound", "The specified instance does not exist.")
+	}
+
+	return inst, nil
+}
+
+func (p *Provider) StartInstanceWithContext(_ context.Context, request *ecs20140526.StartInstanceRequest, _ *dara.RuntimeOptions) (*ecs20140526.StartInstanceResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inst, err := p.getInstance(request.InstanceId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.status != "Stopped" {
+		return nil, sdkError("IncorrectInstanceStatus", "The current status of the resource does not support this operation.")
+	}
+
+	inst.status = "Running"
+	inst.stoppedMode = ""
+
+	return &ecs20140526.StartInstanceResponse{Body: &ecs20140526.StartInstanceResponseBody{RequestId: requestId()}}, nil
+}
+
+func (p *Provider) StopInstanceWithContext(_ context.Context, request *ecs20140526.StopInstanceRequest, _ *dara.RuntimeOptions) (*ecs20140526.StopInstanceResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inst, err := p.getInstance(request.InstanceId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.status != "Running" {
+		return nil, sdkError("IncorrectInstanceStatus", "The current status of the resource does not support this operation.")
+	}
+
+	inst.status = "Stopped"
+	inst.stoppedMode = tea.StringValue(request.StoppedMode)
+
+	// 节省停机模式会释放公网IP地址
+	if inst.stoppedMode == "StopCharging" {
+		inst.ip = ""
+	}
+
+	return &ecs20140526.StopInstanceResponse{Body: &ecs20140526.StopInstanceResponseBody{RequestId: requestId()}}, nil
+}
+
+func (p *Provider) DeleteInstanceWithContext(_ context.Context, request *ecs20140526.DeleteInstanceRequest, _ *dara.RuntimeOptions) (*ecs20140526.DeleteInstanceResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inst, err := p.getInstance(request.InstanceId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.status != "Stopped" && !tea.BoolValue(request.Force) {
+		return nil, sdkError("IncorrectInstanceStatus", "The current status of the resource does not support this operation.")
+	}
+
+	delete(p.instances, inst.id)
+
+	for id, d := range p.disks {
+		if d.instanceId == inst.id {
+			delete(p.disks, id)
+		}
+	}
+
+	return &ecs20140526.DeleteInstanceResponse{Body: &ecs20140526.DeleteInstanceResponseBody{RequestId: requestId()}}, nil
+}
+
+func (p *Provider) AllocatePublicIpAddressWithContext(_ context.Context, request *ecs20140526.AllocatePublicIpAddressRequest, _ *dara.RuntimeOptions) (*ecs20140526.AllocatePublicIpAddressResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	inst, err := p.getInstance(request.InstanceId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.ip != "" {
+		return nil, sdkError("AllocatedAlready", "The public ip address has been allocated.")
+	}
+
+	inst.ip = p.publicIp
+
+	return &ecs20140526.AllocatePublicIpAddressResponse{
+		Body: &ecs20140526.AllocatePublicIpAddressResponseBody{RequestId: requestId(), IpAddress: tea.String(inst.ip)},
+	}, nil
+}
+
+func (p *Provider) DescribeInstanceStatusWithContext(_ context.Context, request *ecs20140526.DescribeInstanceStatusRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribeInstanceStatusResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var statuses []*ecs20140526.DescribeInstanceStatusResponseBodyInstanceStatusesInstanceStatus
+
+	for _, id := range request.InstanceId {
+		if inst, ok := p.instances[tea.StringValue(id)]; ok {
+			statuses = append(statuses, &ecs20140526.DescribeInstanceStatusResponseBodyInstanceStatusesInstanceStatus{
+				InstanceId: tea.String(inst.id),
+				Status:     tea.String(inst.status),
+			})
+		}
+	}
+
+	return &ecs20140526.DescribeInstanceStatusResponse{
+		Body: &ecs20140526.DescribeInstanceStatusResponseBody{
+			RequestId:        requestId(),
+			TotalCount:       tea.Int32(int32(len(statuses))),
+			InstanceStatuses: &ecs20140526.DescribeInstanceStatusResponseBodyInstanceStatuses{InstanceStatus: statuses},
+		},
+	}, nil
+}
+
+func (p *Provider) DescribeInstancesWithContext(_ context.Context, request *ecs20140526.DescribeInstancesRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribeInstancesResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []*ecs20140526.DescribeInstancesResponseBodyInstancesInstance
+
+	for _, id := range parseIds(request.InstanceIds) {
+		inst, ok := p.instances[id]
+
+		if !ok {
+			continue
+		}
+
+		publicIp := &ecs20140526.DescribeInstancesResponseBodyInstancesInstancePublicIpAddress{}
+
+		if inst.ip != "" {
+			publicIp.IpAddress = []*string{tea.String(inst.ip)}
+		}
+
+		result = append(result, &ecs20140526.DescribeInstancesResponseBodyInstancesInstance{
+			InstanceId:         tea.String(inst.id),
+			InstanceType:       tea.String(inst.instanceType),
+			RegionId:           tea.String(p.regionId),
+			ZoneId:             tea.String(inst.zoneId),
+			ImageId:            tea.String(inst.imageId),
+			HostName:           tea.String(inst.hostName),
+			Status:             tea.String(inst.status),
+			StoppedMode:        tea.String(inst.stoppedMode),
+			CreationTime:       formatTime(inst.createdAt),
+			InstanceChargeType: tea.String("PostPaid"),
+			SpotStrategy:       tea.String("SpotAsPriceGo"),
+			PublicIpAddress:    publicIp,
+		})
+	}
+
+	return &ecs20140526.DescribeInstancesResponse{
+		Body: &ecs20140526.DescribeInstancesResponseBody{
+			RequestId:  requestId(),
+			TotalCount: tea.Int32(int32(len(result))),
+			Instances:  &ecs20140526.DescribeInstancesResponseBodyInstances{Instance: result},
+		},
+	}, nil
+}
+
+func (p *Provider) DescribeInstanceHistoryEventsWithContext(_ context.Context, _ *ecs20140526.DescribeInstanceHistoryEventsRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribeInstanceHistoryEventsResponse, error) {
+	// 模拟实现中的实例不会被回收，因此不会产生任何系统事件
+	return &ecs20140526.DescribeInstanceHistoryEventsResponse{
+		Body: &ecs20140526.DescribeInstanceHistoryEventsResponseBody{
+			RequestId:              requestId(),
+			TotalCount:             tea.Int32(0),
+			InstanceSystemEventSet: &ecs20140526.DescribeInstanceHistoryEventsResponseBodyInstanceSystemEventSet{},
+		},
+	}, nil
+}
+
+func (p *Provider) DescribeDisksWithContext(_ context.Context, request *ecs20140526.DescribeDisksRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribeDisksResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []*ecs20140526.DescribeDisksResponseBodyDisksDisk
+
+	for _, d := range p.disks {
+		if request.InstanceId != nil && d.instanceId != tea.StringValue(request.InstanceId) {
+			continue
+		}
+
+		if request.DiskType != nil && tea.StringValue(request.DiskType) != "all" && d.diskType != tea.StringValue(request.DiskType) {
+			continue
+		}
+
+		result = append(result, &ecs20140526.DescribeDisksResponseBodyDisksDisk{
+			DiskId:           tea.String(d.id),
+			InstanceId:       tea.String(d.instanceId),
+			Type:             tea.String(d.diskType),
+			Category:         tea.String(d.category),
+			Size:             tea.Int32(int32(d.size)),
+			SourceSnapshotId: tea.String(d.sourceSnapshotId),
+			Status:           tea.String("In_use"),
+		})
+	}
+
+	return &ecs20140526.DescribeDisksResponse{
+		Body: &ecs20140526.DescribeDisksResponseBody{
+			RequestId:  requestId(),
+			TotalCount: tea.Int32(int32(len(result))),
+			Disks:      &ecs20140526.DescribeDisksResponseBodyDisks{Disk: result},
+		},
+	}, nil
+}
+
+func (p *Provider) CreateSnapshotWithContext(_ context.Context, request *ecs20140526.CreateSnapshotRequest, _ *dara.RuntimeOptions) (*ecs20140526.CreateSnapshotResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d, ok := p.disks[tea.StringValue(request.DiskId)]
+
+	if !ok {
+		return nil, sdkError("InvalidDiskId.NotFound", "The specified disk does not exist.")
+	}
+
+	s := &snapshot{
+		id:         p.nextId("s"),
+		name:       tea.StringValue(request.SnapshotName),
+		diskId:     d.id,
+		diskSize:   d.size,
+		createdAt:  time.Now(),
+		instanceId: d.instanceId,
+	}
+
+	p.snapshots[s.id] = s
+
+	return &ecs20140526.CreateSnapshotResponse{
+		Body: &ecs20140526.CreateSnapshotResponseBody{RequestId: requestId(), SnapshotId: tea.String(s.id)},
+	}, nil
+}
+
+func (p *Provider) DescribeSnapshotsWithContext(_ context.Context, request *ecs20140526.DescribeSnapshotsRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribeSnapshotsResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []*ecs20140526.DescribeSnapshotsResponseBodySnapshotsSnapshot
+
+	for _, id := range parseIds(request.SnapshotIds) {
+		s, ok := p.snapshots[id]
+
+		if !ok {
+			continue
+		}
+
+		result = append(result, &ecs20140526.DescribeSnapshotsResponseBodySnapshotsSnapshot{
+			SnapshotId:     tea.String(s.id),
+			SnapshotName:   tea.String(s.name),
+			SourceDiskId:   tea.String(s.diskId),
+			SourceDiskSize: tea.String(fmt.Sprint(s.diskSize)),
+			CreationTime:   formatTime(s.createdAt),
+			Progress:       tea.String("100%"),
+			Status:         tea.String("accomplished"),
+			Available:      tea.Bool(true),
+		})
+	}
+
+	return &ecs20140526.DescribeSnapshotsResponse{
+		Body: &ecs20140526.DescribeSnapshotsResponseBody{
+			RequestId:  requestId(),
+			TotalCount: tea.Int32(int32(len(result))),
+			Snapshots:  &ecs20140526.DescribeSnapshotsResponseBodySnapshots{Snapshot: result},
+		},
+	}, nil
+}
+
+func (p *Provider) DeleteSnapshotWithContext(_ context.Context, request *ecs20140526.DeleteSnapshotRequest, _ *dara.RuntimeOptions) (*ecs20140526.DeleteSnapshotResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := tea.StringValue(request.SnapshotId)
+
+	if _, ok := p.snapshots[id]; !ok {
+		return nil, sdkError("InvalidSnapshotId.NotFound", "The specified snapshot does not exist.")
+	}
+
+	for _, img := range p.images {
+		if img.snapshotId == id {
+			return nil, sdkError("SnapshotCreatedImage", "The snapshot has been used to create a user-defined image.")
+		}
+	}
+
+	delete(p.snapshots, id)
+
+	return &ecs20140526.DeleteSnapshotResponse{Body: &ecs20140526.DeleteSnapshotResponseBody{RequestId: requestId()}}, nil
+}
+
+func (p *Provider) CreateImageWithContext(_ context.Context, request *ecs20140526.CreateImageRequest, _ *dara.RuntimeOptions) (*ecs20140526.CreateImageResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	snapshotId := tea.StringValue(request.SnapshotId)
+
+	if _, ok := p.snapshots[snapshotId]; !ok {
+		return nil, sdkError("InvalidSnapshotId.NotFound", "The specified snapshot does not exist.")
+	}
+
+	img := &image{
+		id:         p.nextId("m"),
+		name:       tea.StringValue(request.ImageName),
+		snapshotId: snapshotId,
+		createdAt:  time.Now(),
+	}
+
+	p.images[img.id] = img
+
+	return &ecs20140526.CreateImageResponse{
+		Body: &ecs20140526.CreateImageResponseBody{RequestId: requestId(), ImageId: tea.String(img.id)},
+	}, nil
+}
+
+func (p *Provider) DescribeImagesWithContext(_ context.Context, request *ecs20140526.DescribeImagesRequest, _ *dara.RuntimeOptions) (*ecs20140526.DescribeImagesResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []*ecs20140526.DescribeImagesResponseBodyImagesImage
+
+	if img, ok := p.images[tea.StringValue(request.ImageId)]; ok {
+		result = append(result, &ecs20140526.DescribeImagesResponseBodyImagesImage{
+			ImageId:      tea.String(img.id),
+			ImageName:    tea.String(img.name),
+			CreationTime: formatTime(img.createdAt),
+			Progress:     tea.String("100%"),
+			Status:       tea.String("Available"),
+		})
+	}
+
+	return &ecs20140526.DescribeImagesResponse{
+		Body: &ecs20140526.DescribeImagesResponseBody{
+			RequestId:  requestId(),
+			TotalCount: tea.Int32(int32(len(result))),
+			Images:     &ecs20140526.DescribeImagesResponseBodyImages{Image: result},
+		},
+	}, nil
+}
+
+func (p *Provider) DeleteImageWithContext(_ context.Context, request *ecs20140526.DeleteImageRequest, _ *dara.RuntimeOptions) (*ecs20140526.DeleteImageResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := tea.StringValue(request.ImageId)
+
+	if _, ok := p.images[id]; !ok {
+		return nil, sdkError("InvalidImageId.NotFound", "The specified image does not exist.")
+	}
+
+	if !tea.BoolValue(request.Force) {
+		for _, inst := range p.instances {
+			if inst.imageId == id {
+				return nil, sdkError("IncorrectImageStatus", "The image is being used by instances.")
+			}
+		}
+	}
+
+	delete(p.images, id)
+
+	return &ecs20140526.DeleteImageResponse{Body: &ecs20140526.DeleteImageResponseBody{RequestId: requestId()}}, nil
+}
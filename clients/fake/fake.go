@@ -0,0 +1,150 @@
+// Package fake 提供阿里云服务的内存模拟实现，实现了 clients 包中的全部提供者接口。
+//
+// 模拟实现是有状态的：创建的实例、云盘、快照、镜像和交换机保存在内存中，状态按照阿里云的规则变化，但所有操作都立即完成。
+// 系统重启后状态丢失，数据库中遗留的活动实例会被 monitors.ActiveInstance 视为已被外部删除。
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+type instance struct {
+	id           string
+	instanceType string
+	zoneId       string
+	vswitchId    string
+	imageId      string
+	hostName     string
+	status       string
+	stoppedMode  string
+	ip           string
+	createdAt    time.Time
+}
+
+type disk struct {
+	id               string
+	instanceId       string
+	diskType         string
+	category         string
+	size             int
+	sourceSnapshotId string
+}
+
+type snapshot struct {
+	id         string
+	name       string
+	diskId     string
+	diskSize   int
+	createdAt  time.Time
+	instanceId string
+}
+
+type image struct {
+	id         string
+	name       string
+	snapshotId string
+	createdAt  time.Time
+}
+
+// Provider 是阿里云服务的内存模拟实现
+type Provider struct {
+	mu sync.Mutex
+
+	regionId string
+	publicIp string
+	zones    []string
+	seq      int
+
+	instances map[string]*instance
+	disks     map[string]*disk
+	snapshots map[string]*snapshot
+	images    map[string]*image
+	vswitches map[string]string
+
+	// noStock 记录库存不足的可用区及实例类型，键为 stockKey 的返回值
+	noStock map[string]bool
+}
+
+// UnroutablePublicIp 是未指定公网IP地址时分配给实例的地址，属于 RFC 5737 中仅用于文档的 TEST-NET-1，不会连接到任何主机
+const UnroutablePublicIp = "192.0.2.1"
+
+// New 创建一个模拟地域 regionId 的 Provider。分配给实例的公网IP地址均为 publicIp，为空时使用 UnroutablePublicIp
+func New(regionId string, publicIp string) *Provider {
+	if publicIp == "" {
+		publicIp = UnroutablePublicIp
+	}
+
+	p := &Provider{
+		regionId:  regionId,
+		publicIp:  publicIp,
+		zones:     []string{regionId + "-a", regionId + "-b"},
+		instances: make(map[string]*instance),
+		disks:     make(map[string]*disk),
+		snapshots: make(map[string]*snapshot),
+		images:    make(map[string]*image),
+		vswitches: make(map[string]string),
+		noStock:   make(map[string]bool),
+	}
+
+	// 只在第一个可用区内预置默认交换机，其余可用区需要自动创建
+	p.vswitches[p.zones[0]] = p.nextId("vsw")
+
+	return p
+}
+
+// stockKey 返回可用区 zoneId 内实例类型 instanceType 在 noStock 中的键
+func stockKey(zoneId string, instanceType string) string {
+	return zoneId + "/" + instanceType
+}
+
+// SetNoStock 设置可用区 zoneId 内的实例类型 instanceType 是否库存不足。库存不足的实例类型在 DescribeAvailableResource 中显示为无库存，
+// 创建时返回 OperationDenied.NoStock 错误
+func (p *Provider) SetNoStock(zoneId string, instanceType string, noStock bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.noStock[stockKey(zoneId, instanceType)] = noStock
+}
+
+// nextId 生成一个带有前缀 prefix 的资源标识符。调用者需要持有锁
+func (p *Provider) nextId(prefix string) string {
+	p.seq++
+	return fmt.Sprintf("%s-fake%06d", prefix, p.seq)
+}
+
+// requestId 生成一个请求标识符
+func requestId() *string {
+	return tea.String(fmt.Sprintf("fake-%d", time.Now().UnixNano()))
+}
+
+// sdkError 返回一个与阿里云 SDK 格式一致的错误，以便 helpers.AliyunErrorCode 等函数正常工作
+func sdkError(code string, message string) error {
+	return tea.NewSDKError(map[string]interface{}{
+		"code":       code,
+		"message":    message,
+		"statusCode": 400,
+	})
+}
+
+// parseIds 解析形如 ["id1","id2"] 的 JSON 数组参数
+func parseIds(s *string) []string {
+	var ids []string
+
+	if s == nil {
+		return ids
+	}
+
+	_ = json.Unmarshal([]byte(*s), &ids)
+
+	return ids
+}
+
+// formatTime 以阿里云接口返回值的格式格式化时间
+func formatTime(t time.Time) *string {
+	return tea.String(t.UTC().Format("2006-01-02T15:04Z"))
+}
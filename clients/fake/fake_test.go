@@ -0,0 +1,309 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/tea"
+	vpc20160428 "github.com/alibabacloud-go/vpc-20160428/v6/client"
+)
+
+// codeOf 返回模拟实现返回的错误中携带的错误码
+func codeOf(err error) string {
+	var sdkErr *tea.SDKError
+
+	if errors.As(err, &sdkErr) {
+		return tea.StringValue(sdkErr.Code)
+	}
+
+	return ""
+}
+
+// createInstance 在可用区 zoneId 内创建一个实例类型为 instanceType 的实例
+func createInstance(p *Provider, zoneId string, instanceType string) (string, error) {
+	ctx := context.Background()
+
+	vsw, err := p.DescribeVSwitchesWithContext(ctx, &vpc20160428.DescribeVSwitchesRequest{ZoneId: tea.String(zoneId)}, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	var vswitchId *string
+
+	if vs := vsw.Body.VSwitches.VSwitch; len(vs) > 0 {
+		vswitchId = vs[0].VSwitchId
+	}
+
+	resp, err := p.CreateInstanceWithContext(ctx, &ecs20140526.CreateInstanceRequest{
+		ZoneId:       tea.String(zoneId),
+		VSwitchId:    vswitchId,
+		InstanceType: tea.String(instanceType),
+		ImageId:      tea.String("debian_12"),
+		DataDisk:     []*ecs20140526.CreateInstanceRequestDataDisk{{Category: tea.String("cloud_essd"), Size: tea.Int32(40)}},
+	}, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return tea.StringValue(resp.Body.InstanceId), nil
+}
+
+// statusOf 返回实例 instanceId 的状态和公网IP地址
+func statusOf(t *testing.T, p *Provider, instanceId string) (string, string) {
+	t.Helper()
+
+	resp, err := p.DescribeInstancesWithContext(context.Background(), &ecs20140526.DescribeInstancesRequest{
+		InstanceIds: tea.String(`["` + instanceId + `"]`),
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("DescribeInstances() error = %v", err)
+	}
+
+	if len(resp.Body.Instances.Instance) != 1 {
+		t.Fatalf("DescribeInstances() returned %d instances, want 1", len(resp.Body.Instances.Instance))
+	}
+
+	inst := resp.Body.Instances.Instance[0]
+
+	var ip string
+
+	if ips := inst.PublicIpAddress.IpAddress; len(ips) > 0 {
+		ip = tea.StringValue(ips[0])
+	}
+
+	return tea.StringValue(inst.Status), ip
+}
+
+func TestInstanceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	p := New("cn-test", "")
+
+	id, err := createInstance(p, "cn-test-a", "ecs.fake.c2m4")
+
+	if err != nil {
+		t.Fatalf("createInstance() error = %v", err)
+	}
+
+	if status, ip := statusOf(t, p, id); status != "Stopped" || ip != "" {
+		t.Fatalf("new instance is %s with ip %q, want Stopped without ip", status, ip)
+	}
+
+	if _, err := p.StartInstanceWithContext(ctx, &ecs20140526.StartInstanceRequest{InstanceId: tea.String(id)}, nil); err != nil {
+		t.Fatalf("StartInstance() error = %v", err)
+	}
+
+	_, err = p.StartInstanceWithContext(ctx, &ecs20140526.StartInstanceRequest{InstanceId: tea.String(id)}, nil)
+
+	if codeOf(err) != "IncorrectInstanceStatus" {
+		t.Errorf("StartInstance() on running instance error = %v, want IncorrectInstanceStatus", err)
+	}
+
+	ipResp, err := p.AllocatePublicIpAddressWithContext(ctx, &ecs20140526.AllocatePublicIpAddressRequest{InstanceId: tea.String(id)}, nil)
+
+	if err != nil {
+		t.Fatalf("AllocatePublicIpAddress() error = %v", err)
+	}
+
+	if got := tea.StringValue(ipResp.Body.IpAddress); got != UnroutablePublicIp {
+		t.Errorf("AllocatePublicIpAddress() = %q, want %q", got, UnroutablePublicIp)
+	}
+
+	_, err = p.AllocatePublicIpAddressWithContext(ctx, &ecs20140526.AllocatePublicIpAddressRequest{InstanceId: tea.String(id)}, nil)
+
+	if codeOf(err) != "AllocatedAlready" {
+		t.Errorf("second AllocatePublicIpAddress() error = %v, want AllocatedAlready", err)
+	}
+
+	if status, ip := statusOf(t, p, id); status != "Running" || ip != UnroutablePublicIp {
+		t.Fatalf("instance is %s with ip %q, want Running with %q", status, ip, UnroutablePublicIp)
+	}
+
+	_, err = p.DeleteInstanceWithContext(ctx, &ecs20140526.DeleteInstanceRequest{InstanceId: tea.String(id)}, nil)
+
+	if codeOf(err) != "IncorrectInstanceStatus" {
+		t.Errorf("DeleteInstance() on running instance error = %v, want IncorrectInstanceStatus", err)
+	}
+
+	if _, err := p.StopInstanceWithContext(ctx, &ecs20140526.StopInstanceRequest{InstanceId: tea.String(id), StoppedMode: tea.String("StopCharging")}, nil); err != nil {
+		t.Fatalf("StopInstance() error = %v", err)
+	}
+
+	if status, ip := statusOf(t, p, id); status != "Stopped" || ip != "" {
+		t.Errorf("instance stopped with StopCharging is %s with ip %q, want Stopped without ip", status, ip)
+	}
+
+	if _, err := p.DeleteInstanceWithContext(ctx, &ecs20140526.DeleteInstanceRequest{InstanceId: tea.String(id)}, nil); err != nil {
+		t.Fatalf("DeleteInstance() error = %v", err)
+	}
+
+	disks, err := p.DescribeDisksWithContext(ctx, &ecs20140526.DescribeDisksRequest{InstanceId: tea.String(id)}, nil)
+
+	if err != nil {
+		t.Fatalf("DescribeDisks() error = %v", err)
+	}
+
+	if n := len(disks.Body.Disks.Disk); n != 0 {
+		t.Errorf("deleted instance still has %d disks", n)
+	}
+
+	_, err = p.StartInstanceWithContext(ctx, &ecs20140526.StartInstanceRequest{InstanceId: tea.String(id)}, nil)
+
+	if codeOf(err) != "InvalidInstanceId.NotFound" {
+		t.Errorf("StartInstance() on deleted instance error = %v, want InvalidInstanceId.NotFound", err)
+	}
+}
+
+func TestPublicIp(t *testing.T) {
+	p := New("cn-test", "192.0.2.10")
+
+	id, err := createInstance(p, "cn-test-a", "ecs.fake.c2m4")
+
+	if err != nil {
+		t.Fatalf("createInstance() error = %v", err)
+	}
+
+	resp, err := p.AllocatePublicIpAddressWithContext(context.Background(), &ecs20140526.AllocatePublicIpAddressRequest{InstanceId: tea.String(id)}, nil)
+
+	if err != nil {
+		t.Fatalf("AllocatePublicIpAddress() error = %v", err)
+	}
+
+	if got := tea.StringValue(resp.Body.IpAddress); got != "192.0.2.10" {
+		t.Errorf("AllocatePublicIpAddress() = %q, want 192.0.2.10", got)
+	}
+}
+
+func TestSetNoStock(t *testing.T) {
+	ctx := context.Background()
+	p := New("cn-test", "")
+
+	p.SetNoStock("cn-test-a", "ecs.fake.c2m4", true)
+
+	resp, err := p.DescribeAvailableResourceWithContext(ctx, &ecs20140526.DescribeAvailableResourceRequest{
+		Cores:  tea.Int32(2),
+		Memory: tea.Float32(4),
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("DescribeAvailableResource() error = %v", err)
+	}
+
+	want := map[string]string{"cn-test-a": "WithoutStock", "cn-test-b": "WithStock"}
+
+	for _, zone := range resp.Body.AvailableZones.AvailableZone {
+		resource := zone.AvailableResources.AvailableResource[0].SupportedResources.SupportedResource[0]
+
+		if got := tea.StringValue(resource.StatusCategory); got != want[tea.StringValue(zone.ZoneId)] {
+			t.Errorf("zone %s StatusCategory = %s, want %s", tea.StringValue(zone.ZoneId), got, want[tea.StringValue(zone.ZoneId)])
+		}
+	}
+
+	if _, err := createInstance(p, "cn-test-a", "ecs.fake.c2m4"); codeOf(err) != "OperationDenied.NoStock" {
+		t.Errorf("createInstance() without stock error = %v, want OperationDenied.NoStock", err)
+	}
+
+	if _, err := createInstance(p, "cn-test-a", "ecs.fake.c2m8"); err != nil {
+		t.Errorf("createInstance() of another type error = %v", err)
+	}
+
+	p.SetNoStock("cn-test-a", "ecs.fake.c2m4", false)
+
+	if _, err := createInstance(p, "cn-test-a", "ecs.fake.c2m4"); err != nil {
+		t.Errorf("createInstance() after restocking error = %v", err)
+	}
+}
+
+func TestVSwitch(t *testing.T) {
+	ctx := context.Background()
+	p := New("cn-test", "")
+
+	if _, err := createInstance(p, "cn-test-b", "ecs.fake.c2m4"); codeOf(err) != "InvalidVSwitchId.NotFound" {
+		t.Fatalf("createInstance() without vswitch error = %v, want InvalidVSwitchId.NotFound", err)
+	}
+
+	if _, err := p.CreateDefaultVSwitchWithContext(ctx, &vpc20160428.CreateDefaultVSwitchRequest{ZoneId: tea.String("cn-test-b")}, nil); err != nil {
+		t.Fatalf("CreateDefaultVSwitch() error = %v", err)
+	}
+
+	_, err := p.CreateDefaultVSwitchWithContext(ctx, &vpc20160428.CreateDefaultVSwitchRequest{ZoneId: tea.String("cn-test-b")}, nil)
+
+	if codeOf(err) != "DefaultVSwitch.Existed" {
+		t.Errorf("second CreateDefaultVSwitch() error = %v, want DefaultVSwitch.Existed", err)
+	}
+
+	_, err = p.CreateDefaultVSwitchWithContext(ctx, &vpc20160428.CreateDefaultVSwitchRequest{ZoneId: tea.String("cn-test-z")}, nil)
+
+	if codeOf(err) != "InvalidZoneId.NotFound" {
+		t.Errorf("CreateDefaultVSwitch() in unknown zone error = %v, want InvalidZoneId.NotFound", err)
+	}
+
+	if _, err := createInstance(p, "cn-test-b", "ecs.fake.c2m4"); err != nil {
+		t.Errorf("createInstance() after creating vswitch error = %v", err)
+	}
+}
+
+func TestSnapshotAndImage(t *testing.T) {
+	ctx := context.Background()
+	p := New("cn-test", "")
+
+	id, err := createInstance(p, "cn-test-a", "ecs.fake.c2m4")
+
+	if err != nil {
+		t.Fatalf("createInstance() error = %v", err)
+	}
+
+	disks, err := p.DescribeDisksWithContext(ctx, &ecs20140526.DescribeDisksRequest{InstanceId: tea.String(id), DiskType: tea.String("data")}, nil)
+
+	if err != nil || len(disks.Body.Disks.Disk) != 1 {
+		t.Fatalf("DescribeDisks() = %v, %v, want one data disk", disks, err)
+	}
+
+	snap, err := p.CreateSnapshotWithContext(ctx, &ecs20140526.CreateSnapshotRequest{DiskId: disks.Body.Disks.Disk[0].DiskId}, nil)
+
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+
+	img, err := p.CreateImageWithContext(ctx, &ecs20140526.CreateImageRequest{SnapshotId: snap.Body.SnapshotId}, nil)
+
+	if err != nil {
+		t.Fatalf("CreateImage() error = %v", err)
+	}
+
+	_, err = p.DeleteSnapshotWithContext(ctx, &ecs20140526.DeleteSnapshotRequest{SnapshotId: snap.Body.SnapshotId}, nil)
+
+	if codeOf(err) != "SnapshotCreatedImage" {
+		t.Errorf("DeleteSnapshot() of snapshot with image error = %v, want SnapshotCreatedImage", err)
+	}
+
+	vsw, _ := p.DescribeVSwitchesWithContext(ctx, &vpc20160428.DescribeVSwitchesRequest{ZoneId: tea.String("cn-test-a")}, nil)
+
+	_, err = p.CreateInstanceWithContext(ctx, &ecs20140526.CreateInstanceRequest{
+		ZoneId:       tea.String("cn-test-a"),
+		VSwitchId:    vsw.Body.VSwitches.VSwitch[0].VSwitchId,
+		InstanceType: tea.String("ecs.fake.c2m4"),
+		ImageId:      tea.String("debian_12"),
+		DataDisk:     []*ecs20140526.CreateInstanceRequestDataDisk{{SnapshotId: snap.Body.SnapshotId, Size: tea.Int32(20)}},
+	}, nil)
+
+	if codeOf(err) != "InvalidDataDiskSize.ValueNotSupported" {
+		t.Errorf("CreateInstance() with data disk smaller than snapshot error = %v, want InvalidDataDiskSize.ValueNotSupported", err)
+	}
+
+	if n := len(p.instances); n != 1 {
+		t.Errorf("rejected CreateInstance() left %d instances, want 1", n)
+	}
+
+	if _, err := p.DeleteImageWithContext(ctx, &ecs20140526.DeleteImageRequest{ImageId: img.Body.ImageId}, nil); err != nil {
+		t.Fatalf("DeleteImage() error = %v", err)
+	}
+
+	if _, err := p.DeleteSnapshotWithContext(ctx, &ecs20140526.DeleteSnapshotRequest{SnapshotId: snap.Body.SnapshotId}, nil); err != nil {
+		t.Errorf("DeleteSnapshot() after deleting image error = %v", err)
+	}
+}
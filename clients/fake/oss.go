@@ -0,0 +1,16 @@
+package fake
+
+import (
+	"context"
+
+	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
+)
+
+func (p *Provider) ListObjectsV2(_ context.Context, request *oss.ListObjectsV2Request, _ ...func(*oss.Options)) (*oss.ListObjectsV2Result, error) {
+	// 归档和备份由实例上的 ossutil 写入存储桶，模拟实现无法得知其内容，因此总是返回空列表
+	return &oss.ListObjectsV2Result{
+		Name:     request.Bucket,
+		Prefix:   request.Prefix,
+		Contents: []oss.ObjectProperties{},
+	}, nil
+}
@@ -0,0 +1,61 @@
+package fake
+
+import (
+	"context"
+	"slices"
+
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+	vpc20160428 "github.com/alibabacloud-go/vpc-20160428/v6/client"
+)
+
+func (p *Provider) DescribeVSwitchesWithContext(_ context.Context, request *vpc20160428.DescribeVSwitchesRequest, _ *dara.RuntimeOptions) (*vpc20160428.DescribeVSwitchesResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []*vpc20160428.DescribeVSwitchesResponseBodyVSwitchesVSwitch
+
+	for _, zoneId := range p.zones {
+		vswitchId, ok := p.vswitches[zoneId]
+
+		if !ok || (request.ZoneId != nil && tea.StringValue(request.ZoneId) != zoneId) {
+			continue
+		}
+
+		result = append(result, &vpc20160428.DescribeVSwitchesResponseBodyVSwitchesVSwitch{
+			VSwitchId: tea.String(vswitchId),
+			ZoneId:    tea.String(zoneId),
+			IsDefault: tea.Bool(true),
+			Status:    tea.String("Available"),
+		})
+	}
+
+	return &vpc20160428.DescribeVSwitchesResponse{
+		Body: &vpc20160428.DescribeVSwitchesResponseBody{
+			RequestId:  requestId(),
+			TotalCount: tea.Int32(int32(len(result))),
+			VSwitches:  &vpc20160428.DescribeVSwitchesResponseBodyVSwitches{VSwitch: result},
+		},
+	}, nil
+}
+
+func (p *Provider) CreateDefaultVSwitchWithContext(_ context.Context, request *vpc20160428.CreateDefaultVSwitchRequest, _ *dara.RuntimeOptions) (*vpc20160428.CreateDefaultVSwitchResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	zoneId := tea.StringValue(request.ZoneId)
+
+	if !slices.Contains(p.zones, zoneId) {
+		return nil, sdkError("InvalidZoneId.NotFound", "The specified zone does not exist.")
+	}
+
+	if _, ok := p.vswitches[zoneId]; ok {
+		return nil, sdkError("DefaultVSwitch.Existed", "The default vswitch already exists in the zone.")
+	}
+
+	p.vswitches[zoneId] = p.nextId("vsw")
+
+	return &vpc20160428.CreateDefaultVSwitchResponse{
+		Body: &vpc20160428.CreateDefaultVSwitchResponseBody{RequestId: requestId(), VSwitchId: tea.String(p.vswitches[zoneId])},
+	}, nil
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // OssClient 是系统全局对象存储服务客户端
-var OssClient *oss.Client
+var OssClient OssProvider
 
 // GetOssClient 根据凭据创建一个对象存储服务客户端
 func GetOssClient() *oss.Client {
@@ -0,0 +1,56 @@
+package clients
+
+import (
+	"context"
+
+	bss20171214 "github.com/alibabacloud-go/bssopenapi-20171214/v6/client"
+	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
+	vpc20160428 "github.com/alibabacloud-go/vpc-20160428/v6/client"
+	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
+)
+
+// EcsProvider 包含系统使用到的全部云服务器 ECS 接口。方法签名与阿里云 SDK 保持一致，因此 *ecs20140526.Client 直接实现了该接口。
+type EcsProvider interface {
+	AllocatePublicIpAddressWithContext(ctx context.Context, request *ecs20140526.AllocatePublicIpAddressRequest, runtime *dara.RuntimeOptions) (*ecs20140526.AllocatePublicIpAddressResponse, error)
+	CreateImageWithContext(ctx context.Context, request *ecs20140526.CreateImageRequest, runtime *dara.RuntimeOptions) (*ecs20140526.CreateImageResponse, error)
+	CreateInstanceWithContext(ctx context.Context, request *ecs20140526.CreateInstanceRequest, runtime *dara.RuntimeOptions) (*ecs20140526.CreateInstanceResponse, error)
+	CreateSnapshotWithContext(ctx context.Context, request *ecs20140526.CreateSnapshotRequest, runtime *dara.RuntimeOptions) (*ecs20140526.CreateSnapshotResponse, error)
+	DeleteImageWithContext(ctx context.Context, request *ecs20140526.DeleteImageRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DeleteImageResponse, error)
+	DeleteInstanceWithContext(ctx context.Context, request *ecs20140526.DeleteInstanceRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DeleteInstanceResponse, error)
+	DeleteSnapshotWithContext(ctx context.Context, request *ecs20140526.DeleteSnapshotRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DeleteSnapshotResponse, error)
+	DescribeAvailableResourceWithContext(ctx context.Context, request *ecs20140526.DescribeAvailableResourceRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribeAvailableResourceResponse, error)
+	DescribeDisksWithContext(ctx context.Context, request *ecs20140526.DescribeDisksRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribeDisksResponse, error)
+	DescribeImagesWithContext(ctx context.Context, request *ecs20140526.DescribeImagesRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribeImagesResponse, error)
+	DescribeInstanceHistoryEventsWithContext(ctx context.Context, request *ecs20140526.DescribeInstanceHistoryEventsRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribeInstanceHistoryEventsResponse, error)
+	DescribeInstanceStatusWithContext(ctx context.Context, request *ecs20140526.DescribeInstanceStatusRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribeInstanceStatusResponse, error)
+	DescribeInstancesWithContext(ctx context.Context, request *ecs20140526.DescribeInstancesRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribeInstancesResponse, error)
+	DescribePriceWithContext(ctx context.Context, request *ecs20140526.DescribePriceRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribePriceResponse, error)
+	DescribeSnapshotsWithContext(ctx context.Context, request *ecs20140526.DescribeSnapshotsRequest, runtime *dara.RuntimeOptions) (*ecs20140526.DescribeSnapshotsResponse, error)
+	StartInstanceWithContext(ctx context.Context, request *ecs20140526.StartInstanceRequest, runtime *dara.RuntimeOptions) (*ecs20140526.StartInstanceResponse, error)
+	StopInstanceWithContext(ctx context.Context, request *ecs20140526.StopInstanceRequest, runtime *dara.RuntimeOptions) (*ecs20140526.StopInstanceResponse, error)
+}
+
+// VpcProvider 包含系统使用到的全部专有网络 VPC 接口
+type VpcProvider interface {
+	CreateDefaultVSwitchWithContext(ctx context.Context, request *vpc20160428.CreateDefaultVSwitchRequest, runtime *dara.RuntimeOptions) (*vpc20160428.CreateDefaultVSwitchResponse, error)
+	DescribeVSwitchesWithContext(ctx context.Context, request *vpc20160428.DescribeVSwitchesRequest, runtime *dara.RuntimeOptions) (*vpc20160428.DescribeVSwitchesResponse, error)
+}
+
+// BssProvider 包含系统使用到的全部费用 BSS 接口
+type BssProvider interface {
+	QueryAccountBalanceWithOptions(runtime *dara.RuntimeOptions) (*bss20171214.QueryAccountBalanceResponse, error)
+	QueryAccountTransactionDetailsWithContext(ctx context.Context, request *bss20171214.QueryAccountTransactionDetailsRequest, runtime *dara.RuntimeOptions) (*bss20171214.QueryAccountTransactionDetailsResponse, error)
+}
+
+// OssProvider 包含系统使用到的全部对象存储 OSS 接口
+type OssProvider interface {
+	ListObjectsV2(ctx context.Context, request *oss.ListObjectsV2Request, optFns ...func(*oss.Options)) (*oss.ListObjectsV2Result, error)
+}
+
+var (
+	_ EcsProvider = (*ecs20140526.Client)(nil)
+	_ VpcProvider = (*vpc20160428.Client)(nil)
+	_ BssProvider = (*bss20171214.Client)(nil)
+	_ OssProvider = (*oss.Client)(nil)
+)
@@ -0,0 +1,25 @@
+package clients
+
+import (
+	"testing"
+
+	"github.com/Subilan/go-aliyunmc/clients/fake"
+	"github.com/Subilan/go-aliyunmc/config"
+)
+
+func TestUseFakeProvider(t *testing.T) {
+	config.Cfg.Aliyun.RegionId = "cn-test"
+	config.Cfg.Aliyun.FakePublicIp = ""
+
+	UseFakeProvider()
+
+	p, ok := EcsClient.(*fake.Provider)
+
+	if !ok {
+		t.Fatalf("EcsClient is %T, want *fake.Provider", EcsClient)
+	}
+
+	if VpcClient != VpcProvider(p) || BssClient != BssProvider(p) || OssClient != OssProvider(p) {
+		t.Error("UseFakeProvider() does not share one provider across all clients")
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 // VpcClient 是系统全局专有网络服务客户端
-var VpcClient *vpc20160428.Client
+var VpcClient VpcProvider
 
 // ShouldCreateVpcClient 根据凭据创建一个专有网络服务客户端
 func ShouldCreateVpcClient() (*vpc20160428.Client, error) {
@@ -21,10 +21,31 @@ type AliyunConfig struct {
 	// AccessKeySecret 是系统访问阿里云服务器的 AK 密钥
 	AccessKeySecret string `toml:"access_key_secret" validate:"required" comment:"用于访问阿里云服务的AKSecret"`
 
+	// Provider 决定系统调用的云服务实现，取值为 ProviderAliyun 或 ProviderFake，留空等同于 ProviderAliyun
+	//
+	// ProviderFake 是一个有状态的内存实现，不会访问阿里云，也不会产生费用，用于在本地完整运行实例的创建、部署和删除流程。
+	Provider string `toml:"provider" validate:"omitempty,oneof=aliyun fake" comment:"云服务实现。aliyun表示调用阿里云；fake表示使用内存中的模拟实现，不访问阿里云，用于本地开发和测试。留空等同于aliyun"`
+
+	// FakePublicIp 是 ProviderFake 为实例分配的公网IP地址。部署脚本将通过 SSH 以根用户身份在该地址上运行，格式化数据盘并修改系统配置，
+	// 因此只能指向一台专门用于测试的 Debian 主机，切勿指向本机。留空时实例使用不可路由的地址，并且拒绝部署
+	FakePublicIp string `toml:"fake_public_ip" validate:"omitempty,ip" comment:"使用fake实现时为实例分配的公网IP地址。部署脚本将以根用户身份在该主机上格式化数据盘并修改系统配置，只能指向专门用于测试的主机，切勿指向本机。留空时拒绝部署"`
+
 	// Ecs 包含了对云服务器 ECS 服务的相关配置
 	Ecs AliyunEcsConfig `toml:"ecs" validate:"required"`
 }
 
+const (
+	// ProviderAliyun 表示调用阿里云的真实服务
+	ProviderAliyun = "aliyun"
+	// ProviderFake 表示使用内存中的模拟实现
+	ProviderFake = "fake"
+)
+
+// UseFakeProvider 返回是否使用内存中的模拟实现
+func (c AliyunConfig) UseFakeProvider() bool {
+	return c.Provider == ProviderFake
+}
+
 // AliyunEcsConfig 包含了所有与云服务器 ECS 相关的配置内容。参考 https://api.aliyun.com/api/Ecs/2014-05-26/CreateInstance
 type AliyunEcsConfig struct {
 	// InternetMaxBandwidthOut 是实例的峰值带宽，单位 Mbps，取值范围 1～100 且为整数
@@ -74,6 +95,11 @@ func (c AliyunConfig) OssEndpoint() string {
 	return fmt.Sprintf("oss-%s.aliyuncs.com", c.RegionId)
 }
 
+// AlidnsEndpoint 返回云解析（Alidns）的服务地址，由 RegionId 决定。
+func (c AliyunConfig) AlidnsEndpoint() string {
+	return fmt.Sprintf("alidns.%s.aliyuncs.com", c.RegionId)
+}
+
 // VpcEndpoint 返回专有网络（VPC）的服务地址，由 RegionId 决定。
 func (c AliyunConfig) VpcEndpoint() string {
 	return fmt.Sprintf("vpc.%s.aliyuncs.com", c.RegionId)
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// CommandArgTypeString 表示字符串参数，可以使用 Pattern、Enum 校验，Min 和 Max 限制其长度
+	CommandArgTypeString = "string"
+	// CommandArgTypeInt 表示整数参数，Min 和 Max 限制其取值范围
+	CommandArgTypeInt = "int"
+	// CommandArgTypeFloat 表示小数参数，Min 和 Max 限制其取值范围
+	CommandArgTypeFloat = "float"
+	// CommandArgTypeBool 表示布尔参数，取值规范化为 true 或 false
+	CommandArgTypeBool = "bool"
+)
+
+// commandArgNamePattern 是参数名称的格式，使其可以在模板中以 .Args.参数名 引用
+var commandArgNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CommandArgConfig 表示指令的一个参数
+type CommandArgConfig struct {
+	// Name 是参数的名称
+	Name string `toml:"name" validate:"required,max=32" comment:"参数名称，只能包含字母、数字和下划线，且不以数字开头"`
+
+	// Description 是参数的说明，供前端展示
+	Description string `toml:"description" comment:"参数的说明，供前端展示"`
+
+	// Type 是参数的类型，取值为 CommandArgTypeString、CommandArgTypeInt、CommandArgTypeFloat 或 CommandArgTypeBool
+	Type string `toml:"type" validate:"required,oneof=string int float bool" comment:"参数类型，可以为string、int、float或bool"`
+
+	// Required 表示参数是否必须提供
+	Required bool `toml:"required" comment:"是否必须提供该参数"`
+
+	// Default 是未提供参数时使用的值
+	Default string `toml:"default" comment:"未提供该参数时使用的值。留空且参数非必须时，参数值为空"`
+
+	// Pattern 是字符串参数需要完整匹配的正则表达式
+	Pattern string `toml:"pattern" comment:"字符串参数需要完整匹配的正则表达式"`
+
+	// Enum 是字符串参数的可选值
+	Enum []string `toml:"enum" comment:"字符串参数的可选值。留空表示不限制"`
+
+	// Min 是整数、小数参数的最小值，或者字符串参数的最小长度
+	Min *float64 `toml:"min" comment:"整数、小数参数的最小值，或者字符串参数的最小长度"`
+
+	// Max 是整数、小数参数的最大值，或者字符串参数的最大长度
+	Max *float64 `toml:"max" comment:"整数、小数参数的最大值，或者字符串参数的最大长度"`
+}
+
+// Check 检查参数的声明是否合法，包括名称格式、正则表达式以及默认值
+func (a CommandArgConfig) Check() error {
+	if !commandArgNamePattern.MatchString(a.Name) {
+		return fmt.Errorf("invalid arg name %s", a.Name)
+	}
+
+	if a.Type != CommandArgTypeString && (a.Pattern != "" || len(a.Enum) > 0) {
+		return fmt.Errorf("arg %s: pattern and enum are only allowed on string args", a.Name)
+	}
+
+	if a.Pattern != "" {
+		if _, err := regexp.Compile(a.Pattern); err != nil {
+			return fmt.Errorf("arg %s: %w", a.Name, err)
+		}
+	}
+
+	if a.Default != "" {
+		if _, err := a.Validate(a.Default); err != nil {
+			return fmt.Errorf("arg %s: invalid default: %w", a.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate 按照参数的声明校验参数值 value，返回规范化后的参数值
+func (a CommandArgConfig) Validate(value string) (string, error) {
+	switch a.Type {
+	case CommandArgTypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return "", fmt.Errorf("%s 需要是整数", a.Name)
+		}
+
+		if err := a.checkRange(float64(n)); err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(n, 10), nil
+
+	case CommandArgTypeFloat:
+		f, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return "", fmt.Errorf("%s 需要是数字", a.Name)
+		}
+
+		if err := a.checkRange(f); err != nil {
+			return "", err
+		}
+
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+
+	case CommandArgTypeBool:
+		b, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return "", fmt.Errorf("%s 需要是布尔值", a.Name)
+		}
+
+		return strconv.FormatBool(b), nil
+	}
+
+	if err := a.checkRange(float64(utf8.RuneCountInString(value))); err != nil {
+		return "", fmt.Errorf("%s 的长度不符合要求", a.Name)
+	}
+
+	if len(a.Enum) > 0 && !slices.Contains(a.Enum, value) {
+		return "", fmt.Errorf("%s 需要是以下值之一：%v", a.Name, a.Enum)
+	}
+
+	if a.Pattern != "" {
+		pattern, err := regexp.Compile("^(?:" + a.Pattern + ")$")
+
+		if err != nil {
+			return "", err
+		}
+
+		if !pattern.MatchString(value) {
+			return "", fmt.Errorf("%s 的格式不正确", a.Name)
+		}
+	}
+
+	return value, nil
+}
+
+func (a CommandArgConfig) checkRange(v float64) error {
+	if a.Min != nil && v < *a.Min {
+		return fmt.Errorf("%s 不能小于 %v", a.Name, *a.Min)
+	}
+
+	if a.Max != nil && v > *a.Max {
+		return fmt.Errorf("%s 不能大于 %v", a.Name, *a.Max)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// CommandLimitScopeGlobal 表示所有执行者（包括系统自动执行）共享同一个限制
+	CommandLimitScopeGlobal = "global"
+	// CommandLimitScopeUser 表示每个用户分别受到限制
+	CommandLimitScopeUser = "user"
+	// CommandLimitScopeRole 表示同一权限等级的用户共享同一个限制
+	CommandLimitScopeRole = "role"
+)
+
+// CommandLimitConfig 表示指令的一个频率限制，即在 Per 秒内最多执行 Count 次。
+// 限制以令牌桶的方式实现：桶的容量为 Count，每 Per/Count 秒恢复一次执行机会，因此 Count 为 1 时等同于冷却时间为 Per 秒
+type CommandLimitConfig struct {
+	// Scope 是限制的作用范围，取值为 CommandLimitScopeGlobal、CommandLimitScopeUser 或 CommandLimitScopeRole
+	Scope string `toml:"scope" validate:"required,oneof=global user role" comment:"限制的作用范围。global表示所有执行者共享；user表示每个用户分别计算；role表示同一权限等级的用户共享"`
+
+	// Role 表示该限制只作用于该权限等级的用户，留空表示作用于所有用户。不能用于 CommandLimitScopeGlobal
+	Role string `toml:"role" validate:"omitempty,oneof=user admin" comment:"只对该权限等级的用户生效，可以为user或admin。留空表示对所有用户生效，scope为global时必须留空"`
+
+	// Count 是 Per 秒内最多执行的次数
+	Count int `toml:"count" validate:"required,gte=1" comment:"时间窗口内最多执行的次数"`
+
+	// Per 是时间窗口的长度，单位秒
+	Per int `toml:"per" validate:"required,gte=1" comment:"时间窗口的长度，单位秒"`
+}
+
+// Key 返回该限制的标识符，用于区分同一指令的不同限制。修改限制的参数后，原有的计数不再生效
+func (l CommandLimitConfig) Key() string {
+	return fmt.Sprintf("%s/%s:%d/%d", l.Scope, l.Role, l.Count, l.Per)
+}
+
+// Interval 返回恢复一次执行机会所需的时间
+func (l CommandLimitConfig) Interval() time.Duration {
+	return time.Duration(l.Per) * time.Second / time.Duration(l.Count)
+}
+
+// Check 检查限制的声明是否合法
+func (l CommandLimitConfig) Check() error {
+	if l.Scope == CommandLimitScopeGlobal && l.Role != "" {
+		return fmt.Errorf("limit %s: role is not allowed on global limits", l.Key())
+	}
+
+	return nil
+}
+
+// GetLimits 返回指令的所有频率限制。Cooldown 不为 0 时，相当于一个次数为 1、时间窗口为 Cooldown 的全局限制
+func (c CommandConfig) GetLimits() []CommandLimitConfig {
+	if c.Cooldown == 0 {
+		return c.Limits
+	}
+
+	return append([]CommandLimitConfig{{Scope: CommandLimitScopeGlobal, Count: 1, Per: c.Cooldown}}, c.Limits...)
+}
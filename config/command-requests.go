@@ -0,0 +1,46 @@
+package config
+
+import "time"
+
+const (
+	// CommandVotersWhitelisted 表示在档案中有白名单的用户可以投票
+	CommandVotersWhitelisted = "whitelisted"
+	// CommandVotersOnline 表示当前在服务器内在线的用户可以投票
+	CommandVotersOnline = "online"
+)
+
+// defaultCommandRequestTimeLimit 是 CommandRequestConfig.TimeLimit 为 0 时请求的有效时间
+const defaultCommandRequestTimeLimit = 10 * time.Minute
+
+// CommandRequestConfig 表示用户请求执行指令的方式。权限不足的用户可以提出执行该指令的请求，请求经管理员批准，或者在有效时间内得到足够的赞成票后，指令以请求者的身份执行
+type CommandRequestConfig struct {
+	// Enabled 表示是否允许用户请求执行该指令
+	Enabled bool `toml:"enabled" comment:"是否允许权限不足但有白名单的用户请求执行该指令"`
+
+	// Quorum 是请求通过所需的赞成票数。反对票达到该数量时请求被否决。为 0 表示只能由管理员批准
+	Quorum int `toml:"quorum" validate:"gte=0" comment:"请求通过所需的赞成票数，反对票达到该数量时请求被否决。请求者自动投赞成票。为0表示只能由管理员批准"`
+
+	// Voters 是可以投票的用户范围，取值为 CommandVotersWhitelisted 或 CommandVotersOnline
+	Voters string `toml:"voters" validate:"omitempty,oneof=whitelisted online" comment:"可以投票的用户范围。whitelisted表示在档案中有白名单的用户；online表示当前在服务器内在线的用户。留空表示whitelisted"`
+
+	// TimeLimit 是请求的有效时间，单位秒
+	TimeLimit int `toml:"time_limit" validate:"gte=0" comment:"请求的有效时间，单位秒，超时未通过的请求失效。为0表示600"`
+}
+
+// TimeLimitDuration 返回请求的有效时间
+func (r CommandRequestConfig) TimeLimitDuration() time.Duration {
+	if r.TimeLimit == 0 {
+		return defaultCommandRequestTimeLimit
+	}
+
+	return time.Duration(r.TimeLimit) * time.Second
+}
+
+// GetVoters 返回可以投票的用户范围
+func (r CommandRequestConfig) GetVoters() string {
+	if r.Voters == "" {
+		return CommandVotersWhitelisted
+	}
+
+	return r.Voters
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Subilan/go-aliyunmc/helpers/cron"
+)
+
+// CommandScheduleConfig 表示一个定时指令计划。每当 Cron 触发时，系统在档案的活动实例上以自动执行的身份运行指令 Command，或者在服务器内广播消息 Message。参见 monitors.CommandScheduler
+type CommandScheduleConfig struct {
+	// Name 是计划的名称，同时是计划的标识符
+	Name string `toml:"name" validate:"required,alphanum,max=20" comment:"计划名称，仅允许字母和数字，最长20个字符，用作计划的标识符"`
+
+	// Profile 是计划作用的档案。留空则为 DefaultProfileName
+	Profile string `toml:"profile" comment:"计划作用的档案名称，留空则为default"`
+
+	// Cron 是运行时间的 cron 表达式，使用系统本地时区
+	Cron string `toml:"cron" validate:"required" comment:"运行时间的cron表达式，格式为'分 时 日 月 周'，使用系统本地时区。例如'0 * * * *'表示每小时整点"`
+
+	// Command 是要运行的指令类型，与 Message 二选一
+	Command string `toml:"command" validate:"required_without=Message" comment:"要运行的指令类型，必须是已声明的非查询类指令。与message二选一"`
+
+	// Args 是运行指令时传入的参数
+	Args map[string]string `toml:"args" comment:"运行指令时传入的参数"`
+
+	// Message 是在服务器内广播的消息，与 Command 二选一
+	Message string `toml:"message" validate:"excluded_with=Command,max=200" comment:"在服务器内广播的消息，与command二选一"`
+}
+
+// Check 检查计划的 cron 表达式能否解析且会触发、档案是否存在，以及指令和参数或者消息是否合法。调用前需要先将留空的 Profile 填充为默认值
+func (s CommandScheduleConfig) Check(c Config) error {
+	expr, err := cron.Parse(s.Cron)
+
+	if err != nil {
+		return fmt.Errorf("command schedule %s: %w", s.Name, err)
+	}
+
+	if err := expr.Validate(); err != nil {
+		return fmt.Errorf("command schedule %s: %w", s.Name, err)
+	}
+
+	if _, ok := c.GetProfile(s.Profile); !ok {
+		return fmt.Errorf("command schedule %s: profile %s not found", s.Name, s.Profile)
+	}
+
+	if s.Message != "" {
+		if strings.IndexFunc(s.Message, unicode.IsControl) >= 0 {
+			return fmt.Errorf("command schedule %s: message contains control characters", s.Name)
+		}
+
+		return nil
+	}
+
+	var cmd *CommandConfig
+
+	for i := range c.Commands {
+		if c.Commands[i].Type == s.Command {
+			cmd = &c.Commands[i]
+			break
+		}
+	}
+
+	if cmd == nil {
+		return fmt.Errorf("command schedule %s: command %s is not declared", s.Name, s.Command)
+	}
+
+	if cmd.IsQuery {
+		return fmt.Errorf("command schedule %s: query command %s cannot be scheduled", s.Name, s.Command)
+	}
+
+	for name, value := range s.Args {
+		var arg *CommandArgConfig
+
+		for i := range cmd.Args {
+			if cmd.Args[i].Name == name {
+				arg = &cmd.Args[i]
+				break
+			}
+		}
+
+		if arg == nil {
+			return fmt.Errorf("command schedule %s: unknown arg %s", s.Name, name)
+		}
+
+		if _, err := arg.Validate(value); err != nil {
+			return fmt.Errorf("command schedule %s: %w", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// resolveCommandSchedules 在指令加载之后调用，填充定时指令计划留空的字段并检查每个计划
+func (c *Config) resolveCommandSchedules() error {
+	for i := range c.CommandSchedules {
+		s := &c.CommandSchedules[i]
+
+		if s.Profile == "" {
+			s.Profile = DefaultProfileName
+		}
+
+		if err := s.Check(*c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/go-playground/validator/v10"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	// CommandPrerequisiteInstanceDeployed 表示指令要求活动实例已经部署且分配了IP地址
+	CommandPrerequisiteInstanceDeployed = "instance_deployed"
+	// CommandPrerequisiteServerOnline 表示指令要求活动实例已经部署，且服务器在线
+	CommandPrerequisiteServerOnline = "server_online"
+	// CommandPrerequisiteServerOffline 表示指令要求活动实例已经部署，且服务器不在线
+	CommandPrerequisiteServerOffline = "server_offline"
+)
+
+const (
+	// CommandRoleUser 表示指令要求普通用户权限
+	CommandRoleUser = "user"
+	// CommandRoleAdmin 表示指令要求管理员权限
+	CommandRoleAdmin = "admin"
+)
+
+// DefaultCommandsFile 是 CommandsFile 留空时使用的指令文件
+const DefaultCommandsFile = "commands.toml"
+
+// requiredCommands 是系统自身的流程所依赖的指令，必须在配置中声明
+var requiredCommands = []consts.CommandType{
+	consts.CmdTypeStartServer,
+	consts.CmdTypeStopServer,
+	consts.CmdTypeBackupWorlds,
+	consts.CmdTypeArchiveServer,
+	consts.CmdTypeWarnSpotInterruption,
+}
+
+// CommandConfig 表示一个系统代用户在活动实例上执行的预先编写的指令，参见 commands.Command
+type CommandConfig struct {
+	// Type 是指令的类型，同时是指令的标识符。长度限制与数据库中各指令类型列的长度一致
+	Type string `toml:"type" validate:"required,max=32" comment:"指令类型，同时是指令的标识符，最长32个字符"`
+
+	// Description 是指令的说明，供前端展示
+	Description string `toml:"description" comment:"指令的说明，供前端展示"`
+
+	// ExecuteLocation 是指令执行的位置，取值为 shell 或 server
+	ExecuteLocation string `toml:"execute_location" validate:"required,oneof=shell server" comment:"指令执行的位置。shell表示在实例的Shell中执行；server表示通过RCON在服务器内执行"`
+
+	// Role 是指令要求的权限等级，取值为 CommandRoleUser 或 CommandRoleAdmin，留空表示不限制
+	Role string `toml:"role" validate:"omitempty,oneof=user admin" comment:"指令要求的权限等级，可以为user或admin。留空表示不限制"`
+
+	// Whitelisted 表示指令是否要求用户绑定游戏账号且有白名单
+	Whitelisted bool `toml:"whitelisted" comment:"是否要求用户绑定游戏账号且在档案中有白名单"`
+
+	// Cooldown 是指令的冷却时间，单位秒
+	Cooldown int `toml:"cooldown" validate:"gte=0" comment:"冷却时间，单位秒，所有执行者共享。查询类指令没有冷却时间"`
+
+	// Limits 是指令的频率限制，与 Cooldown 同时生效，参见 CommandLimitConfig
+	Limits []CommandLimitConfig `toml:"limits" validate:"omitempty,dive" comment:"指令的频率限制，与cooldown同时生效，任何一个限制未满足时指令都处于冷却中。查询类指令不受限制"`
+
+	// Timeout 是指令的推荐超时时间，单位秒
+	Timeout int `toml:"timeout" validate:"required,gte=1" comment:"超时时间，单位秒"`
+
+	// IsQuery 表示指令是否属于查询类指令
+	IsQuery bool `toml:"is_query" comment:"是否为查询类指令。查询类指令没有冷却时间，总是返回输出，且不会记录在指令执行记录中"`
+
+	// Prerequisite 是指令运行的前置条件，取值为 CommandPrerequisiteInstanceDeployed、CommandPrerequisiteServerOnline 或 CommandPrerequisiteServerOffline，留空表示没有前置条件
+	Prerequisite string `toml:"prerequisite" validate:"omitempty,oneof=instance_deployed server_online server_offline" comment:"前置条件，可以为instance_deployed、server_online或server_offline。留空表示没有前置条件"`
+
+	// Content 是指令的内容，每一项按顺序执行，可以使用 templateData.ArchiveTemplateData 中的字段作为模板参数
+	Content []string `toml:"content" validate:"required_without=ContentFile" comment:"指令的内容，每一项按顺序执行。可以使用Go模板语法引用档案的参数，如{{ .ArchiveOSSPath }}"`
+
+	// ContentFile 是存放指令内容的模板文件，与 Content 二选一。适用于较长的脚本
+	ContentFile string `toml:"content_file" validate:"excluded_with=Content" comment:"存放指令内容的模板文件，与content二选一，适用于较长的脚本"`
+
+	// Args 是指令接受的参数。参数值经过校验和转义后，可以在内容中以 {{ .Args.参数名 }} 引用
+	Args []CommandArgConfig `toml:"args" validate:"omitempty,unique=Name,dive" comment:"指令接受的参数。参数值经过校验，并按照execute_location转义后，可以在内容中以{{ .Args.参数名 }}引用"`
+
+	// Request 是用户请求执行该指令的方式，参见 CommandRequestConfig
+	Request CommandRequestConfig `toml:"request" comment:"权限不足的用户请求执行该指令的方式。请求经管理员批准，或者得到足够的赞成票后执行"`
+}
+
+// commandsFile 是 CommandsFile 的文件结构
+type commandsFile struct {
+	Commands []CommandConfig `toml:"commands" validate:"dive"`
+}
+
+// GetCommandsFile 返回指令文件的路径
+func (c Config) GetCommandsFile() string {
+	if c.CommandsFile == "" {
+		return DefaultCommandsFile
+	}
+
+	return c.CommandsFile
+}
+
+// ReadContent 返回指令的内容。声明了 ContentFile 时，读取该文件的内容
+func (c CommandConfig) ReadContent() ([]string, error) {
+	if c.ContentFile == "" {
+		return c.Content, nil
+	}
+
+	content, err := os.ReadFile(c.ContentFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{string(content)}, nil
+}
+
+// Check 检查指令的内容能否作为模板被正确解析，以及参数和频率限制的声明是否合法
+func (c CommandConfig) Check() error {
+	content, err := c.ReadContent()
+
+	if err != nil {
+		return fmt.Errorf("command %s: %w", c.Type, err)
+	}
+
+	for _, item := range content {
+		if _, err := template.New(c.Type).Parse(item); err != nil {
+			return fmt.Errorf("command %s: %w", c.Type, err)
+		}
+	}
+
+	for _, arg := range c.Args {
+		if err := arg.Check(); err != nil {
+			return fmt.Errorf("command %s: %w", c.Type, err)
+		}
+	}
+
+	for _, limit := range c.Limits {
+		if err := limit.Check(); err != nil {
+			return fmt.Errorf("command %s: %w", c.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// resolveCommands 读取指令文件，将其中的指令与配置文件中的指令合并并检查。配置文件中的指令会覆盖指令文件中类型相同的指令
+func (c *Config) resolveCommands() error {
+	var file commandsFile
+
+	content, err := os.ReadFile(c.GetCommandsFile())
+
+	if err != nil && !(errors.Is(err, os.ErrNotExist) && c.CommandsFile == "") {
+		return err
+	}
+
+	if err == nil {
+		if err := toml.Unmarshal(content, &file); err != nil {
+			return fmt.Errorf("%s: %w", c.GetCommandsFile(), err)
+		}
+
+		if err := validator.New().Struct(file); err != nil {
+			return fmt.Errorf("%s: %w", c.GetCommandsFile(), err)
+		}
+	}
+
+	declared := make(map[string]bool, len(c.Commands))
+
+	for _, cmd := range c.Commands {
+		declared[cmd.Type] = true
+	}
+
+	merged := make([]CommandConfig, 0, len(file.Commands)+len(c.Commands))
+
+	for _, cmd := range file.Commands {
+		if declared[cmd.Type] {
+			continue
+		}
+
+		if hasCommand(merged, cmd.Type) {
+			return fmt.Errorf("%s: duplicate command %s", c.GetCommandsFile(), cmd.Type)
+		}
+
+		merged = append(merged, cmd)
+	}
+
+	c.Commands = append(merged, c.Commands...)
+
+	for _, cmd := range c.Commands {
+		if err := cmd.Check(); err != nil {
+			return err
+		}
+	}
+
+	for _, typ := range requiredCommands {
+		if !hasCommand(c.Commands, string(typ)) {
+			return fmt.Errorf("required command %s is not declared", typ)
+		}
+	}
+
+	return nil
+}
+
+func hasCommand(commands []CommandConfig, typ string) bool {
+	for _, cmd := range commands {
+		if cmd.Type == typ {
+			return true
+		}
+	}
+
+	return false
+}
@@ -30,16 +30,36 @@ type Config struct {
 
 	// Server 是与 Minecraft 服务器的相关配置。
 	Server ServerConfig `toml:"server" validate:"required"`
+
+	// Profiles 是系统管理的所有档案。留空时系统生成一个默认档案。
+	Profiles []ProfileConfig `toml:"profiles" validate:"omitempty,unique=Name,dive" comment:"系统管理的服务器档案列表，每个档案拥有独立的实例、归档和监控器。留空时使用deploy和server中的配置生成名为default的默认档案"`
+
+	// Dns 是与解析记录管理相关的配置。
+	Dns DnsConfig `toml:"dns"`
+
+	// Schedules 是服务器的开放时段计划。
+	Schedules []ScheduleConfig `toml:"schedules" validate:"omitempty,unique=Name,dive" comment:"服务器的开放时段计划列表。系统在开放时段开始前自动开启服务器，在结束时关闭服务器并删除实例"`
+
+	// CommandsFile 是声明指令的文件，留空时使用 DefaultCommandsFile。
+	CommandsFile string `toml:"commands_file" comment:"声明指令的文件，其格式与本文件的commands相同。留空使用commands.toml"`
+
+	// Commands 是系统代用户在活动实例上执行的指令。与 CommandsFile 中类型相同的指令会覆盖后者。
+	Commands []CommandConfig `toml:"commands" validate:"omitempty,unique=Type,dive" comment:"系统代用户在活动实例上执行的指令列表，会覆盖commands_file中类型相同的指令"`
+
+	// CommandSchedules 是定时指令计划。
+	CommandSchedules []CommandScheduleConfig `toml:"command_schedules" validate:"omitempty,unique=Name,dive" comment:"定时指令计划列表。系统按照cron表达式在活动实例上自动运行指令或者在服务器内广播消息，前置条件未满足时跳过"`
+
+	// Exporter 是 Prometheus 指标导出的相关配置。
+	Exporter ExporterConfig `toml:"exporter"`
 }
 
 func (c Config) GetAliyunEcsConfig() AliyunEcsConfig {
 	return c.Aliyun.Ecs
 }
-func (c Config) GetGamePort() uint16 {
-	return c.Server.Port
-}
-func (c Config) GetGameRconPort() uint16 {
-	return c.Server.RconPort
+
+// ImageFingerprint 返回当前配置下预制镜像的指纹，参见 DeployConfig.ImageFingerprint
+func (c Config) ImageFingerprint() string {
+	return c.Deploy.ImageFingerprint(c.Aliyun.Ecs.ImageId)
 }
 
 // Load 用于完成配置文件内容的读取，如果出现了错误，此函数将导致程序退出。
@@ -78,6 +98,28 @@ func load(filename string) error {
 		return err
 	}
 
-	log.Print("OK")
+	Cfg.resolveProfiles()
+
+	if err := Cfg.resolveSchedules(); err != nil {
+		log.Println("config validation error:", err)
+		return err
+	}
+
+	if err := Cfg.resolveCommands(); err != nil {
+		log.Println("config validation error:", err)
+		return err
+	}
+
+	if err := Cfg.resolveCommandSchedules(); err != nil {
+		log.Println("config validation error:", err)
+		return err
+	}
+
+	if err := Cfg.Monitor.Migration.Check(); err != nil {
+		log.Println("config validation error:", err)
+		return err
+	}
+
+	log.Printf("OK, %d profile(s) loaded", len(Cfg.Profiles))
 	return nil
 }
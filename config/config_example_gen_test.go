@@ -26,6 +26,8 @@ func TestGenConfig(t *testing.T) {
 			RegionId:        "",
 			AccessKeyId:     "",
 			AccessKeySecret: "",
+			Provider:        ProviderAliyun,
+			FakePublicIp:    "",
 			Ecs: AliyunEcsConfig{
 				InternetMaxBandwidthOut: 5,
 				ImageId:                 "",
@@ -69,7 +71,8 @@ func TestGenConfig(t *testing.T) {
 					MaxTradePrice:         0.6,
 					InstanceTypeExclusion: "^ecs\\.(e|s6|xn4|n4|mn4|e4|t|d).*$",
 				},
-				CacheFile: "latest_preferred_instance_charge.json",
+				CandidateMaxTradePrice: 0.8,
+				CacheFile:              "latest_preferred_instance_charge.json",
 			},
 			Backup: Backup{
 				Interval:      600,
@@ -77,29 +80,156 @@ func TestGenConfig(t *testing.T) {
 				Timeout:       120,
 			},
 			EmptyServer: EmptyServer{
-				EmptyTimeout: 3600,
+				EmptyTimeout:     3600,
+				Mode:             EmptyServerModeArchive,
+				HibernateMaxDays: 7,
 			},
 			ServerStatus: ServerStatus{
-				Interval: 5,
-				Timeout:  10,
+				Interval:        5,
+				Timeout:         10,
+				SampleRetention: 365,
 			},
 			StartInstance: StartInstance{
 				Interval: 5,
 				Timeout:  120,
 			},
+			SpotInterruption: SpotInterruption{
+				Interval:   10,
+				Timeout:    10,
+				Action:     SpotInterruptionActionBackup,
+				EventTypes: []string{"Instance:PreemptionInfo"},
+			},
+			Migration: Migration{
+				Enabled:        false,
+				Interval:       300,
+				Margin:         0.1,
+				WhenEmpty:      true,
+				Window:         "0 5 * * *",
+				WindowDuration: 60,
+				Cooldown:       60,
+			},
+			Metrics: Metrics{
+				Enabled:         true,
+				Interval:        30,
+				Timeout:         10,
+				DownsampleAfter: 24,
+				DownsampleStep:  300,
+				Retention:       30,
+			},
+			CrashWatchdog: CrashWatchdog{
+				Enabled:        true,
+				MaxAttempts:    3,
+				InitialBackoff: 30,
+				MaxBackoff:     600,
+				StartupTimeout: 300,
+				StableAfter:    600,
+				LogLines:       100,
+			},
+			GameLog: GameLog{
+				Enabled:   true,
+				Retention: 90,
+			},
 		},
 		Deploy: DeployConfig{
-			Packages:     []string{"screen", "unzip", "zip", "screenfetch", "vim", "htop"},
-			SSHPublicKey: "",
-			JavaVersion:  21,
-			OSSRoot:      "oss://mybucket",
-			BackupPath:   "/backups",
-			ArchivePath:  "/archive",
+			Packages:          []string{"screen", "unzip", "zip", "screenfetch", "vim", "htop"},
+			SSHPublicKey:      "",
+			JavaVersion:       21,
+			OSSRoot:           "oss://mybucket",
+			BackupPath:        "/backups",
+			ArchivePath:       "/archive",
+			ArchiveStrategy:   ArchiveStrategyOSS,
+			SnapshotRetention: 3,
+			BakeImage:         false,
 		},
 		Server: ServerConfig{
 			Port:         25565,
 			RconPort:     25575,
 			RconPassword: "",
+			Flavor:       ServerFlavorPaper,
+		},
+		Dns: DnsConfig{
+			Provider:      "",
+			Domain:        "example.com",
+			Name:          "mc",
+			TTL:           600,
+			Srv:           false,
+			ParkIp:        "",
+			FilePath:      "dns.zone",
+			RetryInterval: 60,
+		},
+		Schedules: []ScheduleConfig{
+			{
+				Name:      "weekend",
+				Profile:   "default",
+				Cron:      "0 19 * * 5,6",
+				Duration:  240,
+				LeadTime:  15,
+				Warnings:  []int{10, 5, 1},
+				Protected: true,
+			},
+		},
+		CommandsFile: "commands.toml",
+		Commands: []CommandConfig{
+			{
+				Type:            "list_players",
+				Description:     "列出在线玩家",
+				ExecuteLocation: "server",
+				Role:            "",
+				Whitelisted:     false,
+				Cooldown:        0,
+				Timeout:         5,
+				IsQuery:         true,
+				Prerequisite:    CommandPrerequisiteServerOnline,
+				Content:         []string{"list"},
+			},
+			{
+				Type:            "time_set",
+				Description:     "设置游戏内时间",
+				ExecuteLocation: "server",
+				Role:            CommandRoleAdmin,
+				Cooldown:        10,
+				Limits: []CommandLimitConfig{
+					{Scope: CommandLimitScopeUser, Count: 3, Per: 3600},
+				},
+				Timeout:      5,
+				Prerequisite: CommandPrerequisiteServerOnline,
+				Content:      []string{"time set {{ .Args.value }}"},
+				Args: []CommandArgConfig{
+					{
+						Name:        "value",
+						Description: "时间",
+						Type:        CommandArgTypeString,
+						Required:    true,
+						Pattern:     `day|night|noon|midnight|\d+`,
+					},
+				},
+				Request: CommandRequestConfig{
+					Enabled:   true,
+					Quorum:    3,
+					Voters:    CommandVotersOnline,
+					TimeLimit: 300,
+				},
+			},
+		},
+		CommandSchedules: []CommandScheduleConfig{
+			{
+				Name:    "morning",
+				Profile: "default",
+				Cron:    "0 8 * * *",
+				Command: "time_set",
+				Args:    map[string]string{"value": "day"},
+			},
+			{
+				Name:    "announce",
+				Profile: "default",
+				Cron:    "0 * * * *",
+				Message: "欢迎来到服务器，请遵守服务器规则。",
+			},
+		},
+		Exporter: ExporterConfig{
+			Enabled:  false,
+			Token:    "",
+			AllowIPs: []string{"127.0.0.1", "10.0.0.0/8"},
 		},
 	})
 
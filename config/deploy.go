@@ -1,6 +1,12 @@
 package config
 
-import "path/filepath"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
 
 // DeployConfig 包含与实例部署行为的相关配置内容。目前实例部署仅支持 debian 系系统，欢迎贡献扩展。
 type DeployConfig struct {
@@ -21,18 +27,50 @@ type DeployConfig struct {
 
 	// ArchivePath 是用于存储归档的存储桶内地址，相对于 OSSRoot
 	ArchivePath string `toml:"archive_path" validate:"required" comment:"用于存储归档的备份桶内地址，相对于OSSRoot，例如/archive"`
+
+	// ArchiveStrategy 是归档和恢复服务器的方式，取值为 ArchiveStrategyOSS 或 ArchiveStrategySnapshot，留空等同于 ArchiveStrategyOSS
+	ArchiveStrategy string `toml:"archive_strategy" validate:"omitempty,oneof=oss snapshot" comment:"归档方式。oss表示使用ossutil将服务器目录复制到存储桶；snapshot表示为数据盘创建快照，下次创建实例时从最新快照创建数据盘。留空等同于oss"`
+
+	// SnapshotRetention 是每个档案最多保留的归档快照数量，超出的旧快照会在归档后被删除。为0时不限制
+	SnapshotRetention int `toml:"snapshot_retention" validate:"gte=0" comment:"使用snapshot归档方式时，每个档案最多保留的快照数量，超出的旧快照将被删除。为0表示不限制"`
+
+	// BakeImage 表示是否在部署成功后为实例的系统盘制作预制镜像。之后创建的实例将从预制镜像启动，部署时只需挂载数据盘和复制归档
+	BakeImage bool `toml:"bake_image" comment:"是否在部署成功后制作预制镜像。之后创建的实例将从预制镜像启动，跳过软件安装等步骤。修改packages或java_version后，旧的预制镜像将失效"`
+}
+
+const (
+	// ArchiveStrategyOSS 表示通过 ossutil 将服务器目录复制到存储桶进行归档，部署时再从存储桶复制回来
+	ArchiveStrategyOSS = "oss"
+	// ArchiveStrategySnapshot 表示通过为数据盘创建快照进行归档，创建实例时从最新快照创建数据盘
+	ArchiveStrategySnapshot = "snapshot"
+)
+
+// UseSnapshotArchive 返回是否使用快照进行归档
+func (d DeployConfig) UseSnapshotArchive() bool {
+	return d.ArchiveStrategy == ArchiveStrategySnapshot
 }
 
 func (d DeployConfig) BucketName() string {
 	return d.OSSRoot[6:]
 }
 
+// OSSPath 返回从 OSSRoot 和 path 合并出的最终存储桶内地址
+func (d DeployConfig) OSSPath(path string) string {
+	return "oss://" + filepath.Join(d.OSSRoot[6:], path)
+}
+
 // BackupOSSPath 返回从 OSSRoot 和 BackupPath 合并出的最终存储桶内地址
 func (d DeployConfig) BackupOSSPath() string {
-	return "oss://" + filepath.Join(d.OSSRoot[6:], d.BackupPath)
+	return d.OSSPath(d.BackupPath)
 }
 
 // ArchiveOSSPath 返回从 OSSRoot 和 ArchivePath 合并出的最终存储桶内地址
 func (d DeployConfig) ArchiveOSSPath() string {
-	return "oss://" + filepath.Join(d.OSSRoot[6:], d.ArchivePath)
+	return d.OSSPath(d.ArchivePath)
+}
+
+// ImageFingerprint 返回预制镜像内容的指纹，由基础镜像、Packages 和 JavaVersion 决定。指纹不同的预制镜像被视为已失效
+func (d DeployConfig) ImageFingerprint(baseImageId string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%d\n%s", baseImageId, d.JavaVersion, strings.Join(d.Packages, " "))))
+	return hex.EncodeToString(sum[:])[:16]
 }
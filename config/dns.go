@@ -0,0 +1,68 @@
+package config
+
+import "time"
+
+// DnsConfig 包含了解析记录管理的相关配置。启用后，系统在活动实例的公网IP地址变化时自动更新档案对应的A记录（以及可选的SRV记录），使玩家可以通过固定的域名连接服务器。参见 monitors.DNS
+type DnsConfig struct {
+	// Provider 是管理解析记录的方式，取值为 DnsProviderAlidns 或 DnsProviderFile，留空表示不管理解析记录
+	Provider string `toml:"provider" validate:"omitempty,oneof=alidns file" comment:"管理解析记录的方式。alidns表示使用阿里云云解析DNS；file表示写入本地的zone格式文件，供本地DNS服务器加载。留空表示不管理解析记录"`
+
+	// Domain 是解析记录所属的域名，例如 example.com
+	Domain string `toml:"domain" validate:"required_with=Provider" comment:"解析记录所属的域名，例如example.com。使用alidns时，该域名需要已经添加到云解析DNS中"`
+
+	// Name 是默认档案的主机记录，例如 mc 表示 mc.example.com。声明了档案时，使用 ProfileConfig.DnsName
+	Name string `toml:"name" comment:"默认档案的主机记录，例如mc表示mc.example.com。声明了profiles时使用各档案的dns_name"`
+
+	// TTL 是解析记录的生存时间，单位秒
+	TTL int `toml:"ttl" validate:"omitempty,gte=1" comment:"解析记录的生存时间，单位秒。留空使用600"`
+
+	// Srv 表示是否同时管理 _minecraft._tcp SRV 记录，使服务器使用非默认端口时玩家无需输入端口
+	Srv bool `toml:"srv" comment:"是否同时管理_minecraft._tcp的SRV记录，使服务器使用非默认端口时玩家无需输入端口"`
+
+	// ParkIp 是实例被删除或休眠后A记录指向的地址。留空则在实例被删除或休眠后删除解析记录
+	ParkIp string `toml:"park_ip" validate:"omitempty,ip" comment:"实例被删除或休眠后A记录指向的地址，例如一台展示服务器开放时间的主机。留空则删除解析记录"`
+
+	// FilePath 是 DnsProviderFile 写入的文件路径
+	FilePath string `toml:"file_path" validate:"required_if=Provider file" comment:"使用file时写入解析记录的文件路径"`
+
+	// RetryInterval 是更新解析记录失败后的重试间隔，单位秒
+	RetryInterval int `toml:"retry_interval" validate:"omitempty,gte=1" comment:"更新解析记录失败后的重试间隔，单位秒。留空使用60"`
+}
+
+const (
+	// DnsProviderAlidns 表示使用阿里云云解析DNS管理解析记录
+	DnsProviderAlidns = "alidns"
+	// DnsProviderFile 表示将解析记录写入本地文件
+	DnsProviderFile = "file"
+)
+
+// Enabled 返回是否启用了解析记录管理
+func (d DnsConfig) Enabled() bool {
+	return d.Provider != ""
+}
+
+// GetTTL 返回解析记录的生存时间
+func (d DnsConfig) GetTTL() int {
+	if d.TTL == 0 {
+		return 600
+	}
+
+	return d.TTL
+}
+
+func (d DnsConfig) RetryIntervalDuration() time.Duration {
+	if d.RetryInterval == 0 {
+		return time.Minute
+	}
+
+	return time.Duration(d.RetryInterval) * time.Second
+}
+
+// Address 返回档案 p 的完整域名。如果没有启用解析记录管理，或档案没有配置 ProfileConfig.DnsName，返回空字符串
+func (d DnsConfig) Address(p ProfileConfig) string {
+	if !d.Enabled() || p.DnsName == "" {
+		return ""
+	}
+
+	return p.DnsName + "." + d.Domain
+}
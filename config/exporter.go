@@ -0,0 +1,43 @@
+package config
+
+import (
+	"net"
+	"strings"
+)
+
+// ExporterConfig 包含了 Prometheus 指标导出的相关配置。启用后，系统在 /metrics 以 Prometheus 文本格式导出后端和监控器的状态，参见 handlers.HandleMetrics
+type ExporterConfig struct {
+	// Enabled 表示是否启用指标导出
+	Enabled bool `toml:"enabled" comment:"是否在/metrics以Prometheus文本格式导出后端和监控器的状态"`
+
+	// Token 是访问指标时需要在 Authorization 头中以 Bearer 方式提供的令牌，留空表示不检查令牌
+	Token string `toml:"token" comment:"访问/metrics时需要在Authorization头中以Bearer方式提供的令牌。留空表示不检查令牌"`
+
+	// AllowIPs 是允许访问指标的IP地址或CIDR网段，留空表示不限制来源地址
+	AllowIPs []string `toml:"allow_ips" validate:"omitempty,dive,cidr|ip" comment:"允许访问/metrics的IP地址或CIDR网段列表。留空表示不限制来源地址。同时设置了token时两项检查都需要通过"`
+}
+
+// IsAllowedIP 返回地址 ip 是否在 AllowIPs 中。AllowIPs 为空时总是返回 true
+func (e ExporterConfig) IsAllowedIP(ip string) bool {
+	if len(e.AllowIPs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+
+	if addr == nil {
+		return false
+	}
+
+	for _, allowed := range e.AllowIPs {
+		if strings.Contains(allowed, "/") {
+			if _, network, err := net.ParseCIDR(allowed); err == nil && network.Contains(addr) {
+				return true
+			}
+		} else if allowedAddr := net.ParseIP(allowed); allowedAddr != nil && allowedAddr.Equal(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package config
+
+import "time"
+
+// CrashWatchdog 包含了 monitors.CrashWatchdog 的相关配置
+//
+// 启用后，如果服务器在实例运行期间意外退出（即退出前系统没有发出关闭服务器的指令），系统收集崩溃报告或者最后的日志，
+// 并按照指数退避的间隔尝试重新开启服务器，连续失败 MaxAttempts 次后放弃。
+type CrashWatchdog struct {
+	// Enabled 表示是否启用崩溃检测和自动重启
+	Enabled bool `toml:"enabled" comment:"是否在服务器意外退出时收集崩溃报告并自动重新开启服务器"`
+
+	// MaxAttempts 是连续重启的最大次数
+	MaxAttempts int `toml:"max_attempts" validate:"omitempty,gte=1" comment:"连续重启的最大次数，超过后放弃并等待管理员处理。留空使用3"`
+
+	// InitialBackoff 是第一次重启前等待的时间，单位秒。之后每次等待的时间翻倍
+	InitialBackoff int `toml:"initial_backoff" validate:"omitempty,gte=1" comment:"第一次重启前等待的时间，单位秒，之后每次翻倍。留空使用30"`
+
+	// MaxBackoff 是重启前等待的最长时间，单位秒
+	MaxBackoff int `toml:"max_backoff" validate:"omitempty,gte=1" comment:"重启前等待的最长时间，单位秒。留空使用600"`
+
+	// StartupTimeout 是重启后等待服务器上线的时间，单位秒，超过后视为重启失败
+	StartupTimeout int `toml:"startup_timeout" validate:"omitempty,gte=1" comment:"重启后等待服务器上线的时间，单位秒，超过后视为本次重启失败。留空使用300"`
+
+	// StableAfter 是服务器上线后需要保持在线的时间，单位秒，之后连续重启的次数清零
+	StableAfter int `toml:"stable_after" validate:"omitempty,gte=1" comment:"服务器重新上线后需要保持在线的时间，单位秒，之后连续重启次数清零。在此之前再次崩溃视为同一次故障。留空使用600"`
+
+	// LogLines 是找不到崩溃报告时收集的日志行数
+	LogLines int `toml:"log_lines" validate:"omitempty,gte=1,lte=1000" comment:"找不到崩溃报告时收集的最后日志行数。留空使用100"`
+}
+
+func (w CrashWatchdog) MaxAttemptsOrDefault() int {
+	if w.MaxAttempts == 0 {
+		return 3
+	}
+
+	return w.MaxAttempts
+}
+
+// BackoffDuration 返回第 attempt 次（从 1 开始）重启前等待的时间
+func (w CrashWatchdog) BackoffDuration(attempt int) time.Duration {
+	initial, maximum := 30*time.Second, 10*time.Minute
+
+	if w.InitialBackoff != 0 {
+		initial = time.Duration(w.InitialBackoff) * time.Second
+	}
+
+	if w.MaxBackoff != 0 {
+		maximum = time.Duration(w.MaxBackoff) * time.Second
+	}
+
+	backoff := initial
+
+	for i := 1; i < attempt && backoff < maximum; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maximum)
+}
+
+func (w CrashWatchdog) StartupTimeoutDuration() time.Duration {
+	if w.StartupTimeout == 0 {
+		return 5 * time.Minute
+	}
+
+	return time.Duration(w.StartupTimeout) * time.Second
+}
+
+func (w CrashWatchdog) StableAfterDuration() time.Duration {
+	if w.StableAfter == 0 {
+		return 10 * time.Minute
+	}
+
+	return time.Duration(w.StableAfter) * time.Second
+}
+
+func (w CrashWatchdog) LogLinesOrDefault() int {
+	if w.LogLines == 0 {
+		return 100
+	}
+
+	return w.LogLines
+}
@@ -6,10 +6,27 @@ import "time"
 type EmptyServer struct {
 	// EmptyTimeout 表示服务器的空转超时时间，单位秒
 	//
-	// 当 monitors.EmptyServer 发现服务器无玩家在线的状态持续超过此时间后，将停止服务器、归档并删除实例。
-	EmptyTimeout int `toml:"empty_timeout" validate:"required,gte=1" comment:"服务器空转超时时间，单位秒。超过此时间，服务器会被关闭、归档并删除"`
+	// 当 monitors.EmptyServer 发现服务器无玩家在线的状态持续超过此时间后，将根据 Mode 停止服务器、归档并删除实例，或者使实例休眠。
+	EmptyTimeout int `toml:"empty_timeout" validate:"required,gte=1" comment:"服务器空转超时时间，单位秒。超过此时间，服务器会被关闭，并根据mode归档删除或休眠"`
+
+	// Mode 表示服务器空转超时后的处理方式，取值为 EmptyServerModeArchive 或 EmptyServerModeHibernate，留空等同于 EmptyServerModeArchive
+	Mode string `toml:"mode" validate:"omitempty,oneof=archive hibernate" comment:"空转超时后的处理方式。archive表示归档并删除实例；hibernate表示以节省停机模式停止实例并保留云盘，下次开启时无需重新部署。留空等同于archive"`
+
+	// HibernateMaxDays 表示实例最长的休眠天数。休眠超过此天数的实例将被唤醒、归档并删除，以避免长期保留云盘产生费用。为0时不限制
+	HibernateMaxDays int `toml:"hibernate_max_days" validate:"gte=0" comment:"实例最长的休眠天数，超过此天数的实例将被唤醒、归档并删除。为0表示不限制"`
 }
 
+const (
+	// EmptyServerModeArchive 表示空转超时后停止服务器、归档并删除实例
+	EmptyServerModeArchive = "archive"
+	// EmptyServerModeHibernate 表示空转超时后停止服务器并以节省停机模式停止实例
+	EmptyServerModeHibernate = "hibernate"
+)
+
 func (e EmptyServer) EmptyTimeoutDuration() time.Duration {
 	return time.Duration(e.EmptyTimeout) * time.Second
 }
+
+func (e EmptyServer) HibernateMaxDuration() time.Duration {
+	return time.Duration(e.HibernateMaxDays) * 24 * time.Hour
+}
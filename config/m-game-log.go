@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// GameLog 包含了 monitors.GameLog 的相关配置
+//
+// 启用后，系统持续跟踪活动实例上的服务器日志，按照 ServerConfig.Flavor 将聊天、进出服务器、死亡、进度和服务器警告解析为游戏事件，保存并推送给用户。
+type GameLog struct {
+	// Enabled 表示是否启用服务器日志解析
+	Enabled bool `toml:"enabled" comment:"是否跟踪服务器日志，将聊天、进出服务器、死亡、进度和服务器警告记录为游戏事件并推送"`
+
+	// Retention 是游戏事件保留的时间，单位天
+	Retention int `toml:"retention" validate:"omitempty,gte=1" comment:"游戏事件保留的时间，单位天。留空使用90"`
+}
+
+func (g GameLog) RetentionDuration() time.Duration {
+	if g.Retention == 0 {
+		return 90 * 24 * time.Hour
+	}
+
+	return time.Duration(g.Retention) * 24 * time.Hour
+}
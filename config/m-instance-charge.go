@@ -30,6 +30,9 @@ type InstanceCharge struct {
 	// Filters 包含了对获得的实例的筛选配置
 	Filters InstanceChargeFilters `toml:"filters" validate:"required"`
 
+	// CandidateMaxTradePrice 表示创建实例时，如果最佳实例因库存等原因无法创建，回退尝试的候选实例的最大交易价格，单位 CNY。为0时不额外限制候选实例的价格（仍受 Filters.MaxTradePrice 约束）。
+	CandidateMaxTradePrice float32 `toml:"candidate_max_trade_price" validate:"gte=0" comment:"最佳实例无法创建时，回退尝试的候选实例的最大可接受交易价格，单位CNY。为0表示不额外限制"`
+
 	// CacheFile 是刷新数据的缓存文件名，必须以 .json 结尾。系统刚启动时将先使用该文件记录的信息。
 	CacheFile string `toml:"cache_file" validate:"required,endswith=.json" comment:"刷新数据的缓存文件名"`
 
@@ -49,6 +52,11 @@ func (i InstanceCharge) RetryIntervalDuration() time.Duration {
 	return time.Duration(i.RetryInterval) * time.Second
 }
 
+// ProfileCacheFile 返回档案 profile 的缓存文件名。默认档案沿用 CacheFile，其它档案在扩展名前插入档案名称。
+func (i InstanceCharge) ProfileCacheFile(profile string) string {
+	return profileFileName(i.CacheFile, profile)
+}
+
 // InstanceChargeFilters 包含了对获取到的实例设置的筛选条件。
 //
 // InstanceTypeExclusion 主要用于过滤一些性能不佳或者不适合运行 Minecraft 服务器的实例类型，例如共享性实例（ecs.e*）的性能不佳，大数据型实例（ecs.d*）对 Minecraft 服务的运行意义不大。示例：
@@ -0,0 +1,76 @@
+package config
+
+import "time"
+
+const (
+	// ServerFlavorVanilla 表示原版服务器，通过 tick query 指令采集 MSPT（需要 1.20.3 及以上版本）
+	ServerFlavorVanilla = "vanilla"
+	// ServerFlavorPaper 表示 Paper 及其衍生服务器，通过 tps 和 mspt 指令采集
+	ServerFlavorPaper = "paper"
+	// ServerFlavorForge 表示 Forge 服务器，通过 forge tps 指令采集
+	ServerFlavorForge = "forge"
+)
+
+// Metrics 包含了 monitors.Metrics 的相关配置
+//
+// 启用后，系统定期通过 RCON 采集服务器的 TPS 和 MSPT（方式取决于 ServerConfig.Flavor），并通过 SSH 从 /proc 采集实例的 CPU、内存、负载和磁盘使用情况。
+// 采样保存在数据库中，超过 DownsampleAfter 的采样被合并为 DownsampleStep 粒度的平均值，超过 Retention 的采样被删除。
+type Metrics struct {
+	// Enabled 表示是否启用指标采集
+	Enabled bool `toml:"enabled" comment:"是否采集服务器TPS、MSPT以及实例CPU、内存、负载和磁盘指标"`
+
+	// Interval 是采样的间隔，单位秒
+	Interval int `toml:"interval" validate:"omitempty,gte=5" comment:"采样间隔，单位秒，至少为5。留空使用30"`
+
+	// Timeout 是一次采样的超时时间，单位秒
+	Timeout int `toml:"timeout" validate:"omitempty,gte=1" comment:"一次采样的超时时间，单位秒。留空使用10"`
+
+	// DownsampleAfter 是采样保持原始粒度的时间，单位小时
+	DownsampleAfter int `toml:"downsample_after" validate:"omitempty,gte=1" comment:"采样保持原始粒度的时间，单位小时，超过后合并为downsample_step粒度的平均值。留空使用24"`
+
+	// DownsampleStep 是合并后的采样粒度，单位秒
+	DownsampleStep int `toml:"downsample_step" validate:"omitempty,gte=60" comment:"合并后的采样粒度，单位秒，至少为60。留空使用300"`
+
+	// Retention 是采样保留的时间，单位天
+	Retention int `toml:"retention" validate:"omitempty,gte=1" comment:"采样保留的时间，单位天。留空使用30"`
+}
+
+func (m Metrics) IntervalDuration() time.Duration {
+	if m.Interval == 0 {
+		return 30 * time.Second
+	}
+
+	return time.Duration(m.Interval) * time.Second
+}
+
+func (m Metrics) TimeoutDuration() time.Duration {
+	if m.Timeout == 0 {
+		return 10 * time.Second
+	}
+
+	return time.Duration(m.Timeout) * time.Second
+}
+
+func (m Metrics) DownsampleAfterDuration() time.Duration {
+	if m.DownsampleAfter == 0 {
+		return 24 * time.Hour
+	}
+
+	return time.Duration(m.DownsampleAfter) * time.Hour
+}
+
+func (m Metrics) DownsampleStepDuration() time.Duration {
+	if m.DownsampleStep == 0 {
+		return 5 * time.Minute
+	}
+
+	return time.Duration(m.DownsampleStep) * time.Second
+}
+
+func (m Metrics) RetentionDuration() time.Duration {
+	if m.Retention == 0 {
+		return 30 * 24 * time.Hour
+	}
+
+	return time.Duration(m.Retention) * 24 * time.Hour
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/cron"
+)
+
+// Migration 包含了 monitors.Migration 的相关配置
+//
+// 启用后，当活动实例当前的交易价格比 monitors.InstanceCharge 获取的最佳实例高出 Margin 以上，并且服务器无玩家在线（WhenEmpty）或者处于迁移时段（Window）内时，
+// 系统停止服务器、归档并删除实例，以最佳实例类型重新创建并部署实例。新实例部署失败时，系统以原实例类型重新创建并部署实例。
+type Migration struct {
+	// Enabled 表示是否启用自动迁移
+	Enabled bool `toml:"enabled" comment:"是否在活动实例价格明显高于最佳实例时，自动迁移到最佳实例类型"`
+
+	// Interval 是检查的时间间隔，单位秒
+	Interval int `toml:"interval" validate:"omitempty,gte=1" comment:"检查间隔，单位秒。留空使用300"`
+
+	// Margin 表示触发迁移所需的最小差价，单位 CNY。活动实例的交易价格减去最佳实例的交易价格不小于该值时才会迁移
+	Margin float32 `toml:"margin" validate:"gte=0" comment:"触发迁移所需的最小差价（活动实例交易价格减去最佳实例交易价格），单位CNY"`
+
+	// WhenEmpty 表示是否在服务器无玩家在线时迁移
+	WhenEmpty bool `toml:"when_empty" comment:"是否在服务器无玩家在线时迁移"`
+
+	// Window 是迁移时段开始时间的 cron 表达式，使用系统本地时区。迁移时段内即使有玩家在线也会迁移。留空表示没有迁移时段
+	Window string `toml:"window" comment:"迁移时段开始时间的cron表达式，格式为'分 时 日 月 周'，使用系统本地时区。迁移时段内即使有玩家在线也会迁移。留空表示没有迁移时段"`
+
+	// WindowDuration 是迁移时段的时长，单位分钟
+	WindowDuration int `toml:"window_duration" validate:"required_with=Window,omitempty,gte=1" comment:"迁移时段的时长，单位分钟"`
+
+	// Cooldown 是一次迁移开始后，下一次迁移之前至少间隔的时间，单位分钟。用于避免价格波动时频繁迁移
+	Cooldown int `toml:"cooldown" validate:"omitempty,gte=1" comment:"一次迁移开始后至少间隔多少分钟才能再次迁移，避免价格波动时频繁迁移。留空使用60"`
+}
+
+func (m Migration) IntervalDuration() time.Duration {
+	if m.Interval == 0 {
+		return 5 * time.Minute
+	}
+
+	return time.Duration(m.Interval) * time.Second
+}
+
+func (m Migration) WindowLength() time.Duration {
+	return time.Duration(m.WindowDuration) * time.Minute
+}
+
+func (m Migration) CooldownDuration() time.Duration {
+	if m.Cooldown == 0 {
+		return time.Hour
+	}
+
+	return time.Duration(m.Cooldown) * time.Minute
+}
+
+// Check 检查迁移时段的 cron 表达式能否解析且会触发
+func (m Migration) Check() error {
+	if m.Window == "" {
+		return nil
+	}
+
+	expr, err := cron.Parse(m.Window)
+
+	if err != nil {
+		return fmt.Errorf("migration window: %w", err)
+	}
+
+	if err := expr.Validate(); err != nil {
+		return fmt.Errorf("migration window: %w", err)
+	}
+
+	return nil
+}
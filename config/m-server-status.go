@@ -9,6 +9,9 @@ type ServerStatus struct {
 
 	// Timeout 表示获取服务器状态的超时时间，单位秒
 	Timeout int `toml:"timeout" validate:"required,gte=1" comment:"超时时间，单位秒"`
+
+	// SampleRetention 是每分钟状态采样保留的时间，单位天，参见 store.ServerStatusSample
+	SampleRetention int `toml:"sample_retention" validate:"omitempty,gte=1" comment:"每分钟记录的在线人数、运行状态和延迟保留的时间，单位天。留空使用365"`
 }
 
 func (s ServerStatus) IntervalDuration() time.Duration {
@@ -18,3 +21,11 @@ func (s ServerStatus) IntervalDuration() time.Duration {
 func (s ServerStatus) TimeoutDuration() time.Duration {
 	return time.Duration(s.Timeout) * time.Second
 }
+
+func (s ServerStatus) SampleRetentionDuration() time.Duration {
+	if s.SampleRetention == 0 {
+		return 365 * 24 * time.Hour
+	}
+
+	return time.Duration(s.SampleRetention) * 24 * time.Hour
+}
@@ -0,0 +1,33 @@
+package config
+
+import "time"
+
+const (
+	// SpotInterruptionActionBackup 表示收到中断通知后立即备份服务器
+	SpotInterruptionActionBackup = "backup"
+	// SpotInterruptionActionArchive 表示收到中断通知后停止服务器并归档
+	SpotInterruptionActionArchive = "archive"
+)
+
+// SpotInterruption 包含了 monitors.SpotInterruption 的相关配置
+type SpotInterruption struct {
+	// Interval 表示查询实例系统事件的间隔，单位秒。抢占式实例从发出中断通知到被回收通常只有数分钟，建议采用较小数值。
+	Interval int `toml:"interval" validate:"required,gte=1" comment:"刷新间隔，单位秒。抢占式实例从收到中断通知到被回收通常只有数分钟，建议采用较小数值，如10"`
+
+	// Timeout 表示查询实例系统事件的超时时间，单位秒
+	Timeout int `toml:"timeout" validate:"required,gte=1" comment:"超时时间，单位秒"`
+
+	// Action 表示收到中断通知后执行的操作，取值为 SpotInterruptionActionBackup 或 SpotInterruptionActionArchive
+	Action string `toml:"action" validate:"required,oneof=backup archive" comment:"收到中断通知后执行的操作，backup表示立即备份，archive表示停止服务器并归档"`
+
+	// EventTypes 是被视为抢占式实例中断通知的系统事件类型
+	EventTypes []string `toml:"event_types" validate:"required,min=1" comment:"被视为抢占式实例中断通知的系统事件类型"`
+}
+
+func (s SpotInterruption) IntervalDuration() time.Duration {
+	return time.Duration(s.Interval) * time.Second
+}
+
+func (s SpotInterruption) TimeoutDuration() time.Duration {
+	return time.Duration(s.Timeout) * time.Second
+}
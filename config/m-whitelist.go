@@ -20,3 +20,8 @@ func (w *Whitelist) IntervalDuration() time.Duration {
 func (w *Whitelist) TimeoutDuration() time.Duration {
 	return time.Duration(w.Timeout) * time.Second
 }
+
+// ProfileCacheFile 返回档案 profile 的白名单缓存文件名。默认档案沿用 CacheFile，其它档案在扩展名前插入档案名称。
+func (w *Whitelist) ProfileCacheFile(profile string) string {
+	return profileFileName(w.CacheFile, profile)
+}
@@ -28,4 +28,19 @@ type MonitorConfig struct {
 
 	// Whitelist 是对 monitors.Whitelist 的相关配置
 	Whitelist Whitelist `toml:"whitelist" validate:"required"`
+
+	// SpotInterruption 是对 monitors.SpotInterruption 的相关配置
+	SpotInterruption SpotInterruption `toml:"spot_interruption" validate:"required"`
+
+	// Migration 是对 monitors.Migration 的相关配置
+	Migration Migration `toml:"migration"`
+
+	// Metrics 是对 monitors.Metrics 的相关配置
+	Metrics Metrics `toml:"metrics"`
+
+	// CrashWatchdog 是对 monitors.CrashWatchdog 的相关配置
+	CrashWatchdog CrashWatchdog `toml:"crash_watchdog"`
+
+	// GameLog 是对 monitors.GameLog 的相关配置
+	GameLog GameLog `toml:"game_log"`
 }
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"strings"
+)
+
+// DefaultProfileName 是未配置任何档案时系统自动生成的默认档案名称。
+const DefaultProfileName = "default"
+
+// ProfileConfig 表示一个档案（profile）的配置。一个档案对应一个由系统管理的 Minecraft 服务器，拥有独立的活动实例、归档路径、实例规格和监控器。
+//
+// 多个档案可以同时运行，例如同时运行一个生存服务器和一个创造服务器。如果配置文件中没有声明任何档案，系统将根据 DeployConfig 和 ServerConfig 生成一个名为 DefaultProfileName 的默认档案，行为与单服务器时一致。
+type ProfileConfig struct {
+	// Name 是档案的名称，同时是档案的标识符，出现在路由、数据库记录和推送事件中。
+	Name string `toml:"name" validate:"required,alphanum,max=20" comment:"档案名称，仅允许字母和数字，最长20个字符，用作档案的标识符"`
+
+	// ArchivePath 是该档案用于存储归档的存储桶内地址，相对于 DeployConfig.OSSRoot
+	ArchivePath string `toml:"archive_path" validate:"required" comment:"该档案用于存储归档的存储桶内地址，相对于OSSRoot，例如/survival/archive"`
+
+	// BackupPath 是该档案用于存放备份的存储桶内地址，相对于 DeployConfig.OSSRoot
+	BackupPath string `toml:"backup_path" validate:"required" comment:"该档案用于存储备份的存储桶内地址，相对于OSSRoot，例如/survival/backups"`
+
+	// HostName 是该档案实例的主机名。留空则使用 AliyunEcsConfig.HostName
+	HostName string `toml:"hostname" comment:"该档案实例的主机名，留空则使用aliyun.ecs.hostname"`
+
+	// DataDisk 是该档案实例使用的数据盘配置。留空则使用 AliyunEcsConfig.DataDisk
+	DataDisk *EcsDiskConfig `toml:"data_disk,omitempty"`
+
+	// MemChoices 是该档案可接受的实例内存大小，单位 GiB。留空则使用 InstanceCharge.MemChoices
+	MemChoices []int `toml:"mem_choices" validate:"omitempty,dive,gte=1" comment:"该档案实例可接受的内存大小列表，单位GiB，留空则使用monitor.instance_charge.mem_choices"`
+
+	// CpuCoreCountChoices 是该档案可接受的实例虚拟 CPU 核数。留空则使用 InstanceCharge.CpuCoreCountChoices
+	CpuCoreCountChoices []int `toml:"cpu_core_count_choices" validate:"omitempty,dive,gte=1" comment:"该档案实例可接受的vCPU数量，留空则使用monitor.instance_charge.cpu_core_count_choices"`
+
+	// DnsName 是该档案在 DnsConfig.Domain 下的主机记录。留空则不为该档案管理解析记录
+	DnsName string `toml:"dns_name" validate:"omitempty,hostname" comment:"该档案在dns.domain下的主机记录，例如survival表示survival.example.com。留空则不为该档案管理解析记录"`
+
+	// Server 是该档案对应的 Minecraft 服务器配置。留空则使用 ServerConfig
+	Server *ServerConfig `toml:"server,omitempty"`
+}
+
+// GetGamePort 返回该档案服务器的游戏端口
+func (p ProfileConfig) GetGamePort() uint16 {
+	return p.Server.Port
+}
+
+// GetGameRconPort 返回该档案服务器的 RCON 端口
+func (p ProfileConfig) GetGameRconPort() uint16 {
+	return p.Server.RconPort
+}
+
+// profileFileName 将文件名 filename 转换为档案 profile 专用的文件名。默认档案沿用原文件名，其它档案在扩展名之前插入档案名称，如 cache.json -> cache.survival.json
+func profileFileName(filename string, profile string) string {
+	if profile == DefaultProfileName {
+		return filename
+	}
+
+	idx := strings.LastIndex(filename, ".")
+
+	if idx == -1 {
+		return filename + "." + profile
+	}
+
+	return filename[:idx] + "." + profile + filename[idx:]
+}
+
+// resolveProfiles 在配置文件读取后调用。如果没有声明任何档案，则生成默认档案；对于已声明的档案，用全局配置填充其留空的字段。
+func (c *Config) resolveProfiles() {
+	if len(c.Profiles) == 0 {
+		c.Profiles = []ProfileConfig{
+			{
+				Name:        DefaultProfileName,
+				ArchivePath: c.Deploy.ArchivePath,
+				BackupPath:  c.Deploy.BackupPath,
+				DnsName:     c.Dns.Name,
+			},
+		}
+	}
+
+	for i := range c.Profiles {
+		p := &c.Profiles[i]
+
+		if p.HostName == "" {
+			p.HostName = c.Aliyun.Ecs.HostName
+		}
+
+		if p.DataDisk == nil {
+			dataDisk := c.Aliyun.Ecs.DataDisk
+			p.DataDisk = &dataDisk
+		}
+
+		if len(p.MemChoices) == 0 {
+			p.MemChoices = c.Monitor.InstanceCharge.MemChoices
+		}
+
+		if len(p.CpuCoreCountChoices) == 0 {
+			p.CpuCoreCountChoices = c.Monitor.InstanceCharge.CpuCoreCountChoices
+		}
+
+		if p.Server == nil {
+			server := c.Server
+			p.Server = &server
+		}
+	}
+}
+
+// GetProfile 返回名称为 name 的档案配置
+func (c Config) GetProfile(name string) (ProfileConfig, bool) {
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return ProfileConfig{}, false
+}
+
+// ProfileNames 返回所有档案的名称
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+
+	for _, p := range c.Profiles {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
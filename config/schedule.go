@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/Subilan/go-aliyunmc/helpers/cron"
+)
+
+// ScheduleConfig 表示一个服务器开放时段计划。每当 Cron 触发时，开放时段开始，持续 Duration 分钟。
+//
+// 系统在开放时段开始前 LeadTime 分钟自动创建并部署实例、开启服务器；在开放时段结束前按 Warnings 在游戏内警告玩家，结束时停止服务器、归档并删除实例。参见 monitors.Scheduler。
+type ScheduleConfig struct {
+	// Name 是计划的名称，同时是计划的标识符
+	Name string `toml:"name" validate:"required,alphanum,max=20" comment:"计划名称，仅允许字母和数字，最长20个字符，用作计划的标识符"`
+
+	// Profile 是计划作用的档案。留空则为 DefaultProfileName
+	Profile string `toml:"profile" comment:"计划作用的档案名称，留空则为default"`
+
+	// Cron 是开放时段开始时间的 cron 表达式，使用系统本地时区
+	Cron string `toml:"cron" validate:"required" comment:"开放时段开始时间的cron表达式，格式为'分 时 日 月 周'，使用系统本地时区。例如'0 19 * * 5,6'表示每周五、周六19:00"`
+
+	// Duration 是开放时段的时长，单位分钟
+	Duration int `toml:"duration" validate:"required,gte=1" comment:"开放时段的时长，单位分钟"`
+
+	// LeadTime 是提前创建并部署实例的时间，单位分钟
+	LeadTime int `toml:"lead_time" validate:"gte=0" comment:"提前多少分钟开始创建并部署实例，使服务器在开放时段开始时已经就绪"`
+
+	// Warnings 是开放时段结束前在游戏内警告玩家的时间点，单位分钟
+	Warnings []int `toml:"warnings" validate:"omitempty,dive,gte=1" comment:"开放时段结束前多少分钟在游戏内警告玩家，例如[10, 5, 1]"`
+
+	// Protected 表示开放时段内是否阻止 monitors.EmptyServer 因空转而提前关闭服务器
+	Protected bool `toml:"protected" comment:"开放时段内是否阻止monitor.empty_server因空转而提前关闭服务器"`
+}
+
+// Check 检查计划的 cron 表达式能否解析且会触发，以及档案是否存在。调用前需要先将留空的 Profile 填充为默认值
+func (s ScheduleConfig) Check(c Config) error {
+	expr, err := cron.Parse(s.Cron)
+
+	if err != nil {
+		return err
+	}
+
+	if err := expr.Validate(); err != nil {
+		return fmt.Errorf("schedule %s: %w", s.Name, err)
+	}
+
+	if _, ok := c.GetProfile(s.Profile); !ok {
+		return fmt.Errorf("schedule %s: profile %s not found", s.Name, s.Profile)
+	}
+
+	return nil
+}
+
+// resolveSchedules 在档案生成之后调用，填充计划留空的字段并检查每个计划
+func (c *Config) resolveSchedules() error {
+	for i := range c.Schedules {
+		s := &c.Schedules[i]
+
+		if s.Profile == "" {
+			s.Profile = DefaultProfileName
+		}
+
+		if err := s.Check(*c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -10,4 +10,7 @@ type ServerConfig struct {
 
 	// RconPassword 是游戏的 RCON 密码，用于发送指令。
 	RconPassword string `toml:"rcon_password" validate:"required" comment:"MC服务器的RCON密码，可在server.properties中设置和查看"`
+
+	// Flavor 是服务器的类型，决定采集 TPS 和 MSPT 的方式，参见 Metrics。留空表示不采集
+	Flavor string `toml:"flavor" validate:"omitempty,oneof=vanilla paper forge" comment:"服务器类型，可以为vanilla（需要1.20.3及以上）、paper或forge，用于采集TPS和MSPT。留空表示不采集"`
 }
@@ -24,4 +24,12 @@ const (
 	CmdTypeGetCachedPlayers CommandType = "get_cached_players"
 	// CmdTypeGetWhitelist 是获取服务器 whitelist.json 文件内容的指令（基于 cat）
 	CmdTypeGetWhitelist CommandType = "get_whitelist"
+	// CmdTypeWarnSpotInterruption 是在服务器内向玩家广播实例即将被回收的指令（基于 say）
+	CmdTypeWarnSpotInterruption CommandType = "warn_spot_interruption"
+	// CmdTypeWarnScheduledClose 是在服务器内向玩家广播开放时段即将结束的指令（基于 say），其内容在运行时生成
+	CmdTypeWarnScheduledClose CommandType = "warn_scheduled_close"
+	// CmdTypeScheduledMessage 是定时指令计划在服务器内广播消息的指令（基于 say），其内容在运行时生成
+	CmdTypeScheduledMessage CommandType = "scheduled_message"
+	// CmdTypeConsole 是管理员在控制台中输入、在服务器内原样执行的指令，输入的内容记录在执行记录的参数中
+	CmdTypeConsole CommandType = "console"
 )
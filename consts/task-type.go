@@ -6,4 +6,8 @@ type TaskType string
 const (
 	// TaskTypeInstanceDeployment 表示一个实例部署任务
 	TaskTypeInstanceDeployment TaskType = "instance_deployment"
+	// TaskTypeInstanceMigration 表示一个将活动实例迁移到另一实例类型的任务
+	TaskTypeInstanceMigration TaskType = "instance_migration"
+	// TaskTypeCommandExecution 表示一个在后台运行指令的任务，参见 commands.Command.RunAsync
+	TaskTypeCommandExecution TaskType = "command_execution"
 )
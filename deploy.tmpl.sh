@@ -39,6 +39,9 @@ fi
 chown -R "${USERNAME}:${USERNAME}" "${SSH_DIR}"
 chmod 600 "${AUTHORIZED_KEYS}"
 
+{{ if .Baked -}}
+echo "3-5. 实例从预制镜像启动，跳过软件安装"
+{{- else -}}
 echo "3. 配置源"
 
 echo "# 默认注释了源码镜像以提高 apt update 速度，如有需要可自行取消注释
@@ -79,8 +82,9 @@ apt install -y zulu{{ .JavaVersion }}-jre-headless {{ range .Packages }}{{ . }}
 echo "6. 安装 ossutil"
 
 curl https://gosspublic.alicdn.com/ossutil/install.sh | bash
+{{- end }}
 
-echo "[Credentials]" >> "/root/.ossutilconfig"
+echo "[Credentials]" > "/root/.ossutilconfig"
 echo "endpoint=oss-{{ .RegionId }}-internal.aliyuncs.com" >> "/root/.ossutilconfig"
 echo "accessKeySecret={{ .AccessKeySecret }}" >> "/root/.ossutilconfig"
 echo "accessKeyID={{ .AccessKeyId }}" >> "/root/.ossutilconfig"
@@ -94,17 +98,31 @@ DATA_DISK=$(fdisk -l | grep "${DATA_DISK_SIZE}" | head -n 1 | awk '{print $2}' |
 DATA_DISK_MOUNT_POINT="${USER_HOME}/server"
 
 mkdir -p "${DATA_DISK_MOUNT_POINT}"
+{{- if .RestoreFromSnapshot }}
+echo "数据盘从快照创建，跳过格式化"
+{{- else }}
 mkfs.ext4 $DATA_DISK
+{{- end }}
 DATA_DISK_UUID=`blkid | grep $DATA_DISK | sed 's/UUID=/ /g' | sed 's/"/ /g' | awk '{print $2}'`
 mount "${DATA_DISK}" "${DATA_DISK_MOUNT_POINT}"
+{{- if .RestoreFromSnapshot }}
+# 数据盘可能大于快照的源数据盘，扩展文件系统以使用全部空间
+resize2fs "${DATA_DISK}"
+{{- end }}
 cp /etc/fstab /etc/fstab.bak
-echo "UUID=${DATA_DISK_UUID} ${DATA_DISK_MOUNT_POINT} ext4 defaults 0 0"
-echo "UUID=${DATA_DISK_UUID} ${DATA_DISK_MOUNT_POINT} ext4 defaults 0 0" >> /etc/fstab
+# 预制镜像中可能残留制作镜像时的数据盘挂载项，先将其移除
+sed -i "\|${DATA_DISK_MOUNT_POINT}|d" /etc/fstab
+echo "UUID=${DATA_DISK_UUID} ${DATA_DISK_MOUNT_POINT} ext4 defaults,nofail 0 0"
+echo "UUID=${DATA_DISK_UUID} ${DATA_DISK_MOUNT_POINT} ext4 defaults,nofail 0 0" >> /etc/fstab
 systemctl daemon-reload
 
+{{ if .RestoreFromSnapshot -}}
+echo "8. 归档数据已从快照恢复"
+{{- else -}}
 echo "8. 复制归档数据"
 
 ossutil cp -r "{{ .ArchiveOSSPath }}" "${USER_HOME}/server"
+{{- end }}
 chmod +x "${USER_HOME}/server/archive/boot.sh"
 chmod +x "${USER_HOME}/server/archive/start.sh"
 
@@ -10,6 +10,11 @@ import "github.com/gin-gonic/gin"
 //   - InstanceEventDeploymentTaskStatusUpdate 表示部署任务的状态的更新，主要由执行部署任务的 goroutine 触发
 //   - InstanceEventCreateAndDeployFailed 表示“一键开启服务器”功能流程的失败，参见 instances.HandleCreateAndDeployInstance
 //   - InstanceEventCreateAndDeployStep 表示“一键开启服务器”功能过程的状态更新，用于前端更新页面或告知用户，参见 instances.HandleCreateAndDeployInstance
+//   - InstanceEventCreateAttempt 表示一次创建实例的尝试结果。最佳实例无法创建时，系统会依次尝试候选实例，每次尝试都会触发此事件，参见 instances.HandleCreatePreferredInstance
+//   - InstanceEventResumeStep 表示唤醒休眠实例过程的状态更新，参见 instances.HandleResumeInstance
+//   - InstanceEventResumeFailed 表示唤醒休眠实例的失败，参见 instances.HandleResumeInstance
+//   - InstanceEventSpotInterruption 表示活动实例收到了抢占式实例中断通知，即将被回收，主要由 monitors.SpotInterruption 触发
+//   - InstanceEventMigrationTaskStatusUpdate 表示实例迁移任务的状态的更新，迁移过程的各个步骤作为该任务的有状态事件推送，参见 instances.MigrateInstance
 type InstanceEventType string
 
 const (
@@ -20,19 +25,28 @@ const (
 	InstanceEventDeploymentTaskStatusUpdate InstanceEventType = "deployment_task_status_update"
 	InstanceEventCreateAndDeployFailed      InstanceEventType = "create_and_deploy_failed"
 	InstanceEventCreateAndDeployStep        InstanceEventType = "create_and_deploy_step"
+	InstanceEventSpotInterruption           InstanceEventType = "spot_interruption"
+	InstanceEventCreateAttempt              InstanceEventType = "create_attempt"
+	InstanceEventResumeStep                 InstanceEventType = "resume_step"
+	InstanceEventResumeFailed               InstanceEventType = "resume_failed"
+	InstanceEventMigrationTaskStatusUpdate  InstanceEventType = "migration_task_status_update"
 )
 
 const (
 	// InstanceNotificationDeleted 表示实例被删除
 	InstanceNotificationDeleted = "instance_deleted"
+	// InstanceNotificationHibernated 表示实例进入休眠
+	InstanceNotificationHibernated = "instance_hibernated"
+	// InstanceNotificationResumed 表示休眠的实例被唤醒
+	InstanceNotificationResumed = "instance_resumed"
 )
 
-// Instance 创建一个指定类型、带有指定载荷的实例事件
-func Instance(typ InstanceEventType, data any, isPublic ...bool) *Event {
+// Instance 创建一个属于档案 profile 的、指定类型、带有指定载荷的实例事件
+func Instance(profile string, typ InstanceEventType, data any, isPublic ...bool) *Event {
 	public := false
 	if len(isPublic) > 0 {
 		public = isPublic[0]
 	}
 
-	return Stateless(gin.H{"type": typ, "data": data}, TypeInstance, public)
+	return Stateless(gin.H{"profile": profile, "type": typ, "data": data}, TypeInstance, public)
 }
@@ -6,12 +6,28 @@ import "github.com/gin-gonic/gin"
 //   - ServerEventNotify 表示一个与服务器相关的通知事件
 //   - ServerEventOnlineCountUpdate 表示服务器玩家数量的更新事件
 //   - ServerEventOnlinePlayersUpdate 表示服务器在线玩家列表的更新事件
+//   - ServerEventCommandTaskStatusUpdate 表示在后台运行指令的任务的状态的更新，指令的输出作为该任务的有状态事件推送，参见 commands.Command.RunAsync
+//   - ServerEventCommandRequestUpdate 表示指令执行请求被创建、收到投票或者得到结果，载荷为请求的最新信息，参见 requests.HandleCreateRequest
+//   - ServerEventMetricsUpdate 表示服务器和实例性能指标的一次新采样，载荷为 store.MetricsSample，参见 monitors.Metrics
+//   - ServerEventCrashIncidentUpdate 表示服务器意外退出或者系统对其的处理有了进展，载荷为不含崩溃报告的 store.CrashIncident，参见 monitors.CrashWatchdog
+//   - ServerEventGameChat、ServerEventGameJoin、ServerEventGameLeave、ServerEventGameDeath、ServerEventGameAdvancement 和 ServerEventGameWarning
+//     分别表示从服务器日志中解析出的聊天、进入服务器、离开服务器、死亡、进度和服务器警告，载荷为不含IP地址的 store.GameEvent，参见 monitors.GameLog
 type ServerEventType string
 
 const (
-	ServerEventNotify              ServerEventType = "notify"
-	ServerEventOnlineCountUpdate   ServerEventType = "online_count_update"
-	ServerEventOnlinePlayersUpdate ServerEventType = "online_players_update"
+	ServerEventNotify                  ServerEventType = "notify"
+	ServerEventOnlineCountUpdate       ServerEventType = "online_count_update"
+	ServerEventOnlinePlayersUpdate     ServerEventType = "online_players_update"
+	ServerEventCommandTaskStatusUpdate ServerEventType = "command_task_status_update"
+	ServerEventCommandRequestUpdate    ServerEventType = "command_request_update"
+	ServerEventMetricsUpdate           ServerEventType = "metrics_update"
+	ServerEventCrashIncidentUpdate     ServerEventType = "crash_incident_update"
+	ServerEventGameChat                ServerEventType = "game_chat"
+	ServerEventGameJoin                ServerEventType = "game_join"
+	ServerEventGameLeave               ServerEventType = "game_leave"
+	ServerEventGameDeath               ServerEventType = "game_death"
+	ServerEventGameAdvancement         ServerEventType = "game_advancement"
+	ServerEventGameWarning             ServerEventType = "game_warning"
 )
 
 const (
@@ -21,11 +37,11 @@ const (
 	ServerNotificationRunning = "running"
 )
 
-// Server 创建一个指定类型、带有指定载荷的服务器事件
-func Server(typ ServerEventType, data any, isPublic ...bool) *Event {
+// Server 创建一个属于档案 profile 的、指定类型、带有指定载荷的服务器事件
+func Server(profile string, typ ServerEventType, data any, isPublic ...bool) *Event {
 	public := false
 	if len(isPublic) > 0 {
 		public = isPublic[0]
 	}
-	return Stateless(gin.H{"type": typ, "data": data}, TypeServer, public)
+	return Stateless(gin.H{"profile": profile, "type": typ, "data": data}, TypeServer, public)
 }
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"log"
+	"sync/atomic"
 
 	"github.com/Subilan/go-aliyunmc/broker"
 	"go.jetify.com/sse"
@@ -10,6 +11,9 @@ import (
 // publicChannel 本质上是一个 broker.Broker，表示一个面向所有用户的消息频道
 var publicChannel = broker.New[*sse.Event]()
 
+// publicSubscribers 记录公共频道当前的订阅者数量
+var publicSubscribers atomic.Int64
+
 // publicChannelInitialized 用于记录 publicChannel 的初始化情况，避免重复初始化
 var publicChannelInitialized = false
 
@@ -24,10 +28,17 @@ func InitPublicChannel() {
 
 // SubPublicChannel 从公共频道上订阅消息，相当于调用 broker.Broker 的 Subscribe
 func SubPublicChannel() chan *sse.Event {
+	publicSubscribers.Add(1)
 	return publicChannel.Subscribe()
 }
 
 // UnsubPublicChannel 取消从公共频道上订阅消息，相当于调用 broker.Broker 的 Unsubscribe
 func UnsubPublicChannel(ch chan *sse.Event) {
+	publicSubscribers.Add(-1)
 	publicChannel.Unsubscribe(ch)
 }
+
+// CountPublicSubscribers 返回公共频道当前的订阅者数量
+func CountPublicSubscribers() int {
+	return int(publicSubscribers.Load())
+}
@@ -140,3 +140,11 @@ func Unregister(connId string) {
 	defer userStreamsMu.Unlock()
 	delete(userStreams, connId)
 }
+
+// CountUserStreams 返回当前已连接的用户 SSE 连接数量
+func CountUserStreams() int {
+	userStreamsMu.Lock()
+	defer userStreamsMu.Unlock()
+
+	return len(userStreams)
+}
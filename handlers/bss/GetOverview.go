@@ -7,6 +7,7 @@ import (
 	"github.com/Subilan/go-aliyunmc/clients"
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/alibabacloud-go/tea/dara"
 	"github.com/gin-gonic/gin"
 )
 
@@ -27,7 +28,7 @@ func HandleGetOverview() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
 		var result Overview
 
-		queryAccountBalanceResponse, err := clients.BssClient.QueryAccountBalance()
+		queryAccountBalanceResponse, err := clients.BssClient.QueryAccountBalanceWithOptions(&dara.RuntimeOptions{})
 
 		if err != nil {
 			return nil, err
@@ -1,6 +1,9 @@
 package instances
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Subilan/go-aliyunmc/consts"
@@ -8,126 +11,158 @@ import (
 	"github.com/Subilan/go-aliyunmc/events/stream"
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/remote"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/Subilan/go-aliyunmc/monitors"
 	"github.com/gin-gonic/gin"
 )
 
-func HandleCreateAndDeployInstance() gin.HandlerFunc {
-	return helpers.QueryHandler[CreateInstanceQuery](func(query CreateInstanceQuery, c *gin.Context) (any, error) {
-		createInstanceFunc := createPreferredInstance()
-		deployInstanceFunc := deployInstance()
-		ctxCopy := c.Copy()
+// CreateAndDeploy 为档案 profile 创建最佳实例，等待实例运行后进行部署，部署成功后开启服务器。
+// 该函数会阻塞直到整个流程结束，过程中的各个步骤通过 events.InstanceEventCreateAndDeployStep 和 events.InstanceEventCreateAndDeployFailed 推送。
+// 如果 by 为 nil，表示该流程由系统自动发起。
+func CreateAndDeploy(profile string, autoVSwitch bool, by *int64) error {
+	var err error
+
+	if !monitors.SnapshotPreferredInstanceChargePresent(profile) {
+		err = errors.New("preferred instance charge not present")
+	} else {
+		err = createAndDeploy(profile, createInstanceCandidates(profile), autoVSwitch, by, func(content string) {
+			stream.Broadcast(events.Instance(profile, events.InstanceEventCreateAndDeployStep, content))
+		})
+	}
+
+	if err != nil {
+		stream.Broadcast(events.Instance(profile, events.InstanceEventCreateAndDeployFailed, err.Error()))
+	}
+
+	return err
+}
 
-		go func() {
-			_, err := createInstanceFunc(query, ctxCopy)
+// createAndDeploy 为档案 profile 依次尝试以 items 中的实例类型及可用区创建实例，等待实例运行后进行部署，部署成功后开启服务器。
+// 该函数会阻塞直到整个流程结束，过程中的各个步骤通过 step 报告。
+func createAndDeploy(profile string, items []monitors.AvailableInstanceItem, autoVSwitch bool, by *int64, step func(content string)) error {
+	if err := createInstance(context.Background(), profile, items, autoVSwitch); err != nil {
+		return err
+	}
+
+	timeout := time.NewTimer(25 * time.Second)
+	instanceStatusUpdate := monitors.SubscribeInstanceStatus(profile)
+
+loop1:
+	for {
+		select {
+		case status := <-instanceStatusUpdate:
+			if status == consts.InstanceRunning {
+				step("waiting for instance to be initialized")
+
+				waitSSHTimeout := time.NewTimer(40 * time.Second)
+				waitSSHTicker := time.NewTicker(3 * time.Second)
+
+			waitSSHLoop:
+				for {
+					select {
+					case <-waitSSHTicker.C:
+						ok := remote.TryDialRoot(monitors.SnapshotInstanceIp(profile), time.Second*5)
+						if ok {
+							break waitSSHLoop
+						}
+					case <-waitSSHTimeout.C:
+						return errors.New("instance initialization timeout")
+					}
+				}
+
+				if _, err := DeployInstance(profile, by); err != nil {
+					return err
+				}
 
-			if err != nil {
-				event := events.Instance(events.InstanceEventCreateAndDeployFailed, err.Error())
-				stream.Broadcast(event)
-				return
+				step("requested instance deployment")
+				break loop1
 			}
+		case <-timeout.C:
+			return errors.New("timeout waiting for instance to be running")
+		}
+	}
+
+	timeout = time.NewTimer(5 * time.Minute)
+	deployInstanceStatusUpdate := SubscribeDeployInstanceTaskStatus(profile)
+
+	for {
+		select {
+		case taskStatus := <-deployInstanceStatusUpdate:
+			if taskStatus == consts.TaskStatusSuccess {
+				cmd, ok := commands.ShouldGetCommand(consts.CmdTypeStartServer)
+
+				if !ok {
+					return errors.New("cannot find start_server command")
+				}
 
-			timeout := time.NewTimer(25 * time.Second)
-			instanceStatusUpdate := monitors.SubscribeInstanceStatus()
-
-		loop1:
-			for {
-				select {
-				case status := <-instanceStatusUpdate:
-					if status == consts.InstanceRunning {
-						event := events.Instance(events.InstanceEventCreateAndDeployStep, "waiting for instance to be initialized")
-						stream.Broadcast(event)
-
-						waitSSHTimeout := time.NewTimer(40 * time.Second)
-						waitSSHTicker := time.NewTicker(3 * time.Second)
-
-					waitSSHLoop:
-						for {
-							select {
-							case <-waitSSHTicker.C:
-								ok := remote.TryDialRoot(monitors.SnapshotInstanceIp(), time.Second*5)
-								if ok {
-									break waitSSHLoop
-								}
-							case <-waitSSHTimeout.C:
-								event := events.Instance(events.InstanceEventCreateAndDeployFailed, "instance initialization timeout")
-								stream.Broadcast(event)
-								return
-							}
-						}
+				instance, err := store.GetDeployedActiveInstance(profile)
+
+				if err != nil {
+					return fmt.Errorf("cannot get instance: %w", err)
+				}
 
-						_, err = deployInstanceFunc(ctxCopy)
+				ctx, cancel := cmd.DefaultContext()
+				defer cancel()
 
-						if err != nil {
-							event := events.Instance(events.InstanceEventCreateAndDeployFailed, err.Error())
-							stream.Broadcast(event)
-							return
-						}
+				_, err = cmd.RunWithoutCooldown(ctx, instance, by, nil)
 
-						event = events.Instance(events.InstanceEventCreateAndDeployStep, "requested instance deployment")
-						stream.Broadcast(event)
-						break loop1
-					}
-				case <-timeout.C:
-					event := events.Instance(events.InstanceEventCreateAndDeployFailed, "timeout waiting for instance to be running")
-					stream.Broadcast(event)
-					return
+				if err != nil {
+					return fmt.Errorf("cannot start server: %w", err)
 				}
+
+				step("requested server start")
+				return nil
+			} else if taskStatus == consts.TaskStatusFailed {
+				return errors.New("deploy task failed")
 			}
+		case <-timeout.C:
+			return errors.New("timeout waiting for instance to be deployed")
+		}
+	}
+}
 
-			timeout = time.NewTimer(5 * time.Minute)
-			deployInstanceStatusUpdate := SubscribeDeployInstanceTaskStatus()
+// OpenServer 确保档案 profile 的服务器被开启：存在休眠实例时唤醒实例并开启服务器，不存在活动实例时创建并部署实例，已经存在活动实例时不做任何事情。
+// 该函数由系统自动调用，参见 monitors.ScheduleOpenFunc。
+func OpenServer(profile string) error {
+	if _, err := store.GetHibernatedActiveInstance(profile); err == nil {
+		mu := resumeInstanceMutex.Get(profile)
 
-		loop2:
-			for {
-				select {
-				case taskStatus := <-deployInstanceStatusUpdate:
-					if taskStatus == consts.TaskStatusSuccess {
-						cmd, ok := commands.ShouldGetCommand(consts.CmdTypeStartServer)
+		if !mu.TryLock() {
+			return errors.New("instance is being resumed")
+		}
+		defer mu.Unlock()
 
-						if !ok {
-							event := events.Instance(events.InstanceEventCreateAndDeployFailed, "cannot find start_server command")
-							stream.Broadcast(event)
-							return
-						}
+		return resumeAndStartServer(profile)
+	}
 
-						instance, err := store.GetDeployedActiveInstance()
+	var cnt int
 
-						if err != nil {
-							event := events.Instance(events.InstanceEventCreateAndDeployFailed, "cannot get instance: "+err.Error())
-							stream.Broadcast(event)
-							return
-						}
+	if err := db.Pool.QueryRow("SELECT count(*) FROM instances WHERE profile = ? AND deleted_at IS NULL", profile).Scan(&cnt); err != nil {
+		return err
+	}
 
-						func() {
-							ctx, cancel := cmd.DefaultContext()
-							defer cancel()
-
-							_, err = cmd.RunWithoutCooldown(ctx, *instance.Ip, nil, nil)
-
-							if err != nil {
-								event := events.Instance(events.InstanceEventCreateAndDeployFailed, "cannot start server: "+err.Error())
-								stream.Broadcast(event)
-								return
-							}
-
-							event := events.Instance(events.InstanceEventCreateAndDeployStep, "requested server start")
-							stream.Broadcast(event)
-						}()
-						break loop2
-					} else if taskStatus == consts.TaskStatusFailed {
-						event := events.Instance(events.InstanceEventCreateAndDeployFailed, "deploy task failed")
-						stream.Broadcast(event)
-						return
-					}
-				case <-timeout.C:
-					event := events.Instance(events.InstanceEventCreateAndDeployFailed, "timeout waiting for instance to be deployed")
-					stream.Broadcast(event)
-					return
-				}
-			}
+	if cnt > 0 {
+		return nil
+	}
+
+	return CreateAndDeploy(profile, false, nil)
+}
+
+func HandleCreateAndDeployInstance() gin.HandlerFunc {
+	return helpers.QueryHandler[CreateInstanceQuery](func(query CreateInstanceQuery, c *gin.Context) (any, error) {
+		profile := gctx.GetProfile(c)
+
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		go func() {
+			_ = CreateAndDeploy(profile, query.AutoVSwitch, &userId)
 		}()
 
 		return gin.H{}, nil
@@ -2,9 +2,10 @@ package instances
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/Subilan/go-aliyunmc/clients"
@@ -13,9 +14,11 @@ import (
 	"github.com/Subilan/go-aliyunmc/events/stream"
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/Subilan/go-aliyunmc/monitors"
 	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
 	"github.com/alibabacloud-go/tea/tea"
 	vpc20160428 "github.com/alibabacloud-go/vpc-20160428/v6/client"
 	"github.com/gin-gonic/gin"
@@ -34,142 +37,294 @@ type CreateInstanceResponseBody struct {
 
 const createInstanceTimeout = 15 * time.Second
 
-var createInstanceMutex sync.Mutex
+var createInstanceMutex helpers.KeyedMutex
 
-func createPreferredInstance() helpers.QueryHandlerFunc[CreateInstanceQuery] {
-	return func(query CreateInstanceQuery, c *gin.Context) (any, error) {
-		// 长耗时任务，避免重复执行
-		ok := createInstanceMutex.TryLock()
-		if !ok {
-			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "instance is being created"}
-		}
-		defer createInstanceMutex.Unlock()
+// errVSwitchNotFound 表示可用区内不存在默认交换机，且没有指定自动创建
+var errVSwitchNotFound = &helpers.HttpError{Code: http.StatusNotFound, Details: "vswitch not found"}
 
-		if !monitors.SnapshotPreferredInstanceChargePresent() {
-			return nil, &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "preferred instance charge not present"}
-		}
+// CreateInstanceAttempt 是 events.InstanceEventCreateAttempt 事件的载荷，表示一次创建实例的尝试
+type CreateInstanceAttempt struct {
+	InstanceType string  `json:"instanceType"`
+	ZoneId       string  `json:"zoneId"`
+	TradePrice   float32 `json:"tradePrice"`
+	Success      bool    `json:"success"`
+	Error        string  `json:"error,omitempty"`
+}
 
-		var cnt int
-		var err error
+// isStockErrorCode 返回阿里云错误码 code 是否表示实例类型在可用区内库存不足或不可售卖，此类错误可以通过更换实例类型或可用区规避
+func isStockErrorCode(code string) bool {
+	switch code {
+	case "OperationDenied.NoStock",
+		"Zone.NotOnSale",
+		"Zone.NotOpen",
+		"ResourceNotAvailable",
+		"InvalidResourceType.NotSupported",
+		"InvalidInstanceType.ZoneNotSupported":
+		return true
+	}
 
-		err = db.Pool.QueryRow("SELECT count(*) FROM instances WHERE deleted_at IS NULL").Scan(&cnt)
+	return false
+}
 
-		if err != nil {
-			return nil, err
-		}
+// resolveVSwitch 返回可用区 zoneId 内的默认交换机标识符。如果默认交换机不存在且 autoVSwitch 为 true，则创建默认交换机
+func resolveVSwitch(ctx context.Context, zoneId string, autoVSwitch bool) (string, error) {
+	describeVSwitchesRequest := &vpc20160428.DescribeVSwitchesRequest{
+		ZoneId:    tea.String(zoneId),
+		IsDefault: tea.Bool(true),
+	}
 
-		if cnt > 0 {
-			return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "an instance already exists"}
-		}
+	describeVSwitchesResponse, err := clients.VpcClient.DescribeVSwitchesWithContext(ctx, describeVSwitchesRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return "", &helpers.HttpError{Code: http.StatusInternalServerError, Details: "cannot describe vswitches in zone " + zoneId}
+	}
 
-		inst := monitors.SnapshotPreferredInstanceCharge()
-		zoneId := inst.ZoneId
-		instanceType := inst.InstanceType
+	if len(describeVSwitchesResponse.Body.VSwitches.VSwitch) > 0 {
+		return *describeVSwitchesResponse.Body.VSwitches.VSwitch[0].VSwitchId, nil
+	}
 
-		describeVSwitchesRequest := &vpc20160428.DescribeVSwitchesRequest{
-			ZoneId:    tea.String(zoneId),
-			IsDefault: tea.Bool(true),
-		}
+	if !autoVSwitch {
+		return "", errVSwitchNotFound
+	}
 
-		describeVSwitchesResponse, err := clients.VpcClient.DescribeVSwitches(describeVSwitchesRequest)
+	createDefaultVSwitchRequest := &vpc20160428.CreateDefaultVSwitchRequest{
+		ZoneId:   tea.String(zoneId),
+		RegionId: tea.String(config.Cfg.Aliyun.RegionId),
+	}
 
-		if err != nil {
-			return nil, &helpers.HttpError{Code: http.StatusInternalServerError, Details: "cannot describe vswitches in zone " + zoneId}
+	createDefaultVSwitchResponse, err := clients.VpcClient.CreateDefaultVSwitchWithContext(ctx, createDefaultVSwitchRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return "", &helpers.HttpError{Code: http.StatusInternalServerError, Details: "cannot create default vswitch in zone " + zoneId}
+	}
+
+	// 此时交换机可能仍然在准备中
+	return *createDefaultVSwitchResponse.Body.VSwitchId, nil
+}
+
+// createInstanceCandidates 返回档案 profile 创建实例时依次尝试的实例类型及可用区：最佳实例在前，其后是价格不超过 config.InstanceCharge.CandidateMaxTradePrice 的候选实例
+func createInstanceCandidates(profile string) []monitors.AvailableInstanceItem {
+	ceiling := config.Cfg.Monitor.InstanceCharge.CandidateMaxTradePrice
+
+	result := []monitors.AvailableInstanceItem{monitors.SnapshotPreferredInstanceCharge(profile)}
+
+	for _, candidate := range monitors.SnapshotPreferredInstanceChargeCandidates(profile) {
+		if ceiling > 0 && candidate.TradePrice > ceiling {
+			continue
 		}
 
-		var vswitchId string
+		result = append(result, candidate)
+	}
+
+	return result
+}
+
+// recordCreateInstanceAttempt 将一次创建实例的尝试记录到日志并推送给用户
+func recordCreateInstanceAttempt(profile string, attempt CreateInstanceAttempt) {
+	log.Printf("create instance attempt: profile=%s, type=%s, zone=%s, success=%v, error=%s", profile, attempt.InstanceType, attempt.ZoneId, attempt.Success, attempt.Error)
+
+	err := stream.BroadcastAndSave(events.Instance(profile, events.InstanceEventCreateAttempt, attempt))
+
+	if err != nil {
+		log.Println("cannot broadcast and save event:", err)
+	}
+}
+
+// tryCreateInstance 尝试在档案 profile 下以 item 指定的实例类型及可用区创建实例，返回新实例的标识符和所用交换机。
+// 如果 snapshot 不为 nil，数据盘将从该快照创建；如果 bakedImageId 不为空，实例将从该预制镜像启动。
+func tryCreateInstance(ctx context.Context, profile config.ProfileConfig, item monitors.AvailableInstanceItem, autoVSwitch bool, snapshot *store.Snapshot, bakedImageId string) (string, string, error) {
+	vswitchId, err := resolveVSwitch(ctx, item.ZoneId, autoVSwitch)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	ecsConfig := config.Cfg.GetAliyunEcsConfig()
+
+	dataDisk := &ecs20140526.CreateInstanceRequestDataDisk{
+		Category: tea.String(profile.DataDisk.Category),
+		Size:     tea.Int32(int32(profile.DataDisk.Size)),
+		DiskName: tea.String("data"),
+	}
+
+	if snapshot != nil {
+		dataDisk.SnapshotId = tea.String(snapshot.SnapshotId)
+		// 从快照创建的数据盘不能小于快照的源数据盘
+		dataDisk.Size = tea.Int32(int32(max(profile.DataDisk.Size, snapshot.DiskSize)))
+	}
+
+	imageId := ecsConfig.ImageId
+
+	if bakedImageId != "" {
+		imageId = bakedImageId
+	}
+
+	createInstanceRequest := &ecs20140526.CreateInstanceRequest{
+		RegionId:     tea.String(config.Cfg.Aliyun.RegionId),
+		ZoneId:       tea.String(item.ZoneId),
+		InstanceType: tea.String(item.InstanceType),
+		SystemDisk: &ecs20140526.CreateInstanceRequestSystemDisk{
+			Category: tea.String(ecsConfig.SystemDisk.Category),
+			Size:     tea.Int32(int32(ecsConfig.SystemDisk.Size)),
+		},
+		DataDisk:                 []*ecs20140526.CreateInstanceRequestDataDisk{dataDisk},
+		InternetChargeType:       tea.String("PayByTraffic"), // This line costs CNY 400.
+		InternetMaxBandwidthOut:  tea.Int32(int32(ecsConfig.InternetMaxBandwidthOut)),
+		HostName:                 tea.String(profile.HostName),
+		Password:                 tea.String(ecsConfig.RootPassword),
+		InstanceChargeType:       tea.String("PostPaid"),
+		SpotStrategy:             tea.String("SpotAsPriceGo"),
+		SpotDuration:             tea.Int32(1),
+		SpotInterruptionBehavior: tea.String(ecsConfig.SpotInterruptionBehavior),
+		SecurityGroupId:          tea.String(ecsConfig.SecurityGroupId),
+		VSwitchId:                tea.String(vswitchId),
+		ImageId:                  tea.String(imageId),
+	}
+
+	createInstanceResponse, err := clients.EcsClient.CreateInstanceWithContext(ctx, createInstanceRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return *createInstanceResponse.Body.InstanceId, vswitchId, nil
+}
+
+// CreatePreferredInstance 为档案 profileName 依次尝试创建最佳实例和候选实例，成功后记录实例并开始等待实例就绪。autoVSwitch 的含义参见 CreateInstanceQuery
+func CreatePreferredInstance(ctx context.Context, profileName string, autoVSwitch bool) error {
+	if !monitors.SnapshotPreferredInstanceChargePresent(profileName) {
+		return &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "preferred instance charge not present"}
+	}
+
+	return createInstance(ctx, profileName, createInstanceCandidates(profileName), autoVSwitch)
+}
+
+// createInstance 为档案 profileName 依次尝试以 items 中的实例类型及可用区创建实例，成功后记录实例并开始等待实例就绪
+func createInstance(ctx context.Context, profileName string, items []monitors.AvailableInstanceItem, autoVSwitch bool) error {
+	profile, _ := config.Cfg.GetProfile(profileName)
+
+	// 长耗时任务，避免同一档案重复执行
+	mu := createInstanceMutex.Get(profileName)
+	ok := mu.TryLock()
+	if !ok {
+		return &helpers.HttpError{Code: http.StatusForbidden, Details: "instance is being created"}
+	}
+	defer mu.Unlock()
+
+	var cnt int
+	var err error
 
-		if len(describeVSwitchesResponse.Body.VSwitches.VSwitch) == 0 {
-			if !query.AutoVSwitch {
-				return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "vswitch not found"}
-			}
-			createDefaultVSwitchRequest := &vpc20160428.CreateDefaultVSwitchRequest{
-				ZoneId:   tea.String(zoneId),
-				RegionId: tea.String(config.Cfg.Aliyun.RegionId),
-			}
+	err = db.Pool.QueryRow("SELECT count(*) FROM instances WHERE profile = ? AND deleted_at IS NULL", profileName).Scan(&cnt)
 
-			createDefaultVSwitchResponse, err := clients.VpcClient.CreateDefaultVSwitch(createDefaultVSwitchRequest)
+	if err != nil {
+		return err
+	}
 
-			if err != nil {
-				return nil, &helpers.HttpError{Code: http.StatusInternalServerError, Details: "cannot create default vswitch in zone " + zoneId}
-			}
+	if cnt > 0 {
+		return &helpers.HttpError{Code: http.StatusConflict, Details: "an instance already exists"}
+	}
 
-			// 此时交换机可能仍然在准备中
-			vswitchId = *createDefaultVSwitchResponse.Body.VSwitchId
-		} else {
-			vswitchId = *describeVSwitchesResponse.Body.VSwitches.VSwitch[0].VSwitchId
+	// 使用快照归档时，从最新的快照恢复数据盘。如果还没有快照，则创建空白数据盘，部署时从存储桶复制归档
+	var snapshot *store.Snapshot
+	var snapshotId *string
+
+	if config.Cfg.Deploy.UseSnapshotArchive() {
+		snapshot, err = store.GetLatestSnapshot(profileName)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
 		}
 
-		ctx, cancel := context.WithTimeout(c, createInstanceTimeout)
-		defer cancel()
-
-		ecsConfig := config.Cfg.GetAliyunEcsConfig()
-
-		createInstanceRequest := &ecs20140526.CreateInstanceRequest{
-			RegionId:     tea.String(config.Cfg.Aliyun.RegionId),
-			ZoneId:       &zoneId,
-			InstanceType: tea.String(instanceType),
-			SystemDisk: &ecs20140526.CreateInstanceRequestSystemDisk{
-				Category: tea.String(ecsConfig.SystemDisk.Category),
-				Size:     tea.Int32(int32(ecsConfig.SystemDisk.Size)),
-			},
-			DataDisk: []*ecs20140526.CreateInstanceRequestDataDisk{
-				{
-					Category: tea.String(ecsConfig.DataDisk.Category),
-					Size:     tea.Int32(int32(ecsConfig.DataDisk.Size)),
-					DiskName: tea.String("data"),
-				},
-			},
-			InternetChargeType:       tea.String("PayByTraffic"), // This line costs CNY 400.
-			InternetMaxBandwidthOut:  tea.Int32(int32(ecsConfig.InternetMaxBandwidthOut)),
-			HostName:                 tea.String(ecsConfig.HostName),
-			Password:                 tea.String(ecsConfig.RootPassword),
-			InstanceChargeType:       tea.String("PostPaid"),
-			SpotStrategy:             tea.String("SpotAsPriceGo"),
-			SpotDuration:             tea.Int32(1),
-			SpotInterruptionBehavior: tea.String(ecsConfig.SpotInterruptionBehavior),
-			SecurityGroupId:          tea.String(ecsConfig.SecurityGroupId),
-			VSwitchId:                tea.String(vswitchId),
-			ImageId:                  tea.String(ecsConfig.ImageId),
+		if snapshot != nil {
+			snapshotId = &snapshot.SnapshotId
 		}
+	}
 
-		createInstanceResponse, err := clients.EcsClient.CreateInstance(createInstanceRequest)
+	// 存在可用的预制镜像时从预制镜像启动
+	var bakedImageId *string
+
+	if config.Cfg.Deploy.BakeImage {
+		imageId, err := helpers.GetAvailableBakedImageId()
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		_, err = db.Pool.ExecContext(ctx, `
-INSERT INTO instances (instance_id, instance_type, region_id, zone_id, vswitch_id) VALUES (?, ?, ?, ?, ?)
-`, *createInstanceResponse.Body.InstanceId, instanceType, config.Cfg.Aliyun.RegionId, zoneId, vswitchId)
+		if imageId != "" {
+			bakedImageId = &imageId
+		}
+	}
 
-		if err != nil {
-			return nil, err
+	var instanceId, vswitchId string
+	var created monitors.AvailableInstanceItem
+
+	// 依次尝试最佳实例和候选实例，只有库存不足或可用区内没有交换机时才回退到下一个
+	for _, item := range items {
+		attempt := CreateInstanceAttempt{InstanceType: item.InstanceType, ZoneId: item.ZoneId, TradePrice: item.TradePrice}
+
+		instanceId, vswitchId, err = tryCreateInstance(ctx, profile, item, autoVSwitch, snapshot, tea.StringValue(bakedImageId))
+
+		if err == nil {
+			attempt.Success = true
+			recordCreateInstanceAttempt(profileName, attempt)
+			created = item
+			break
 		}
 
-		// 将实例创建广播给所有用户
-		event := events.Instance(events.InstanceEventCreated, store.Instance{
-			InstanceId:   *createInstanceResponse.Body.InstanceId,
-			InstanceType: instanceType,
-			RegionId:     config.Cfg.Aliyun.RegionId,
-			ZoneId:       zoneId,
-			DeletedAt:    nil,
-			CreatedAt:    time.Now(),
-			Ip:           nil,
-			VSwitchId:    vswitchId,
-		}, true)
-		err = stream.BroadcastAndSave(event)
+		attempt.Error = err.Error()
+		recordCreateInstanceAttempt(profileName, attempt)
 
-		if err != nil {
-			log.Println("cannot broadcast and save event:", err)
+		if !errors.Is(err, errVSwitchNotFound) && !isStockErrorCode(helpers.AliyunErrorCode(err)) {
+			return err
 		}
+	}
 
-		go monitors.StartActiveInstanceWhenReady()
+	if instanceId == "" {
+		return &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "no candidate instance type can be created: " + err.Error()}
+	}
 
-		return gin.H{}, nil
+	insertCtx, cancel := context.WithTimeout(ctx, createInstanceTimeout)
+	defer cancel()
+
+	_, err = db.Pool.ExecContext(insertCtx, `
+INSERT INTO instances (instance_id, profile, instance_type, region_id, zone_id, vswitch_id, snapshot_id, baked_image_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`, instanceId, profileName, created.InstanceType, config.Cfg.Aliyun.RegionId, created.ZoneId, vswitchId, snapshotId, bakedImageId)
+
+	if err != nil {
+		return err
 	}
+
+	// 将实例创建广播给所有用户
+	event := events.Instance(profileName, events.InstanceEventCreated, store.Instance{
+		InstanceId:   instanceId,
+		Profile:      profileName,
+		InstanceType: created.InstanceType,
+		RegionId:     config.Cfg.Aliyun.RegionId,
+		ZoneId:       created.ZoneId,
+		DeletedAt:    nil,
+		CreatedAt:    time.Now(),
+		Ip:           nil,
+		VSwitchId:    vswitchId,
+		SnapshotId:   snapshotId,
+		BakedImageId: bakedImageId,
+	}, true)
+	err = stream.BroadcastAndSave(event)
+
+	if err != nil {
+		log.Println("cannot broadcast and save event:", err)
+	}
+
+	go monitors.StartActiveInstanceWhenReady(profileName)
+
+	return nil
 }
 
 func HandleCreatePreferredInstance() gin.HandlerFunc {
-	return helpers.QueryHandler(createPreferredInstance())
+	return helpers.QueryHandler(func(query CreateInstanceQuery, c *gin.Context) (any, error) {
+		if err := CreatePreferredInstance(c, gctx.GetProfile(c), query.AutoVSwitch); err != nil {
+			return nil, err
+		}
+
+		return gin.H{}, nil
+	})
 }
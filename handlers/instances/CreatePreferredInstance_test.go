@@ -0,0 +1,284 @@
+package instances
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/clients"
+	"github.com/Subilan/go-aliyunmc/clients/fake"
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/db/dbtest"
+	"github.com/Subilan/go-aliyunmc/monitors"
+)
+
+func TestMain(m *testing.M) {
+	// 监控器的日志写入工作目录下的 logs 目录
+	dir, err := os.MkdirTemp("", "instances")
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		log.Fatalln(err)
+	}
+
+	stream.InitPublicChannel()
+
+	code := m.Run()
+
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// setupCreateInstance 以模拟实现和内存数据库替换全局客户端和 db.Pool，并运行默认档案的 monitors.InstanceCharge 直到获取到最佳实例。
+// 模拟地域内有两个可用区，只有 cn-test-a 预置了交换机。按价格排序后，最佳实例为 cn-test-a 的 ecs.fake.c2m4，候选实例依次为
+// cn-test-b 的 ecs.fake.c2m4 和 cn-test-a 的 ecs.fake.c2m8。count 是数据库中档案已有的实例数
+func setupCreateInstance(t *testing.T, count int) (*fake.Provider, *dbtest.DB) {
+	t.Helper()
+
+	config.Cfg.Aliyun.RegionId = "cn-test"
+	config.Cfg.Aliyun.Ecs = config.AliyunEcsConfig{
+		InternetMaxBandwidthOut:  10,
+		ImageId:                  "debian_12",
+		SystemDisk:               config.EcsDiskConfig{Category: "cloud_essd", Size: 20},
+		DataDisk:                 config.EcsDiskConfig{Category: "cloud_essd", Size: 40},
+		HostName:                 "mc",
+		SpotInterruptionBehavior: "Stop",
+	}
+	config.Cfg.Monitor.InstanceCharge = config.InstanceCharge{
+		Interval:      3600,
+		RetryInterval: 3600,
+		Timeout:       5,
+		CacheFile:     filepath.Join(t.TempDir(), "preferred.json"),
+		Filters:       config.InstanceChargeFilters{MaxTradePrice: 10},
+	}
+	config.Cfg.Monitor.StartInstance = config.StartInstance{Interval: 3600, Timeout: 1}
+	config.Cfg.Profiles = []config.ProfileConfig{{
+		Name:                config.DefaultProfileName,
+		HostName:            "mc",
+		DataDisk:            &config.EcsDiskConfig{Category: "cloud_essd", Size: 40},
+		MemChoices:          []int{4, 8},
+		CpuCoreCountChoices: []int{2},
+	}}
+
+	p := fake.New("cn-test", "")
+	clients.EcsClient = p
+	clients.VpcClient = p
+
+	// 创建成功后 monitors.StartActiveInstanceWhenReady 查询实例标识符，没有结果时立即退出
+	startQueried := make(chan struct{}, 1)
+
+	d := &dbtest.DB{Query: func(query string, _ []driver.Value) (*dbtest.Rows, error) {
+		if strings.Contains(query, "count(*)") {
+			return &dbtest.Rows{Columns: []string{"count(*)"}, Values: [][]driver.Value{{int64(count)}}}, nil
+		}
+
+		if strings.HasPrefix(query, "SELECT instance_id") {
+			startQueried <- struct{}{}
+		}
+
+		return nil, nil
+	}}
+	db.Pool = dbtest.Open(d)
+
+	monitors.Init()
+
+	quit := make(chan bool)
+	done := make(chan struct{})
+
+	go func() {
+		monitors.InstanceCharge(config.DefaultProfileName, quit)
+		close(done)
+	}()
+
+	// 等待后台的监控器退出，避免下一个测试替换全局变量时与其竞争
+	t.Cleanup(func() {
+		close(quit)
+		<-done
+
+		if len(d.Execs("INSERT INTO instances")) > 0 {
+			select {
+			case <-startQueried:
+			case <-time.After(5 * time.Second):
+				t.Error("StartActiveInstanceWhenReady did not query the instance")
+			}
+		}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !monitors.SnapshotPreferredInstanceChargePresent(config.DefaultProfileName) {
+		if time.Now().After(deadline) {
+			t.Fatal("preferred instance charge not present")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return p, d
+}
+
+// createdInstance 返回数据库中记录的新实例的可用区和实例类型
+func createdInstance(t *testing.T, d *dbtest.DB) (string, string) {
+	t.Helper()
+
+	inserts := d.Execs("INSERT INTO instances")
+
+	if len(inserts) != 1 {
+		t.Fatalf("executed %d instance inserts, want 1", len(inserts))
+	}
+
+	args := inserts[0].Args
+
+	return args[4].(string), args[2].(string)
+}
+
+// createAttempts 返回推送的创建实例尝试事件数
+func createAttempts(d *dbtest.DB) int {
+	n := 0
+
+	for _, e := range d.Execs("INSERT INTO `pushed_events`") {
+		if content, ok := e.Args[5].(string); ok && strings.Contains(content, `"success"`) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestCreatePreferredInstance(t *testing.T) {
+	_, d := setupCreateInstance(t, 0)
+
+	if err := CreatePreferredInstance(context.Background(), config.DefaultProfileName, false); err != nil {
+		t.Fatalf("CreatePreferredInstance() error = %v", err)
+	}
+
+	zoneId, instanceType := createdInstance(t, d)
+
+	if zoneId != "cn-test-a" || instanceType != "ecs.fake.c2m4" {
+		t.Errorf("created %s in %s, want ecs.fake.c2m4 in cn-test-a", instanceType, zoneId)
+	}
+
+	if n := createAttempts(d); n != 1 {
+		t.Errorf("recorded %d create attempts, want 1", n)
+	}
+}
+
+func TestCreatePreferredInstanceFallback(t *testing.T) {
+	p, d := setupCreateInstance(t, 0)
+
+	// 最佳实例无库存，第一个候选实例所在可用区没有交换机，应当回退到第二个候选实例
+	p.SetNoStock("cn-test-a", "ecs.fake.c2m4", true)
+
+	if err := CreatePreferredInstance(context.Background(), config.DefaultProfileName, false); err != nil {
+		t.Fatalf("CreatePreferredInstance() error = %v", err)
+	}
+
+	zoneId, instanceType := createdInstance(t, d)
+
+	if zoneId != "cn-test-a" || instanceType != "ecs.fake.c2m8" {
+		t.Errorf("created %s in %s, want ecs.fake.c2m8 in cn-test-a", instanceType, zoneId)
+	}
+
+	if n := createAttempts(d); n != 3 {
+		t.Errorf("recorded %d create attempts, want 3", n)
+	}
+}
+
+func TestCreatePreferredInstanceAutoVSwitch(t *testing.T) {
+	p, d := setupCreateInstance(t, 0)
+
+	p.SetNoStock("cn-test-a", "ecs.fake.c2m4", true)
+
+	if err := CreatePreferredInstance(context.Background(), config.DefaultProfileName, true); err != nil {
+		t.Fatalf("CreatePreferredInstance() error = %v", err)
+	}
+
+	zoneId, instanceType := createdInstance(t, d)
+
+	if zoneId != "cn-test-b" || instanceType != "ecs.fake.c2m4" {
+		t.Errorf("created %s in %s, want ecs.fake.c2m4 in cn-test-b", instanceType, zoneId)
+	}
+}
+
+func TestCreatePreferredInstanceNoStock(t *testing.T) {
+	p, d := setupCreateInstance(t, 0)
+
+	p.SetNoStock("cn-test-a", "ecs.fake.c2m4", true)
+	p.SetNoStock("cn-test-a", "ecs.fake.c2m8", true)
+
+	err := CreatePreferredInstance(context.Background(), config.DefaultProfileName, false)
+
+	var httpErr *helpers.HttpError
+
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("CreatePreferredInstance() error = %v, want status %d", err, http.StatusServiceUnavailable)
+	}
+
+	if n := len(d.Execs("INSERT INTO instances")); n != 0 {
+		t.Errorf("executed %d instance inserts, want 0", n)
+	}
+
+	if n := createAttempts(d); n != 3 {
+		t.Errorf("recorded %d create attempts, want 3", n)
+	}
+}
+
+func TestCreatePreferredInstanceOtherError(t *testing.T) {
+	_, d := setupCreateInstance(t, 0)
+
+	// 镜像不存在不能通过更换实例类型规避，应当立即返回
+	config.Cfg.Aliyun.Ecs.ImageId = ""
+
+	err := CreatePreferredInstance(context.Background(), config.DefaultProfileName, false)
+
+	if helpers.AliyunErrorCode(err) != "InvalidImageId.NotFound" {
+		t.Fatalf("CreatePreferredInstance() error = %v, want InvalidImageId.NotFound", err)
+	}
+
+	if n := createAttempts(d); n != 1 {
+		t.Errorf("recorded %d create attempts, want 1", n)
+	}
+}
+
+func TestCreatePreferredInstanceExists(t *testing.T) {
+	_, d := setupCreateInstance(t, 1)
+
+	err := CreatePreferredInstance(context.Background(), config.DefaultProfileName, false)
+
+	var httpErr *helpers.HttpError
+
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusConflict {
+		t.Fatalf("CreatePreferredInstance() error = %v, want status %d", err, http.StatusConflict)
+	}
+
+	if n := createAttempts(d); n != 0 {
+		t.Errorf("recorded %d create attempts, want 0", n)
+	}
+}
+
+func TestIsStockErrorCode(t *testing.T) {
+	for _, code := range []string{"OperationDenied.NoStock", "Zone.NotOnSale", "InvalidInstanceType.ZoneNotSupported"} {
+		if !isStockErrorCode(code) {
+			t.Errorf("isStockErrorCode(%q) = false, want true", code)
+		}
+	}
+
+	for _, code := range []string{"", "InvalidImageId.NotFound", "InvalidVSwitchId.NotFound"} {
+		if isStockErrorCode(code) {
+			t.Errorf("isStockErrorCode(%q) = true, want false", code)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package instances
 import (
 	"context"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/Subilan/go-aliyunmc/consts"
@@ -29,17 +28,20 @@ type DeleteInstanceQuery struct {
 	ArchiveAndForce bool `form:"archiveAndForce"`
 }
 
-var deleteInstanceMutex sync.Mutex
+var deleteInstanceMutex helpers.KeyedMutex
 
 func HandleDeleteInstance() gin.HandlerFunc {
 	return helpers.QueryHandler[DeleteInstanceQuery](func(query DeleteInstanceQuery, c *gin.Context) (any, error) {
-		ok := deleteInstanceMutex.TryLock()
+		profile := gctx.GetProfile(c)
+
+		mu := deleteInstanceMutex.Get(profile)
+		ok := mu.TryLock()
 
 		if !ok {
 			return nil, &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "instance is being deleted"}
 		}
 
-		defer deleteInstanceMutex.Unlock()
+		defer mu.Unlock()
 
 		userId, err := gctx.ShouldGetUserId(c)
 
@@ -58,7 +60,7 @@ func HandleDeleteInstance() gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 
-		inst, err := store.GetIpAllocatedActiveInstance()
+		inst, err := store.GetIpAllocatedActiveInstance(profile)
 
 		if err != nil {
 			return nil, err
@@ -69,14 +71,14 @@ func HandleDeleteInstance() gin.HandlerFunc {
 				return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "instance is not deployed and cannot be archived"}
 			}
 
-			err = commands.StopAndArchiveServer(ctx, *inst.Ip, &userId, "During delete & archive instance procedure")
+			err = commands.StopAndArchiveServer(ctx, inst, &userId, "During delete & archive instance procedure")
 
 			if err != nil {
 				return nil, err
 			}
 		}
 
-		err = helpers.DeleteInstance(ctx, inst.InstanceId, query.ArchiveAndForce || query.Force)
+		err = helpers.DeleteInstance(ctx, profile, inst.InstanceId, query.ArchiveAndForce || query.Force)
 
 		if err != nil {
 			return nil, err
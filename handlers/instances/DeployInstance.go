@@ -5,10 +5,10 @@ import (
 	"errors"
 	"log"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/Subilan/go-aliyunmc/broker"
+	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/consts"
 	"github.com/Subilan/go-aliyunmc/events"
 	"github.com/Subilan/go-aliyunmc/events/stream"
@@ -23,33 +23,41 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-var deployInstanceMutex sync.Mutex
-var deployInstanceTaskStatusBroker = broker.New[consts.TaskStatus]()
+var deployInstanceMutex helpers.KeyedMutex
 
+// deployInstanceTaskStatusBrokers 记录每个档案的部署任务状态广播器，键为档案名称
+var deployInstanceTaskStatusBrokers = make(map[string]*broker.Broker[consts.TaskStatus])
+
+// StartDeployInstanceTaskStatusBroker 为每个档案创建并启动部署任务状态广播器
 func StartDeployInstanceTaskStatusBroker() {
-	go deployInstanceTaskStatusBroker.Start()
+	for _, profile := range config.Cfg.ProfileNames() {
+		b := broker.New[consts.TaskStatus]()
+		deployInstanceTaskStatusBrokers[profile] = b
+		go b.Start()
+	}
 }
 
-func SubscribeDeployInstanceTaskStatus() <-chan consts.TaskStatus {
-	return deployInstanceTaskStatusBroker.Subscribe()
+// SubscribeDeployInstanceTaskStatus 订阅档案 profile 的部署任务状态更新
+func SubscribeDeployInstanceTaskStatus(profile string) <-chan consts.TaskStatus {
+	return deployInstanceTaskStatusBrokers[profile].Subscribe()
 }
 
-func syncDeployInstanceStatusWithUser(taskId string) {
-	taskStatusUpdate := deployInstanceTaskStatusBroker.Subscribe()
+func syncDeployInstanceStatusWithUser(profile string, taskId string) {
+	taskStatusUpdate := deployInstanceTaskStatusBrokers[profile].Subscribe()
 
 	for taskStatus := range taskStatusUpdate {
-		updateAndSend(taskId, taskStatus)
+		updateAndSend(profile, taskId, taskStatus)
 	}
 }
 
-func updateAndSend(taskId string, taskStatus consts.TaskStatus) {
+func updateAndSend(profile string, taskId string, taskStatus consts.TaskStatus) {
 	_, err := db.Pool.Exec("UPDATE tasks SET status = ? WHERE task_id = ?", taskStatus, taskId)
 
 	if err != nil {
 		log.Println("cannot update task status: " + err.Error())
 	}
 
-	event := events.Instance(events.InstanceEventDeploymentTaskStatusUpdate, taskStatus)
+	event := events.Instance(profile, events.InstanceEventDeploymentTaskStatusUpdate, taskStatus)
 	err = stream.BroadcastAndSave(event)
 
 	if err != nil {
@@ -57,141 +65,186 @@ func updateAndSend(taskId string, taskStatus consts.TaskStatus) {
 	}
 }
 
-func deployInstance() helpers.BasicHandlerFunc {
-	return func(c *gin.Context) (any, error) {
-		ok := deployInstanceMutex.TryLock()
-		if !ok {
-			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "instance is being deployed"}
-		}
-		defer deployInstanceMutex.Unlock()
-
-		userId, err := gctx.ShouldGetUserId(c)
-
-		if err != nil {
-			return nil, err
-		}
+// DeployInstance 在档案 profileName 正在运行且尚未部署的活动实例上运行部署脚本，返回部署任务的标识符。部署过程在后台进行，状态可以通过 SubscribeDeployInstanceTaskStatus 订阅。
+// 如果 by 为 nil，表示部署由系统自动发起。
+func DeployInstance(profileName string, by *int64) (string, error) {
+	// 模拟实现的实例没有真实的主机，未指定测试主机时不能运行部署脚本
+	if config.Cfg.Aliyun.UseFakeProvider() && config.Cfg.Aliyun.FakePublicIp == "" {
+		return "", &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "fake_public_ip is not set, refusing to deploy"}
+	}
 
-		// 检查实例是否处于运行状态
-		if monitors.SnapshotInstanceStatus() != consts.InstanceRunning {
-			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "instance is not running"}
-		}
+	profile, _ := config.Cfg.GetProfile(profileName)
+	taskStatusBroker := deployInstanceTaskStatusBrokers[profileName]
 
-		var ip string
+	mu := deployInstanceMutex.Get(profileName)
+	ok := mu.TryLock()
+	if !ok {
+		return "", &helpers.HttpError{Code: http.StatusForbidden, Details: "instance is being deployed"}
+	}
+	defer mu.Unlock()
 
-		err = db.Pool.QueryRow("SELECT ip FROM instances WHERE ip IS NOT NULL AND deleted_at IS NULL AND deployed = 0").Scan(&ip)
+	// 检查实例是否处于运行状态
+	if monitors.SnapshotInstanceStatus(profileName) != consts.InstanceRunning {
+		return "", &helpers.HttpError{Code: http.StatusBadRequest, Details: "instance is not running"}
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	var instanceId, ip string
+	var snapshotId, bakedImageId *string
 
-		// 检查是否存在部署任务正在运行
-		runningTaskCnt, err := store.GetRunningTaskCount(consts.TaskTypeInstanceDeployment)
+	err := db.Pool.QueryRow("SELECT instance_id, ip, snapshot_id, baked_image_id FROM instances WHERE profile = ? AND ip IS NOT NULL AND deleted_at IS NULL AND deployed = 0", profileName).Scan(&instanceId, &ip, &snapshotId, &bakedImageId)
 
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return "", err
+	}
 
-		if runningTaskCnt != 0 {
-			return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "已经存在部署任务正在运行"}
-		}
+	data := templateData.Deploy(profile)
+	data.Baked = bakedImageId != nil
 
-		// 为新的部署任务分配UUID并插入记录
-		taskId, err := store.InsertTask(consts.TaskTypeInstanceDeployment, userId)
+	// 数据盘从快照创建时，其大小可能大于档案配置的大小，部署脚本依据实际大小查找数据盘
+	if snapshotId != nil {
+		snapshot, err := store.GetSnapshotById(*snapshotId)
 
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 
-		// 与用户和数据库同步
-		go syncDeployInstanceStatusWithUser(taskId)
-
-		// 创建当前任务的全局流
-		stream.RecordStateForTask(taskId)
-
-		// 创建超时上下文
-		runCtx, cancelRunCtx := context.WithTimeout(context.Background(), 5*time.Minute)
-
-		// 记录取消函数
-		tasks.Register(cancelRunCtx, taskId)
-
-		// 更新为运行状态（此时不进行Publish）
-		updateAndSend(taskId, consts.TaskStatusRunning)
-
-		// 运行并借助全局流输出内容
-		go remote.RunScriptAsRootAsync(runCtx, ip, "deploy.tmpl.sh", templateData.Deploy(),
-			func(bytes []byte) {
-				// log.Println("debug: deploy.sh stdout: ", string(bytes))
-
-				state, stateExists := stream.GetStateOfTask(taskId)
-
-				if !stateExists {
-					log.Println("warning: trying to get state but state does not exist")
-					return
-				}
-
-				err = stream.BroadcastAndSave(&events.Event{
-					EventState: *state,
-					Content:    string(bytes),
-				})
-
-				stream.IncrStateOrdOfTask(taskId)
+		data.RestoreFromSnapshot = true
+		data.DataDiskSize = max(data.DataDiskSize, snapshot.DiskSize)
+	}
 
-				if err != nil {
-					log.Println(err.Error())
-					log.Printf("cannot send and save script step: userid=%v, deployment, is_error=false, content=%s\n", userId, string(bytes))
-				}
-			},
-			func(err error) {
-				log.Println("dedug: deploy.sh stderr: ", err.Error())
+	// 检查是否存在部署任务正在运行
+	runningTaskCnt, err := store.GetRunningTaskCount(consts.TaskTypeInstanceDeployment, profileName)
 
-				state, stateExists := stream.GetStateOfTask(taskId)
+	if err != nil {
+		return "", err
+	}
 
-				if !stateExists {
-					log.Println("warning: trying to get state but state does not exist")
-					return
-				}
+	if runningTaskCnt != 0 {
+		return "", &helpers.HttpError{Code: http.StatusConflict, Details: "已经存在部署任务正在运行"}
+	}
 
-				sendAndSaveError := stream.BroadcastAndSave(&events.Event{
-					EventState: *state,
-					IsError:    true,
-					Content:    err.Error(),
-				})
+	// 为新的部署任务分配UUID并插入记录
+	taskId, err := store.InsertTask(consts.TaskTypeInstanceDeployment, profileName, by)
 
-				if sendAndSaveError != nil {
-					log.Println(sendAndSaveError.Error())
-					log.Printf("cannot send and save script step: userid=%v, deployment, is_error=true, content=%s\n", userId, err.Error())
-				}
+	if err != nil {
+		return "", err
+	}
 
-				var status = consts.TaskStatusFailed
+	// 与用户和数据库同步
+	go syncDeployInstanceStatusWithUser(profileName, taskId)
+
+	// 创建当前任务的全局流
+	stream.RecordStateForTask(taskId)
+
+	// 创建超时上下文
+	runCtx, cancelRunCtx := context.WithTimeout(context.Background(), 5*time.Minute)
+
+	// 记录取消函数
+	tasks.Register(cancelRunCtx, taskId)
+
+	// 更新为运行状态（此时不进行Publish）
+	updateAndSend(profileName, taskId, consts.TaskStatusRunning)
+
+	// 运行并借助全局流输出内容
+	go remote.RunScriptAsRootAsync(runCtx, ip, "deploy.tmpl.sh", data,
+		func(bytes []byte) {
+			// log.Println("debug: deploy.sh stdout: ", string(bytes))
+
+			state, stateExists := stream.GetStateOfTask(taskId)
+
+			if !stateExists {
+				log.Println("warning: trying to get state but state does not exist")
+				return
+			}
+
+			err = stream.BroadcastAndSave(&events.Event{
+				EventState: *state,
+				Content:    string(bytes),
+			})
+
+			stream.IncrStateOrdOfTask(taskId)
+
+			if err != nil {
+				log.Println(err.Error())
+				log.Printf("cannot send and save script step: task=%s, deployment, is_error=false, content=%s\n", taskId, string(bytes))
+			}
+		},
+		func(err error) {
+			log.Println("dedug: deploy.sh stderr: ", err.Error())
+
+			state, stateExists := stream.GetStateOfTask(taskId)
+
+			if !stateExists {
+				log.Println("warning: trying to get state but state does not exist")
+				return
+			}
+
+			sendAndSaveError := stream.BroadcastAndSave(&events.Event{
+				EventState: *state,
+				IsError:    true,
+				Content:    err.Error(),
+			})
+
+			if sendAndSaveError != nil {
+				log.Println(sendAndSaveError.Error())
+				log.Printf("cannot send and save script step: task=%s, deployment, is_error=true, content=%s\n", taskId, err.Error())
+			}
+
+			var status = consts.TaskStatusFailed
+
+			if errors.Is(err, context.Canceled) {
+				status = consts.TaskStatusCancelled
+			}
+
+			if errors.Is(err, context.DeadlineExceeded) {
+				status = consts.TaskStatusTimedOut
+			}
+
+			taskStatusBroker.Publish(status)
+		},
+		func() {
+			_, err = db.Pool.Exec("UPDATE instances SET deployed = 1 WHERE profile = ? AND deleted_at IS NULL", profileName)
+			if err != nil {
+				log.Println("cannot update instance deployed status: " + err.Error())
+			}
+
+			taskStatusBroker.Publish(consts.TaskStatusSuccess)
+
+			// 从基础镜像启动的实例部署成功后，制作预制镜像供之后的实例使用
+			if config.Cfg.Deploy.BakeImage && !data.Baked {
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), helpers.BakeImageTimeout)
+					defer cancel()
+
+					if err := helpers.BakeImage(ctx, instanceId); err != nil {
+						log.Println("cannot bake image:", err)
+					}
+				}()
+			}
+		},
+		func() {
+			tasks.Unregister(taskId)
+			stream.DeleteStateOfTask(taskId)
+		},
+	)
+
+	return taskId, nil
+}
 
-				if errors.Is(err, context.Canceled) {
-					status = consts.TaskStatusCancelled
-				}
+func HandleDeployInstance() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
 
-				if errors.Is(err, context.DeadlineExceeded) {
-					status = consts.TaskStatusTimedOut
-				}
+		if err != nil {
+			return nil, err
+		}
 
-				deployInstanceTaskStatusBroker.Publish(status)
-			},
-			func() {
-				_, err = db.Pool.Exec("UPDATE instances SET deployed = 1 WHERE deleted_at IS NULL")
-				if err != nil {
-					log.Println("cannot update instance deployed status: " + err.Error())
-				}
+		taskId, err := DeployInstance(gctx.GetProfile(c), &userId)
 
-				deployInstanceTaskStatusBroker.Publish(consts.TaskStatusSuccess)
-			},
-			func() {
-				tasks.Unregister(taskId)
-				stream.DeleteStateOfTask(taskId)
-			},
-		)
+		if err != nil {
+			return nil, err
+		}
 
 		return helpers.Data(taskId), nil
-	}
-}
-
-func HandleDeployInstance() gin.HandlerFunc {
-	return helpers.BasicHandler(deployInstance())
+	})
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/helpers"
 	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
 	"github.com/alibabacloud-go/tea/tea"
 	"github.com/gin-gonic/gin"
 )
@@ -25,7 +26,7 @@ func HandleDescribeInstance() gin.HandlerFunc {
 			InstanceIds: tea.String(fmt.Sprintf("[\"%s\"]", instanceId)),
 		}
 
-		describeInstancesResponse, err := clients.EcsClient.DescribeInstances(&describeInstancesRequest)
+		describeInstancesResponse, err := clients.EcsClient.DescribeInstancesWithContext(c, &describeInstancesRequest, &dara.RuntimeOptions{})
 
 		if err != nil {
 			return nil, err
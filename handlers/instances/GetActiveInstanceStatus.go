@@ -2,13 +2,14 @@ package instances
 
 import (
 	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/monitors"
 	"github.com/gin-gonic/gin"
 )
 
 func HandleGetActiveInstanceStatus() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
-		instanceStatus := monitors.SnapshotInstanceStatus()
+		instanceStatus := monitors.SnapshotInstanceStatus(gctx.GetProfile(c))
 
 		return helpers.Data(instanceStatus), nil
 	})
@@ -1,8 +1,10 @@
 package instances
 
 import (
+	"net/http"
+
 	"github.com/Subilan/go-aliyunmc/helpers"
-	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/gin-gonic/gin"
 )
@@ -10,9 +12,10 @@ import (
 func HandleGetInstance() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
 		instanceId := c.Param("instanceId")
+		profile := gctx.GetProfile(c)
 
 		if instanceId == "" {
-			activeInstance, err := store.GetIpAllocatedActiveInstance()
+			activeInstance, err := store.GetIpAllocatedActiveInstance(profile)
 
 			if err != nil {
 				return nil, err
@@ -21,26 +24,27 @@ func HandleGetInstance() gin.HandlerFunc {
 			return helpers.Data(activeInstance), nil
 		}
 
-		var result store.Instance
-
-		err := db.Pool.QueryRow(`
-SELECT instance_id, instance_type, region_id, zone_id, ip, created_at, deleted_at FROM instances WHERE instance_id = ?
-`, instanceId).Scan(&result.InstanceId, &result.InstanceType, &result.RegionId, &result.ZoneId, &result.Ip, &result.CreatedAt, &result.DeletedAt)
+		result, err := store.GetInstanceById(instanceId)
 
 		if err != nil {
 			return nil, err
 		}
 
+		if result.Profile != profile {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "实例不属于该档案"}
+		}
+
 		return helpers.Data(result), nil
 	})
 }
 
 func HandleGetActiveOrLatestInstance() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
-		activeInstance, err := store.GetIpAllocatedActiveInstance()
+		profile := gctx.GetProfile(c)
+		activeInstance, err := store.GetIpAllocatedActiveInstance(profile)
 
 		if err != nil {
-			latestInstance, err := store.GetLatestInstance()
+			latestInstance, err := store.GetLatestInstance(profile)
 
 			if err != nil {
 				return nil, err
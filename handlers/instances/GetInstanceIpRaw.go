@@ -3,6 +3,7 @@ package instances
 import (
 	"net/http"
 
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/gin-gonic/gin"
 )
@@ -11,7 +12,7 @@ func HandleGetInstanceIpRaw() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		fallback := c.Query("fallback")
 
-		activeInstance, err := store.GetIpAllocatedActiveInstance()
+		activeInstance, err := store.GetIpAllocatedActiveInstance(gctx.GetProfile(c))
 
 		if err != nil {
 			c.Data(http.StatusOK, "text/plain", []byte(fallback))
@@ -0,0 +1,112 @@
+package instances
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// resumeInstanceMutex 避免同一档案的休眠实例被重复唤醒
+var resumeInstanceMutex helpers.KeyedMutex
+
+// HandleHibernateInstance 停止档案的服务器，并以节省停机模式停止活动实例（休眠）。休眠的实例保留云盘，可以通过 HandleResumeInstance 唤醒，无需重新部署。
+func HandleHibernateInstance() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		profile := gctx.GetProfile(c)
+
+		mu := deleteInstanceMutex.Get(profile)
+		ok := mu.TryLock()
+
+		if !ok {
+			return nil, &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "instance is being deleted or hibernated"}
+		}
+
+		defer mu.Unlock()
+
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		inst, err := store.GetDeployedActiveInstance(profile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), consts.StopAndArchiveTimeout)
+		defer cancel()
+
+		err = monitors.HibernateInstance(ctx, profile, inst, &userId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return gin.H{}, nil
+	})
+}
+
+// HandleResumeInstance 唤醒档案处于休眠状态的活动实例，并在唤醒后开启服务器。唤醒过程在后台进行，过程中的各个步骤通过 events.InstanceEventResumeStep 和 events.InstanceEventResumeFailed 推送。
+func HandleResumeInstance() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		profile := gctx.GetProfile(c)
+
+		if _, err := store.GetHibernatedActiveInstance(profile); err != nil {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "没有处于休眠状态的实例"}
+		}
+
+		mu := resumeInstanceMutex.Get(profile)
+
+		if !mu.TryLock() {
+			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "instance is being resumed"}
+		}
+
+		go func() {
+			defer mu.Unlock()
+			_ = resumeAndStartServer(profile)
+		}()
+
+		return gin.H{}, nil
+	})
+}
+
+// resumeAndStartServer 唤醒档案 profile 处于休眠状态的活动实例，并在唤醒后开启服务器，过程中的各个步骤通过 events.InstanceEventResumeStep 和 events.InstanceEventResumeFailed 推送。调用者需要持有 resumeInstanceMutex
+func resumeAndStartServer(profile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), consts.StopAndArchiveTimeout)
+	defer cancel()
+
+	inst, err := monitors.ResumeInstance(ctx, profile, func(step string) {
+		stream.Broadcast(events.Instance(profile, events.InstanceEventResumeStep, step))
+	})
+
+	if err != nil {
+		stream.Broadcast(events.Instance(profile, events.InstanceEventResumeFailed, err.Error()))
+		return err
+	}
+
+	cmd := commands.MustGetCommand(consts.CmdTypeStartServer)
+
+	cmdCtx, cmdCancel := cmd.DefaultContext()
+	defer cmdCancel()
+
+	_, err = cmd.RunWithoutCooldown(cmdCtx, inst, nil, nil)
+
+	if err != nil {
+		stream.Broadcast(events.Instance(profile, events.InstanceEventResumeFailed, "cannot start server: "+err.Error()))
+		return err
+	}
+
+	stream.Broadcast(events.Instance(profile, events.InstanceEventResumeStep, "requested server start"))
+	return nil
+}
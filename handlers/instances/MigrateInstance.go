@@ -0,0 +1,276 @@
+package instances
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// migrateInstanceMutex 避免同一档案的实例被重复迁移
+var migrateInstanceMutex helpers.KeyedMutex
+
+// migrationCandidates 返回档案 profile 迁移时依次尝试的实例类型及可用区：在创建实例时会尝试的实例中，排除与活动实例 inst 相同的实例，
+// 只保留交易价格比 currentPrice 至少低 config.Migration.Margin 的实例
+func migrationCandidates(profile string, inst *store.Instance, currentPrice float32) []monitors.AvailableInstanceItem {
+	margin := config.Cfg.Monitor.Migration.Margin
+
+	var result []monitors.AvailableInstanceItem
+
+	for _, item := range createInstanceCandidates(profile) {
+		if item.InstanceType == inst.InstanceType && item.ZoneId == inst.ZoneId {
+			continue
+		}
+
+		if item.TradePrice < 0 || item.TradePrice >= currentPrice || currentPrice-item.TradePrice < margin {
+			continue
+		}
+
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// updateMigrationTaskStatus 更新迁移任务 taskId 的状态并推送给用户
+func updateMigrationTaskStatus(profile string, taskId string, taskStatus consts.TaskStatus) {
+	_, err := db.Pool.Exec("UPDATE tasks SET status = ? WHERE task_id = ?", taskStatus, taskId)
+
+	if err != nil {
+		log.Println("cannot update task status: " + err.Error())
+	}
+
+	err = stream.BroadcastAndSave(events.Instance(profile, events.InstanceEventMigrationTaskStatusUpdate, gin.H{"taskId": taskId, "status": taskStatus}))
+
+	if err != nil {
+		log.Println("cannot send and save instance event", err)
+	}
+}
+
+// sendMigrationStep 将迁移任务 taskId 的一个步骤作为该任务的有状态事件推送给用户
+func sendMigrationStep(taskId string, content string, isError bool) {
+	state, stateExists := stream.GetStateOfTask(taskId)
+
+	if !stateExists {
+		log.Println("warning: trying to get state but state does not exist")
+		return
+	}
+
+	err := stream.BroadcastAndSave(&events.Event{
+		EventState: *state,
+		IsError:    isError,
+		Content:    content,
+	})
+
+	stream.IncrStateOrdOfTask(taskId)
+
+	if err != nil {
+		log.Printf("cannot send and save migration step: task=%s, is_error=%v, content=%s, error=%s\n", taskId, isError, content, err.Error())
+	}
+}
+
+// MigrateInstance 将档案 profile 已部署的活动实例迁移到更便宜的实例类型：停止服务器、归档并删除实例，依次尝试以最佳实例和更便宜的候选实例创建实例，部署后开启服务器。
+// 新实例创建或部署失败时，删除新实例并以原实例类型及可用区重新创建、部署实例，即回滚。
+//
+// 迁移作为一个 consts.TaskTypeInstanceMigration 任务在后台进行，函数返回任务的标识符。迁移的各个步骤作为该任务的有状态事件推送，任务状态通过 events.InstanceEventMigrationTaskStatusUpdate 推送。
+// 如果 by 为 nil，表示迁移由系统自动发起，参见 monitors.Migration。
+func MigrateInstance(profile string, by *int64) (string, error) {
+	mu := migrateInstanceMutex.Get(profile)
+
+	if !mu.TryLock() {
+		return "", &helpers.HttpError{Code: http.StatusForbidden, Details: "instance is being migrated"}
+	}
+
+	taskId, inst, items, currentPrice, err := prepareMigration(profile, by)
+
+	if err != nil {
+		mu.Unlock()
+		return "", err
+	}
+
+	go func() {
+		defer mu.Unlock()
+		defer stream.DeleteStateOfTask(taskId)
+
+		step := func(content string, isError bool) {
+			sendMigrationStep(taskId, content, isError)
+		}
+
+		status := consts.TaskStatusSuccess
+
+		if err := migrate(profile, inst, currentPrice, items, by, step); err != nil {
+			log.Printf("migration failed: profile=%s, task=%s, error=%s", profile, taskId, err.Error())
+			status = consts.TaskStatusFailed
+		}
+
+		updateMigrationTaskStatus(profile, taskId, status)
+	}()
+
+	return taskId, nil
+}
+
+// prepareMigration 检查档案 profile 能否迁移，并插入迁移任务记录。返回任务的标识符、活动实例、迁移时依次尝试的实例以及活动实例当前的交易价格
+func prepareMigration(profile string, by *int64) (string, *store.Instance, []monitors.AvailableInstanceItem, float32, error) {
+	if !monitors.SnapshotPreferredInstanceChargePresent(profile) {
+		return "", nil, nil, 0, &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "preferred instance charge not present"}
+	}
+
+	inst, err := store.GetDeployedActiveInstance(profile)
+
+	if err != nil {
+		return "", nil, nil, 0, &helpers.HttpError{Code: http.StatusNotFound, Details: "没有已部署的活动实例"}
+	}
+
+	if monitors.SnapshotInstanceStatus(profile) != consts.InstanceRunning {
+		return "", nil, nil, 0, &helpers.HttpError{Code: http.StatusBadRequest, Details: "instance is not running"}
+	}
+
+	profileCfg, _ := config.Cfg.GetProfile(profile)
+
+	ctx, cancel := context.WithTimeout(context.Background(), createInstanceTimeout)
+	defer cancel()
+
+	currentPrice, err := monitors.GetSpotTradePrice(ctx, profileCfg, inst.ZoneId, inst.InstanceType)
+
+	if err != nil {
+		return "", nil, nil, 0, err
+	}
+
+	items := migrationCandidates(profile, inst, currentPrice)
+
+	if len(items) == 0 {
+		return "", nil, nil, 0, &helpers.HttpError{Code: http.StatusConflict, Details: "没有足够便宜的实例类型可供迁移"}
+	}
+
+	taskId, err := store.InsertTask(consts.TaskTypeInstanceMigration, profile, by)
+
+	if err != nil {
+		return "", nil, nil, 0, err
+	}
+
+	stream.RecordStateForTask(taskId)
+	updateMigrationTaskStatus(profile, taskId, consts.TaskStatusRunning)
+
+	return taskId, inst, items, currentPrice, nil
+}
+
+// migrate 完成一次迁移的全部流程，会阻塞直到迁移或回滚结束。迁移失败时返回非 nil 的错误，即使回滚成功
+func migrate(profile string, inst *store.Instance, currentPrice float32, items []monitors.AvailableInstanceItem, by *int64, step func(content string, isError bool)) error {
+	step(fmt.Sprintf("migrating from %s in %s (%.4f CNY/h), target %s in %s (%.4f CNY/h)", inst.InstanceType, inst.ZoneId, currentPrice, items[0].InstanceType, items[0].ZoneId, items[0].TradePrice), false)
+
+	if err := archiveAndDeleteForMigration(profile, inst, by, step); err != nil {
+		step("cannot archive and delete instance: "+err.Error(), true)
+		return err
+	}
+
+	err := createAndDeploy(profile, items, false, by, func(content string) {
+		step(content, false)
+	})
+
+	if err == nil {
+		step("migration completed", false)
+		return nil
+	}
+
+	step("cannot create and deploy new instance: "+err.Error(), true)
+	step(fmt.Sprintf("rolling back to %s in %s", inst.InstanceType, inst.ZoneId), false)
+
+	if rollbackErr := rollbackMigration(profile, inst, currentPrice, by, step); rollbackErr != nil {
+		step("rollback failed: "+rollbackErr.Error(), true)
+		return fmt.Errorf("%w; rollback failed: %w", err, rollbackErr)
+	}
+
+	step("rollback completed", false)
+	return err
+}
+
+// archiveAndDeleteForMigration 停止活动实例 inst 上的服务器、归档并删除实例。归档失败时尝试重新开启服务器，使其恢复到迁移前的状态
+func archiveAndDeleteForMigration(profile string, inst *store.Instance, by *int64, step func(content string, isError bool)) error {
+	mu := deleteInstanceMutex.Get(profile)
+
+	if !mu.TryLock() {
+		return errors.New("instance is being deleted or hibernated")
+	}
+	defer mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.StopAndArchiveTimeout)
+	defer cancel()
+
+	step("stopping and archiving server", false)
+
+	if err := commands.StopAndArchiveServer(ctx, inst, by, "During instance migration procedure"); err != nil {
+		cmd := commands.MustGetCommand(consts.CmdTypeStartServer)
+
+		cmdCtx, cmdCancel := cmd.DefaultContext()
+		defer cmdCancel()
+
+		if _, startErr := cmd.RunWithoutCooldown(cmdCtx, inst, by, nil); startErr != nil {
+			step("cannot restart server: "+startErr.Error(), true)
+		}
+
+		return err
+	}
+
+	step("deleting instance "+inst.InstanceId, false)
+
+	return helpers.DeleteInstance(ctx, profile, inst.InstanceId, true)
+}
+
+// rollbackMigration 删除迁移过程中创建的新实例（如果存在），并以原实例 inst 的实例类型及可用区重新创建、部署实例并开启服务器
+func rollbackMigration(profile string, inst *store.Instance, price float32, by *int64, step func(content string, isError bool)) error {
+	created, err := store.GetActiveInstance(profile)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if created != nil {
+		step("deleting instance "+created.InstanceId, false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), deleteInstanceTimeout)
+		defer cancel()
+
+		if err := helpers.DeleteInstance(ctx, profile, created.InstanceId, true); err != nil {
+			return err
+		}
+	}
+
+	original := monitors.AvailableInstanceItem{InstanceType: inst.InstanceType, ZoneId: inst.ZoneId, TradePrice: price}
+
+	return createAndDeploy(profile, []monitors.AvailableInstanceItem{original}, false, by, func(content string) {
+		step(content, false)
+	})
+}
+
+// HandleMigrateInstance 立即将档案的活动实例迁移到更便宜的实例类型，返回迁移任务的标识符，参见 MigrateInstance
+func HandleMigrateInstance() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		taskId, err := MigrateInstance(gctx.GetProfile(c), &userId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(taskId), nil
+	})
+}
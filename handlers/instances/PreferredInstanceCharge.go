@@ -4,19 +4,21 @@ import (
 	"net/http"
 
 	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/monitors"
 	"github.com/gin-gonic/gin"
 )
 
 func HandleGetPreferredInstanceCharge() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
-		chargePresent := monitors.SnapshotPreferredInstanceChargePresent()
+		profile := gctx.GetProfile(c)
+		chargePresent := monitors.SnapshotPreferredInstanceChargePresent(profile)
 
 		if !chargePresent {
 			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "暂无符合要求的实例信息"}
 		}
 
-		charge := monitors.SnapshotPreferredInstanceCharge()
+		charge := monitors.SnapshotPreferredInstanceCharge(profile)
 
 		return helpers.Data(charge), nil
 	})
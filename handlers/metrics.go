@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/Subilan/go-aliyunmc/broker"
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// metricsContentType 是 Prometheus 文本格式的内容类型
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// boolValue 将 b 转换为 0 或 1
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// writeProfileMetrics 输出各个档案的服务器、实例和报价状态
+func writeProfileMetrics(w *bytes.Buffer) {
+	profiles := config.Cfg.ProfileNames()
+
+	var running, players, status, price []prom.Sample
+
+	for _, profile := range profiles {
+		running = append(running, prom.Sample{Labels: []string{profile}, Value: boolValue(monitors.SnapshotIsServerRunning(profile))})
+
+		if count := monitors.SnapshotPlayerCount(profile); count >= 0 {
+			players = append(players, prom.Sample{Labels: []string{profile}, Value: float64(count)})
+		}
+
+		if s := monitors.SnapshotInstanceStatus(profile); s != consts.InstanceInvalid {
+			status = append(status, prom.Sample{Labels: []string{profile, string(s)}, Value: 1})
+		}
+
+		if monitors.SnapshotPreferredInstanceChargePresent(profile) {
+			item := monitors.SnapshotPreferredInstanceCharge(profile)
+			price = append(price, prom.Sample{Labels: []string{profile, item.InstanceType, item.ZoneId}, Value: float64(item.TradePrice)})
+		}
+	}
+
+	prom.WriteSamples(w, "aliyunmc_server_running", "Whether the Minecraft server of the profile is online.", "gauge", []string{"profile"}, running)
+	prom.WriteSamples(w, "aliyunmc_online_players", "Number of online players. Absent when the server is offline.", "gauge", []string{"profile"}, players)
+	prom.WriteSamples(w, "aliyunmc_instance_status", "Status of the active instance of the profile. Absent when there is no active instance.", "gauge", []string{"profile", "status"}, status)
+	prom.WriteSamples(w, "aliyunmc_preferred_instance_price", "Trade price per hour of the preferred instance type.", "gauge", []string{"profile", "instance_type", "zone"}, price)
+}
+
+// writeStreamMetrics 输出当前的 SSE 连接数量
+func writeStreamMetrics(w *bytes.Buffer) {
+	var console int
+
+	for _, profile := range config.Cfg.ProfileNames() {
+		console += monitors.CountConsoleSubscribers(profile)
+	}
+
+	prom.WriteSamples(w, "aliyunmc_sse_connections", "Number of active server-sent event connections by kind.", "gauge", []string{"kind"}, []prom.Sample{
+		{Labels: []string{"user"}, Value: float64(stream.CountUserStreams())},
+		{Labels: []string{"public"}, Value: float64(stream.CountPublicSubscribers())},
+		{Labels: []string{"console"}, Value: float64(console)},
+	})
+}
+
+// authorizeMetrics 检查请求是否满足 config.ExporterConfig 中设置的所有访问限制
+func authorizeMetrics(c *gin.Context) bool {
+	cfg := config.Cfg.Exporter
+
+	if cfg.Token != "" {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		// 使用固定时间的比较，避免通过响应时间逐字节猜测令牌
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) != 1 {
+			return false
+		}
+	}
+
+	return cfg.IsAllowedIP(c.ClientIP())
+}
+
+// HandleMetrics 以 Prometheus 文本格式导出后端和监控器的状态，包括各档案的服务器和实例状态、账户余额、指令执行次数、
+// 监控器错误次数、消息丢弃数量、SSE 连接数量以及 HTTP 请求耗时。访问限制参见 config.ExporterConfig
+func HandleMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorizeMetrics(c) {
+			c.JSON(http.StatusForbidden, helpers.Details("无权访问指标"))
+			return
+		}
+
+		var w bytes.Buffer
+
+		writeProfileMetrics(&w)
+
+		if balance, err := store.GetLatestBalance(); err == nil {
+			prom.WriteSamples(&w, "aliyunmc_account_balance", "Account balance after the latest synchronized transaction.", "gauge", nil, []prom.Sample{{Value: balance}})
+		}
+
+		prom.CommandExecutions.Write(&w)
+		prom.MonitorErrors.Write(&w)
+
+		prom.WriteSamples(&w, "aliyunmc_broker_dropped_messages_total", "Number of messages dropped because a subscriber buffer was full.", "counter", nil, []prom.Sample{{Value: float64(broker.Dropped.Load())}})
+
+		writeStreamMetrics(&w)
+
+		prom.HTTPRequestDuration.Write(&w)
+
+		c.Data(http.StatusOK, metricsContentType, w.Bytes())
+	}
+}
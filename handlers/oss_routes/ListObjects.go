@@ -8,6 +8,7 @@ import (
 	"github.com/Subilan/go-aliyunmc/clients"
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/alibabacloud-go/tea/tea"
 	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
 	"github.com/gin-gonic/gin"
@@ -26,10 +27,12 @@ type ListObjectsResponseItem struct {
 
 func ListObjects() gin.HandlerFunc {
 	return helpers.QueryHandler[ListObjectsQuery](func(query ListObjectsQuery, c *gin.Context) (any, error) {
+		profile, _ := config.Cfg.GetProfile(gctx.GetProfile(c))
+
 		var prefix string
 		switch query.Target {
 		case "backups":
-			prefix = "backups"
+			prefix = strings.Trim(profile.BackupPath, "/")
 		default:
 			return nil, fmt.Errorf("target %s not supported", query.Target)
 		}
@@ -0,0 +1,36 @@
+package profiles
+
+import (
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileItem 是档案列表中的一项，只包含可以公开的档案信息
+type ProfileItem struct {
+	Name           string `json:"name"`
+	GamePort       uint16 `json:"gamePort"`
+	Address        string `json:"address,omitempty"`
+	InstanceStatus string `json:"instanceStatus"`
+	Running        bool   `json:"running"`
+}
+
+// HandleGetProfiles 返回所有档案及其活动实例和服务器的运行状态
+func HandleGetProfiles() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		var result = make([]ProfileItem, 0, len(config.Cfg.Profiles))
+
+		for _, p := range config.Cfg.Profiles {
+			result = append(result, ProfileItem{
+				Name:           p.Name,
+				GamePort:       p.GetGamePort(),
+				Address:        config.Cfg.Dns.Address(p),
+				InstanceStatus: string(monitors.SnapshotInstanceStatus(p.Name)),
+				Running:        monitors.SnapshotIsServerRunning(p.Name),
+			})
+		}
+
+		return helpers.Data(result), nil
+	})
+}
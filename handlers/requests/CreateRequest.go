@@ -0,0 +1,111 @@
+package requests
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateRequestRequest 是 HandleCreateRequest 接口的请求体
+type CreateRequestRequest struct {
+	CommandType consts.CommandType `json:"commandType" binding:"required"`
+	Args        map[string]string  `json:"args"`
+	Reason      string             `json:"reason" binding:"max=200"`
+}
+
+// HandleCreateRequest godoc
+//
+//	@Summary		请求执行指令
+//	@Description	请求在档案的活动实例上执行一个允许请求的指令。请求者自动投赞成票（如果有投票资格）；请求经管理员批准，或者在有效时间内得到足够的赞成票后，指令以请求者的身份在后台执行。同一指令同时只能有一个等待中的请求
+//	@Tags			requests
+//	@Accept			json
+//	@Produce		json
+//	@Param			profile					path		string					true	"档案名称"
+//	@Param			createrequestrequest	body		CreateRequestRequest	true	"请求执行指令请求体"
+//	@Success		200						{object}	helpers.DataResp[store.CommandRequest]
+//	@Failure		400						{object}	helpers.ErrorResp
+//	@Failure		403						{object}	helpers.ErrorResp
+//	@Failure		404						{object}	helpers.ErrorResp
+//	@Failure		409						{object}	helpers.ErrorResp
+//	@Failure		500						{object}	helpers.ErrorResp
+//	@Router			/server/{profile}/requests [post]
+func HandleCreateRequest() gin.HandlerFunc {
+	return helpers.BodyHandler[CreateRequestRequest](func(body CreateRequestRequest, c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		profile := gctx.GetProfile(c)
+
+		cmd, ok := commands.ShouldGetCommand(body.CommandType)
+
+		if !ok {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "command not found"}
+		}
+
+		if !cmd.Request.Enabled {
+			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "该指令不允许请求执行"}
+		}
+
+		args, err := cmd.ValidateArgs(body.Args)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		pending, err := store.HasPendingCommandRequest(profile, cmd.Type)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if pending {
+			return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "该指令已经存在等待中的请求"}
+		}
+
+		id, err := store.InsertCommandRequest(&store.CommandRequest{
+			Profile:     profile,
+			CommandType: cmd.Type,
+			Args:        args,
+			Reason:      body.Reason,
+			CreatedBy:   userId,
+			ExpiresAt:   time.Now().Add(cmd.Request.TimeLimitDuration()),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if canVote(profile, cmd, userId) {
+			if err := store.InsertCommandRequestVote(id, userId, true); err != nil {
+				return nil, err
+			}
+		}
+
+		r, err := store.GetCommandRequest(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		broadcast(r)
+		scheduleExpiry(r)
+
+		if err := tally(r, cmd); err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(r), nil
+	})
+}
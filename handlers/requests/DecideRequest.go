@@ -0,0 +1,66 @@
+package requests
+
+import (
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// DecideRequestRequest 是 HandleDecideRequest 接口的请求体
+type DecideRequestRequest struct {
+	Approve *bool  `json:"approve" binding:"required"`
+	Comment string `json:"comment" binding:"max=200"`
+}
+
+// HandleDecideRequest godoc
+//
+//	@Summary		批准或否决指令执行请求
+//	@Description	管理员直接批准或否决一个等待中的请求。批准后指令以请求者的身份在后台执行
+//	@Tags			requests, admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			profile					path		string					true	"档案名称"
+//	@Param			requestId				path		int						true	"请求ID"
+//	@Param			deciderequestrequest	body		DecideRequestRequest	true	"批准或否决请求体"
+//	@Success		200						{object}	helpers.DataResp[store.CommandRequest]
+//	@Failure		404						{object}	helpers.ErrorResp
+//	@Failure		409						{object}	helpers.ErrorResp
+//	@Failure		500						{object}	helpers.ErrorResp
+//	@Router			/server/{profile}/requests/{requestId}/decision [post]
+func HandleDecideRequest() gin.HandlerFunc {
+	return helpers.BodyHandler[DecideRequestRequest](func(body DecideRequestRequest, c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		r, cmd, err := getPending(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var comment *string
+
+		if body.Comment != "" {
+			comment = &body.Comment
+		}
+
+		if *body.Approve {
+			err = execute(r, cmd, &userId, comment)
+		} else {
+			err = setResult(r, store.CommandRequestRejected, &userId, comment, nil)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(r), nil
+	})
+}
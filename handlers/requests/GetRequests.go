@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// GetRequestsQuery 定义 HandleGetRequests 接口的查询格式
+type GetRequestsQuery struct {
+	helpers.Paginated
+
+	// Status 表示只返回该状态的请求，留空返回所有请求
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected expired failed"`
+}
+
+// HandleGetRequests godoc
+//
+//	@Summary		获取指令执行请求
+//	@Description	分页获取档案的指令执行请求及其票数，按照创建时间倒序排列
+//	@Tags			requests
+//	@Produce		json
+//	@Param			profile		path		string	true	"档案名称"
+//	@Param			status		query		string	false	"请求状态"
+//	@Param			page		query		int		false	"页码"
+//	@Param			pageSize	query		int		false	"每页数量"
+//	@Success		200			{object}	helpers.DataResp[[]store.CommandRequest]
+//	@Failure		400			{object}	helpers.ErrorResp
+//	@Failure		500			{object}	helpers.ErrorResp
+//	@Router			/server/{profile}/requests/s [get]
+func HandleGetRequests() gin.HandlerFunc {
+	return helpers.QueryHandler[GetRequestsQuery](func(query GetRequestsQuery, c *gin.Context) (any, error) {
+		if query.PageSize == 0 {
+			query.PageSize = 10
+		}
+
+		if query.Page == 0 {
+			query.Page = 1
+		}
+
+		result, err := store.GetCommandRequests(gctx.GetProfile(c), query.Status, query.PageSize, (query.Page-1)*query.PageSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(result), nil
+	})
+}
@@ -0,0 +1,79 @@
+package requests
+
+import (
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// VoteRequestRequest 是 HandleVoteRequest 接口的请求体
+type VoteRequestRequest struct {
+	Approve *bool `json:"approve" binding:"required"`
+}
+
+// HandleVoteRequest godoc
+//
+//	@Summary		对指令执行请求投票
+//	@Description	对一个等待中的请求投赞成票或反对票，每个用户只能投票一次。投票资格由指令的 request.voters 决定。票数达到法定票数时请求立即得到结果
+//	@Tags			requests
+//	@Accept			json
+//	@Produce		json
+//	@Param			profile				path		string				true	"档案名称"
+//	@Param			requestId			path		int					true	"请求ID"
+//	@Param			voterequestrequest	body		VoteRequestRequest	true	"投票请求体"
+//	@Success		200					{object}	helpers.DataResp[store.CommandRequest]
+//	@Failure		403					{object}	helpers.ErrorResp
+//	@Failure		404					{object}	helpers.ErrorResp
+//	@Failure		409					{object}	helpers.ErrorResp
+//	@Failure		500					{object}	helpers.ErrorResp
+//	@Router			/server/{profile}/requests/{requestId}/vote [post]
+func HandleVoteRequest() gin.HandlerFunc {
+	return helpers.BodyHandler[VoteRequestRequest](func(body VoteRequestRequest, c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		r, cmd, err := getPending(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if cmd.Request.Quorum == 0 {
+			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "该请求只能由管理员批准"}
+		}
+
+		if !canVote(r.Profile, cmd, userId) {
+			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "没有投票资格"}
+		}
+
+		if err := store.InsertCommandRequestVote(r.Id, userId, *body.Approve); err != nil {
+			if store.IsDuplicateEntryError(err) {
+				return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "已经投过票"}
+			}
+			return nil, err
+		}
+
+		r, err = store.GetCommandRequest(r.Id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		broadcast(r)
+
+		if err := tally(r, cmd); err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(r), nil
+	})
+}
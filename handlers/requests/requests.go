@@ -0,0 +1,181 @@
+// Package requests 实现权限不足的用户请求执行指令的流程。请求经管理员批准，或者在有效时间内得到足够的赞成票后，指令以请求者的身份在后台执行，参见 config.CommandRequestConfig
+package requests
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// mu 使请求结果的判定串行进行，避免同一请求被重复执行
+var mu sync.Mutex
+
+// broadcast 推送请求 r 的最新信息，推送的事件同时作为审计记录保存
+func broadcast(r *store.CommandRequest) {
+	err := stream.BroadcastAndSave(events.Server(r.Profile, events.ServerEventCommandRequestUpdate, r))
+
+	if err != nil {
+		log.Println("cannot send and save server event", err)
+	}
+}
+
+// canVote 返回用户 userId 能否对档案 profile 中执行指令 cmd 的请求投票
+func canVote(profile string, cmd *commands.Command, userId int64) bool {
+	bound, exists := store.GetGameBound(userId)
+
+	if !exists {
+		return false
+	}
+
+	if cmd.Request.GetVoters() == config.CommandVotersOnline {
+		return slices.Contains(monitors.SnapshotOnlinePlayers(profile), bound.GameId)
+	}
+
+	return store.IsWhitelistedIn(profile, bound.GameId)
+}
+
+// setResult 更新请求 r 的结果并推送
+func setResult(r *store.CommandRequest, status string, decidedBy *int64, comment *string, taskId *string) error {
+	if err := store.UpdateCommandRequestResult(r.Id, status, decidedBy, comment, taskId); err != nil {
+		return err
+	}
+
+	r.Status, r.DecidedBy, r.Comment, r.TaskId = status, decidedBy, comment, taskId
+
+	broadcast(r)
+
+	return nil
+}
+
+// execute 以请求者的身份在档案的活动实例上后台执行请求 r 的指令。指令无法执行时，请求的状态为 store.CommandRequestFailed
+func execute(r *store.CommandRequest, cmd *commands.Command, decidedBy *int64, comment *string) error {
+	inst, err := store.GetDeployedActiveInstance(r.Profile)
+
+	var taskId string
+
+	if err == nil {
+		taskId, err = cmd.RunAsync(inst, &r.CreatedBy, &commands.CommandRunOption{
+			Args:    r.Args,
+			Comment: fmt.Sprintf("request #%d", r.Id),
+		})
+	}
+
+	if err != nil {
+		reason := err.Error()
+		return setResult(r, store.CommandRequestFailed, decidedBy, &reason, nil)
+	}
+
+	return setResult(r, store.CommandRequestApproved, decidedBy, comment, &taskId)
+}
+
+// tally 根据请求 r 当前的票数判定结果：赞成票达到法定票数时执行指令，反对票达到法定票数时否决。法定票数为 0 表示只能由管理员批准
+func tally(r *store.CommandRequest, cmd *commands.Command) error {
+	quorum := cmd.Request.Quorum
+
+	if quorum == 0 {
+		return nil
+	}
+
+	if r.Yes >= quorum {
+		return execute(r, cmd, nil, nil)
+	}
+
+	if r.No >= quorum {
+		return setResult(r, store.CommandRequestRejected, nil, nil, nil)
+	}
+
+	return nil
+}
+
+// expire 将仍在等待中的请求 id 标记为失效
+func expire(id int64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r, err := store.GetCommandRequest(id)
+
+	if err != nil {
+		log.Printf("cannot get command request %d: %s", id, err.Error())
+		return
+	}
+
+	if r.Status != store.CommandRequestPending {
+		return
+	}
+
+	if err := setResult(r, store.CommandRequestExpired, nil, nil, nil); err != nil {
+		log.Printf("cannot expire command request %d: %s", id, err.Error())
+	}
+}
+
+// scheduleExpiry 在请求 r 的有效时间结束时将其标记为失效
+func scheduleExpiry(r *store.CommandRequest) {
+	id := r.Id
+	time.AfterFunc(time.Until(r.ExpiresAt), func() {
+		expire(id)
+	})
+}
+
+// Resume 为系统启动前创建的等待中的请求重新安排失效时间。已经超过有效时间的请求会立即失效
+func Resume() {
+	pending, err := store.GetPendingCommandRequests()
+
+	if err != nil {
+		log.Println("cannot get pending command requests:", err)
+		return
+	}
+
+	for _, r := range pending {
+		scheduleExpiry(r)
+	}
+}
+
+// getPending 获取路径参数 requestId 对应的档案中等待中的请求及其指令
+func getPending(c *gin.Context) (*store.CommandRequest, *commands.Command, error) {
+	id, err := strconv.ParseInt(c.Param("requestId"), 10, 64)
+
+	if err != nil {
+		return nil, nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "请求ID无效"}
+	}
+
+	r, err := store.GetCommandRequest(id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "请求不存在"}
+		}
+		return nil, nil, err
+	}
+
+	if r.Profile != gctx.GetProfile(c) {
+		return nil, nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "请求不存在"}
+	}
+
+	if r.Status != store.CommandRequestPending || time.Now().After(r.ExpiresAt) {
+		return nil, nil, &helpers.HttpError{Code: http.StatusConflict, Details: "请求已经结束"}
+	}
+
+	cmd, ok := commands.ShouldGetCommand(r.CommandType)
+
+	if !ok {
+		return nil, nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "command not found"}
+	}
+
+	return r, cmd, nil
+}
@@ -0,0 +1,88 @@
+package schedules
+
+import (
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCommandScheduleRequest 是 HandleCreateCommandSchedule 接口的请求体，字段含义参见 config.CommandScheduleConfig
+type CreateCommandScheduleRequest struct {
+	Name    string            `json:"name" binding:"required,alphanum,max=20"`
+	Profile string            `json:"profile"`
+	Cron    string            `json:"cron" binding:"required"`
+	Command string            `json:"command" binding:"required_without=Message"`
+	Args    map[string]string `json:"args"`
+	Message string            `json:"message" binding:"excluded_with=Command,max=200"`
+}
+
+// HandleCreateCommandSchedule godoc
+//
+//	@Summary		添加定时指令计划
+//	@Description	添加一个定时指令计划，在 cron 表达式触发时运行指令或者在服务器内广播消息。计划名称不能与已有的定时指令计划重复，cron 表达式使用系统本地时区
+//	@Tags			schedules
+//	@Accept			json
+//	@Produce		json
+//	@Param			createcommandschedulerequest	body	CreateCommandScheduleRequest	true	"添加定时指令计划请求体"
+//	@Success		200
+//	@Failure		400	{object}	helpers.ErrorResp
+//	@Failure		409	{object}	helpers.ErrorResp
+//	@Failure		500	{object}	helpers.ErrorResp
+//	@Router			/schedule/commands [post]
+func HandleCreateCommandSchedule() gin.HandlerFunc {
+	return helpers.BodyHandler[CreateCommandScheduleRequest](func(body CreateCommandScheduleRequest, c *gin.Context) (any, error) {
+		if body.Profile == "" {
+			body.Profile = config.DefaultProfileName
+		}
+
+		sc := config.CommandScheduleConfig{
+			Name:    body.Name,
+			Profile: body.Profile,
+			Cron:    body.Cron,
+			Command: body.Command,
+			Args:    body.Args,
+			Message: body.Message,
+		}
+
+		if err := sc.Check(config.Cfg); err != nil {
+			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: err.Error()}
+		}
+
+		for _, existing := range config.Cfg.CommandSchedules {
+			if existing.Name == body.Name {
+				return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "计划名称重复"}
+			}
+		}
+
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = store.InsertCommandSchedule(&store.CommandSchedule{
+			Name:      body.Name,
+			Profile:   body.Profile,
+			Cron:      body.Cron,
+			Command:   consts.CommandType(body.Command),
+			Args:      body.Args,
+			Message:   body.Message,
+			CreatedBy: &userId,
+		})
+
+		if err != nil {
+			if store.IsDuplicateEntryError(err) {
+				return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "计划名称重复"}
+			}
+
+			return nil, err
+		}
+
+		return gin.H{}, nil
+	})
+}
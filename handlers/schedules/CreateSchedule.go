@@ -0,0 +1,90 @@
+package schedules
+
+import (
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateScheduleRequest 是 HandleCreateSchedule 接口的请求体，字段含义参见 config.ScheduleConfig
+type CreateScheduleRequest struct {
+	Name      string `json:"name" binding:"required,alphanum,max=20"`
+	Profile   string `json:"profile"`
+	Cron      string `json:"cron" binding:"required"`
+	Duration  int    `json:"duration" binding:"required,gte=1"`
+	LeadTime  int    `json:"leadTime" binding:"gte=0"`
+	Warnings  []int  `json:"warnings" binding:"omitempty,dive,gte=1"`
+	Protected bool   `json:"protected"`
+}
+
+// HandleCreateSchedule godoc
+//
+//	@Summary		添加开放时段计划
+//	@Description	添加一个开放时段计划。计划名称不能与已有的计划重复，cron 表达式使用系统本地时区
+//	@Tags			schedules
+//	@Accept			json
+//	@Produce		json
+//	@Param			createschedulerequest	body	CreateScheduleRequest	true	"添加计划请求体"
+//	@Success		200
+//	@Failure		400	{object}	helpers.ErrorResp
+//	@Failure		409	{object}	helpers.ErrorResp
+//	@Failure		500	{object}	helpers.ErrorResp
+//	@Router			/schedule [post]
+func HandleCreateSchedule() gin.HandlerFunc {
+	return helpers.BodyHandler[CreateScheduleRequest](func(body CreateScheduleRequest, c *gin.Context) (any, error) {
+		if body.Profile == "" {
+			body.Profile = config.DefaultProfileName
+		}
+
+		sc := config.ScheduleConfig{
+			Name:      body.Name,
+			Profile:   body.Profile,
+			Cron:      body.Cron,
+			Duration:  body.Duration,
+			LeadTime:  body.LeadTime,
+			Warnings:  body.Warnings,
+			Protected: body.Protected,
+		}
+
+		if err := sc.Check(config.Cfg); err != nil {
+			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: err.Error()}
+		}
+
+		for _, existing := range config.Cfg.Schedules {
+			if existing.Name == body.Name {
+				return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "计划名称重复"}
+			}
+		}
+
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = store.InsertSchedule(&store.Schedule{
+			Name:      body.Name,
+			Profile:   body.Profile,
+			Cron:      body.Cron,
+			Duration:  body.Duration,
+			LeadTime:  body.LeadTime,
+			Warnings:  body.Warnings,
+			Protected: body.Protected,
+			CreatedBy: &userId,
+		})
+
+		if err != nil {
+			if store.IsDuplicateEntryError(err) {
+				return nil, &helpers.HttpError{Code: http.StatusConflict, Details: "计划名称重复"}
+			}
+
+			return nil, err
+		}
+
+		return gin.H{}, nil
+	})
+}
@@ -0,0 +1,46 @@
+package schedules
+
+import (
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleDeleteCommandSchedule godoc
+//
+//	@Summary		删除定时指令计划
+//	@Description	删除一个通过接口添加的定时指令计划。配置文件中的计划只能通过修改配置文件删除
+//	@Tags			schedules
+//	@Produce		json
+//	@Param			name	path	string	true	"计划名称"
+//	@Success		200
+//	@Failure		403	{object}	helpers.ErrorResp
+//	@Failure		404	{object}	helpers.ErrorResp
+//	@Failure		500	{object}	helpers.ErrorResp
+//	@Router			/schedule/commands/{name} [delete]
+func HandleDeleteCommandSchedule() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		schedule, err := monitors.GetCommandSchedule(c.Param("name"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if schedule == nil {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "计划不存在"}
+		}
+
+		if schedule.FromConfig {
+			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "配置文件中的计划不能通过接口删除"}
+		}
+
+		if _, err := store.DeleteCommandSchedule(schedule.Name); err != nil {
+			return nil, err
+		}
+
+		return gin.H{}, nil
+	})
+}
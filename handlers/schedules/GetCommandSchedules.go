@@ -0,0 +1,49 @@
+package schedules
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// CommandScheduleWithRuns 是 HandleGetCommandSchedules 接口返回的定时指令计划及其接下来的运行时间
+type CommandScheduleWithRuns struct {
+	*monitors.CommandSchedule
+	Upcoming []time.Time `json:"upcoming"`
+}
+
+// HandleGetCommandSchedules godoc
+//
+//	@Summary		获取定时指令计划
+//	@Description	获取配置文件中的定时指令计划和通过接口添加的定时指令计划，以及每个计划接下来的运行时间。计划的运行记录参见指令执行记录中自动执行的部分
+//	@Tags			schedules
+//	@Produce		json
+//	@Param			profile	query		string	false	"档案名称"
+//	@Param			count	query		int		false	"每个计划返回的运行时间数量"
+//	@Success		200		{object}	helpers.DataResp[[]CommandScheduleWithRuns]
+//	@Failure		500		{object}	helpers.ErrorResp
+//	@Router			/schedule/commands [get]
+func HandleGetCommandSchedules() gin.HandlerFunc {
+	return helpers.QueryHandler[GetSchedulesQuery](func(query GetSchedulesQuery, c *gin.Context) (any, error) {
+		if query.Count == 0 {
+			query.Count = 5
+		}
+
+		schedules, err := monitors.GetCommandSchedules(query.Profile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result := make([]CommandScheduleWithRuns, 0, len(schedules))
+
+		for _, s := range schedules {
+			result = append(result, CommandScheduleWithRuns{CommandSchedule: s, Upcoming: s.NextRuns(now, query.Count)})
+		}
+
+		return helpers.Data(result), nil
+	})
+}
@@ -0,0 +1,64 @@
+package schedules
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSchedulesQuery 定义 HandleGetSchedules 接口的查询格式
+type GetSchedulesQuery struct {
+	// Profile 表示只返回该档案的计划，留空返回所有计划
+	Profile string `form:"profile"`
+
+	// Count 是每个计划返回的即将到来的开放时段数量，默认为5
+	Count int `form:"count" binding:"omitempty,gte=1,lte=50"`
+}
+
+// ScheduleWithOccurrences 是 HandleGetSchedules 接口返回的计划及其即将到来的开放时段
+type ScheduleWithOccurrences struct {
+	*monitors.Schedule
+	Upcoming []monitors.ScheduleOccurrence `json:"upcoming"`
+}
+
+// HandleGetSchedules godoc
+//
+//	@Summary		获取开放时段计划
+//	@Description	获取配置文件中的计划和通过接口添加的计划，以及每个计划即将到来的开放时段（包括正在进行的时段和被跳过的时段）
+//	@Tags			schedules
+//	@Produce		json
+//	@Param			profile	query		string	false	"档案名称"
+//	@Param			count	query		int		false	"每个计划返回的开放时段数量"
+//	@Success		200		{object}	helpers.DataResp[[]ScheduleWithOccurrences]
+//	@Failure		500		{object}	helpers.ErrorResp
+//	@Router			/schedule/s [get]
+func HandleGetSchedules() gin.HandlerFunc {
+	return helpers.QueryHandler[GetSchedulesQuery](func(query GetSchedulesQuery, c *gin.Context) (any, error) {
+		if query.Count == 0 {
+			query.Count = 5
+		}
+
+		schedules, err := monitors.GetSchedules(query.Profile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result := make([]ScheduleWithOccurrences, 0, len(schedules))
+
+		for _, s := range schedules {
+			occurrences, err := s.Occurrences(now, query.Count)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, ScheduleWithOccurrences{Schedule: s, Upcoming: occurrences})
+		}
+
+		return helpers.Data(result), nil
+	})
+}
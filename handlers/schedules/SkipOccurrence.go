@@ -0,0 +1,82 @@
+package schedules
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+)
+
+// skipSearchCount 是查找要跳过的开放时段时向后查找的最大时段数量
+const skipSearchCount = 100
+
+// SkipOccurrenceRequest 是 HandleSkipOccurrence 接口的请求体
+type SkipOccurrenceRequest struct {
+	// StartsAt 是要跳过的开放时段的开始时间，留空则跳过下一个尚未开始创建实例的开放时段
+	StartsAt *time.Time `json:"startsAt"`
+}
+
+// HandleSkipOccurrence godoc
+//
+//	@Summary		跳过开放时段
+//	@Description	跳过计划即将到来的一个开放时段。被跳过的时段不会自动开启或关闭服务器，也不会阻止空转关闭。返回被跳过的时段
+//	@Tags			schedules
+//	@Accept			json
+//	@Produce		json
+//	@Param			name					path		string					true	"计划名称"
+//	@Param			skipoccurrencerequest	body		SkipOccurrenceRequest	true	"跳过开放时段请求体"
+//	@Success		200						{object}	helpers.DataResp[monitors.ScheduleOccurrence]
+//	@Failure		404						{object}	helpers.ErrorResp
+//	@Failure		500						{object}	helpers.ErrorResp
+//	@Router			/schedule/{name}/skip [post]
+func HandleSkipOccurrence() gin.HandlerFunc {
+	return helpers.BodyHandler[SkipOccurrenceRequest](func(body SkipOccurrenceRequest, c *gin.Context) (any, error) {
+		schedule, err := monitors.GetSchedule(c.Param("name"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if schedule == nil {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "计划不存在"}
+		}
+
+		now := time.Now()
+		occurrences, err := schedule.Occurrences(now, skipSearchCount)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var target *monitors.ScheduleOccurrence
+
+		for i, o := range occurrences {
+			if (body.StartsAt == nil && o.OpensAt.After(now)) || (body.StartsAt != nil && o.StartsAt.Equal(*body.StartsAt)) {
+				target = &occurrences[i]
+				break
+			}
+		}
+
+		if target == nil {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "没有找到对应的开放时段"}
+		}
+
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := store.InsertScheduleSkip(schedule.Name, target.StartsAt, &userId); err != nil {
+			return nil, err
+		}
+
+		target.Skipped = true
+
+		return helpers.Data(*target), nil
+	})
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// ConsoleExecuteRequest 是 HandleConsoleExecute 接口的请求体
+type ConsoleExecuteRequest struct {
+	// Command 是要在服务器内执行的指令，不需要以斜杠开头
+	Command string `json:"command" binding:"required,max=1000"`
+}
+
+// HandleConsoleExecute 通过 RCON 在档案的活动实例上的服务器内原样执行管理员在控制台中输入的一条指令，返回指令的响应。
+// 每次执行都记录在指令执行记录中，类型为 consts.CmdTypeConsole，输入的指令记录在参数中
+func HandleConsoleExecute() gin.HandlerFunc {
+	return helpers.BodyHandler[ConsoleExecuteRequest](func(body ConsoleExecuteRequest, c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		inst, err := store.GetDeployedActiveInstance(gctx.GetProfile(c))
+
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		output, err := commands.RunConsoleCommand(ctx, inst, userId, body.Command)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(output), nil
+	})
+}
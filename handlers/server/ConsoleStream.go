@@ -0,0 +1,51 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/monitors"
+	"github.com/gin-gonic/gin"
+	"go.jetify.com/sse"
+)
+
+// HandleConsoleStream 以 SSE 的形式推送档案的活动实例上的服务器日志，每个事件的数据为一个 monitors.ConsoleLine。
+// 连接建立时先推送最近的日志，之后实时推送新的日志。与实例的连接断开或者实例的IP地址变化时，系统会自动重新连接，并推送 monitors.ConsoleLineSystem 类型的提示
+func HandleConsoleStream() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		lines, unsubscribe := monitors.SubscribeConsole(gctx.GetProfile(c))
+
+		if lines == nil {
+			c.JSON(http.StatusNotFound, helpers.Details("档案不存在"))
+			return
+		}
+		defer unsubscribe()
+
+		conn, err := sse.Upgrade(ctx, c.Writer, sse.WithHeartbeatInterval(5*time.Second), sse.WithWriteTimeout(10*time.Second))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, helpers.Details(err.Error()))
+			return
+		}
+		defer conn.Close()
+
+		for {
+			select {
+			case line := <-lines:
+				err = conn.SendEvent(ctx, &sse.Event{Data: line})
+
+				if err != nil {
+					log.Println("cannot send console line:", err)
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
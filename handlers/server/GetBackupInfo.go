@@ -2,13 +2,14 @@ package server
 
 import (
 	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/gin-gonic/gin"
 )
 
 func HandleGetBackupInfo() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
-		info, err := store.GetExistingBackups()
+		info, err := store.GetExistingBackups(gctx.GetProfile(c))
 
 		if err != nil {
 			return nil, err
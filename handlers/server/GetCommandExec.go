@@ -0,0 +1,36 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetCommandExec 返回一次指令执行的完整记录，包括标准输出、标准错误、退出码、开始和结束的时间、执行的实例以及参数，用于诊断失败的执行。
+// 输出中可能包含敏感信息，因此仅管理员可用
+func HandleGetCommandExec() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		id, err := strconv.ParseInt(c.Param("execId"), 10, 64)
+
+		if err != nil {
+			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "执行记录ID无效"}
+		}
+
+		result, err := store.GetCommandExecDetail(gctx.GetProfile(c), id)
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "执行记录不存在"}
+			}
+			return nil, err
+		}
+
+		return helpers.Data(result), nil
+	})
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/gin-gonic/gin"
 )
@@ -17,19 +18,21 @@ func HandleGetCommandExecOverview() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
 		var result CommandExecOverview
 
-		err := db.Pool.QueryRow("SELECT COUNT(*) FROM command_exec WHERE status='success'").Scan(&result.SuccessCount)
+		profile := gctx.GetProfile(c)
+
+		err := db.Pool.QueryRow("SELECT COUNT(*) FROM command_exec WHERE profile = ? AND status='success'", profile).Scan(&result.SuccessCount)
 		if err != nil {
 			return nil, err
 		}
 
-		err = db.Pool.QueryRow("SELECT COUNT(*) FROM command_exec WHERE status='error'").Scan(&result.ErrorCount)
+		err = db.Pool.QueryRow("SELECT COUNT(*) FROM command_exec WHERE profile = ? AND status='error'", profile).Scan(&result.ErrorCount)
 
 		if err != nil {
 			return nil, err
 		}
 
 		_ = db.Pool.
-			QueryRow("SELECT c.id, c.type, c.by, c.created_at, c.updated_at, c.status, c.auto, c.comment, u.username FROM command_exec c LEFT JOIN users u ON c.by=u.id ORDER BY c.created_at DESC LIMIT 1").
+			QueryRow("SELECT c.id, c.type, c.by, c.created_at, c.updated_at, c.status, c.auto, c.comment, c.args, u.username FROM command_exec c LEFT JOIN users u ON c.by=u.id WHERE c.profile = ? ORDER BY c.created_at DESC LIMIT 1", profile).
 			Scan(
 				&result.LatestCommandExec.Id,
 				&result.LatestCommandExec.Type,
@@ -39,6 +42,7 @@ func HandleGetCommandExecOverview() gin.HandlerFunc {
 				&result.LatestCommandExec.Status,
 				&result.LatestCommandExec.Auto,
 				&result.LatestCommandExec.Comment,
+				&result.LatestCommandExec.Args,
 				&result.LatestCommandExec.Username,
 			)
 
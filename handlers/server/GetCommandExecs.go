@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/gin-gonic/gin"
 )
@@ -23,7 +24,9 @@ func HandleGetCommandExecs() gin.HandlerFunc {
 
 		var results = make([]store.JoinedCommandExec, 0, 10)
 
-		rows, err := db.Pool.Query("SELECT c.id, c.type, c.by, c.created_at, c.updated_at, c.status, c.auto, c.comment, u.username FROM command_exec c LEFT JOIN users u ON c.by=u.id ORDER BY c.created_at DESC LIMIT ? OFFSET ?", query.PageSize, (query.Page-1)*query.PageSize)
+		profile := gctx.GetProfile(c)
+
+		rows, err := db.Pool.Query("SELECT c.id, c.type, c.by, c.created_at, c.updated_at, c.status, c.auto, c.comment, c.args, u.username FROM command_exec c LEFT JOIN users u ON c.by=u.id WHERE c.profile = ? ORDER BY c.created_at DESC LIMIT ? OFFSET ?", profile, query.PageSize, (query.Page-1)*query.PageSize)
 
 		if err != nil {
 			return nil, err
@@ -32,7 +35,7 @@ func HandleGetCommandExecs() gin.HandlerFunc {
 		defer rows.Close()
 		for rows.Next() {
 			var result store.JoinedCommandExec
-			err = rows.Scan(&result.Id, &result.Type, &result.By, &result.CreatedAt, &result.UpdatedAt, &result.Status, &result.Auto, &result.Comment, &result.Username)
+			err = rows.Scan(&result.Id, &result.Type, &result.By, &result.CreatedAt, &result.UpdatedAt, &result.Status, &result.Auto, &result.Comment, &result.Args, &result.Username)
 
 			if err != nil {
 				return nil, err
@@ -42,7 +45,7 @@ func HandleGetCommandExecs() gin.HandlerFunc {
 		}
 
 		var total int64
-		err = db.Pool.QueryRow("SELECT COUNT(*) FROM command_exec").Scan(&total)
+		err = db.Pool.QueryRow("SELECT COUNT(*) FROM command_exec WHERE profile = ?", profile).Scan(&total)
 
 		if err != nil {
 			return nil, err
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/gin-gonic/gin"
+)
+
+// CommandItem 是指令列表中的一项
+type CommandItem struct {
+	Type            consts.CommandType            `json:"type"`
+	Description     string                        `json:"description"`
+	ExecuteLocation consts.CommandExecuteLocation `json:"executeLocation"`
+	Role            consts.UserRole               `json:"role"`
+	Whitelisted     bool                          `json:"whitelisted"`
+	Cooldown        int                           `json:"cooldown"`
+	Limits          []CommandLimitItem            `json:"limits"`
+	Timeout         int                           `json:"timeout"`
+	IsQuery         bool                          `json:"isQuery"`
+	Prerequisite    string                        `json:"prerequisite"`
+	Args            []CommandArgItem              `json:"args"`
+
+	// CooldownLeft 是当前用户运行该指令前剩余的冷却时间，单位秒
+	CooldownLeft int `json:"cooldownLeft"`
+
+	// Allowed 表示当前用户是否满足该指令的权限和白名单要求
+	Allowed bool `json:"allowed"`
+
+	// Requestable 表示权限不足的用户能否请求执行该指令，参见 requests.HandleCreateRequest
+	Requestable bool `json:"requestable"`
+}
+
+// CommandLimitItem 是指令的一个频率限制，即在 Per 秒内最多执行 Count 次
+type CommandLimitItem struct {
+	Scope string `json:"scope"`
+	Role  string `json:"role,omitempty"`
+	Count int    `json:"count"`
+	Per   int    `json:"per"`
+}
+
+// CommandArgItem 是指令参数的声明，供前端生成表单
+type CommandArgItem struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Default     string   `json:"default"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+}
+
+func commandLimitItems(limits []config.CommandLimitConfig) []CommandLimitItem {
+	result := make([]CommandLimitItem, 0, len(limits))
+
+	for _, limit := range limits {
+		result = append(result, CommandLimitItem(limit))
+	}
+
+	return result
+}
+
+func commandArgItems(args []config.CommandArgConfig) []CommandArgItem {
+	result := make([]CommandArgItem, 0, len(args))
+
+	for _, arg := range args {
+		result = append(result, CommandArgItem(arg))
+	}
+
+	return result
+}
+
+// HandleGetCommands 返回档案可用的所有指令，以及当前用户能否执行这些指令、剩余的冷却时间。指令的前置条件在执行时检查
+func HandleGetCommands() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		profile := gctx.GetProfile(c)
+
+		list := commands.List()
+		result := make([]CommandItem, 0, len(list))
+
+		for _, cmd := range list {
+			cooldownLeft, err := cmd.CooldownLeft(profile, &userId)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, CommandItem{
+				Type:            cmd.Type,
+				Description:     cmd.Description,
+				ExecuteLocation: cmd.ExecuteLocation,
+				Role:            cmd.Role,
+				Whitelisted:     cmd.Whitelisted,
+				Cooldown:        cmd.Cooldown,
+				Limits:          commandLimitItems(cmd.Limits),
+				CooldownLeft:    cooldownLeft,
+				Timeout:         cmd.Timeout,
+				IsQuery:         cmd.IsQuery,
+				Prerequisite:    cmd.PrerequisiteName,
+				Args:            commandArgItems(cmd.Args),
+				Allowed:         cmd.TestRole(c) && cmd.TestWhitelisted(c, profile),
+				Requestable:     cmd.Request.Enabled,
+			})
+		}
+
+		return helpers.Data(result), nil
+	})
+}
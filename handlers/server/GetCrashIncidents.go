@@ -0,0 +1,29 @@
+package server
+
+import (
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetCrashIncidents 分页返回档案的服务器意外退出记录，包括收集到的崩溃报告和自动重启的结果，按照发现时间倒序排列
+func HandleGetCrashIncidents() gin.HandlerFunc {
+	return helpers.QueryHandler[helpers.Paginated](func(query helpers.Paginated, c *gin.Context) (any, error) {
+		if query.PageSize == 0 {
+			query.PageSize = 10
+		}
+
+		if query.Page == 0 {
+			query.Page = 1
+		}
+
+		result, err := store.GetCrashIncidents(gctx.GetProfile(c), query.PageSize, (query.Page-1)*query.PageSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(result), nil
+	})
+}
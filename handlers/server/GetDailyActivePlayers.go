@@ -0,0 +1,31 @@
+package server
+
+import (
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// GetDailyActivePlayersQuery 定义 HandleGetDailyActivePlayers 接口的查询格式
+type GetDailyActivePlayersQuery struct {
+	// Days 是统计的天数（包括今天），默认为30
+	Days int `form:"days" binding:"omitempty,gte=1,lte=365"`
+}
+
+// HandleGetDailyActivePlayers 返回档案最近若干天中每天在服务器中出现过的玩家数量
+func HandleGetDailyActivePlayers() gin.HandlerFunc {
+	return helpers.QueryHandler[GetDailyActivePlayersQuery](func(query GetDailyActivePlayersQuery, c *gin.Context) (any, error) {
+		if query.Days == 0 {
+			query.Days = 30
+		}
+
+		result, err := store.GetDailyActivePlayers(gctx.GetProfile(c), query.Days)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(result), nil
+	})
+}
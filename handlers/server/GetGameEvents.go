@@ -0,0 +1,68 @@
+package server
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// GetGameEventsQuery 定义 HandleGetGameEvents 接口的查询格式
+type GetGameEventsQuery struct {
+	helpers.Paginated
+
+	// Kind 是事件的类型，可以重复指定多个，留空表示所有类型
+	Kind []string `form:"kind" binding:"omitempty,dive,oneof=chat join leave death advancement warning"`
+
+	// Player 是事件相关的玩家
+	Player string `form:"player" binding:"omitempty,max=16"`
+
+	// Keyword 是消息中包含的内容
+	Keyword string `form:"keyword" binding:"omitempty,max=100"`
+
+	// From 和 To 是事件发生的时间范围，RFC3339 格式
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// HandleGetGameEvents 分页搜索档案中从服务器日志解析出的游戏事件，按照发生时间倒序排列。玩家的IP地址只向管理员返回
+func HandleGetGameEvents() gin.HandlerFunc {
+	return helpers.QueryHandler[GetGameEventsQuery](func(query GetGameEventsQuery, c *gin.Context) (any, error) {
+		if query.PageSize == 0 {
+			query.PageSize = 50
+		}
+
+		if query.Page == 0 {
+			query.Page = 1
+		}
+
+		result, err := store.SearchGameEvents(gctx.GetProfile(c), store.GameEventFilter{
+			Kinds:   query.Kind,
+			Player:  query.Player,
+			Keyword: query.Keyword,
+			From:    query.From,
+			To:      query.To,
+		}, query.PageSize, (query.Page-1)*query.PageSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if role, _ := store.GetUserRole(userId, consts.UserRoleUser); role < consts.UserRoleAdmin {
+			for _, e := range result {
+				e.IP = nil
+			}
+		}
+
+		return helpers.Data(result), nil
+	})
+}
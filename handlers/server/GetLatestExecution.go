@@ -3,13 +3,14 @@ package server
 import (
 	"github.com/Subilan/go-aliyunmc/consts"
 	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/gin-gonic/gin"
 )
 
 func HandleGetLatestSuccessBackup() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
-		backup, err := store.GetLatestSuccessCommandExecByType(consts.CmdTypeBackupWorlds)
+		backup, err := store.GetLatestSuccessCommandExecByType(gctx.GetProfile(c), consts.CmdTypeBackupWorlds)
 
 		if err != nil {
 			return nil, err
@@ -21,7 +22,7 @@ func HandleGetLatestSuccessBackup() gin.HandlerFunc {
 
 func HandleGetLatestSuccessArchive() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
-		backup, err := store.GetLatestSuccessCommandExecByType(consts.CmdTypeArchiveServer)
+		backup, err := store.GetLatestSuccessCommandExecByType(gctx.GetProfile(c), consts.CmdTypeArchiveServer)
 
 		if err != nil {
 			return nil, err
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// maxMetricsPoints 是 HandleGetMetrics 接口一次最多返回的时间段数量
+const maxMetricsPoints = 2000
+
+// GetMetricsQuery 定义 HandleGetMetrics 接口的查询格式
+type GetMetricsQuery struct {
+	// From 是查询范围的开始时间，RFC3339 格式，默认为 To 之前一小时
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+
+	// To 是查询范围的结束时间，RFC3339 格式，默认为当前时间
+	To *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+
+	// Step 是每个时间段的长度，单位秒，默认使查询范围分为约 300 段且不小于采样间隔
+	Step int `form:"step" binding:"omitempty,gte=1"`
+}
+
+// HandleGetMetrics 返回档案在查询范围内的服务器 tick 性能和实例资源使用情况，按照长度为 step 的时间段取平均值。没有采样的时间段不返回
+func HandleGetMetrics() gin.HandlerFunc {
+	return helpers.QueryHandler[GetMetricsQuery](func(query GetMetricsQuery, c *gin.Context) (any, error) {
+		to := time.Now()
+
+		if query.To != nil {
+			to = *query.To
+		}
+
+		from := to.Add(-time.Hour)
+
+		if query.From != nil {
+			from = *query.From
+		}
+
+		if !from.Before(to) {
+			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "开始时间需要早于结束时间"}
+		}
+
+		step := time.Duration(query.Step) * time.Second
+
+		if step == 0 {
+			step = max(to.Sub(from)/300, config.Cfg.Monitor.Metrics.IntervalDuration()).Truncate(time.Second)
+		}
+
+		if to.Sub(from)/step > maxMetricsPoints {
+			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "时间段数量过多，请增大step或缩小查询范围"}
+		}
+
+		result, err := store.GetMetrics(gctx.GetProfile(c), from, to, step)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(gin.H{"step": int(step.Seconds()), "samples": result}), nil
+	})
+}
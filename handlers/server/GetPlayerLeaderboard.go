@@ -0,0 +1,50 @@
+package server
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlayerLeaderboardQuery 定义 HandleGetPlayerLeaderboard 接口的查询格式
+type GetPlayerLeaderboardQuery struct {
+	// Period 是统计的时间范围，可以为 day、week、month 或 all，分别表示最近 24 小时、7 天、30 天和所有时间，默认为 week
+	Period string `form:"period" binding:"omitempty,oneof=day week month all"`
+
+	// Limit 是返回的玩家数量，默认为10
+	Limit int `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+// HandleGetPlayerLeaderboard 返回档案中在指定时间范围内游玩时长最长的玩家，以及每个玩家在该范围内的会话数量
+func HandleGetPlayerLeaderboard() gin.HandlerFunc {
+	return helpers.QueryHandler[GetPlayerLeaderboardQuery](func(query GetPlayerLeaderboardQuery, c *gin.Context) (any, error) {
+		if query.Limit == 0 {
+			query.Limit = 10
+		}
+
+		var since *time.Time
+
+		switch query.Period {
+		case "day":
+			t := time.Now().Add(-24 * time.Hour)
+			since = &t
+		case "week", "":
+			t := time.Now().AddDate(0, 0, -7)
+			since = &t
+		case "month":
+			t := time.Now().AddDate(0, 0, -30)
+			since = &t
+		}
+
+		result, err := store.GetPlaytimeLeaderboard(gctx.GetProfile(c), since, query.Limit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(result), nil
+	})
+}
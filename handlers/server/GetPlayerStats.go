@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetPlayerStats 返回玩家在档案中的总游玩时长、会话数量、第一次进入和最后一次在线的时间
+func HandleGetPlayerStats() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		stats, err := store.GetPlayerStats(gctx.GetProfile(c), c.Param("gameId"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if stats == nil {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "该玩家没有游玩记录"}
+		}
+
+		return helpers.Data(stats), nil
+	})
+}
+
+// HandleGetPlayerSessions 分页返回玩家在档案中的会话，按照进入时间倒序排列
+func HandleGetPlayerSessions() gin.HandlerFunc {
+	return helpers.QueryHandler[helpers.Paginated](func(query helpers.Paginated, c *gin.Context) (any, error) {
+		if query.PageSize == 0 {
+			query.PageSize = 10
+		}
+
+		if query.Page == 0 {
+			query.Page = 1
+		}
+
+		result, err := store.GetPlayerSessions(gctx.GetProfile(c), c.Param("gameId"), query.PageSize, (query.Page-1)*query.PageSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(result), nil
+	})
+}
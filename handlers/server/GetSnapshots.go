@@ -0,0 +1,21 @@
+package server
+
+import (
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetSnapshots 返回档案当前保留的所有归档快照，按创建时间从新到旧排列
+func HandleGetSnapshots() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		snapshots, err := store.GetSnapshots(gctx.GetProfile(c))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(snapshots), nil
+	})
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatusHistoryPoints 是 HandleGetStatusHistory 接口一次最多返回的时间段数量
+const maxStatusHistoryPoints = 2000
+
+// statusHistorySteps 是 HandleGetStatusHistory 接口支持的汇总粒度
+var statusHistorySteps = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// GetStatusHistoryQuery 定义 HandleGetStatusHistory 接口的查询格式
+type GetStatusHistoryQuery struct {
+	// From 是查询范围的开始时间，RFC3339 格式，默认为 To 之前一天。会向前对齐到 Step 的边界
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+
+	// To 是查询范围的结束时间，RFC3339 格式，默认为当前时间
+	To *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+
+	// Step 是汇总的粒度，取值为 minute、hour 或 day，默认为 hour。day 按照系统本地时区划分
+	Step string `form:"step" binding:"omitempty,oneof=minute hour day"`
+}
+
+// truncateLocal 将 t 向前对齐到系统本地时区中长度为 step 的时间段的边界
+func truncateLocal(t time.Time, step time.Duration) time.Time {
+	t = t.Local()
+
+	if step == 24*time.Hour {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	}
+
+	return t.Truncate(step)
+}
+
+// HandleGetStatusHistory 返回档案在查询范围内每分钟记录的在线人数、运行状态和延迟，按照 step 汇总。没有采样的时间段不返回
+func HandleGetStatusHistory() gin.HandlerFunc {
+	return helpers.QueryHandler[GetStatusHistoryQuery](func(query GetStatusHistoryQuery, c *gin.Context) (any, error) {
+		if query.Step == "" {
+			query.Step = "hour"
+		}
+
+		step := statusHistorySteps[query.Step]
+		to := time.Now()
+
+		if query.To != nil {
+			to = *query.To
+		}
+
+		from := to.Add(-24 * time.Hour)
+
+		if query.From != nil {
+			from = *query.From
+		}
+
+		from = truncateLocal(from, step)
+
+		if !from.Before(to) {
+			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "开始时间需要早于结束时间"}
+		}
+
+		if to.Sub(from)/step > maxStatusHistoryPoints {
+			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "时间段数量过多，请增大step或缩小查询范围"}
+		}
+
+		result, err := store.GetServerStatusRollups(gctx.GetProfile(c), from, to, step)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(gin.H{"step": query.Step, "from": from, "rollups": result}), nil
+	})
+}
+
+// GetPlayerActivityQuery 定义 HandleGetPlayerActivity 接口的查询格式
+type GetPlayerActivityQuery struct {
+	// Days 是统计的天数，默认为28
+	Days int `form:"days" binding:"omitempty,gte=1,lte=365"`
+}
+
+// HandleGetPlayerActivity 返回档案最近若干天中一周内每个小时服务器在线时的平均在线人数，用于展示玩家通常在什么时间游玩
+func HandleGetPlayerActivity() gin.HandlerFunc {
+	return helpers.QueryHandler[GetPlayerActivityQuery](func(query GetPlayerActivityQuery, c *gin.Context) (any, error) {
+		if query.Days == 0 {
+			query.Days = 28
+		}
+
+		to := time.Now()
+		from := to.AddDate(0, 0, -query.Days)
+
+		result, err := store.GetPlayerActivity(gctx.GetProfile(c), from, to)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(result), nil
+	})
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleResetCommandCooldown 清除指令在档案下的冷却时间和所有频率限制记录，使任何用户都可以立即执行该指令
+func HandleResetCommandCooldown() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		cmd, ok := commands.ShouldGetCommand(consts.CommandType(c.Param("commandType")))
+
+		if !ok {
+			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "command not found"}
+		}
+
+		if err := cmd.ResetCooldown(gctx.GetProfile(c)); err != nil {
+			return nil, err
+		}
+
+		return gin.H{}, nil
+	})
+}
@@ -14,9 +14,18 @@ import (
 type ExecuteOnServerQuery struct {
 	CommandType consts.CommandType `form:"commandType" binding:"required"`
 	WithOutput  bool               `form:"withOutput"`
+
+	// IgnoreCooldown 表示本次执行忽略冷却时间和频率限制，仅管理员可用
+	IgnoreCooldown bool `form:"ignoreCooldown"`
+
+	// Async 表示以任务的形式在后台运行指令，此时返回任务的标识符，输出通过任务事件推送
+	Async bool `form:"async"`
 }
 
-// HandleServerExecute 尝试在活动实例上运行一个操作，该操作必须在预先固定的有限操作中选取一个。
+// HandleServerExecute 尝试在档案的活动实例上运行一个操作，该操作必须在预先固定的有限操作中选取一个。
+// 指令的参数以 args[参数名]=参数值 的形式在查询字符串中传入，参见 commands.Command.Args
+// 管理员可以通过 ignoreCooldown 忽略冷却时间执行指令，执行仍然会消耗执行次数
+// 指定 async 时，指令在后台运行，接口立即返回任务的标识符，参见 commands.Command.RunAsync
 func HandleServerExecute() gin.HandlerFunc {
 	return helpers.QueryHandler[ExecuteOnServerQuery](func(body ExecuteOnServerQuery, c *gin.Context) (any, error) {
 		userId, err := gctx.ShouldGetUserId(c)
@@ -25,7 +34,9 @@ func HandleServerExecute() gin.HandlerFunc {
 			return nil, err
 		}
 
-		activeInstance, err := store.GetDeployedActiveInstance()
+		profile := gctx.GetProfile(c)
+
+		activeInstance, err := store.GetDeployedActiveInstance(profile)
 
 		if err != nil {
 			return nil, err
@@ -41,14 +52,30 @@ func HandleServerExecute() gin.HandlerFunc {
 			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "无权执行"}
 		}
 
-		if !cmd.TestWhitelisted(c) {
+		if !cmd.TestWhitelisted(c, profile) {
 			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "需要白名单"}
 		}
 
+		if body.IgnoreCooldown && !isAdmin(c) {
+			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "无权忽略冷却时间"}
+		}
+
+		option := &commands.CommandRunOption{Output: body.WithOutput, IgnoreCooldown: body.IgnoreCooldown, Args: c.QueryMap("args")}
+
+		if body.Async {
+			taskId, err := cmd.RunAsync(activeInstance, &userId, option)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return helpers.Data(taskId), nil
+		}
+
 		ctx, cancel := cmd.DefaultContext()
 		defer cancel()
 
-		output, err := cmd.Run(ctx, *activeInstance.Ip, &userId, &commands.CommandRunOption{Output: body.WithOutput})
+		output, err := cmd.Run(ctx, activeInstance, &userId, option)
 
 		if err != nil {
 			return nil, err
@@ -57,3 +84,10 @@ func HandleServerExecute() gin.HandlerFunc {
 		return helpers.Data(output), nil
 	})
 }
+
+// isAdmin 返回 *gin.Context 中携带的权限等级是否为管理员
+func isAdmin(c *gin.Context) bool {
+	role, ok := c.Get("role")
+
+	return ok && role == consts.UserRoleAdmin
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/monitors"
 	"github.com/gin-gonic/gin"
 	"github.com/mcstatus-io/mcutil/v4/response"
@@ -15,10 +16,12 @@ type GetServerInfoResponse struct {
 
 func HandleGetServerInfo() gin.HandlerFunc {
 	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
-		if !monitors.SnapshotIsServerRunning() {
+		profile := gctx.GetProfile(c)
+
+		if !monitors.SnapshotIsServerRunning(profile) {
 			return helpers.Data(GetServerInfoResponse{Running: false}), nil
 		}
 
-		return helpers.Data(GetServerInfoResponse{Running: true, Data: monitors.SnapshotServerStatus(), OnlinePlayers: monitors.SnapshotOnlinePlayers()}), nil
+		return helpers.Data(GetServerInfoResponse{Running: true, Data: monitors.SnapshotServerStatus(profile), OnlinePlayers: monitors.SnapshotOnlinePlayers(profile)}), nil
 	})
 }
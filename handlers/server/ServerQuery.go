@@ -8,17 +8,20 @@ import (
 	"github.com/Subilan/go-aliyunmc/consts"
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/gin-gonic/gin"
 )
 
 type QueryOnServerQuery struct {
-	QueryType consts.CommandType `form:"queryType" binding:"required,oneof=get_server_sizes screenfetch get_ops get_cached_players get_server_properties"`
+	QueryType consts.CommandType `form:"queryType" binding:"required"`
 }
 
 func HandleServerQuery() gin.HandlerFunc {
 	return helpers.QueryHandler[QueryOnServerQuery](func(query QueryOnServerQuery, c *gin.Context) (any, error) {
-		activeInstance, err := store.GetDeployedActiveInstance()
+		profile := gctx.GetProfile(c)
+
+		activeInstance, err := store.GetDeployedActiveInstance(profile)
 
 		if err != nil {
 			return nil, err
@@ -29,7 +32,7 @@ func HandleServerQuery() gin.HandlerFunc {
 
 		cmd, ok := commands.ShouldGetCommand(query.QueryType)
 
-		if !ok {
+		if !ok || !cmd.IsQuery {
 			return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "query type not found"}
 		}
 
@@ -37,11 +40,11 @@ func HandleServerQuery() gin.HandlerFunc {
 			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "无权执行"}
 		}
 
-		if !cmd.TestWhitelisted(c) {
+		if !cmd.TestWhitelisted(c, profile) {
 			return nil, &helpers.HttpError{Code: http.StatusForbidden, Details: "需要白名单"}
 		}
 
-		output, err := cmd.Run(ctx, *activeInstance.Ip, nil, nil)
+		output, err := cmd.Run(ctx, activeInstance, nil, &commands.CommandRunOption{Args: c.QueryMap("args")})
 
 		if err != nil {
 			return nil, err
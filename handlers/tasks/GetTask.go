@@ -20,6 +20,9 @@ type GetTaskQuery struct {
 
 	// WithJoinedPushedEvents 指定是否在结果中包含与该任务相关的所有推送事件内容的合并值
 	WithJoinedPushedEvents bool `form:"withJoinedPushedEvents"`
+
+	// Profile 指定按类型获取任务时所属的档案，仅对 HandleGetActiveTaskByType 有效
+	Profile string `form:"profile"`
 }
 
 // GetTaskResponse 是 HandleGetTask 接口的返回数据结构
@@ -41,11 +44,11 @@ const (
 	retrievalByActive retrievalType = "active"
 )
 
-func getResponse(withPushedEvents bool, withJoinedPushedEvents bool, retrievalTyp retrievalType, retrievalArg string) (*GetTaskResponse, error) {
+func getResponse(withPushedEvents bool, withJoinedPushedEvents bool, retrievalTyp retrievalType, retrievalArg string, profile string) (*GetTaskResponse, error) {
 	var task store.Task
 
-	stmt := "SELECT task_id, type, user_id, status, created_at FROM tasks "
-	args := make([]any, 0, 2)
+	stmt := "SELECT task_id, type, user_id, profile, status, created_at FROM tasks "
+	args := make([]any, 0, 3)
 
 	if retrievalTyp == retrievalById {
 		stmt += "WHERE task_id = ?"
@@ -53,9 +56,14 @@ func getResponse(withPushedEvents bool, withJoinedPushedEvents bool, retrievalTy
 	} else {
 		stmt += "WHERE type = ? AND status = ?"
 		args = append(args, retrievalArg, consts.TaskStatusRunning)
+
+		if profile != "" {
+			stmt += " AND profile = ?"
+			args = append(args, profile)
+		}
 	}
 
-	err := db.Pool.QueryRow(stmt, args...).Scan(&task.Id, &task.Type, &task.UserId, &task.Status, &task.CreatedAt)
+	err := db.Pool.QueryRow(stmt, args...).Scan(&task.Id, &task.Type, &task.UserId, &task.Profile, &task.Status, &task.CreatedAt)
 
 	if err != nil {
 		return nil, err
@@ -126,7 +134,7 @@ func HandleGetTask() gin.HandlerFunc {
 			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "must provide taskId"}
 		}
 
-		res, err := getResponse(query.WithPushedEvents, query.WithJoinedPushedEvents, retrievalById, taskId)
+		res, err := getResponse(query.WithPushedEvents, query.WithJoinedPushedEvents, retrievalById, taskId, "")
 
 		if err != nil {
 			return nil, err
@@ -161,7 +169,7 @@ func HandleGetActiveTaskByType() gin.HandlerFunc {
 			return nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "must provide taskType"}
 		}
 
-		res, err := getResponse(query.WithPushedEvents, query.WithJoinedPushedEvents, retrievalByActive, taskType)
+		res, err := getResponse(query.WithPushedEvents, query.WithJoinedPushedEvents, retrievalByActive, taskType, query.Profile)
 
 		if err != nil {
 			return nil, err
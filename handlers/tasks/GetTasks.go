@@ -34,7 +34,7 @@ func HandleGetTasks() gin.HandlerFunc {
 		}
 
 		rows, err := db.Pool.Query(
-			"SELECT t.task_id, t.`type`, t.`status`, t.created_at, t.updated_at, u.username FROM tasks t JOIN `users` u ON t.user_id = u.id ORDER BY t.created_at DESC LIMIT ? OFFSET ?",
+			"SELECT t.task_id, t.`type`, t.profile, t.`status`, t.created_at, t.updated_at, u.username FROM tasks t LEFT JOIN `users` u ON t.user_id = u.id ORDER BY t.created_at DESC LIMIT ? OFFSET ?",
 			query.PageSize, (query.Page-1)*query.PageSize,
 		)
 		defer rows.Close()
@@ -47,7 +47,7 @@ func HandleGetTasks() gin.HandlerFunc {
 
 		for rows.Next() {
 			var task store.JoinedTask
-			err = rows.Scan(&task.Id, &task.Type, &task.Status, &task.CreatedAt, &task.UpdatedAt, &task.Username)
+			err = rows.Scan(&task.Id, &task.Type, &task.Profile, &task.Status, &task.CreatedAt, &task.UpdatedAt, &task.Username)
 
 			if err != nil {
 				return nil, err
@@ -95,8 +95,8 @@ func HandleGetTaskOverview() gin.HandlerFunc {
 			return nil, err
 		}
 
-		_ = db.Pool.QueryRow("SELECT t.task_id, t.type, t.status, t.created_at, t.updated_at, u.username FROM tasks t JOIN users u ON t.user_id = u.id ORDER BY t.created_at DESC LIMIT 1").
-			Scan(&res.Latest.Id, &res.Latest.Type, &res.Latest.Status, &res.Latest.CreatedAt, &res.Latest.UpdatedAt, &res.Latest.Username)
+		_ = db.Pool.QueryRow("SELECT t.task_id, t.type, t.profile, t.status, t.created_at, t.updated_at, u.username FROM tasks t LEFT JOIN users u ON t.user_id = u.id ORDER BY t.created_at DESC LIMIT 1").
+			Scan(&res.Latest.Id, &res.Latest.Type, &res.Latest.Profile, &res.Latest.Status, &res.Latest.CreatedAt, &res.Latest.UpdatedAt, &res.Latest.Username)
 
 		return helpers.Data(res), nil
 	})
@@ -0,0 +1,50 @@
+package users
+
+import (
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/gin-gonic/gin"
+)
+
+// SelfPlayerStatsResponse 是 HandleGetSelfPlayerStats 接口的返回值
+type SelfPlayerStatsResponse struct {
+	// Bound 表示用户是否绑定了游戏名。没有绑定时不返回统计
+	Bound bool `json:"bound"`
+
+	// Profiles 是用户绑定的游戏名在每个档案中的游玩统计，键为档案名称
+	Profiles map[string]*store.PlayerStats `json:"profiles"`
+}
+
+// HandleGetSelfPlayerStats 返回当前用户绑定的游戏名在每个档案中的游玩统计。
+//
+//	@Summary		获取当前用户的游玩统计
+//	@Description	根据当前用户绑定的游戏名，返回其在每个档案中的总游玩时长、会话数量、第一次进入和最后一次在线的时间。统计包括绑定之前的游玩记录
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	helpers.DataResp[SelfPlayerStatsResponse]
+//	@Failure		500	{object}	helpers.ErrorResp
+//	@Router			/user/stats [get]
+func HandleGetSelfPlayerStats() gin.HandlerFunc {
+	return helpers.BasicHandler(func(c *gin.Context) (any, error) {
+		userId, err := gctx.ShouldGetUserId(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bound, exists := store.GetGameBound(userId)
+
+		if !exists {
+			return helpers.Data(SelfPlayerStatsResponse{Bound: false, Profiles: map[string]*store.PlayerStats{}}), nil
+		}
+
+		stats, err := store.GetPlayerStatsInProfiles(bound.GameId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return helpers.Data(SelfPlayerStatsResponse{Bound: true, Profiles: stats}), nil
+	})
+}
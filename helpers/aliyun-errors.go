@@ -0,0 +1,25 @@
+package helpers
+
+import (
+	"errors"
+
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// AliyunErrorCode 返回阿里云 SDK 错误中携带的错误码，如 OperationDenied.NoStock。如果 err 不是阿里云 SDK 错误，返回空字符串
+func AliyunErrorCode(err error) string {
+	var daraErr *dara.SDKError
+
+	if errors.As(err, &daraErr) {
+		return tea.StringValue(daraErr.Code)
+	}
+
+	var teaErr *tea.SDKError
+
+	if errors.As(err, &teaErr) {
+		return tea.StringValue(teaErr.Code)
+	}
+
+	return ""
+}
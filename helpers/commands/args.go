@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers"
+)
+
+// rconBarePattern 是在服务器指令中无需加引号的参数值的格式，与 Minecraft 指令解析器中不加引号的字符串一致
+var rconBarePattern = regexp.MustCompile(`^[0-9A-Za-z_.+\-]+$`)
+
+// escapeShellArg 将 value 转义为一个 Shell 参数，总是使用单引号包围
+func escapeShellArg(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// escapeServerArg 将 value 转义为一个服务器指令参数。含有特殊字符的值使用双引号包围，不允许包含控制字符（如换行），以免注入额外的指令
+func escapeServerArg(value string) (string, error) {
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return "", errors.New("参数不能包含控制字符")
+	}
+
+	if rconBarePattern.MatchString(value) {
+		return value, nil
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`, nil
+}
+
+// resolveArgs 按照该指令声明的参数校验 args 并填充默认值，返回按照指令的执行位置转义后用于渲染的参数，以及规范化后用于记录的参数。
+// 未声明的参数会导致错误；未提供且没有默认值的可选参数在渲染时值为空字符串，不进行转义，以便在模板中使用 {{ if .Args.参数名 }} 判断
+func (c *Command) resolveArgs(args map[string]string) (map[string]string, map[string]string, error) {
+	result := make(map[string]string, len(c.Args))
+	normalized := make(map[string]string, len(c.Args))
+
+	for name := range args {
+		if !c.hasArg(name) {
+			return nil, nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "未知的参数：" + name}
+		}
+	}
+
+	for _, arg := range c.Args {
+		value, ok := args[arg.Name]
+
+		if !ok || value == "" {
+			value = arg.Default
+		}
+
+		if value == "" {
+			if arg.Required {
+				return nil, nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: "缺少参数：" + arg.Name}
+			}
+
+			result[arg.Name] = ""
+			continue
+		}
+
+		value, err := arg.Validate(value)
+
+		if err != nil {
+			return nil, nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: err.Error()}
+		}
+
+		normalized[arg.Name] = value
+
+		switch {
+		// 在 Shell 中执行的指令总是转义参数，不受 RawArgs 影响
+		case c.ExecuteLocation == consts.ExecuteLocationShell:
+			value = escapeShellArg(value)
+		case c.RawArgs:
+			if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+				return nil, nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: arg.Name + " 参数不能包含控制字符"}
+			}
+		default:
+			value, err = escapeServerArg(value)
+
+			if err != nil {
+				return nil, nil, &helpers.HttpError{Code: http.StatusBadRequest, Details: arg.Name + " " + err.Error()}
+			}
+		}
+
+		result[arg.Name] = value
+	}
+
+	return result, normalized, nil
+}
+
+// ValidateArgs 按照该指令声明的参数校验 args，返回规范化后的参数，用于在执行之前预先检查参数，参见 resolveArgs
+func (c *Command) ValidateArgs(args map[string]string) (map[string]string, error) {
+	_, normalized, err := c.resolveArgs(args)
+
+	return normalized, err
+}
+
+func (c *Command) hasArg(name string) bool {
+	for _, arg := range c.Args {
+		if arg.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+)
+
+func TestResolveArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		location consts.CommandExecuteLocation
+		raw      bool
+		value    string
+		want     string
+		wantErr  bool
+	}{
+		{"shell", consts.ExecuteLocationShell, false, "a'; rm -rf /", `'a'\''; rm -rf /'`, false},
+		{"shell ignores raw args", consts.ExecuteLocationShell, true, "a'; rm -rf /", `'a'\''; rm -rf /'`, false},
+		{"server bare", consts.ExecuteLocationServer, false, "Steve_01", "Steve_01", false},
+		{"server quoted", consts.ExecuteLocationServer, false, `say "hi"`, `"say \"hi\""`, false},
+		{"server control character", consts.ExecuteLocationServer, false, "a\nstop", "", true},
+		{"server raw", consts.ExecuteLocationServer, true, `say "hi"`, `say "hi"`, false},
+		{"server raw control character", consts.ExecuteLocationServer, true, "a\nstop", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Command{
+				ExecuteLocation: tt.location,
+				RawArgs:         tt.raw,
+				Args:            []config.CommandArgConfig{{Name: "value", Type: config.CommandArgTypeString, Required: true}},
+			}
+
+			got, _, err := c.resolveArgs(map[string]string{"value": tt.value})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveArgs() = %q, want error", got["value"])
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("resolveArgs() error = %v", err)
+			}
+
+			if got["value"] != tt.want {
+				t.Errorf("resolveArgs() = %q, want %q", got["value"], tt.want)
+			}
+		})
+	}
+}
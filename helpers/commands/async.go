@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/rcon"
+	"github.com/Subilan/go-aliyunmc/helpers/remote"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/Subilan/go-aliyunmc/helpers/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+// updateCommandTaskStatus 更新指令任务 taskId 的状态并推送给用户
+func updateCommandTaskStatus(profile string, taskId string, commandType consts.CommandType, taskStatus consts.TaskStatus) {
+	_, err := db.Pool.Exec("UPDATE tasks SET status = ? WHERE task_id = ?", taskStatus, taskId)
+
+	if err != nil {
+		log.Println("cannot update task status: " + err.Error())
+	}
+
+	err = stream.BroadcastAndSave(events.Server(profile, events.ServerEventCommandTaskStatusUpdate, gin.H{"taskId": taskId, "commandType": commandType, "status": taskStatus}))
+
+	if err != nil {
+		log.Println("cannot send and save server event", err)
+	}
+}
+
+// sendCommandOutput 将指令任务 taskId 的一段输出作为该任务的有状态事件推送给用户。isError 表示该输出来自标准错误或者是执行的错误信息
+func sendCommandOutput(taskId string, content string, isError bool) {
+	state, stateExists := stream.GetStateOfTask(taskId)
+
+	if !stateExists {
+		log.Println("warning: trying to get state but state does not exist")
+		return
+	}
+
+	err := stream.BroadcastAndSave(&events.Event{
+		EventState: *state,
+		IsError:    isError,
+		Content:    content,
+	})
+
+	stream.IncrStateOrdOfTask(taskId)
+
+	if err != nil {
+		log.Printf("cannot send and save command output: task=%s, is_error=%v, content=%s, error=%s\n", taskId, isError, content, err.Error())
+	}
+}
+
+// taskStatusOf 返回以错误 err 结束的任务的状态
+func taskStatusOf(err error) consts.TaskStatus {
+	switch {
+	case err == nil:
+		return consts.TaskStatusSuccess
+	case errors.Is(err, context.Canceled):
+		return consts.TaskStatusCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return consts.TaskStatusTimedOut
+	}
+
+	return consts.TaskStatusFailed
+}
+
+// RunAsync 以 consts.TaskTypeCommandExecution 任务的形式在后台运行该指令，返回任务的标识符。检查、冷却时间和执行记录与 Run 相同。
+//
+// 运行过程中，标准输出和标准错误的内容（在服务器内执行时为每一条指令的响应）作为该任务的有状态事件实时推送，因此断线重连时可以通过 Last-Event-Id 补齐。
+// 任务状态通过 events.ServerEventCommandTaskStatusUpdate 推送。任务的超时时间为 Command.Timeout，可以通过 tasks.CancelById 取消。
+func (c *Command) RunAsync(inst *store.Instance, by *int64, option *CommandRunOption) (string, error) {
+	if option == nil {
+		option = &CommandRunOption{}
+	}
+
+	e, err := c.prepare(inst, by, option)
+
+	if err != nil {
+		return "", err
+	}
+
+	taskId, err := store.InsertTask(consts.TaskTypeCommandExecution, e.profile.Name, by)
+
+	if err != nil {
+		c.finish(e, err, option.Comment)
+		return "", err
+	}
+
+	stream.RecordStateForTask(taskId)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.TimeoutDuration())
+
+	tasks.Register(cancel, taskId)
+
+	updateCommandTaskStatus(e.profile.Name, taskId, c.Type, consts.TaskStatusRunning)
+
+	go func() {
+		defer func() {
+			tasks.Unregister(taskId)
+			stream.DeleteStateOfTask(taskId)
+		}()
+
+		err := c.stream(ctx, e, func(content string, isError bool) {
+			sendCommandOutput(taskId, content, isError)
+		})
+
+		if err != nil {
+			sendCommandOutput(taskId, err.Error(), true)
+		}
+
+		c.finish(e, err, option.Comment)
+
+		updateCommandTaskStatus(e.profile.Name, taskId, c.Type, taskStatusOf(err))
+	}()
+
+	return taskId, nil
+}
+
+// stream 执行 e 中的指令内容，将输出实时传递给 sink，并记录到 e.stdout 和 e.stderr 中。
+// 在 Shell 中执行时，sink 收到的是输出的片段；在服务器内执行时，sink 收到的是每一条指令的完整响应
+func (c *Command) stream(ctx context.Context, e *execution, sink func(content string, isError bool)) error {
+	e.startedAt = time.Now()
+
+	if c.ExecuteLocation == consts.ExecuteLocationShell {
+		return remote.RunCommandAsProdStream(ctx, e.host, e.content,
+			func(b []byte) {
+				_, _ = e.stdout.Write(b)
+				sink(string(b), false)
+			},
+			func(b []byte) {
+				_, _ = e.stderr.Write(b)
+				sink(string(b), true)
+			},
+		)
+	}
+
+	for i, content := range e.content {
+		messages, err := rcon.Run(ctx, e.host, e.profile.GetGameRconPort(), e.profile.Server.RconPassword, content)
+
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if i > 0 {
+				_, _ = e.stdout.Write([]byte("\n"))
+			}
+
+			_, _ = e.stdout.Write([]byte(message))
+			sink(message, false)
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -14,11 +15,11 @@ import (
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
 	"github.com/Subilan/go-aliyunmc/helpers/gctx"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	"github.com/Subilan/go-aliyunmc/helpers/remote"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 	"github.com/Subilan/go-aliyunmc/helpers/templateData"
 	"github.com/gin-gonic/gin"
-	"github.com/mcstatus-io/mcutil/v4/rcon"
 	"github.com/mcstatus-io/mcutil/v4/status"
 )
 
@@ -30,20 +31,36 @@ type Command struct {
 	// Type 是该指令的类型，也可以认为是该指令的标识符。
 	Type consts.CommandType
 
+	// Description 是该指令的说明，供前端展示
+	Description string
+
 	// ExecuteLocation 表示该指令执行的位置。
 	ExecuteLocation consts.CommandExecuteLocation
 
-	// Cooldown 是该指令的冷却时间，单位秒
+	// Cooldown 是该指令在配置中声明的冷却时间，单位秒。它已经包含在 Limits 中
 	Cooldown int
 
+	// Limits 是该指令的所有频率限制，参见 config.CommandConfig.GetLimits
+	Limits []config.CommandLimitConfig
+
 	// Content 是该指令的具体文本内容
 	Content []string
 
 	// Timeout 是该指令的推荐超时时间。具体的超时由指令运行的上下文决定，而不是由此字段。
 	Timeout int
 
-	// Prerequisite 返回该指令运行的前置条件是否满足。如果该函数返回 false，则指令不可运行。
-	Prerequisite func() bool
+	// Prerequisite 返回该指令在实例 inst 上运行的前置条件是否满足。如果该函数返回 false，则指令不可运行。
+	Prerequisite func(inst *store.Instance) bool
+
+	// PrerequisiteName 是该指令在配置中声明的前置条件名称，参见 config.CommandConfig.Prerequisite
+	PrerequisiteName string
+
+	// Args 是该指令接受的参数
+	Args []config.CommandArgConfig
+
+	// RawArgs 表示在服务器内执行时参数在渲染时不进行转义，原样填入指令内容，但仍然不能包含控制字符。仅用于系统内部构造、参数由管理员输入
+	// 或者来源于配置的指令，例如控制台输入。在 Shell 中执行的指令忽略此字段，参数总是被转义
+	RawArgs bool
 
 	// IsQuery 表示该指令是否属于查询类指令。查询类指令永远没有冷却时间，运行时也不会在数据库中记录其过程。
 	IsQuery bool
@@ -54,6 +71,9 @@ type Command struct {
 	// Whitelisted 表示该指令是否要求用户绑定游戏账号且有白名单
 	// 通常，当 Role 设置为高权限等级，如 admin 的时候，不需要设置此项
 	Whitelisted bool
+
+	// Request 是权限不足的用户请求执行该指令的方式
+	Request config.CommandRequestConfig
 }
 
 // DefaultContext 获取该指令用于运行的默认上下文，它是 context.Background 的子上下文，附带了 Command.Timeout 对应的超时时间。
@@ -66,55 +86,12 @@ func (c *Command) TimeoutDuration() time.Duration {
 	return time.Duration(c.Timeout) * time.Second
 }
 
-// cooldownContexts 用于记录正在运行的冷却计时 goroutine 对应上下文的取消函数，以便重置冷却计时。在实际运行中，该字典中可能包含已经被取消的上下文的取消函数。
-var cooldownContexts = make(map[consts.CommandType]context.CancelFunc)
-
-// StartCooldown 开始当前指令的冷却计时。开始计时之前，将尝试取消该指令已经存在的未完成冷却计时（重置冷却计时）。如果该指令没有冷却时间（为0），该方法无效果。
-func (c *Command) StartCooldown() {
-	if c.Cooldown == 0 {
-		return
-	}
-
-	previousCancel, ok := cooldownContexts[c.Type]
-
-	if ok {
-		previousCancel()
-	}
-
-	commandCooldownLeft[c.Type] = c.Cooldown
-	ctx, cancel := context.WithCancel(context.Background())
-	cooldownContexts[c.Type] = cancel
-
-	go func() {
-		for commandCooldownLeft[c.Type] > 0 {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				commandCooldownLeft[c.Type] -= 1
-				time.Sleep(time.Second)
-			}
-		}
-	}()
-}
-
-// IsCoolingDown 返回该指令是否处于冷却时间内
-func (c *Command) IsCoolingDown() bool {
-	cd, ok := commandCooldownLeft[c.Type]
-
-	if !ok {
-		return false
-	}
-
-	return cd > 0
-}
-
 // CommandRunOption 表示对指令运行的配置
 type CommandRunOption struct {
-	// IgnoreCooldown 如果为true，本次执行无需满足冷却时间条件。
+	// IgnoreCooldown 如果为true，本次执行无需满足冷却时间和频率限制条件。
 	IgnoreCooldown bool
 
-	// DisableResetCooldown 如果为true，本次执行不会重置冷却时间
+	// DisableResetCooldown 如果为true，本次执行不会消耗执行次数，即不会重置冷却时间
 	DisableResetCooldown bool
 
 	// DisableAudit 如果为true，表示不在数据库中记录执行信息。
@@ -127,6 +104,9 @@ type CommandRunOption struct {
 
 	// Comment 是本次执行成功后在数据库中填入的备注字段
 	Comment string
+
+	// Args 是本次执行的参数，参见 Command.Args
+	Args map[string]string
 }
 
 // TestRole 判断 *gin.Context 中携带的权限等级信息是否大于或等于所要求的权限等级
@@ -146,7 +126,8 @@ func (c *Command) TestRole(ctx *gin.Context) bool {
 	return roleInt >= c.Role
 }
 
-func (c *Command) TestWhitelisted(ctx *gin.Context) bool {
+// TestWhitelisted 判断 *gin.Context 中携带的用户是否满足该指令在档案 profile 下的白名单要求
+func (c *Command) TestWhitelisted(ctx *gin.Context, profile string) bool {
 	if !c.Whitelisted {
 		return true
 	}
@@ -163,104 +144,205 @@ func (c *Command) TestWhitelisted(ctx *gin.Context) bool {
 		return false
 	}
 
-	return gameBound.Whitelisted
+	return store.IsWhitelistedIn(profile, gameBound.GameId)
 }
 
-// Run 运行该指令。
-// option 可以填 nil 表示使用默认值。
-// 传入的上下文只会影响该指令的执行过程，不会影响数据库的记录过程。
-// 如果 by 参数填 nil，表示该运行是自动发起。
-// 注意：如果运行的指令为查询类，则行为有所差异，详见 Command.IsQuery。
-func (c *Command) Run(ctx context.Context, host string, by *int64, option *CommandRunOption) (string, error) {
-	if option == nil {
-		option = &CommandRunOption{}
+// render 使用档案 profile 的模板数据和已经转义的参数 args 渲染该指令的内容
+func (c *Command) render(profile config.ProfileConfig, args map[string]string) ([]string, error) {
+	result := make([]string, 0, len(c.Content))
+
+	for _, content := range c.Content {
+		tmpl, err := template.New(string(c.Type)).Parse(content)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+
+		if err := tmpl.Execute(&buf, templateData.Command(profile, args)); err != nil {
+			return nil, err
+		}
+
+		result = append(result, buf.String())
+	}
+
+	return result, nil
+}
+
+// execution 是一次指令执行在检查、渲染、扣除执行次数以及插入执行记录之后得到的信息，以及执行过程中记录的输出
+type execution struct {
+	profile  config.ProfileConfig
+	host     string
+	content  []string
+	doRecord bool
+	recordId int64
+	refund   func()
+
+	// stdout 和 stderr 记录执行的标准输出和标准错误，写入执行记录时最多保留 maxRecordedOutput 字节
+	stdout *cappedBuffer
+	stderr *cappedBuffer
+
+	// startedAt 是指令开始执行的时间
+	startedAt time.Time
+}
+
+// prepare 检查指令能否在实例 inst 上运行，渲染指令的内容，扣除执行者 by 的执行次数，并插入执行记录
+func (c *Command) prepare(inst *store.Instance, by *int64, option *CommandRunOption) (*execution, error) {
+	if inst.Ip == nil {
+		return nil, &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "实例未分配IP地址"}
 	}
 
-	if c.IsCoolingDown() && !option.IgnoreCooldown {
-		return "", &helpers.HttpError{Code: http.StatusTooManyRequests, Details: "该指令仍在冷却中"}
+	profile, ok := config.Cfg.GetProfile(inst.Profile)
+
+	if !ok {
+		return nil, &helpers.HttpError{Code: http.StatusNotFound, Details: "档案不存在"}
 	}
 
-	if c.Prerequisite != nil && !c.Prerequisite() {
-		return "", &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "该指令前置条件未满足"}
+	if c.Prerequisite != nil && !c.Prerequisite(inst) {
+		return nil, &helpers.HttpError{Code: http.StatusServiceUnavailable, Details: "该指令前置条件未满足"}
 	}
 
-	doRecord := !option.DisableAudit && !c.IsQuery
-	doOutput := option.Output || c.IsQuery
+	args, recordedArgs, err := c.resolveArgs(option.Args)
 
-	var recordId int64
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := c.render(profile, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e := &execution{
+		profile:  profile,
+		host:     *inst.Ip,
+		content:  content,
+		doRecord: !option.DisableAudit && !c.IsQuery,
+		refund:   func() {},
+		stdout:   newCappedBuffer(maxRecordedOutput),
+		stderr:   newCappedBuffer(maxRecordedOutput),
+	}
+
+	if !option.IgnoreCooldown || !option.DisableResetCooldown {
+		e.refund, err = c.consumeCooldown(profile.Name, by, !option.IgnoreCooldown)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if isStopCommand(c.Type, args) {
+		markStopRequested(profile.Name)
+	}
 
-	if doRecord {
-		row, err := db.Pool.Exec("INSERT INTO command_exec (`type`, `by`, `status`, `auto`) VALUES (?, ?, ?, ?)", c.Type, by, "created", by == nil)
+	if e.doRecord {
+		row, err := db.Pool.Exec("INSERT INTO command_exec (`type`, profile, `by`, `status`, `auto`, args, instance_id) VALUES (?, ?, ?, ?, ?, ?, ?)", c.Type, profile.Name, by, "created", by == nil, store.CommandArgs(recordedArgs), inst.InstanceId)
 
 		if err != nil {
-			return "", err
+			e.refund()
+			return nil, err
 		}
 
-		recordId, err = row.LastInsertId()
+		e.recordId, err = row.LastInsertId()
 
 		if err != nil {
-			return "", err
+			e.refund()
+			return nil, err
 		}
 	}
 
-	var output []byte
-	var err error
+	return e, nil
+}
 
-	if c.ExecuteLocation == consts.ExecuteLocationShell {
-		output, err = remote.RunCommandAsProdSync(ctx, host, c.Content, doOutput)
+// finish 根据执行的错误 err 更新执行记录，记录输出、退出码以及开始和结束的时间，并计入 prom.CommandExecutions。执行失败时退还扣除的执行次数
+func (c *Command) finish(e *execution, err error, comment string) {
+	status := "success"
+
+	if err != nil {
+		e.refund()
+		status = "error"
+		comment = err.Error()
 	}
 
-	if c.ExecuteLocation == consts.ExecuteLocationServer {
-		rconClient, rconClientErr := rcon.Dial(host, config.Cfg.GetGameRconPort())
-		if rconClientErr != nil {
-			return "", rconClientErr
-		}
-		defer rconClient.Close()
+	prom.CommandExecutions.Inc(e.profile.Name, string(c.Type), status)
+
+	if !e.doRecord {
+		return
+	}
+
+	var exitCode *int
 
-		if err := rconClient.Login(config.Cfg.Server.RconPassword); err != nil {
-			return "", err
+	if c.ExecuteLocation == consts.ExecuteLocationShell {
+		if err == nil {
+			exitCode = new(int)
+		} else if code, ok := remote.ExitCode(err); ok {
+			exitCode = &code
 		}
+	}
 
-		var messages strings.Builder
+	var startedAt *time.Time
 
-		for _, serverCmd := range c.Content {
-			if err := rconClient.Run(serverCmd); err != nil {
-				return "", err
-			}
+	if !e.startedAt.IsZero() {
+		startedAt = &e.startedAt
+	}
 
-			if doOutput {
-				messages.WriteString(<-rconClient.Messages)
-			}
-		}
+	_, dbErr := db.Pool.Exec("UPDATE `command_exec` SET `status` = ?, `comment` = ?, stdout = ?, stderr = ?, exit_code = ?, started_at = ?, finished_at = ? WHERE id = ?",
+		status, comment, e.stdout.String(), e.stderr.String(), exitCode, startedAt, time.Now(), e.recordId)
 
-		if doOutput {
-			output = []byte(messages.String())
-		} else {
-			output = []byte{}
-		}
+	if dbErr != nil {
+		log.Println("cannot update command execution record:", dbErr)
 	}
+}
 
-	outputStr := string(output)
+// Run 在实例 inst 上运行该指令，指令的内容和服务器连接信息由实例所属的档案决定。
+// option 可以填 nil 表示使用默认值。
+// 传入的上下文只会影响该指令的执行过程，不会影响数据库的记录过程。
+// 如果 by 参数填 nil，表示该运行是自动发起。
+// 运行前检查并消耗执行者在各个频率限制中的执行次数，运行失败时退还，参见 Command.Limits。
+// 注意：如果运行的指令为查询类，则行为有所差异，详见 Command.IsQuery。
+func (c *Command) Run(ctx context.Context, inst *store.Instance, by *int64, option *CommandRunOption) (string, error) {
+	if option == nil {
+		option = &CommandRunOption{}
+	}
+
+	e, err := c.prepare(inst, by, option)
 
-	if err == nil && !option.DisableResetCooldown {
-		c.StartCooldown()
+	if err != nil {
+		return "", err
 	}
 
-	if doRecord {
-		if err != nil {
-			_, _ = db.Pool.Exec("UPDATE `command_exec` SET `status` = ?, `comment` = ? WHERE id = ?", "error", err.Error(), recordId)
-			return outputStr, err
+	doOutput := option.Output || c.IsQuery
+
+	var outputMu sync.Mutex
+	var output []string
+
+	err = c.stream(ctx, e, func(content string, isError bool) {
+		if !doOutput {
+			return
 		}
 
-		_, _ = db.Pool.Exec("UPDATE `command_exec` SET `status` = ?, `comment` = ? WHERE id = ?", "success", option.Comment, recordId)
-		return outputStr, nil
+		outputMu.Lock()
+		defer outputMu.Unlock()
+
+		output = append(output, content)
+	})
+
+	c.finish(e, err, option.Comment)
+
+	// 在服务器内执行时，每一项是一条指令的完整响应
+	separator := ""
+
+	if c.ExecuteLocation == consts.ExecuteLocationServer {
+		separator = "\n"
 	}
 
-	return outputStr, err
+	return strings.Join(output, separator), err
 }
 
 // RunWithoutCooldown 以无冷却时间相关考虑运行该指令。这样，指令的执行不会考虑冷却时间，亦不会重置冷却时间。仍然可以传入其它选项。
-func (c *Command) RunWithoutCooldown(ctx context.Context, host string, by *int64, option *CommandRunOption) (string, error) {
+func (c *Command) RunWithoutCooldown(ctx context.Context, inst *store.Instance, by *int64, option *CommandRunOption) (string, error) {
 	if option == nil {
 		option = &CommandRunOption{
 			IgnoreCooldown:       true,
@@ -271,166 +353,97 @@ func (c *Command) RunWithoutCooldown(ctx context.Context, host string, by *int64
 		option.DisableResetCooldown = true
 	}
 
-	return c.Run(ctx, host, by, option)
+	return c.Run(ctx, inst, by, option)
 }
 
-var commandCooldownLeft = make(map[consts.CommandType]int)
-
-// Load 加载系统的所有指令并写入到 Commands 字典中，用于调用。应当在系统初始化时调用，且在所有依赖指令的操作开始之前调用。
-func Load() {
-	Commands[consts.CmdTypeStartServer] = &Command{
-		Type:            consts.CmdTypeStartServer,
-		ExecuteLocation: consts.ExecuteLocationShell,
-		Cooldown:        60,
-		Content:         []string{"cd /home/mc/server/archive && ./start.sh && sleep 0.5 && screen -S server -Q select . >/dev/null || echo 'server cannot be started'"},
-		Timeout:         5,
-		Prerequisite: func() bool {
-			activeInstance, err := store.GetDeployedActiveInstance()
-
-			if err != nil {
-				return false
-			}
-
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-
-			_, err = status.Modern(ctx, *activeInstance.Ip, config.Cfg.GetGamePort())
-
-			// 需要服务器不在线
-			return err != nil
-		},
-		Whitelisted: true,
-	}
+// isDeployed 返回实例 inst 是否已经完成部署且分配了IP地址
+func isDeployed(inst *store.Instance) bool {
+	return inst.Deployed && inst.Ip != nil
+}
 
-	Commands[consts.CmdTypeStopServer] = &Command{
-		Type:            consts.CmdTypeStopServer,
-		ExecuteLocation: consts.ExecuteLocationServer,
-		Cooldown:        60,
-		Content:         []string{"stop"},
-		Timeout:         5,
-		Prerequisite: func() bool {
-			activeInstance, err := store.GetDeployedActiveInstance()
+// IsServerOnline 返回实例 inst 上运行的服务器是否在线，端口由实例所属的档案决定。与指令的前置条件无关，可以在任何时候直接检查服务器状态
+func IsServerOnline(inst *store.Instance) bool {
+	profile, ok := config.Cfg.GetProfile(inst.Profile)
 
-			if err != nil {
-				return false
-			}
+	if !ok || inst.Ip == nil {
+		return false
+	}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-			_, err = status.Modern(ctx, *activeInstance.Ip, config.Cfg.GetGamePort())
+	_, err := status.Modern(ctx, *inst.Ip, profile.GetGamePort())
 
-			// 需要服务器在线
-			return err == nil
-		},
-		Role: consts.UserRoleAdmin,
-	}
-	Commands[consts.CmdTypeGetServerSizes] = &Command{
-		Type:            consts.CmdTypeGetServerSizes,
-		ExecuteLocation: consts.ExecuteLocationShell,
-		Cooldown:        0,
-		Content:         []string{"du -sh /home/mc/server/archive", "du -sh /home/mc/server/archive/world", "du -sh /home/mc/server/archive/world_nether", "du -sh /home/mc/server/archive/world_the_end", "du -sh /home/mc/server/archive/bluemap"},
-		Timeout:         5,
-		IsQuery:         true,
-		Prerequisite: func() bool {
-			_, err := store.GetDeployedActiveInstance()
-			return err == nil
-		},
-	}
-	Commands[consts.CmdTypeScreenfetch] = &Command{
-		Type:            consts.CmdTypeScreenfetch,
-		ExecuteLocation: consts.ExecuteLocationShell,
-		Cooldown:        0,
-		Content:         []string{"screenfetch -N"},
-		Timeout:         5,
-		IsQuery:         true,
-	}
-	Commands[consts.CmdTypeGetCachedPlayers] = &Command{
-		Type:            consts.CmdTypeGetCachedPlayers,
-		ExecuteLocation: consts.ExecuteLocationShell,
-		Cooldown:        0,
-		Content:         []string{"cat /home/mc/server/archive/usercache.json"},
-		Timeout:         5,
-		IsQuery:         true,
-		Prerequisite: func() bool {
-			_, err := store.GetDeployedActiveInstance()
-			return err == nil
-		},
-	}
-	Commands[consts.CmdTypeGetServerProperties] = &Command{
-		Type:            consts.CmdTypeGetServerProperties,
-		ExecuteLocation: consts.ExecuteLocationShell,
-		Cooldown:        0,
-		Content:         []string{"cat /home/mc/server/archive/server.properties | grep -E '^(white-list|view-distance|simulation-distance|spawn-protection|online-mode|difficulty|max-players).*='"},
-		Timeout:         5,
-		IsQuery:         true,
-		Prerequisite: func() bool {
-			_, err := store.GetDeployedActiveInstance()
-			return err == nil
-		},
-	}
+	return err == nil
+}
 
-	Commands[consts.CmdTypeGetWhitelist] = &Command{
-		Type:            consts.CmdTypeGetWhitelist,
-		ExecuteLocation: consts.ExecuteLocationShell,
-		Cooldown:        0,
-		Content:         []string{"cat /home/mc/server/archive/whitelist.json"},
-		Timeout:         5,
-		IsQuery:         true,
-		Prerequisite: func() bool {
-			_, err := store.GetDeployedActiveInstance()
-			return err == nil
-		},
-		Role: consts.UserRoleAdmin,
-	}
+// prerequisites 是 config.CommandConfig 中声明式前置条件对应的检查函数
+var prerequisites = map[string]func(inst *store.Instance) bool{
+	config.CommandPrerequisiteInstanceDeployed: isDeployed,
+	config.CommandPrerequisiteServerOnline: func(inst *store.Instance) bool {
+		return isDeployed(inst) && IsServerOnline(inst)
+	},
+	config.CommandPrerequisiteServerOffline: func(inst *store.Instance) bool {
+		return isDeployed(inst) && !IsServerOnline(inst)
+	},
+}
 
-	Commands[consts.CmdTypeGetOps] = &Command{
-		Type:            consts.CmdTypeGetOps,
-		ExecuteLocation: consts.ExecuteLocationShell,
-		Cooldown:        0,
-		Content:         []string{"cat /home/mc/server/archive/ops.json"},
-		Timeout:         5,
-		IsQuery:         true,
-		Prerequisite: func() bool {
-			_, err := store.GetDeployedActiveInstance()
-			return err == nil
-		},
-	}
+// roles 是 config.CommandConfig 中权限等级名称对应的权限等级
+var roles = map[string]consts.UserRole{
+	"":                      consts.UserRoleEmpty,
+	config.CommandRoleUser:  consts.UserRoleUser,
+	config.CommandRoleAdmin: consts.UserRoleAdmin,
+}
 
-	for _, typ := range []consts.CommandType{consts.CmdTypeBackupWorlds, consts.CmdTypeArchiveServer} {
-		var filename string
-		if typ == consts.CmdTypeBackupWorlds {
-			filename = "backup.tmpl.sh"
-		} else {
-			filename = "archive.tmpl.sh"
-		}
+// commandOrder 记录指令在配置中声明的顺序，用于 List
+var commandOrder []consts.CommandType
 
-		parsed, err := template.ParseFiles(filename)
+// Load 根据 config.Config.Commands 加载系统的所有指令并写入到 Commands 字典中，用于调用。应当在系统初始化时调用，且在所有依赖指令的操作开始之前调用。
+// 指令的合法性已经在加载配置时检查过，参见 config.CommandConfig.Check
+func Load() {
+	for _, cfg := range config.Cfg.Commands {
+		content, err := cfg.ReadContent()
 
 		if err != nil {
-			log.Fatalf("Error parsing template '%s': %s", filename, err)
+			log.Fatalf("Error reading content of command '%s': %s", cfg.Type, err)
 		}
 
-		var buf bytes.Buffer
-		err = parsed.Execute(&buf, templateData.Archive())
-
-		if err != nil {
-			log.Fatalf("Error parsing template '%s': %s", filename, err)
-		}
+		typ := consts.CommandType(cfg.Type)
 
 		Commands[typ] = &Command{
-			Type:            typ,
-			ExecuteLocation: consts.ExecuteLocationShell,
-			Cooldown:        30,
-			Content:         []string{buf.String()},
-			Timeout:         300,
-			Role:            consts.UserRoleAdmin,
+			Type:             typ,
+			Description:      cfg.Description,
+			ExecuteLocation:  consts.CommandExecuteLocation(cfg.ExecuteLocation),
+			Cooldown:         cfg.Cooldown,
+			Limits:           cfg.GetLimits(),
+			Content:          content,
+			Timeout:          cfg.Timeout,
+			Prerequisite:     prerequisites[cfg.Prerequisite],
+			PrerequisiteName: cfg.Prerequisite,
+			IsQuery:          cfg.IsQuery,
+			Role:             roles[cfg.Role],
+			Whitelisted:      cfg.Whitelisted,
+			Args:             cfg.Args,
+			Request:          cfg.Request,
 		}
+
+		commandOrder = append(commandOrder, typ)
 	}
 
 	log.Printf("Loaded %d commands\n", len(Commands))
 }
 
+// List 按照声明的顺序返回所有指令
+func List() []*Command {
+	result := make([]*Command, 0, len(commandOrder))
+
+	for _, typ := range commandOrder {
+		result = append(result, Commands[typ])
+	}
+
+	return result
+}
+
 // MustGetCommand 一定获取到 commandType 对应的指令，否则将会导致程序退出。
 func MustGetCommand(commandType consts.CommandType) *Command {
 	command, ok := Commands[commandType]
@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// cooldownMu 使频率限制的检查、扣除和退还串行进行
+var cooldownMu sync.Mutex
+
+// cooldownBucket 是一个频率限制在某个对象上的令牌桶，参见 config.CommandLimitConfig
+type cooldownBucket struct {
+	limit   config.CommandLimitConfig
+	subject string
+}
+
+// tokensAt 返回令牌桶在 now 时剩余的执行次数。record 为 nil 表示令牌桶从未被使用过，即剩余次数已满
+func (b cooldownBucket) tokensAt(record *store.CommandCooldown, now time.Time) float64 {
+	if record == nil {
+		return float64(b.limit.Count)
+	}
+
+	elapsed := max(now.Sub(record.UpdatedAt), 0)
+
+	return min(float64(b.limit.Count), record.Tokens+elapsed.Seconds()/b.limit.Interval().Seconds())
+}
+
+// wait 返回剩余 tokens 次的令牌桶恢复到可以执行一次所需的时间
+func (b cooldownBucket) wait(tokens float64) time.Duration {
+	if tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - tokens) * float64(b.limit.Interval()))
+}
+
+// executorRole 返回执行者 by 的权限等级。by 为 nil 表示系统自动执行，返回 consts.UserRoleEmpty
+func executorRole(by *int64) (consts.UserRole, error) {
+	if by == nil {
+		return consts.UserRoleEmpty, nil
+	}
+
+	return store.GetUserRole(*by, consts.UserRoleUser)
+}
+
+// buckets 返回执行者 by 运行该指令时适用的令牌桶。系统自动执行只受全局限制
+func (c *Command) buckets(by *int64) ([]cooldownBucket, error) {
+	if c.IsQuery || len(c.Limits) == 0 {
+		return nil, nil
+	}
+
+	role, err := executorRole(by)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]cooldownBucket, 0, len(c.Limits))
+
+	for _, limit := range c.Limits {
+		if limit.Scope == config.CommandLimitScopeGlobal {
+			result = append(result, cooldownBucket{limit: limit})
+			continue
+		}
+
+		if by == nil || (limit.Role != "" && roles[limit.Role] != role) {
+			continue
+		}
+
+		subject := strconv.FormatInt(*by, 10)
+
+		if limit.Scope == config.CommandLimitScopeRole {
+			subject = strconv.Itoa(int(role))
+		}
+
+		result = append(result, cooldownBucket{limit: limit, subject: subject})
+	}
+
+	return result, nil
+}
+
+// loadTokens 返回 buckets 中每个令牌桶在 now 时剩余的执行次数
+func (c *Command) loadTokens(profile string, buckets []cooldownBucket, now time.Time) ([]float64, error) {
+	records, err := store.GetCommandCooldowns(profile, c.Type)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(buckets))
+
+	for i, b := range buckets {
+		var record *store.CommandCooldown
+
+		for j := range records {
+			if records[j].LimitKey == b.limit.Key() && records[j].Subject == b.subject {
+				record = &records[j]
+				break
+			}
+		}
+
+		result[i] = b.tokensAt(record, now)
+	}
+
+	return result, nil
+}
+
+// saveTokens 将 buckets 中每个令牌桶在 now 时剩余的执行次数写入数据库
+func (c *Command) saveTokens(profile string, buckets []cooldownBucket, tokens []float64, now time.Time) error {
+	for i, b := range buckets {
+		err := store.SaveCommandCooldown(profile, c.Type, store.CommandCooldown{
+			LimitKey:  b.limit.Key(),
+			Subject:   b.subject,
+			Tokens:    tokens[i],
+			UpdatedAt: now,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maxWait 返回 buckets 中所有令牌桶都可以执行一次所需的时间
+func maxWait(buckets []cooldownBucket, tokens []float64) time.Duration {
+	var result time.Duration
+
+	for i, b := range buckets {
+		result = max(result, b.wait(tokens[i]))
+	}
+
+	return result
+}
+
+// CooldownLeft 返回执行者 by 在档案 profile 下运行该指令前剩余的冷却时间，单位秒。by 为 nil 表示系统自动执行
+func (c *Command) CooldownLeft(profile string, by *int64) (int, error) {
+	buckets, err := c.buckets(by)
+
+	if err != nil || len(buckets) == 0 {
+		return 0, err
+	}
+
+	tokens, err := c.loadTokens(profile, buckets, time.Now())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(math.Ceil(maxWait(buckets, tokens).Seconds())), nil
+}
+
+// consumeCooldown 在执行者 by 适用的每个令牌桶中扣除一次执行机会。如果 check 为 true，任何一个令牌桶没有剩余执行机会时不扣除，并返回冷却中的错误。
+// 返回的函数用于在指令执行失败时退还扣除的执行机会
+func (c *Command) consumeCooldown(profile string, by *int64, check bool) (refund func(), err error) {
+	buckets, err := c.buckets(by)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buckets) == 0 {
+		return func() {}, nil
+	}
+
+	cooldownMu.Lock()
+	defer cooldownMu.Unlock()
+
+	now := time.Now()
+
+	tokens, err := c.loadTokens(profile, buckets, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if wait := maxWait(buckets, tokens); check && wait > 0 {
+		return nil, &helpers.HttpError{Code: http.StatusTooManyRequests, Details: fmt.Sprintf("该指令仍在冷却中，剩余 %d 秒", int(math.Ceil(wait.Seconds())))}
+	}
+
+	for i := range tokens {
+		tokens[i] = max(tokens[i]-1, 0)
+	}
+
+	if err := c.saveTokens(profile, buckets, tokens, now); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		cooldownMu.Lock()
+		defer cooldownMu.Unlock()
+
+		now := time.Now()
+
+		tokens, err := c.loadTokens(profile, buckets, now)
+
+		if err != nil {
+			return
+		}
+
+		for i, b := range buckets {
+			tokens[i] = min(tokens[i]+1, float64(b.limit.Count))
+		}
+
+		_ = c.saveTokens(profile, buckets, tokens, now)
+	}, nil
+}
+
+// ResetCooldown 清除该指令在档案 profile 下的所有频率限制记录，使任何执行者都可以立即运行该指令
+func (c *Command) ResetCooldown(profile string) error {
+	cooldownMu.Lock()
+	defer cooldownMu.Unlock()
+
+	_, err := store.DeleteCommandCooldowns(profile, c.Type)
+
+	return err
+}
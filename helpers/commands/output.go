@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxRecordedOutput 是执行记录中标准输出和标准错误各自保留的最大字节数。超出时只保留最后的部分，因为错误信息通常位于输出的末尾
+const maxRecordedOutput = 64 * 1024
+
+// cappedBuffer 是只保留最后 limit 字节的缓冲区，可以被多个 goroutine 同时写入
+type cappedBuffer struct {
+	mu      sync.Mutex
+	data    []byte
+	limit   int
+	dropped int
+}
+
+func newCappedBuffer(limit int) *cappedBuffer {
+	return &cappedBuffer{limit: limit}
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, p...)
+
+	if over := len(b.data) - b.limit; over > 0 {
+		b.dropped += over
+		b.data = append(b.data[:0:0], b.data[over:]...)
+	}
+
+	return len(p), nil
+}
+
+// String 返回缓冲区中的内容。如果有内容被丢弃，在开头注明被丢弃的字节数
+func (b *cappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 丢弃内容时可能截断了多字节字符
+	content := strings.ToValidUTF8(string(b.data), "")
+
+	if b.dropped > 0 {
+		return fmt.Sprintf("...（省略了前 %d 字节）\n%s", b.dropped, content)
+	}
+
+	return content
+}
+
+// Len 返回缓冲区中保留的字节数
+func (b *cappedBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.data)
+}
@@ -2,27 +2,36 @@ package commands
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/consts"
-	"github.com/mcstatus-io/mcutil/v4/status"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/remote"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
 )
 
-func StopAndArchiveServer(ctx context.Context, host string, by *int64, comment string) error {
+// StopAndArchiveServer 停止实例 inst 上正在运行的服务器（如果在线），并将服务器归档到实例所属档案的归档路径中。
+//
+// 如果 config.DeployConfig 配置了快照归档方式，则改为等待服务器退出后为实例的数据盘创建快照，参见 helpers.CreateDataDiskSnapshot。
+func StopAndArchiveServer(ctx context.Context, inst *store.Instance, by *int64, comment string) error {
 	stopServerCmd := MustGetCommand(consts.CmdTypeStopServer)
 	archiveServerCmd := MustGetCommand(consts.CmdTypeArchiveServer)
 
-	_, err := status.Modern(ctx, host, config.Cfg.GetGamePort())
-
-	if err == nil {
-		_, err := stopServerCmd.RunWithoutCooldown(ctx, host, by, &CommandRunOption{Output: true, Comment: comment})
+	if IsServerOnline(inst) {
+		_, err := stopServerCmd.RunWithoutCooldown(ctx, inst, by, &CommandRunOption{Output: true, Comment: comment})
 
 		if err != nil {
 			return err
 		}
 	}
 
-	_, err = archiveServerCmd.RunWithoutCooldown(ctx, host, by, &CommandRunOption{Output: true, Comment: comment})
+	if config.Cfg.Deploy.UseSnapshotArchive() {
+		return snapshotServer(ctx, inst)
+	}
+
+	_, err := archiveServerCmd.RunWithoutCooldown(ctx, inst, by, &CommandRunOption{Output: true, Comment: comment})
 
 	if err != nil {
 		return err
@@ -30,3 +39,87 @@ func StopAndArchiveServer(ctx context.Context, host string, by *int64, comment s
 
 	return nil
 }
+
+// snapshotServer 等待实例 inst 上的服务器退出，将文件系统缓存写入磁盘后为数据盘创建快照
+func snapshotServer(ctx context.Context, inst *store.Instance) error {
+	for IsServerOnline(inst) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(3 * time.Second):
+		}
+	}
+
+	_, err := remote.RunCommandAsProdSync(ctx, *inst.Ip, []string{"sync"}, false)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = helpers.CreateDataDiskSnapshot(ctx, inst.Profile, inst.InstanceId)
+
+	return err
+}
+
+// WarnScheduledClose 在实例 inst 上的服务器内向玩家广播服务器将在 minutes 分钟后按计划关闭。服务器不在线时不做任何事情
+func WarnScheduledClose(ctx context.Context, inst *store.Instance, minutes int) error {
+	if !IsServerOnline(inst) {
+		return nil
+	}
+
+	cmd := &Command{
+		Type:            consts.CmdTypeWarnScheduledClose,
+		ExecuteLocation: consts.ExecuteLocationServer,
+		Content:         []string{fmt.Sprintf("say 服务器将在 %d 分钟后按计划关闭，请及时保存并下线。", minutes)},
+		Timeout:         5,
+		Role:            consts.UserRoleAdmin,
+	}
+
+	_, err := cmd.RunWithoutCooldown(ctx, inst, nil, nil)
+
+	return err
+}
+
+// BroadcastScheduledMessage 在实例 inst 上的服务器内向玩家广播定时指令计划的消息 message。服务器不在线时不做任何事情
+func BroadcastScheduledMessage(ctx context.Context, inst *store.Instance, message string, comment string) error {
+	if !IsServerOnline(inst) {
+		return nil
+	}
+
+	cmd := &Command{
+		Type:            consts.CmdTypeScheduledMessage,
+		ExecuteLocation: consts.ExecuteLocationServer,
+		Content:         []string{"say {{ .Args.message }}"},
+		Timeout:         5,
+		Args:            []config.CommandArgConfig{{Name: "message", Type: config.CommandArgTypeString, Required: true}},
+		RawArgs:         true,
+		Role:            consts.UserRoleAdmin,
+	}
+
+	_, err := cmd.RunWithoutCooldown(ctx, inst, nil, &CommandRunOption{
+		Args:    map[string]string{"message": message},
+		Comment: comment,
+	})
+
+	return err
+}
+
+// RunConsoleCommand 在实例 inst 上的服务器内原样执行管理员 by 在控制台中输入的指令 line，返回指令的响应。
+// 输入的指令作为参数记录在执行记录中，参见 consts.CmdTypeConsole
+func RunConsoleCommand(ctx context.Context, inst *store.Instance, by int64, line string) (string, error) {
+	cmd := &Command{
+		Type:            consts.CmdTypeConsole,
+		ExecuteLocation: consts.ExecuteLocationServer,
+		Content:         []string{"{{ .Args.command }}"},
+		Timeout:         10,
+		Args:            []config.CommandArgConfig{{Name: "command", Type: config.CommandArgTypeString, Required: true}},
+		RawArgs:         true,
+		Role:            consts.UserRoleAdmin,
+	}
+
+	return cmd.RunWithoutCooldown(ctx, inst, &by, &CommandRunOption{
+		Args:    map[string]string{"command": line},
+		Output:  true,
+		Comment: "console",
+	})
+}
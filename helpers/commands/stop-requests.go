@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+)
+
+// stopRequests 记录每个档案最近一次由系统发出关闭服务器指令的时间，键为档案名称，用于区分服务器的主动关闭和意外退出
+var stopRequests = make(map[string]time.Time)
+
+// stopRequestsMu 保护 stopRequests 的读写
+var stopRequestsMu sync.Mutex
+
+// markStopRequested 记录档案 profile 的服务器在此时被要求关闭
+func markStopRequested(profile string) {
+	stopRequestsMu.Lock()
+	defer stopRequestsMu.Unlock()
+
+	stopRequests[profile] = time.Now()
+}
+
+// LastStopRequestedAt 返回最近一次要求关闭档案 profile 的服务器的时间，包括运行 consts.CmdTypeStopServer 以及在控制台中输入 stop。
+// 从未要求关闭时返回零值，参见 monitors.CrashWatchdog
+func LastStopRequestedAt(profile string) time.Time {
+	stopRequestsMu.Lock()
+	defer stopRequestsMu.Unlock()
+
+	return stopRequests[profile]
+}
+
+// isStopCommand 返回是否需要将运行类型为 typ、参数为 args 的指令视为关闭服务器
+func isStopCommand(typ consts.CommandType, args map[string]string) bool {
+	if typ == consts.CmdTypeStopServer {
+		return true
+	}
+
+	if typ == consts.CmdTypeConsole {
+		line := strings.TrimPrefix(strings.TrimSpace(args["command"]), "/")
+		return line == "stop" || strings.HasPrefix(line, "stop ")
+	}
+
+	return false
+}
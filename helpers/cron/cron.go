@@ -0,0 +1,233 @@
+// Package cron 提供标准五段式 cron 表达式的解析和计算。
+//
+// 表达式由空格分隔的五个字段组成，依次为分（0-59）、时（0-23）、日（1-31）、月（1-12）和周（0-7，0 和 7 均表示周日）。
+// 每个字段可以是 *、单个数值、范围 a-b、步长 */n 或 a-b/n，以及由逗号分隔的上述形式的列表。
+// 与常见实现一致，当日和周字段均不为 * 时，二者满足其一即可。
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears 是 Expr.Next 向后查找的最大年数，超过此范围仍未找到匹配时间的表达式（如 2 月 30 日）被认为永远不会触发
+const maxSearchYears = 5
+
+// field 表示一个字段的取值范围
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Expr 是解析后的 cron 表达式，每个字段以位集合表示其允许的取值
+type Expr struct {
+	source string
+
+	minute, hour, dom, month, dow uint64
+
+	// domStar 和 dowStar 表示日和周字段是否为 *，用于决定二者的组合方式
+	domStar, dowStar bool
+}
+
+// Parse 解析 cron 表达式 s
+func Parse(s string) (*Expr, error) {
+	parts := strings.Fields(s)
+
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron: expected %d fields, got %d in %q", len(fields), len(parts), s)
+	}
+
+	var bits [5]uint64
+
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+
+		if err != nil {
+			return nil, fmt.Errorf("cron: %w in %q", err, s)
+		}
+
+		bits[i] = b
+	}
+
+	// 周字段中的 7 等同于 0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &Expr{
+		source:  s,
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+// parseField 将字段文本 s 解析为位集合
+func parseField(s string, f field) (uint64, error) {
+	var result uint64
+
+	for _, item := range strings.Split(s, ",") {
+		if item == "" {
+			return 0, fmt.Errorf("empty item in %s field", f.name)
+		}
+
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			loPart, hiPart, _ := strings.Cut(rangePart, "-")
+
+			var err error
+
+			if lo, err = parseValue(loPart, f); err != nil {
+				return 0, err
+			}
+
+			if hi, err = parseValue(hiPart, f); err != nil {
+				return 0, err
+			}
+
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		default:
+			v, err := parseValue(rangePart, f)
+
+			if err != nil {
+				return 0, err
+			}
+
+			lo = v
+
+			// 形如 5/15 的写法表示从 5 开始直到最大值，每 15 取一次
+			if hasStep {
+				hi = f.max
+			} else {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			result |= 1 << v
+		}
+	}
+
+	return result, nil
+}
+
+// parseValue 解析字段中的单个数值并检查其范围
+func parseValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in %s field", v, f.min, f.max, f.name)
+	}
+
+	return v, nil
+}
+
+// ErrNeverFires 表示表达式在 maxSearchYears 年内都不会触发
+var ErrNeverFires = errors.New("cron: expression never fires")
+
+// String 返回表达式的原始文本
+func (e *Expr) String() string {
+	return e.source
+}
+
+// matchDay 返回日期 t 是否满足日和周字段
+func (e *Expr) matchDay(t time.Time) bool {
+	domMatch := e.dom&(1<<t.Day()) != 0
+	dowMatch := e.dow&(1<<int(t.Weekday())) != 0
+
+	if e.domStar || e.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// Next 返回严格晚于 t 的第一个满足表达式的时间，精确到分钟，时区与 t 相同。如果表达式永远不会触发，返回零值
+func (e *Expr) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if e.month&(1<<int(t.Month())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if !e.matchDay(t) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if e.hour&(1<<t.Hour()) == 0 {
+			// 按经过的时间前进到下一个整点，避免 time.Date 在夏令时跳过的时间段中回退
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+
+		if e.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// forward 返回 next，但当 next 落在夏令时跳过的时间段中而被 time.Date 规范化为不晚于 t 的时间时，将其向后推移直到晚于 t
+func forward(t, next time.Time) time.Time {
+	for !next.After(t) {
+		next = next.Add(time.Hour)
+	}
+
+	return next
+}
+
+// Validate 检查表达式是否会在 maxSearchYears 年内触发
+func (e *Expr) Validate() error {
+	if e.Next(time.Now()).IsZero() {
+		return ErrNeverFires
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"*/15 * * * *", false},
+		{"5/15 * * * *", false},
+		{"0 0 * * 1-5", false},
+		{"0 0 * * 7", false},
+		{"0,30 8-18/2 1,15 * 0", false},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"1,,2 * * * *", true},
+		{"a * * * *", true},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseFieldBits(t *testing.T) {
+	bitsOf := func(values ...int) uint64 {
+		var b uint64
+
+		for _, v := range values {
+			b |= 1 << v
+		}
+
+		return b
+	}
+
+	tests := []struct {
+		expr string
+		get  func(e *Expr) uint64
+		want uint64
+	}{
+		{"*/15 * * * *", func(e *Expr) uint64 { return e.minute }, bitsOf(0, 15, 30, 45)},
+		{"5/15 * * * *", func(e *Expr) uint64 { return e.minute }, bitsOf(5, 20, 35, 50)},
+		{"* 1-5 * * *", func(e *Expr) uint64 { return e.hour }, bitsOf(1, 2, 3, 4, 5)},
+		{"* 1-10/3 * * *", func(e *Expr) uint64 { return e.hour }, bitsOf(1, 4, 7, 10)},
+		{"0 0 * * 7", func(e *Expr) uint64 { return e.dow }, bitsOf(0)},
+		{"0 0 * * 0,7", func(e *Expr) uint64 { return e.dow }, bitsOf(0)},
+		{"0 0 * * 5-7", func(e *Expr) uint64 { return e.dow }, bitsOf(0, 5, 6)},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+
+		if got := tt.get(e); got != tt.want {
+			t.Errorf("Parse(%q) bits = %b, want %b", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	santiago, err := time.LoadLocation("America/Santiago")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every 15 minutes",
+			expr: "*/15 * * * *",
+			from: time.Date(2024, 5, 1, 10, 7, 30, 0, time.UTC),
+			want: time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name: "strictly after",
+			expr: "*/15 * * * *",
+			from: time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC),
+			want: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "offset step",
+			expr: "5/15 * * * *",
+			from: time.Date(2024, 5, 1, 10, 51, 0, 0, time.UTC),
+			want: time.Date(2024, 5, 1, 11, 5, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays skip weekend",
+			expr: "0 9 * * 1-5",
+			from: time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC), // 周五
+			want: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 0 * * 7",
+			from: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), // 周三
+			want: time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week",
+			expr: "0 0 13 * 5",
+			from: time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC), // 周六
+			want: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or day of week, day of month first",
+			expr: "0 0 13 * 5",
+			from: time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "across month boundary",
+			expr: "30 6 1 * *",
+			from: time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "31st skips short months",
+			expr: "0 0 31 * *",
+			from: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "across year boundary",
+			expr: "0 0 1 1 *",
+			from: time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC),
+			want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			expr: "0 0 29 2 *",
+			from: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "hourly across spring forward",
+			expr: "0 * * * *",
+			from: time.Date(2024, 3, 10, 1, 30, 0, 0, newYork),
+			want: time.Date(2024, 3, 10, 3, 0, 0, 0, newYork),
+		},
+		{
+			name: "skipped local time fires next day",
+			expr: "30 2 * * *",
+			from: time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			want: time.Date(2024, 3, 11, 2, 30, 0, 0, newYork),
+		},
+		{
+			name: "skipped midnight",
+			expr: "15 0 8-9 9 *",
+			from: time.Date(2024, 9, 7, 12, 0, 0, 0, santiago),
+			want: time.Date(2024, 9, 9, 0, 15, 0, 0, santiago),
+		},
+		{
+			name: "never fires",
+			expr: "0 0 30 2 *",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+
+			if got := e.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	e, _ := Parse("0 0 30 2 *")
+
+	if err := e.Validate(); err != ErrNeverFires {
+		t.Errorf("Validate() = %v, want ErrNeverFires", err)
+	}
+
+	e, _ = Parse("0 0 * * *")
+
+	if err := e.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package dbtest 提供一个内存中的数据库驱动，用于在测试中替换 db.Pool。
+//
+// 驱动不解析 SQL：执行的语句按顺序记录下来供测试检查，查询的结果由测试通过 DB.Query 决定。
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Exec 是一条执行过的语句
+type Exec struct {
+	Query string
+	Args  []driver.Value
+}
+
+// Rows 是一次查询的结果
+type Rows struct {
+	Columns []string
+	Values  [][]driver.Value
+}
+
+// DB 是一个记录执行语句的内存数据库
+type DB struct {
+	// Query 返回查询 query 的结果。为 nil 或返回 nil 时，查询没有结果
+	Query func(query string, args []driver.Value) (*Rows, error)
+
+	mu     sync.Mutex
+	execs  []Exec
+	lastId int64
+}
+
+// Open 返回一个使用 d 作为数据源的 *sql.DB
+func Open(d *DB) *sql.DB {
+	return sql.OpenDB(connector{d})
+}
+
+// Execs 返回所有语句中包含 substr 的已执行语句
+func (d *DB) Execs(substr string) []Exec {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var result []Exec
+
+	for _, e := range d.execs {
+		if strings.Contains(e.Query, substr) {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	result := make([]driver.Value, len(args))
+
+	for i, arg := range args {
+		result[i] = arg.Value
+	}
+
+	return result
+}
+
+type connector struct {
+	db *DB
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return conn(c), nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return drv{}
+}
+
+type drv struct{}
+
+func (drv) Open(string) (driver.Conn, error) {
+	return nil, errors.New("dbtest: use Open instead")
+}
+
+type conn struct {
+	db *DB
+}
+
+func (c conn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("dbtest: prepared statements are not supported")
+}
+
+func (c conn) Close() error {
+	return nil
+}
+
+func (c conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+func (c conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	c.db.execs = append(c.db.execs, Exec{Query: query, Args: values(args)})
+	c.db.lastId++
+
+	return result{lastId: c.db.lastId}, nil
+}
+
+func (c conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r := &Rows{}
+
+	if c.db.Query != nil {
+		got, err := c.db.Query(query, values(args))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if got != nil {
+			r = got
+		}
+	}
+
+	return &rows{columns: r.Columns, values: r.Values}, nil
+}
+
+type tx struct{}
+
+func (tx) Commit() error {
+	return nil
+}
+
+func (tx) Rollback() error {
+	return nil
+}
+
+type result struct {
+	lastId int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastId, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	// database/sql 以列数作为每行的长度，未指定列名时按第一行的长度生成
+	if r.columns == nil && len(r.values) > 0 {
+		return make([]string, len(r.values[0]))
+	}
+
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
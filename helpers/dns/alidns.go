@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Subilan/go-aliyunmc/clients"
+	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
+	openapiutil "github.com/alibabacloud-go/darabonba-openapi/v2/utils"
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// alidnsApiVersion 是云解析接口的版本
+const alidnsApiVersion = "2015-01-09"
+
+// alidnsProvider 通过云解析（Alidns）管理域名 domain 下的解析记录
+type alidnsProvider struct {
+	client *openapi.Client
+	domain string
+}
+
+type alidnsRecord struct {
+	RecordId string `json:"RecordId"`
+	RR       string `json:"RR"`
+	Type     string `json:"Type"`
+	Value    string `json:"Value"`
+	TTL      int    `json:"TTL"`
+}
+
+type describeSubDomainRecordsResponseBody struct {
+	TotalCount    int `json:"TotalCount"`
+	DomainRecords struct {
+		Record []alidnsRecord `json:"Record"`
+	} `json:"DomainRecords"`
+}
+
+func newAlidnsProvider(domain string) (*alidnsProvider, error) {
+	client, err := clients.ShouldCreateAlidnsClient()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &alidnsProvider{client: client, domain: domain}, nil
+}
+
+// call 调用云解析接口 action，如果 result 不为 nil，将响应体解析到 result 中
+func (p *alidnsProvider) call(ctx context.Context, action string, query map[string]any, result any) error {
+	req := &openapiutil.OpenApiRequest{
+		Query: openapiutil.Query(query),
+	}
+
+	params := &openapiutil.Params{
+		Action:      tea.String(action),
+		Version:     tea.String(alidnsApiVersion),
+		Protocol:    tea.String("HTTPS"),
+		Pathname:    tea.String("/"),
+		Method:      tea.String("POST"),
+		AuthType:    tea.String("AK"),
+		Style:       tea.String("RPC"),
+		ReqBodyType: tea.String("formData"),
+		BodyType:    tea.String("json"),
+	}
+
+	resp, err := p.client.CallApiWithCtx(ctx, params, req, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return dara.Convert(resp["body"], result)
+}
+
+// list 返回主机记录为 name、类型为 typ 的所有解析记录
+func (p *alidnsProvider) list(ctx context.Context, name string, typ string) ([]alidnsRecord, error) {
+	var body describeSubDomainRecordsResponseBody
+
+	err := p.call(ctx, "DescribeSubDomainRecords", map[string]any{
+		"DomainName": p.domain,
+		"SubDomain":  name + "." + p.domain,
+		"Type":       typ,
+		"PageSize":   "100",
+	}, &body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return body.DomainRecords.Record, nil
+}
+
+func (p *alidnsProvider) Upsert(ctx context.Context, r Record) error {
+	records, err := p.list(ctx, r.Name, r.Type)
+
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		return p.call(ctx, "AddDomainRecord", map[string]any{
+			"DomainName": p.domain,
+			"RR":         r.Name,
+			"Type":       r.Type,
+			"Value":      r.Value,
+			"TTL":        strconv.Itoa(r.TTL),
+		}, nil)
+	}
+
+	// 多余的记录会导致解析结果轮询到错误的地址，只保留第一条
+	for _, extra := range records[1:] {
+		if err := p.call(ctx, "DeleteDomainRecord", map[string]any{"RecordId": extra.RecordId}, nil); err != nil {
+			return err
+		}
+	}
+
+	// 记录值没有变化时，云解析会拒绝更新请求
+	if records[0].Value == r.Value && records[0].TTL == r.TTL {
+		return nil
+	}
+
+	return p.call(ctx, "UpdateDomainRecord", map[string]any{
+		"RecordId": records[0].RecordId,
+		"RR":       r.Name,
+		"Type":     r.Type,
+		"Value":    r.Value,
+		"TTL":      strconv.Itoa(r.TTL),
+	}, nil)
+}
+
+func (p *alidnsProvider) Remove(ctx context.Context, name string, typ string) error {
+	records, err := p.list(ctx, name, typ)
+
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := p.call(ctx, "DeleteDomainRecord", map[string]any{"RecordId": record.RecordId}, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+// Package dns 提供解析记录管理的抽象，以及基于云解析（Alidns）和本地 zone 文件的两种实现。
+//
+// 系统通过解析记录为每个档案提供固定的域名，在活动实例的公网IP地址变化时由 monitors.DNS 更新，参见 config.DnsConfig。
+package dns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Subilan/go-aliyunmc/config"
+)
+
+const (
+	// RecordTypeA 是 A 记录
+	RecordTypeA = "A"
+	// RecordTypeSRV 是 SRV 记录
+	RecordTypeSRV = "SRV"
+)
+
+// SrvPrefix 是 Minecraft 服务器 SRV 记录的主机记录前缀
+const SrvPrefix = "_minecraft._tcp."
+
+// Record 表示一条解析记录
+type Record struct {
+	// Name 是主机记录，相对于 config.DnsConfig.Domain，例如 mc 或 _minecraft._tcp.mc
+	Name string
+
+	// Type 是记录类型，取值为 RecordTypeA 或 RecordTypeSRV
+	Type string
+
+	// Value 是记录值。SRV 记录的值格式为“优先级 权重 端口 目标”，目标为不以点结尾的完整域名，参见 SrvValue
+	Value string
+
+	// TTL 是记录的生存时间，单位秒
+	TTL int
+}
+
+// SrvValue 返回指向 target 的 port 端口的 SRV 记录值
+func SrvValue(port uint16, target string) string {
+	return fmt.Sprintf("0 5 %d %s", port, target)
+}
+
+// Provider 是管理解析记录的方式
+type Provider interface {
+	// Upsert 使主机记录为 r.Name、类型为 r.Type 的解析记录有且只有一条，且其值为 r.Value
+	Upsert(ctx context.Context, r Record) error
+
+	// Remove 删除主机记录为 name、类型为 typ 的所有解析记录。记录不存在时不返回错误
+	Remove(ctx context.Context, name string, typ string) error
+}
+
+// Current 是系统使用的解析记录管理方式。未启用解析记录管理时为 nil
+var Current Provider
+
+// Init 根据 config.DnsConfig 初始化 Current
+func Init() error {
+	cfg := config.Cfg.Dns
+
+	switch cfg.Provider {
+	case config.DnsProviderAlidns:
+		p, err := newAlidnsProvider(cfg.Domain)
+
+		if err != nil {
+			return err
+		}
+
+		Current = p
+	case config.DnsProviderFile:
+		p, err := newFileProvider(cfg.FilePath, cfg.Domain)
+
+		if err != nil {
+			return err
+		}
+
+		Current = p
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fileProvider 将域名 domain 下的解析记录以 zone 文件格式写入本地文件 path，供 CoreDNS、BIND 等本地 DNS 服务器加载。
+// 该文件完全由系统管理，每次更新都会重写整个文件，手动添加的内容会被覆盖。
+type fileProvider struct {
+	mu      sync.Mutex
+	path    string
+	domain  string
+	records map[recordKey]Record
+}
+
+type recordKey struct {
+	name string
+	typ  string
+}
+
+func newFileProvider(path string, domain string) (*fileProvider, error) {
+	p := &fileProvider{path: path, domain: domain, records: make(map[recordKey]Record)}
+
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// fqdn 将相对于 domain 的主机记录 name 转换为以点结尾的完整域名
+func (p *fileProvider) fqdn(name string) string {
+	return name + "." + p.domain + "."
+}
+
+// load 读取文件中已有的解析记录。文件不存在时视为没有记录
+func (p *fileProvider) load() error {
+	f, err := os.Open(p.path)
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		// 格式为：完整域名 TTL IN 类型 值
+		fields := strings.Fields(line)
+
+		if len(fields) < 5 || fields[2] != "IN" {
+			return fmt.Errorf("dns: malformed line in %s: %q", p.path, line)
+		}
+
+		name, ok := strings.CutSuffix(fields[0], "."+p.domain+".")
+
+		if !ok {
+			continue
+		}
+
+		ttl, err := strconv.Atoi(fields[1])
+
+		if err != nil {
+			return fmt.Errorf("dns: malformed ttl in %s: %q", p.path, line)
+		}
+
+		value := strings.Join(fields[4:], " ")
+
+		if fields[3] == RecordTypeSRV {
+			value = strings.TrimSuffix(value, ".")
+		}
+
+		p.records[recordKey{name, fields[3]}] = Record{Name: name, Type: fields[3], Value: value, TTL: ttl}
+	}
+
+	return scanner.Err()
+}
+
+// save 将所有解析记录写入文件。先写入临时文件再重命名，避免 DNS 服务器读到不完整的文件
+func (p *fileProvider) save() error {
+	keys := make([]recordKey, 0, len(p.records))
+
+	for k := range p.records {
+		keys = append(keys, k)
+	}
+
+	slices.SortFunc(keys, func(a, b recordKey) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.typ, b.typ)
+	})
+
+	var b strings.Builder
+
+	b.WriteString("; This file is managed by go-aliyunmc. Manual changes will be overwritten.\n")
+
+	for _, k := range keys {
+		r := p.records[k]
+		value := r.Value
+
+		if r.Type == RecordTypeSRV {
+			value += "."
+		}
+
+		_, _ = fmt.Fprintf(&b, "%s %d IN %s %s\n", p.fqdn(r.Name), r.TTL, r.Type, value)
+	}
+
+	tmp := p.path + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, p.path)
+}
+
+func (p *fileProvider) Upsert(_ context.Context, r Record) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := recordKey{r.Name, r.Type}
+
+	if existing, ok := p.records[key]; ok && existing == r {
+		return nil
+	}
+
+	p.records[key] = r
+
+	return p.save()
+}
+
+func (p *fileProvider) Remove(_ context.Context, name string, typ string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := recordKey{name, typ}
+
+	if _, ok := p.records[key]; !ok {
+		return nil
+	}
+
+	delete(p.records, key)
+
+	return p.save()
+}
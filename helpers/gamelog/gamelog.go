@@ -0,0 +1,122 @@
+// Package gamelog 将服务器日志中的行解析为结构化的游戏事件，例如聊天、进出服务器、死亡、进度以及服务器警告，参见 monitors.GameLog
+package gamelog
+
+import (
+	"regexp"
+	"sync"
+)
+
+const (
+	// KindChat 表示玩家在聊天栏发送的消息
+	KindChat = "chat"
+	// KindJoin 表示玩家进入服务器，带有玩家的IP地址
+	KindJoin = "join"
+	// KindLeave 表示玩家离开服务器
+	KindLeave = "leave"
+	// KindDeath 表示玩家死亡，消息为完整的死亡信息
+	KindDeath = "death"
+	// KindAdvancement 表示玩家取得进度、完成挑战或者达成目标，消息为进度的名称
+	KindAdvancement = "advancement"
+	// KindWarning 表示服务器的警告，例如服务器过载，消息为完整的警告内容
+	KindWarning = "warning"
+)
+
+// Event 是从服务器日志的一行中解析出的游戏事件
+type Event struct {
+	Kind    string
+	Player  string
+	IP      string
+	Message string
+}
+
+// Parser 将服务器日志的一行解析为游戏事件。不是游戏事件的行返回 false
+type Parser interface {
+	Parse(line string) (*Event, bool)
+}
+
+// Rule 是 RegexParser 的一条规则。Pattern 匹配去掉前缀后的日志内容，可以使用命名分组 player、ip 和 message 提取事件的字段
+type Rule struct {
+	Kind string
+
+	// Level 是规则要求的日志级别，例如 INFO 或 WARN，留空表示不限制
+	Level string
+
+	Pattern *regexp.Regexp
+}
+
+// RegexParser 是基于正则表达式的 Parser。Prefix 匹配日志行的时间、线程和级别等前缀，可以使用命名分组 level 提取日志级别；
+// 去掉前缀后的内容按顺序与 Rules 匹配，第一条匹配的规则决定事件的类型
+type RegexParser struct {
+	Prefix *regexp.Regexp
+	Rules  []Rule
+}
+
+func (p *RegexParser) Parse(line string) (*Event, bool) {
+	prefix := p.Prefix.FindStringSubmatchIndex(line)
+
+	if prefix == nil {
+		return nil, false
+	}
+
+	var level string
+
+	if i := p.Prefix.SubexpIndex("level"); i >= 0 && prefix[2*i] >= 0 {
+		level = line[prefix[2*i]:prefix[2*i+1]]
+	}
+
+	content := line[prefix[1]:]
+
+	for _, rule := range p.Rules {
+		if rule.Level != "" && rule.Level != level {
+			continue
+		}
+
+		match := rule.Pattern.FindStringSubmatch(content)
+
+		if match == nil {
+			continue
+		}
+
+		event := &Event{Kind: rule.Kind}
+
+		for i, name := range rule.Pattern.SubexpNames() {
+			switch name {
+			case "player":
+				event.Player = match[i]
+			case "ip":
+				event.IP = match[i]
+			case "message":
+				event.Message = match[i]
+			}
+		}
+
+		return event, true
+	}
+
+	return nil, false
+}
+
+var (
+	parsers   = make(map[string]Parser)
+	parsersMu sync.RWMutex
+)
+
+// Register 注册服务器类型 flavor 的解析器，覆盖已经注册的解析器。flavor 与 config.ServerConfig.Flavor 的取值一致
+func Register(flavor string, parser Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	parsers[flavor] = parser
+}
+
+// ParserFor 返回服务器类型 flavor 的解析器。没有为该类型注册解析器时，使用原版服务器的解析器
+func ParserFor(flavor string) Parser {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	if parser, ok := parsers[flavor]; ok {
+		return parser
+	}
+
+	return parsers[flavorVanilla]
+}
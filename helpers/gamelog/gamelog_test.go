@@ -0,0 +1,218 @@
+package gamelog
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		flavor string
+		line   string
+		want   *Event
+	}{
+		{
+			name:   "chat",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: <Steve> hello world",
+			want:   &Event{Kind: KindChat, Player: "Steve", Message: "hello world"},
+		},
+		{
+			name:   "unsigned chat",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: [Not Secure] <Alex_01> hi",
+			want:   &Event{Kind: KindChat, Player: "Alex_01", Message: "hi"},
+		},
+		{
+			name:   "chat that reads like a death",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: <Steve> Alex was slain by Zombie",
+			want:   &Event{Kind: KindChat, Player: "Steve", Message: "Alex was slain by Zombie"},
+		},
+		{
+			name:   "join",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve[/203.0.113.7:51234] logged in with entity id 123 at (0.5, 64.0, 0.5)",
+			want:   &Event{Kind: KindJoin, Player: "Steve", IP: "203.0.113.7"},
+		},
+		{
+			name:   "join over ipv6",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve[/[2001:db8::1]:51234] logged in with entity id 123 at (0.5, 64.0, 0.5)",
+			want:   &Event{Kind: KindJoin, Player: "Steve", IP: "2001:db8::1"},
+		},
+		{
+			name:   "leave",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve left the game",
+			want:   &Event{Kind: KindLeave, Player: "Steve"},
+		},
+		{
+			name:   "advancement",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve has made the advancement [Stone Age]",
+			want:   &Event{Kind: KindAdvancement, Player: "Steve", Message: "Stone Age"},
+		},
+		{
+			name:   "challenge",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve has completed the challenge [Return to Sender]",
+			want:   &Event{Kind: KindAdvancement, Player: "Steve", Message: "Return to Sender"},
+		},
+		{
+			name:   "overloaded",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/WARN]: Can't keep up! Is the server overloaded? Running 2013ms or 40 ticks behind",
+			want:   &Event{Kind: KindWarning, Message: "Can't keep up! Is the server overloaded? Running 2013ms or 40 ticks behind"},
+		},
+		{
+			name:   "moved too quickly",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/WARN]: Steve moved too quickly! 12.3,0.0,4.5",
+			want:   &Event{Kind: KindWarning, Player: "Steve", Message: "Steve moved too quickly! 12.3,0.0,4.5"},
+		},
+		{
+			name:   "death by mob",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve was slain by Zombie",
+			want:   &Event{Kind: KindDeath, Player: "Steve", Message: "Steve was slain by Zombie"},
+		},
+		{
+			name:   "death by player using item",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve was shot by Alex using [Bow of Doom]",
+			want:   &Event{Kind: KindDeath, Player: "Steve", Message: "Steve was shot by Alex using [Bow of Doom]"},
+		},
+		{
+			name:   "death by fall",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve fell from a high place",
+			want:   &Event{Kind: KindDeath, Player: "Steve", Message: "Steve fell from a high place"},
+		},
+		{
+			name:   "death with escape suffix",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve hit the ground too hard while trying to escape Creeper",
+			want:   &Event{Kind: KindDeath, Player: "Steve", Message: "Steve hit the ground too hard while trying to escape Creeper"},
+		},
+		{
+			name:   "death generic",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve died",
+			want:   &Event{Kind: KindDeath, Player: "Steve", Message: "Steve died"},
+		},
+		{
+			name:   "death in flames",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve went up in flames",
+			want:   &Event{Kind: KindDeath, Player: "Steve", Message: "Steve went up in flames"},
+		},
+		{
+			name:   "paper console prefix",
+			flavor: flavorPaper,
+			line:   "[12:34:56 INFO]: Steve drowned",
+			want:   &Event{Kind: KindDeath, Player: "Steve", Message: "Steve drowned"},
+		},
+		{
+			name:   "paper log file prefix",
+			flavor: flavorPaper,
+			line:   "[12:34:56] [Server thread/INFO]: Steve left the game",
+			want:   &Event{Kind: KindLeave, Player: "Steve"},
+		},
+		{
+			name:   "forge prefix",
+			flavor: flavorForge,
+			line:   "[12Jan2024 12:34:56.789] [Server thread/INFO] [net.minecraft.server.MinecraftServer/]: Steve left the game",
+			want:   &Event{Kind: KindLeave, Player: "Steve"},
+		},
+		{
+			name:   "unknown flavor falls back to vanilla",
+			flavor: "fabric",
+			line:   "[12:34:56] [Server thread/INFO]: Steve left the game",
+			want:   &Event{Kind: KindLeave, Player: "Steve"},
+		},
+		{
+			name:   "went afk",
+			flavor: flavorPaper,
+			line:   "[12:34:56 INFO]: Steve went AFK",
+		},
+		{
+			name:   "kicked",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve was kicked for floating too long!",
+		},
+		{
+			name:   "joined the game",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve joined the game",
+		},
+		{
+			name:   "lost connection",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve lost connection: Disconnected",
+		},
+		{
+			name:   "fell asleep",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Steve fell asleep",
+		},
+		{
+			name:   "death text at wrong level",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/WARN]: Steve was slain by Zombie",
+		},
+		{
+			name:   "warning at wrong level",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Can't keep up! Is the server overloaded?",
+		},
+		{
+			name:   "no prefix",
+			flavor: flavorVanilla,
+			line:   "Steve was slain by Zombie",
+		},
+		{
+			name:   "startup",
+			flavor: flavorVanilla,
+			line:   "[12:34:56] [Server thread/INFO]: Done (3.210s)! For help, type \"help\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParserFor(tt.flavor).Parse(tt.line)
+
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("Parse() = %+v, want no event", *got)
+				}
+
+				return
+			}
+
+			if !ok {
+				t.Fatalf("Parse() returned no event, want %+v", *tt.want)
+			}
+
+			if *got != *tt.want {
+				t.Errorf("Parse() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	custom := &RegexParser{Prefix: regexp.MustCompile(`^`)}
+
+	Register("custom", custom)
+	t.Cleanup(func() {
+		parsersMu.Lock()
+		delete(parsers, "custom")
+		parsersMu.Unlock()
+	})
+
+	if ParserFor("custom") != custom {
+		t.Error("ParserFor() does not return the registered parser")
+	}
+}
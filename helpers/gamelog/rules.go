@@ -0,0 +1,104 @@
+package gamelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 与 config.ServerFlavorVanilla 等取值一致。此处不引用 config，以免解析器依赖配置文件的加载
+const (
+	flavorVanilla = "vanilla"
+	flavorPaper   = "paper"
+	flavorForge   = "forge"
+)
+
+// playerName 匹配 Minecraft 玩家名称
+const playerName = `(?P<player>[A-Za-z0-9_]{1,16})`
+
+// deathMessages 是原版语言文件中以 death. 开头的条目去掉开头玩家名称后的部分，其它实体或物品的名称以 .+ 代替。
+// 带有 while fighting、while trying to escape 等后缀的变体与不带后缀的条目合并为可选分组
+var deathMessages = []string{
+	`was squashed by .+`,
+	`was squished too much`,
+	`was shot by .+`,
+	`was killed`,
+	`was killed by .+`,
+	`was killed while trying to hurt .+`,
+	`was pricked to death`,
+	`walked into a cactus while trying to escape .+`,
+	`was roasted in dragon's breath(?: by .+)?`,
+	`drowned(?: while trying to escape .+)?`,
+	`died`,
+	`died because of .+`,
+	`died from dehydration(?: while trying to escape .+)?`,
+	`blew up`,
+	`was blown up by .+`,
+	`hit the ground too hard(?: while trying to escape .+)?`,
+	`was skewered by a falling stalactite(?: while fighting .+)?`,
+	`was fireballed by .+`,
+	`went off with a bang(?: due to a firework fired from .+)?`,
+	`experienced kinetic energy(?: while trying to escape .+)?`,
+	`froze to death`,
+	`was frozen to death by .+`,
+	`discovered the floor was lava`,
+	`walked into the danger zone due to .+`,
+	`went up in flames`,
+	`walked into fire while fighting .+`,
+	`suffocated in a wall(?: while fighting .+)?`,
+	`tried to swim in lava(?: to escape .+)?`,
+	`was struck by lightning(?: while fighting .+)?`,
+	`was smashed by .+`,
+	`was slain by .+`,
+	`burned to death`,
+	`was burned to a crisp while fighting .+`,
+	`fell out of the world`,
+	`didn't want to live in the same world as .+`,
+	`left the confines of this world(?: while fighting .+)?`,
+	`was obliterated by a sonically-charged shriek(?: while trying to escape .+)?`,
+	`was impaled on a stalagmite(?: while fighting .+)?`,
+	`was impaled by .+`,
+	`starved to death(?: while fighting .+)?`,
+	`was stung to death(?: by .+)?`,
+	`was poked to death by a sweet berry bush(?: while trying to escape .+)?`,
+	`was pummeled by .+`,
+	`withered away(?: while fighting .+)?`,
+	`fell from a high place`,
+	`fell off a ladder`,
+	`fell off some (?:vines|weeping vines|twisting vines)`,
+	`fell off scaffolding`,
+	`fell while climbing`,
+	`fell out of the water`,
+	`fell too far and was finished by .+`,
+	`was doomed to fall(?: by .+)?`,
+}
+
+// commonRules 是原版、Paper 和 Forge 服务器共同的规则。死亡信息的规则放在最后，以免与其它规则冲突
+var commonRules = []Rule{
+	{Kind: KindChat, Pattern: regexp.MustCompile(`^(?:\[Not Secure\] )?<` + playerName + `> (?P<message>.*)$`)},
+	{Kind: KindJoin, Level: "INFO", Pattern: regexp.MustCompile(`^` + playerName + `\[/\[?(?P<ip>[0-9A-Fa-f.:]+?)\]?:\d+\] logged in with entity id`)},
+	{Kind: KindLeave, Level: "INFO", Pattern: regexp.MustCompile(`^` + playerName + ` left the game$`)},
+	{Kind: KindAdvancement, Level: "INFO", Pattern: regexp.MustCompile(`^` + playerName + ` has (?:made the advancement|completed the challenge|reached the goal) \[(?P<message>.+)\]$`)},
+	{Kind: KindWarning, Level: "WARN", Pattern: regexp.MustCompile(`^(?P<message>Can't keep up!.*)$`)},
+	{Kind: KindWarning, Level: "WARN", Pattern: regexp.MustCompile(`^(?P<message>` + playerName + ` moved (?:too quickly|wrongly)!.*)$`)},
+	{Kind: KindDeath, Level: "INFO", Pattern: regexp.MustCompile(`^(?P<message>` + playerName + ` (?:` + strings.Join(deathMessages, "|") + `))$`)},
+}
+
+func init() {
+	// [12:34:56] [Server thread/INFO]: ...
+	Register(flavorVanilla, &RegexParser{
+		Prefix: regexp.MustCompile(`^\[\d{2}:\d{2}:\d{2}\] \[[^\]]*/(?P<level>[A-Z]+)\]: `),
+		Rules:  commonRules,
+	})
+
+	// 日志文件中与原版相同，控制台中为 [12:34:56 INFO]: ...
+	Register(flavorPaper, &RegexParser{
+		Prefix: regexp.MustCompile(`^\[\d{2}:\d{2}:\d{2}(?:\] \[[^\]]*/| )(?P<level>[A-Z]+)\]: `),
+		Rules:  commonRules,
+	})
+
+	// [12Jan2024 12:34:56.789] [Server thread/INFO] [net.minecraft.server.MinecraftServer/]: ...
+	Register(flavorForge, &RegexParser{
+		Prefix: regexp.MustCompile(`^\[[^\]]+\] \[[^\]]*/(?P<level>[A-Z]+)\] \[[^\]]*\]: `),
+		Rules:  commonRules,
+	})
+}
@@ -43,3 +43,8 @@ func ShouldGetUserId(c *gin.Context) (int64, error) {
 
 	return result, nil
 }
+
+// GetProfile 返回由 mid.Profile 存储到上下文中的档案名称。如果上下文中没有档案名称，返回空字符串
+func GetProfile(c *gin.Context) string {
+	return c.GetString("profile")
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/clients"
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// BakeImageTimeout 是制作一次预制镜像的最长时间，包括创建系统盘快照和等待镜像可用
+const BakeImageTimeout = 30 * time.Minute
+
+// imagePollInterval 是等待镜像可用时轮询镜像状态的间隔
+const imagePollInterval = 10 * time.Second
+
+// bakeImageMutex 避免多个档案同时部署成功时重复制作预制镜像
+var bakeImageMutex sync.Mutex
+
+// GetAvailableBakedImageId 返回与当前配置指纹一致的可用预制镜像。如果没有可用的预制镜像，返回空字符串
+func GetAvailableBakedImageId() (string, error) {
+	image, err := store.GetBakedImage(config.Cfg.ImageFingerprint(), store.BakedImageStatusAvailable)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return image.ImageId, nil
+}
+
+// waitImageAvailable 阻塞直到镜像 imageId 可用、创建失败或 ctx 结束
+func waitImageAvailable(ctx context.Context, imageId string) error {
+	describeImagesRequest := &client.DescribeImagesRequest{
+		RegionId: tea.String(config.Cfg.Aliyun.RegionId),
+		ImageId:  tea.String(imageId),
+		Status:   tea.String("Creating,Waiting,Available,UnAvailable,CreateFailed"),
+	}
+
+	for {
+		describeImagesResponse, err := clients.EcsClient.DescribeImagesWithContext(ctx, describeImagesRequest, &dara.RuntimeOptions{})
+
+		if err != nil {
+			return err
+		}
+
+		if describeImagesResponse.Body.Images != nil && len(describeImagesResponse.Body.Images.Image) > 0 {
+			switch tea.StringValue(describeImagesResponse.Body.Images.Image[0].Status) {
+			case "Available":
+				return nil
+			case "CreateFailed", "UnAvailable":
+				return errors.New("image " + imageId + " cannot be created")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(imagePollInterval):
+		}
+	}
+}
+
+// BakeImage 为刚刚部署成功的实例 instanceId 的系统盘制作预制镜像，并阻塞直到镜像可用。如果已经存在与当前配置指纹一致的预制镜像（包括正在制作中的），则不做任何操作。
+//
+// 预制镜像只包含系统盘，即部署脚本中用户创建、软件源、Java 和 ossutil 等步骤的结果，不包含数据盘。
+func BakeImage(ctx context.Context, instanceId string) error {
+	if !bakeImageMutex.TryLock() {
+		return nil
+	}
+
+	defer bakeImageMutex.Unlock()
+
+	fingerprint := config.Cfg.ImageFingerprint()
+
+	for _, status := range []string{store.BakedImageStatusAvailable, store.BakedImageStatusCreating} {
+		_, err := store.GetBakedImage(fingerprint, status)
+
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	diskId, _, err := describeDisk(ctx, instanceId, "system")
+
+	if err != nil {
+		return err
+	}
+
+	name := "aliyunmc-" + fingerprint + "-" + time.Now().Format("20060102-150405")
+
+	snapshotId, err := createSnapshot(ctx, diskId, name, "System disk of baked image "+fingerprint)
+
+	if err != nil {
+		return err
+	}
+
+	createImageRequest := &client.CreateImageRequest{
+		RegionId:    tea.String(config.Cfg.Aliyun.RegionId),
+		SnapshotId:  tea.String(snapshotId),
+		ImageName:   tea.String(name),
+		Description: tea.String("Baked image with fingerprint " + fingerprint),
+	}
+
+	createImageResponse, err := clients.EcsClient.CreateImageWithContext(ctx, createImageRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return err
+	}
+
+	imageId := tea.StringValue(createImageResponse.Body.ImageId)
+
+	if err := store.InsertBakedImage(imageId, fingerprint, instanceId, snapshotId); err != nil {
+		return err
+	}
+
+	status := store.BakedImageStatusAvailable
+	waitErr := waitImageAvailable(ctx, imageId)
+
+	if waitErr != nil {
+		status = store.BakedImageStatusFailed
+	}
+
+	if err := store.UpdateBakedImageStatus(imageId, status); err != nil {
+		return err
+	}
+
+	if err := PruneStaleBakedImages(ctx); err != nil {
+		log.Println("cannot prune stale baked images:", err)
+	}
+
+	return waitErr
+}
+
+// PruneStaleBakedImages 删除与当前配置指纹不一致或制作失败的预制镜像及其依赖的系统盘快照
+func PruneStaleBakedImages(ctx context.Context) error {
+	images, err := store.GetStaleBakedImages(config.Cfg.ImageFingerprint())
+
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		deleteImageRequest := &client.DeleteImageRequest{
+			RegionId: tea.String(config.Cfg.Aliyun.RegionId),
+			ImageId:  tea.String(image.ImageId),
+			Force:    tea.Bool(false),
+		}
+
+		_, err := clients.EcsClient.DeleteImageWithContext(ctx, deleteImageRequest, &dara.RuntimeOptions{})
+
+		if err != nil && AliyunErrorCode(err) != "InvalidImageId.NotFound" {
+			return err
+		}
+
+		if err := deleteSnapshot(ctx, image.SnapshotId); err != nil {
+			return err
+		}
+
+		if err := store.MarkBakedImageDeleted(image.ImageId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
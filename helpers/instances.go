@@ -8,12 +8,13 @@ import (
 	"github.com/Subilan/go-aliyunmc/events"
 	"github.com/Subilan/go-aliyunmc/events/stream"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/rcon"
 	"github.com/alibabacloud-go/ecs-20140526/v7/client"
 	"github.com/alibabacloud-go/tea/dara"
 )
 
-// DeleteInstance 完成一次删除实例的业务流程，包括调用API进行删除、更新数据库以及向用户广播删除行为。
-func DeleteInstance(ctx context.Context, instanceId string, force bool) error {
+// DeleteInstance 完成一次删除档案 profile 下实例的业务流程，包括调用API进行删除、更新数据库以及向用户广播删除行为。
+func DeleteInstance(ctx context.Context, profile string, instanceId string, force bool) error {
 	deleteInstanceRequest := &client.DeleteInstanceRequest{
 		InstanceId: &instanceId,
 		Force:      &force,
@@ -26,6 +27,13 @@ func DeleteInstance(ctx context.Context, instanceId string, force bool) error {
 		return err
 	}
 
+	// 关闭实例IP地址上的 RCON 会话
+	var ip *string
+
+	if db.Pool.QueryRowContext(ctx, "SELECT ip FROM instances WHERE instance_id = ?", instanceId).Scan(&ip) == nil && ip != nil {
+		rcon.Close(*ip)
+	}
+
 	_, err = db.Pool.ExecContext(ctx, "UPDATE instances SET deleted_at = CURRENT_TIMESTAMP WHERE instance_id = ?", instanceId)
 
 	if err != nil {
@@ -33,7 +41,7 @@ func DeleteInstance(ctx context.Context, instanceId string, force bool) error {
 	}
 
 	// 将实例删除广播给所有用户
-	event := events.Instance(events.InstanceEventNotify, events.InstanceNotificationDeleted, true)
+	event := events.Instance(profile, events.InstanceEventNotify, events.InstanceNotificationDeleted, true)
 	err = stream.BroadcastAndSave(event)
 
 	if err != nil {
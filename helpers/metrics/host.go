@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// HostDataPath 是统计磁盘使用情况的路径，即服务器所在的数据盘
+const HostDataPath = "/home/mc"
+
+// Host 是实例的资源使用情况
+type Host struct {
+	// CPU 是采样期间的 CPU 使用率，取值为 0 到 100
+	CPU float64
+
+	// MemUsed 和 MemTotal 是已用和总内存，单位字节。已用内存不包括可以回收的缓存
+	MemUsed  int64
+	MemTotal int64
+
+	// Load1、Load5 和 Load15 是最近 1、5、15 分钟的平均负载
+	Load1  float64
+	Load5  float64
+	Load15 float64
+
+	// DiskUsed 和 DiskTotal 是 HostDataPath 所在文件系统的已用和总空间，单位字节
+	DiskUsed  int64
+	DiskTotal int64
+}
+
+// HostCommands 是采集实例资源使用情况的指令，以生产身份执行，输出传入 ParseHost。CPU 使用率通过间隔一秒读取两次 /proc/stat 计算
+var HostCommands = []string{
+	"echo cpu0 $(head -n1 /proc/stat)",
+	"sleep 1",
+	"echo cpu1 $(head -n1 /proc/stat)",
+	"echo mem $(grep -E '^(MemTotal|MemAvailable):' /proc/meminfo | awk '{print $2}')",
+	"echo load $(cat /proc/loadavg)",
+	"echo disk $(df -B1 --output=used,size " + HostDataPath + " | tail -n1)",
+}
+
+// cpuTimes 解析 /proc/stat 的 cpu 行，返回总时间和空闲时间（包括 iowait）
+func cpuTimes(fields []string) (total float64, idle float64, err error) {
+	// fields 为 cpu user nice system idle iowait irq softirq steal ...
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, errors.New("invalid cpu line")
+	}
+
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseFloat(f, 64)
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		// guest 和 guest_nice 已经包含在 user 和 nice 中
+		if i >= 8 {
+			break
+		}
+
+		total += v
+
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+
+	return total, idle, nil
+}
+
+func parseFloats(fields []string, n int) ([]float64, error) {
+	if len(fields) < n {
+		return nil, errors.New("not enough fields")
+	}
+
+	result := make([]float64, n)
+
+	for i := range n {
+		v, err := strconv.ParseFloat(fields[i], 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result[i] = v
+	}
+
+	return result, nil
+}
+
+// ParseHost 解析执行 HostCommands 得到的输出
+func ParseHost(output string) (*Host, error) {
+	var result Host
+	var cpu [2][]string
+	var seen int
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "cpu0":
+			cpu[0] = fields[1:]
+		case "cpu1":
+			cpu[1] = fields[1:]
+		case "mem":
+			var v []float64
+
+			if v, err = parseFloats(fields[1:], 2); err == nil {
+				result.MemTotal = int64(v[0]) * 1024
+				result.MemUsed = result.MemTotal - int64(v[1])*1024
+				seen++
+			}
+		case "load":
+			var v []float64
+
+			if v, err = parseFloats(fields[1:], 3); err == nil {
+				result.Load1, result.Load5, result.Load15 = v[0], v[1], v[2]
+				seen++
+			}
+		case "disk":
+			var v []float64
+
+			if v, err = parseFloats(fields[1:], 2); err == nil {
+				result.DiskUsed, result.DiskTotal = int64(v[0]), int64(v[1])
+				seen++
+			}
+		}
+
+		if err != nil {
+			return nil, errors.New("cannot parse " + fields[0] + ": " + err.Error())
+		}
+	}
+
+	if seen != 3 {
+		return nil, errors.New("incomplete host metrics output")
+	}
+
+	total0, idle0, err := cpuTimes(cpu[0])
+
+	if err != nil {
+		return nil, errors.New("cannot parse cpu: " + err.Error())
+	}
+
+	total1, idle1, err := cpuTimes(cpu[1])
+
+	if err != nil {
+		return nil, errors.New("cannot parse cpu: " + err.Error())
+	}
+
+	if total1 > total0 {
+		result.CPU = 100 * (1 - (idle1-idle0)/(total1-total0))
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseHost(t *testing.T) {
+	const output = `cpu0 cpu 1000 0 500 8000 500 0 0 0 0 0
+cpu1 cpu 1300 0 600 8500 600 0 0 0 0 0
+mem 8000000 6000000
+load 0.52 0.61 0.70 1/234 5678
+disk 10737418240 107374182400
+`
+
+	got, err := ParseHost(output)
+
+	if err != nil {
+		t.Fatalf("ParseHost() error = %v", err)
+	}
+
+	// 两次采样之间总时间增加 1000，空闲（idle + iowait）增加 600
+	if math.Abs(got.CPU-40) > 1e-9 {
+		t.Errorf("CPU = %v, want 40", got.CPU)
+	}
+
+	want := Host{
+		CPU:       got.CPU,
+		MemUsed:   2000000 * 1024,
+		MemTotal:  8000000 * 1024,
+		Load1:     0.52,
+		Load5:     0.61,
+		Load15:    0.70,
+		DiskUsed:  10737418240,
+		DiskTotal: 107374182400,
+	}
+
+	if *got != want {
+		t.Errorf("ParseHost() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestParseHostIgnoresGuestTime(t *testing.T) {
+	// guest 和 guest_nice 已计入 user 和 nice，不应重复计入总时间
+	output := `cpu0 cpu 100 0 0 100 0 0 0 0 9999 9999
+cpu1 cpu 200 0 0 200 0 0 0 0 19999 19999
+mem 1 1
+load 0 0 0
+disk 0 1
+`
+
+	got, err := ParseHost(output)
+
+	if err != nil {
+		t.Fatalf("ParseHost() error = %v", err)
+	}
+
+	if math.Abs(got.CPU-50) > 1e-9 {
+		t.Errorf("CPU = %v, want 50", got.CPU)
+	}
+}
+
+func TestParseHostErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"empty", ""},
+		{"missing disk", "cpu0 cpu 1 1 1 1\ncpu1 cpu 2 2 2 2\nmem 1 1\nload 0 0 0\n"},
+		{"missing cpu", "mem 1 1\nload 0 0 0\ndisk 0 1\n"},
+		{"bad cpu line", "cpu0 intr 1 2 3 4\ncpu1 cpu 2 2 2 2\nmem 1 1\nload 0 0 0\ndisk 0 1\n"},
+		{"bad mem", "cpu0 cpu 1 1 1 1\ncpu1 cpu 2 2 2 2\nmem x 1\nload 0 0 0\ndisk 0 1\n"},
+		{"short load", "cpu0 cpu 1 1 1 1\ncpu1 cpu 2 2 2 2\nmem 1 1\nload 0 0\ndisk 0 1\n"},
+		{"df error", "cpu0 cpu 1 1 1 1\ncpu1 cpu 2 2 2 2\nmem 1 1\nload 0 0 0\ndisk df: /home/mc: No such file or directory\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParseHost(tt.output); err == nil {
+				t.Errorf("ParseHost() = %+v, want error", got)
+			}
+		})
+	}
+}
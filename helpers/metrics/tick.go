@@ -0,0 +1,142 @@
+// Package metrics 提供从服务器指令响应和实例的 /proc 信息中解析性能指标的功能，参见 monitors.Metrics
+package metrics
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Subilan/go-aliyunmc/config"
+)
+
+// Tick 是服务器的 tick 性能
+type Tick struct {
+	// TPS 是最近一分钟的平均每秒 tick 数
+	TPS float64
+
+	// MSPT 是最近的平均每 tick 耗时，单位毫秒
+	MSPT float64
+}
+
+// formattingCodePattern 匹配 Minecraft 的格式化代码，例如 §a
+var formattingCodePattern = regexp.MustCompile(`§.`)
+
+// numberPattern 匹配响应中的数字
+var numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+var (
+	// paperTpsPattern 匹配 Paper tps 指令的响应，例如 TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0
+	paperTpsPattern = regexp.MustCompile(`TPS from last 1m, 5m, 15m:\s*\*?(\d+(?:\.\d+)?)`)
+
+	// forgeOverallPattern 匹配 Forge forge tps 指令响应中的总体一行，例如 Overall: Mean tick time: 1.234 ms. Mean TPS: 20.000
+	forgeOverallPattern = regexp.MustCompile(`Overall\s*:\s*Mean tick time:\s*(\d+(?:\.\d+)?)\s*ms\.\s*Mean TPS:\s*(\d+(?:\.\d+)?)`)
+
+	// vanillaTickPattern 匹配原版 tick query 指令响应中的平均耗时，例如 Average time per tick: 1.2ms (Target: 50.0ms)
+	vanillaTickPattern = regexp.MustCompile(`Average time per tick:\s*(\d+(?:\.\d+)?)\s*ms\s*\(Target:\s*(\d+(?:\.\d+)?)\s*ms\)`)
+)
+
+// ErrUnsupportedFlavor 表示该服务器类型不支持采集 tick 性能
+var ErrUnsupportedFlavor = errors.New("unsupported server flavor")
+
+// TickCommands 返回服务器类型 flavor 采集 tick 性能所需执行的指令，响应按相同顺序传入 ParseTick。不支持的类型返回 nil
+func TickCommands(flavor string) []string {
+	switch flavor {
+	case config.ServerFlavorPaper:
+		return []string{"tps", "mspt"}
+	case config.ServerFlavorForge:
+		return []string{"forge tps"}
+	case config.ServerFlavorVanilla:
+		return []string{"tick query"}
+	}
+
+	return nil
+}
+
+// ParseTick 解析服务器类型 flavor 执行 TickCommands 得到的响应 responses
+func ParseTick(flavor string, responses []string) (*Tick, error) {
+	cleaned := make([]string, len(responses))
+
+	for i, r := range responses {
+		cleaned[i] = formattingCodePattern.ReplaceAllString(r, "")
+	}
+
+	switch flavor {
+	case config.ServerFlavorPaper:
+		return parsePaperTick(cleaned)
+	case config.ServerFlavorForge:
+		return parseForgeTick(cleaned)
+	case config.ServerFlavorVanilla:
+		return parseVanillaTick(cleaned)
+	}
+
+	return nil, ErrUnsupportedFlavor
+}
+
+func parsePaperTick(responses []string) (*Tick, error) {
+	if len(responses) != 2 {
+		return nil, errors.New("unexpected response count")
+	}
+
+	m := paperTpsPattern.FindStringSubmatch(responses[0])
+
+	if m == nil {
+		return nil, errors.New("cannot parse tps response: " + responses[0])
+	}
+
+	tps, _ := strconv.ParseFloat(m[1], 64)
+
+	// mspt 的响应为 Server tick times (avg/min/max) from last 5s, 10s, 1m: 后接三组 平均/最小/最大，取最近 5 秒的平均值
+	_, after, ok := strings.Cut(responses[1], "1m:")
+
+	if !ok {
+		return nil, errors.New("cannot parse mspt response: " + responses[1])
+	}
+
+	n := numberPattern.FindString(after)
+
+	if n == "" {
+		return nil, errors.New("cannot parse mspt response: " + responses[1])
+	}
+
+	mspt, _ := strconv.ParseFloat(n, 64)
+
+	return &Tick{TPS: tps, MSPT: mspt}, nil
+}
+
+func parseForgeTick(responses []string) (*Tick, error) {
+	if len(responses) != 1 {
+		return nil, errors.New("unexpected response count")
+	}
+
+	m := forgeOverallPattern.FindStringSubmatch(responses[0])
+
+	if m == nil {
+		return nil, errors.New("cannot parse forge tps response: " + responses[0])
+	}
+
+	mspt, _ := strconv.ParseFloat(m[1], 64)
+	tps, _ := strconv.ParseFloat(m[2], 64)
+
+	return &Tick{TPS: tps, MSPT: mspt}, nil
+}
+
+func parseVanillaTick(responses []string) (*Tick, error) {
+	if len(responses) != 1 {
+		return nil, errors.New("unexpected response count")
+	}
+
+	m := vanillaTickPattern.FindStringSubmatch(responses[0])
+
+	if m == nil {
+		return nil, errors.New("cannot parse tick query response: " + responses[0])
+	}
+
+	mspt, _ := strconv.ParseFloat(m[1], 64)
+	target, _ := strconv.ParseFloat(m[2], 64)
+
+	// 原版不直接给出 TPS。每 tick 耗时低于目标时，TPS 即为目标 tick 速率
+	tps := 1000 / max(mspt, target)
+
+	return &Tick{TPS: tps, MSPT: mspt}, nil
+}
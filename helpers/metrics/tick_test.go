@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/Subilan/go-aliyunmc/config"
+)
+
+func TestParseTick(t *testing.T) {
+	tests := []struct {
+		name      string
+		flavor    string
+		responses []string
+		want      Tick
+		wantErr   bool
+	}{
+		{
+			name:   "paper",
+			flavor: config.ServerFlavorPaper,
+			responses: []string{
+				"§6TPS from last 1m, 5m, 15m: §a19.87§r, §a19.95§r, §a19.98",
+				"§6Server tick times §e(§7avg§e/§7min§e/§7max§e)§6 from last 5s§e,§6 10s§e,§6 1m§e:\n§6◴ §a12.3§7/§a8.1§7/§a30.2§e, §a11.0§7/§a7.9§7/§a31.5§e, §a10.4§7/§a7.5§7/§a40.1",
+			},
+			want: Tick{TPS: 19.87, MSPT: 12.3},
+		},
+		{
+			name:   "paper capped tps",
+			flavor: config.ServerFlavorPaper,
+			responses: []string{
+				"§6TPS from last 1m, 5m, 15m: §a*20.0§r, §a*20.0§r, §a*20.0",
+				"Server tick times (avg/min/max) from last 5s, 10s, 1m: 1.5/0.9/4.0, 1.6/0.9/4.0, 1.7/0.8/9.0",
+			},
+			want: Tick{TPS: 20, MSPT: 1.5},
+		},
+		{
+			name:      "paper missing mspt response",
+			flavor:    config.ServerFlavorPaper,
+			responses: []string{"TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0"},
+			wantErr:   true,
+		},
+		{
+			name:   "paper malformed mspt",
+			flavor: config.ServerFlavorPaper,
+			responses: []string{
+				"TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0",
+				"Unknown command. Type \"/help\" for help.",
+			},
+			wantErr: true,
+		},
+		{
+			name:      "forge",
+			flavor:    config.ServerFlavorForge,
+			responses: []string{"Dim minecraft:overworld (minecraft:overworld): Mean tick time: 2.010 ms. Mean TPS: 20.000\nOverall: Mean tick time: 3.456 ms. Mean TPS: 20.000"},
+			want:      Tick{TPS: 20, MSPT: 3.456},
+		},
+		{
+			name:      "forge malformed",
+			flavor:    config.ServerFlavorForge,
+			responses: []string{"Dim minecraft:overworld (minecraft:overworld): Mean tick time: 2.010 ms. Mean TPS: 20.000"},
+			wantErr:   true,
+		},
+		{
+			name:      "vanilla under target",
+			flavor:    config.ServerFlavorVanilla,
+			responses: []string{"The game is running normally\nTarget tick rate: 20.0 per second.\nAverage time per tick: 4.2ms (Target: 50.0ms)"},
+			want:      Tick{TPS: 20, MSPT: 4.2},
+		},
+		{
+			name:      "vanilla over target",
+			flavor:    config.ServerFlavorVanilla,
+			responses: []string{"Average time per tick: 100.0ms (Target: 50.0ms)"},
+			want:      Tick{TPS: 10, MSPT: 100},
+		},
+		{
+			name:      "vanilla malformed",
+			flavor:    config.ServerFlavorVanilla,
+			responses: []string{"Unknown or incomplete command, see below for error"},
+			wantErr:   true,
+		},
+		{
+			name:      "vanilla wrong response count",
+			flavor:    config.ServerFlavorVanilla,
+			responses: nil,
+			wantErr:   true,
+		},
+		{
+			name:      "unsupported flavor",
+			flavor:    "bedrock",
+			responses: []string{""},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTick(tt.flavor, tt.responses)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseTick() = %+v, want error", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseTick() error = %v", err)
+			}
+
+			if *got != tt.want {
+				t.Errorf("ParseTick() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickCommands(t *testing.T) {
+	for _, flavor := range []string{config.ServerFlavorPaper, config.ServerFlavorForge, config.ServerFlavorVanilla} {
+		if len(TickCommands(flavor)) == 0 {
+			t.Errorf("TickCommands(%q) is empty", flavor)
+		}
+	}
+
+	if TickCommands("bedrock") != nil {
+		t.Error("TickCommands() for unsupported flavor is not nil")
+	}
+}
@@ -0,0 +1,26 @@
+package mid
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按照请求方法、路由和状态码将请求的处理耗时计入 prom.HTTPRequestDuration。没有匹配任何路由的请求统一记为 unmatched，以免路径参数使标签无限增长
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+
+		if route == "" {
+			route = "unmatched"
+		}
+
+		prom.HTTPRequestDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+	}
+}
@@ -0,0 +1,25 @@
+package mid
+
+import (
+	"net/http"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// Profile 检查路径参数 profile 对应的档案是否存在，并将档案名称存储到上下文中，供后续处理使用
+func Profile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		profile := c.Param("profile")
+
+		if _, ok := config.Cfg.GetProfile(profile); !ok {
+			c.JSON(http.StatusNotFound, helpers.Details("档案不存在"))
+			c.Abort()
+			return
+		}
+
+		c.Set("profile", profile)
+		c.Next()
+	}
+}
@@ -36,7 +36,14 @@ func Whitelist() gin.HandlerFunc {
 			return
 		}
 
-		if !bound.Whitelisted {
+		// 如果路由属于某个档案（参见 Profile），则要求用户在该档案的白名单中
+		if profile := gctx.GetProfile(c); profile != "" {
+			if !store.IsWhitelistedIn(profile, bound.GameId) {
+				c.JSON(http.StatusForbidden, helpers.Details("需要白名单"))
+				c.Abort()
+				return
+			}
+		} else if !bound.Whitelisted {
 			c.JSON(http.StatusForbidden, helpers.Details("需要白名单"))
 			c.Abort()
 			return
@@ -0,0 +1,28 @@
+package helpers
+
+import "sync"
+
+// KeyedMutex 是按键区分的一组互斥锁，不同键对应的锁互不影响。零值可以直接使用。
+type KeyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Get 返回键 key 对应的互斥锁，如果不存在则创建
+func (k *KeyedMutex) Get(key string) *sync.Mutex {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.locks == nil {
+		k.locks = make(map[string]*sync.Mutex)
+	}
+
+	lock, ok := k.locks[key]
+
+	if !ok {
+		lock = &sync.Mutex{}
+		k.locks[key] = lock
+	}
+
+	return lock
+}
@@ -0,0 +1,12 @@
+package prom
+
+var (
+	// CommandExecutions 按档案、指令类型和结果统计指令的执行次数，结果为 success 或 error
+	CommandExecutions = NewCounterVec("aliyunmc_command_executions_total", "Number of command executions by profile, type and status.", "profile", "type", "status")
+
+	// MonitorErrors 按监控器和档案统计监控器遇到的错误次数，监控器在记录错误日志的同时计入。与档案无关的监控器档案为空
+	MonitorErrors = NewCounterVec("aliyunmc_monitor_errors_total", "Number of errors logged by monitors.", "monitor", "profile")
+
+	// HTTPRequestDuration 按请求方法、路由和状态码统计 HTTP 请求的耗时，参见 mid.Metrics
+	HTTPRequestDuration = NewHistogramVec("aliyunmc_http_request_duration_seconds", "HTTP request latency by method, route and status.", DefaultBuckets, "method", "route", "status")
+)
@@ -0,0 +1,226 @@
+// Package prom 提供以 Prometheus 文本格式导出指标所需的最小实现，包括带标签的计数器、直方图以及样本的输出，参见 handlers.HandleMetrics
+package prom
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sample 是一个指标样本，Labels 的顺序与指标声明的标签名称一致
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// escapeLabelValue 按照文本格式的要求转义标签值
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// formatLabels 将标签名称 names 和对应的值 values 格式化为 {a="1",b="2"}。没有标签时返回空字符串
+func formatLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	sb.WriteByte('{')
+
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(values[i]))
+		sb.WriteByte('"')
+	}
+
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeHelp 按照文本格式的要求转义指标说明
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// WriteHeader 输出指标 name 的说明和类型
+func WriteHeader(w io.Writer, name string, help string, typ string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+// WriteSamples 输出类型为 typ 的指标 name 及其样本 samples，labels 是标签名称
+func WriteSamples(w io.Writer, name string, help string, typ string, labels []string, samples []Sample) {
+	WriteHeader(w, name, help, typ)
+
+	for _, s := range samples {
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(labels, s.Labels), formatValue(s.Value))
+	}
+}
+
+// labelKey 将标签值连接为字典的键
+func labelKey(values []string) string {
+	return strings.Join(values, "\x00")
+}
+
+// CounterVec 是带标签的计数器
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*Sample
+}
+
+// NewCounterVec 创建一个名称为 name、标签名称为 labels 的计数器
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, values: make(map[string]*Sample)}
+}
+
+// Add 为标签值为 values 的计数增加 v。values 的数量必须与标签名称一致
+func (c *CounterVec) Add(v float64, values ...string) {
+	if len(values) != len(c.labels) {
+		panic("prom: label count mismatch for " + c.name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := labelKey(values)
+
+	if s, ok := c.values[key]; ok {
+		s.Value += v
+		return
+	}
+
+	c.values[key] = &Sample{Labels: slices.Clone(values), Value: v}
+}
+
+// Inc 为标签值为 values 的计数增加 1
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Write 以文本格式输出该计数器，样本按标签值排序
+func (c *CounterVec) Write(w io.Writer) {
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+
+	for _, s := range c.values {
+		samples = append(samples, *s)
+	}
+	c.mu.Unlock()
+
+	slices.SortFunc(samples, func(a, b Sample) int {
+		return strings.Compare(labelKey(a.Labels), labelKey(b.Labels))
+	})
+
+	WriteSamples(w, c.name, c.help, "counter", c.labels, samples)
+}
+
+// DefaultBuckets 是直方图默认的桶上界，单位秒，适用于 HTTP 请求耗时
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogramSeries 是直方图中一组标签值对应的数据
+type histogramSeries struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec 是带标签的直方图
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// NewHistogramVec 创建一个名称为 name、桶上界为 buckets（升序）、标签名称为 labels 的直方图
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogramSeries)}
+}
+
+// Observe 记录标签值为 values 的一次观测值 v
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	if len(values) != len(h.labels) {
+		panic("prom: label count mismatch for " + h.name)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := labelKey(values)
+	s, ok := h.series[key]
+
+	if !ok {
+		s = &histogramSeries{labels: slices.Clone(values), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+
+	s.count++
+	s.sum += v
+}
+
+// Write 以文本格式输出该直方图，样本按标签值排序
+func (h *HistogramVec) Write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	WriteHeader(w, h.name, h.help, "histogram")
+
+	bucketLabels := append(slices.Clone(h.labels), "le")
+
+	for _, key := range keys {
+		s := h.series[key]
+
+		for i, upper := range h.buckets {
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, append(slices.Clone(s.labels), formatValue(upper))), s.counts[i])
+		}
+
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, append(slices.Clone(s.labels), "+Inf")), s.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.labels), formatValue(s.sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.labels), s.count)
+	}
+}
@@ -0,0 +1,113 @@
+package prom
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestWriteSamples(t *testing.T) {
+	var w bytes.Buffer
+
+	WriteSamples(&w, "test_gauge", "A gauge with \\ and\nnewline.", "gauge", []string{"profile", "zone"}, []Sample{
+		{Labels: []string{"default", "cn-hangzhou-k"}, Value: 1.5},
+		{Labels: []string{`quo"te`, "back\\slash\nnewline"}, Value: 0},
+	})
+	WriteSamples(&w, "test_unlabeled", "No labels.", "gauge", nil, []Sample{{Value: 42}})
+	WriteSamples(&w, "test_special", "Special values.", "gauge", []string{"v"}, []Sample{
+		{Labels: []string{"inf"}, Value: math.Inf(1)},
+		{Labels: []string{"-inf"}, Value: math.Inf(-1)},
+		{Labels: []string{"nan"}, Value: math.NaN()},
+		{Labels: []string{"big"}, Value: 1e21},
+	})
+	WriteSamples(&w, "test_empty", "No samples.", "gauge", []string{"profile"}, nil)
+
+	want := `# HELP test_gauge A gauge with \\ and\nnewline.
+# TYPE test_gauge gauge
+test_gauge{profile="default",zone="cn-hangzhou-k"} 1.5
+test_gauge{profile="quo\"te",zone="back\\slash\nnewline"} 0
+# HELP test_unlabeled No labels.
+# TYPE test_unlabeled gauge
+test_unlabeled 42
+# HELP test_special Special values.
+# TYPE test_special gauge
+test_special{v="inf"} +Inf
+test_special{v="-inf"} -Inf
+test_special{v="nan"} NaN
+test_special{v="big"} 1e+21
+# HELP test_empty No samples.
+# TYPE test_empty gauge
+`
+
+	if got := w.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_total", "A counter.", "profile", "status")
+
+	c.Inc("default", "success")
+	c.Inc("b", "error")
+	c.Add(2.5, "default", "success")
+	c.Inc("a", "success")
+
+	var w bytes.Buffer
+
+	c.Write(&w)
+
+	want := `# HELP test_total A counter.
+# TYPE test_total counter
+test_total{profile="a",status="success"} 1
+test_total{profile="b",status="error"} 1
+test_total{profile="default",status="success"} 3.5
+`
+
+	if got := w.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCounterVecLabelCountMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc() with wrong label count did not panic")
+		}
+	}()
+
+	NewCounterVec("test_total", "A counter.", "profile").Inc("a", "b")
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_seconds", "A histogram.", []float64{0.1, 0.5, 1}, "route")
+
+	h.Observe(0.05, "/b")
+	h.Observe(0.1, "/b")
+	h.Observe(0.7, "/b")
+	h.Observe(3, "/b")
+	h.Observe(0.2, "/a")
+
+	var w bytes.Buffer
+
+	h.Write(&w)
+
+	want := `# HELP test_seconds A histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{route="/a",le="0.1"} 0
+test_seconds_bucket{route="/a",le="0.5"} 1
+test_seconds_bucket{route="/a",le="1"} 1
+test_seconds_bucket{route="/a",le="+Inf"} 1
+test_seconds_sum{route="/a"} 0.2
+test_seconds_count{route="/a"} 1
+test_seconds_bucket{route="/b",le="0.1"} 2
+test_seconds_bucket{route="/b",le="0.5"} 2
+test_seconds_bucket{route="/b",le="1"} 3
+test_seconds_bucket{route="/b",le="+Inf"} 4
+test_seconds_sum{route="/b"} 3.85
+test_seconds_count{route="/b"} 4
+`
+
+	if got := w.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
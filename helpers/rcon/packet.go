@@ -0,0 +1,73 @@
+package rcon
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// packetTypeResponse 是服务器响应指令的数据包类型，同时用作响应结束标记的请求类型
+	packetTypeResponse int32 = 0
+	// packetTypeCommand 是执行指令的数据包类型，也是服务器响应登录的数据包类型
+	packetTypeCommand int32 = 2
+	// packetTypeLogin 是登录的数据包类型
+	packetTypeLogin int32 = 3
+)
+
+// maxPacketLength 是允许读取的数据包长度上限。Minecraft 服务器按 4096 个字符拆分响应后再以 UTF-8 编码，
+// 每个字符最多占 3 个字节，因此单个响应数据包的内容不超过 3×4096 字节
+const maxPacketLength = 3*4096 + 10
+
+// maxCommandLength 是 Minecraft 服务器接受的指令长度上限
+const maxCommandLength = 1446
+
+// ErrCommandTooLong 表示指令超过了服务器接受的长度
+var ErrCommandTooLong = errors.New("rcon: command too long")
+
+// packet 是一个 RCON 数据包，参见 https://minecraft.wiki/w/RCON
+type packet struct {
+	id   int32
+	typ  int32
+	body string
+}
+
+// writePacket 将数据包 p 写入 w
+func writePacket(w io.Writer, p packet) error {
+	buf := make([]byte, 14+len(p.body))
+
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(10+len(p.body)))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(p.id))
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(p.typ))
+	copy(buf[12:], p.body)
+
+	_, err := w.Write(buf)
+
+	return err
+}
+
+// readPacket 从 r 读取一个数据包
+func readPacket(r io.Reader) (packet, error) {
+	var length int32
+
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return packet{}, err
+	}
+
+	if length < 10 || length > maxPacketLength {
+		return packet{}, fmt.Errorf("rcon: invalid packet length %d", length)
+	}
+
+	data := make([]byte, length)
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		return packet{}, err
+	}
+
+	return packet{
+		id:   int32(binary.LittleEndian.Uint32(data[0:4])),
+		typ:  int32(binary.LittleEndian.Uint32(data[4:8])),
+		body: string(data[8 : length-2]),
+	}, nil
+}
@@ -0,0 +1,305 @@
+// Package rcon 提供与 Minecraft 服务器之间长期保持的 RCON 连接。
+//
+// 每个实例IP地址对应一个会话，会话保持一个已经登录的连接，串行地发送指令，并按照请求标识符匹配响应，因此可以完整接收被拆分为多个数据包的长响应。
+// 连接出错时会话会丢弃该连接，并在下一次执行指令时重新连接。实例IP地址变化或者实例被删除时，应当调用 Close 关闭对应的会话。
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultTimeout 是上下文没有截止时间时，一次连接或一条指令的超时时间
+const defaultTimeout = 10 * time.Second
+
+var (
+	// ErrInvalidPassword 表示 RCON 密码不正确
+	ErrInvalidPassword = errors.New("rcon: incorrect password")
+	// ErrSessionClosed 表示会话已经被关闭
+	ErrSessionClosed = errors.New("rcon: session closed")
+)
+
+// session 是与一个服务器之间的 RCON 会话
+type session struct {
+	addr     string
+	password string
+
+	// mu 使指令串行执行
+	mu     sync.Mutex
+	nextId int32
+
+	// connMu 保护 conn 和 closed
+	connMu sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// sessions 存储所有会话，键为实例IP地址
+var sessions = make(map[string]*session)
+
+// sessionsMu 保护 sessions 的读写
+var sessionsMu sync.Mutex
+
+// getSession 返回 host 对应的会话。如果已有会话的端口或密码与传入的不同，则关闭已有会话并创建新的会话
+func getSession(host string, port uint16, password string) *session {
+	addr := net.JoinHostPort(host, fmt.Sprint(port))
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	s, ok := sessions[host]
+
+	if ok && s.addr == addr && s.password == password {
+		return s
+	}
+
+	if ok {
+		s.close()
+	}
+
+	s = &session{addr: addr, password: password}
+	sessions[host] = s
+
+	return s
+}
+
+// Run 通过 host 对应的会话在服务器上依次执行指令 commands，返回每条指令的完整输出。如果会话不存在或者连接已经断开，会先建立连接并登录。
+// 同一服务器上的指令串行执行。
+func Run(ctx context.Context, host string, port uint16, password string, commands ...string) ([]string, error) {
+	return getSession(host, port, password).run(ctx, commands)
+}
+
+// Close 关闭并移除 host 对应的会话。正在执行的指令会因连接被关闭而失败
+func Close(host string) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	if s, ok := sessions[host]; ok {
+		s.close()
+		delete(sessions, host)
+	}
+}
+
+func (s *session) close() {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	s.closed = true
+
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// dropConn 在连接 conn 出错后关闭并丢弃它，下一次执行指令时将重新连接
+func (s *session) dropConn(conn net.Conn) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	_ = conn.Close()
+
+	if s.conn == conn {
+		s.conn = nil
+	}
+}
+
+func (s *session) id() int32 {
+	s.nextId++
+
+	// -1 表示登录失败，不能作为请求标识符
+	if s.nextId < 0 {
+		s.nextId = 1
+	}
+
+	return s.nextId
+}
+
+// getConn 返回已经登录的连接。reused 表示该连接是否是之前建立的
+func (s *session) getConn(ctx context.Context) (conn net.Conn, reused bool, err error) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	if s.closed {
+		return nil, false, ErrSessionClosed
+	}
+
+	if s.conn != nil {
+		return s.conn, true, nil
+	}
+
+	dialCtx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+
+	var dialer net.Dialer
+
+	conn, err = dialer.DialContext(dialCtx, "tcp", s.addr)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := s.login(dialCtx, conn); err != nil {
+		_ = conn.Close()
+		return nil, false, err
+	}
+
+	s.conn = conn
+
+	return conn, false, nil
+}
+
+func (s *session) login(ctx context.Context, conn net.Conn) error {
+	setDeadline(ctx, conn)
+
+	id := s.id()
+
+	if err := writePacket(conn, packet{id: id, typ: packetTypeLogin, body: s.password}); err != nil {
+		return err
+	}
+
+	for {
+		p, err := readPacket(conn)
+
+		if err != nil {
+			return err
+		}
+
+		if p.id == -1 {
+			return ErrInvalidPassword
+		}
+
+		if p.id == id && p.typ == packetTypeCommand {
+			return nil
+		}
+	}
+}
+
+// exec 在连接 conn 上执行指令 command，返回完整的输出。
+//
+// 服务器会将较长的输出拆分为多个请求标识符相同的数据包，且不会标记输出的结束。因此在指令之后紧接着发送一个类型无效的数据包，服务器按顺序处理请求，收到对它的响应即表示指令的输出已经完整。
+// read 表示是否已经从连接读取到了指令的响应。
+func (s *session) exec(ctx context.Context, conn net.Conn, command string) (output string, read bool, err error) {
+	execCtx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+
+	setDeadline(execCtx, conn)
+
+	id := s.id()
+	endId := s.id()
+
+	if err := writePacket(conn, packet{id: id, typ: packetTypeCommand, body: command}); err != nil {
+		return "", false, err
+	}
+
+	if err := writePacket(conn, packet{id: endId, typ: packetTypeResponse}); err != nil {
+		return "", false, err
+	}
+
+	var result strings.Builder
+
+	for {
+		p, err := readPacket(conn)
+
+		if err != nil {
+			return "", read, err
+		}
+
+		read = true
+
+		switch p.id {
+		case id:
+			result.WriteString(p.body)
+		case endId:
+			return result.String(), true, nil
+		}
+
+		// 其它请求标识符的数据包属于之前超时的指令，直接丢弃
+	}
+}
+
+func (s *session) run(ctx context.Context, commands []string) ([]string, error) {
+	for _, command := range commands {
+		if len(command) > maxCommandLength {
+			return nil, ErrCommandTooLong
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]string, 0, len(commands))
+
+	for _, command := range commands {
+		output, err := s.runOne(ctx, command)
+
+		if err != nil {
+			return result, err
+		}
+
+		result = append(result, output)
+	}
+
+	return result, nil
+}
+
+// runOne 执行一条指令。如果之前建立的连接已经被服务器关闭，且没有读取到任何响应（即服务器没有收到该指令），则重新连接后再执行一次
+func (s *session) runOne(ctx context.Context, command string) (string, error) {
+	conn, reused, err := s.getConn(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	output, read, err := s.exec(ctx, conn, command)
+
+	if err == nil {
+		return output, nil
+	}
+
+	s.dropConn(conn)
+
+	if !reused || read || !isConnectionLost(err) {
+		return "", err
+	}
+
+	conn, _, err = s.getConn(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	output, _, err = s.exec(ctx, conn, command)
+
+	if err != nil {
+		s.dropConn(conn)
+		return "", err
+	}
+
+	return output, nil
+}
+
+// isConnectionLost 返回 err 是否表示连接已经被对方关闭
+func isConnectionLost(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, defaultTimeout)
+}
+
+func setDeadline(ctx context.Context, conn net.Conn) {
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+}
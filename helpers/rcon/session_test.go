@@ -0,0 +1,365 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer 是一个按照 Minecraft 服务器的方式响应 RCON 请求的服务器
+type fakeServer struct {
+	ln       net.Listener
+	password string
+
+	// handle 处理一条指令，返回 false 时服务器关闭该连接。为 nil 时以 "ok: " 加指令内容作为单个数据包响应
+	handle func(conn net.Conn, p packet) bool
+
+	mu       sync.Mutex
+	conns    []net.Conn
+	logins   int
+	commands []string
+}
+
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	srv := &fakeServer{ln: ln, password: password}
+
+	t.Cleanup(func() {
+		_ = ln.Close()
+		srv.closeConns()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+
+			if err != nil {
+				return
+			}
+
+			srv.mu.Lock()
+			srv.conns = append(srv.conns, conn)
+			srv.mu.Unlock()
+
+			go srv.serve(conn)
+		}
+	}()
+
+	return srv
+}
+
+func (srv *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		p, err := readPacket(conn)
+
+		if err != nil {
+			return
+		}
+
+		switch p.typ {
+		case packetTypeLogin:
+			srv.mu.Lock()
+			srv.logins++
+			srv.mu.Unlock()
+
+			id := p.id
+
+			if p.body != srv.password {
+				id = -1
+			}
+
+			_ = writePacket(conn, packet{id: id, typ: packetTypeCommand})
+
+		case packetTypeCommand:
+			srv.mu.Lock()
+			srv.commands = append(srv.commands, p.body)
+			handle := srv.handle
+			srv.mu.Unlock()
+
+			if handle == nil {
+				_ = writePacket(conn, packet{id: p.id, typ: packetTypeResponse, body: "ok: " + p.body})
+			} else if !handle(conn, p) {
+				return
+			}
+
+		default:
+			_ = writePacket(conn, packet{id: p.id, typ: packetTypeResponse, body: "Unknown request " + strconv.Itoa(int(p.typ))})
+		}
+	}
+}
+
+func (srv *fakeServer) setHandle(handle func(conn net.Conn, p packet) bool) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.handle = handle
+}
+
+// closeConns 在服务器一侧关闭所有已经建立的连接
+func (srv *fakeServer) closeConns() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, conn := range srv.conns {
+		_ = conn.Close()
+	}
+
+	srv.conns = nil
+}
+
+func (srv *fakeServer) counts() (int, int) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return srv.logins, len(srv.commands)
+}
+
+func (srv *fakeServer) session() *session {
+	return &session{addr: srv.ln.Addr().String(), password: srv.password}
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+func TestRun(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	s := srv.session()
+
+	got, err := s.run(testContext(t), []string{"list", "say hi"})
+
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if len(got) != 2 || got[0] != "ok: list" || got[1] != "ok: say hi" {
+		t.Errorf("run() = %q, want [ok: list ok: say hi]", got)
+	}
+
+	if _, err := s.run(testContext(t), []string{"list"}); err != nil {
+		t.Fatalf("second run() error = %v", err)
+	}
+
+	if logins, _ := srv.counts(); logins != 1 {
+		t.Errorf("logged in %d times, want 1", logins)
+	}
+}
+
+func TestRunSplitResponse(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+
+	// 服务器按 4096 个字符拆分响应，中文字符编码后每个数据包约 12 KiB
+	chunks := []string{strings.Repeat("玩", 4096), strings.Repeat("家", 4096), "末尾"}
+
+	srv.setHandle(func(conn net.Conn, p packet) bool {
+		for _, chunk := range chunks {
+			_ = writePacket(conn, packet{id: p.id, typ: packetTypeResponse, body: chunk})
+		}
+
+		return true
+	})
+
+	got, err := srv.session().run(testContext(t), []string{"banlist"})
+
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if want := strings.Join(chunks, ""); len(got) != 1 || got[0] != want {
+		t.Errorf("run() returned %d bytes, want %d", len(strings.Join(got, "")), len(want))
+	}
+}
+
+func TestRunDiscardsStalePackets(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+
+	// 之前超时的指令的响应在本次指令的响应之前到达
+	srv.setHandle(func(conn net.Conn, p packet) bool {
+		_ = writePacket(conn, packet{id: p.id - 10, typ: packetTypeResponse, body: "stale"})
+		_ = writePacket(conn, packet{id: p.id, typ: packetTypeResponse, body: "fresh"})
+
+		return true
+	})
+
+	got, err := srv.session().run(testContext(t), []string{"list"})
+
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if len(got) != 1 || got[0] != "fresh" {
+		t.Errorf("run() = %q, want [fresh]", got)
+	}
+}
+
+func TestRunInvalidPassword(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	s := &session{addr: srv.ln.Addr().String(), password: "wrong"}
+
+	if _, err := s.run(testContext(t), []string{"list"}); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("run() error = %v, want ErrInvalidPassword", err)
+	}
+
+	if _, commands := srv.counts(); commands != 0 {
+		t.Errorf("server received %d commands, want 0", commands)
+	}
+}
+
+func TestRunReconnectsAfterServerClose(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	s := srv.session()
+
+	if _, err := s.run(testContext(t), []string{"list"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	// 服务器在两条指令之间关闭连接，下一条指令应当在新连接上执行一次
+	srv.closeConns()
+
+	got, err := s.run(testContext(t), []string{"say hi"})
+
+	if err != nil {
+		t.Fatalf("run() after server close error = %v", err)
+	}
+
+	if len(got) != 1 || got[0] != "ok: say hi" {
+		t.Errorf("run() = %q, want [ok: say hi]", got)
+	}
+
+	if logins, commands := srv.counts(); logins != 2 || commands != 2 {
+		t.Errorf("server saw %d logins and %d commands, want 2 and 2", logins, commands)
+	}
+}
+
+func TestRunDoesNotRetryAfterPartialResponse(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	s := srv.session()
+
+	if _, err := s.run(testContext(t), []string{"list"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	// 服务器已经开始响应后断开连接，指令可能已经执行，不能重试
+	srv.setHandle(func(conn net.Conn, p packet) bool {
+		_ = writePacket(conn, packet{id: p.id, typ: packetTypeResponse, body: "partial"})
+
+		return false
+	})
+
+	if _, err := s.run(testContext(t), []string{"stop"}); err == nil {
+		t.Fatal("run() succeeded, want error")
+	}
+
+	if logins, commands := srv.counts(); logins != 1 || commands != 2 {
+		t.Errorf("server saw %d logins and %d commands, want 1 and 2", logins, commands)
+	}
+
+	// 出错的连接被丢弃，之后的指令重新连接
+	srv.setHandle(nil)
+
+	if _, err := s.run(testContext(t), []string{"list"}); err != nil {
+		t.Fatalf("run() after failure error = %v", err)
+	}
+
+	if logins, _ := srv.counts(); logins != 2 {
+		t.Errorf("logged in %d times, want 2", logins)
+	}
+}
+
+func TestCloseDuringCommand(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	received := make(chan struct{})
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	// 服务器收到指令后不响应，直到测试结束
+	srv.setHandle(func(conn net.Conn, p packet) bool {
+		close(received)
+		<-release
+
+		return false
+	})
+
+	host, portStr, _ := net.SplitHostPort(srv.ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	t.Cleanup(func() { Close(host) })
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		_, err := Run(testContext(t), host, uint16(port), "secret", "list")
+		errCh <- err
+	}()
+
+	<-received
+	Close(host)
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("Run() succeeded after Close, want error")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run() did not return after Close")
+	}
+}
+
+func TestClosedSession(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	s := srv.session()
+
+	s.close()
+
+	if _, err := s.run(testContext(t), []string{"list"}); !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("run() error = %v, want ErrSessionClosed", err)
+	}
+
+	if logins, _ := srv.counts(); logins != 0 {
+		t.Errorf("logged in %d times, want 0", logins)
+	}
+}
+
+func TestRunCommandTooLong(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+
+	if _, err := srv.session().run(testContext(t), []string{strings.Repeat("a", maxCommandLength+1)}); !errors.Is(err, ErrCommandTooLong) {
+		t.Fatalf("run() error = %v, want ErrCommandTooLong", err)
+	}
+}
+
+func TestGetSession(t *testing.T) {
+	t.Cleanup(func() { Close("192.0.2.1") })
+
+	a := getSession("192.0.2.1", 25575, "secret")
+
+	if b := getSession("192.0.2.1", 25575, "secret"); b != a {
+		t.Error("getSession() with the same settings returned a new session")
+	}
+
+	c := getSession("192.0.2.1", 25575, "changed")
+
+	if c == a {
+		t.Fatal("getSession() with a new password returned the old session")
+	}
+
+	if _, err := a.run(context.Background(), []string{"list"}); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("replaced session run() error = %v, want ErrSessionClosed", err)
+	}
+}
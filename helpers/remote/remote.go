@@ -168,15 +168,15 @@ func relayWithContext(ctx context.Context, r io.Reader, sink func([]byte)) {
 	}
 }
 
-// RunCommandAsProdSync 在指定上下文 ctx 下，在远程服务器 host 上运行 commands 指定的指令，并在得到结果或错误时返回。
-//
-// 该函数以生产身份运行指令，请注意权限设置正确。
-func RunCommandAsProdSync(
+// runAsProd 在指定上下文 ctx 下，以生产身份在远程服务器 host 上运行 commands 指定的指令，运行过程中标准输出和标准错误的内容分别实时传递给
+// stdoutSink 和 stderrSink。函数在指令结束且输出全部传递完成后返回
+func runAsProd(
 	ctx context.Context,
 	host string,
 	commands []string,
-	output bool,
-) ([]byte, error) {
+	stdoutSink func([]byte),
+	stderrSink func([]byte),
+) error {
 	script := strings.Join(commands, "\n") + "\n"
 
 	cfg := &ssh.ClientConfig{
@@ -190,13 +190,13 @@ func RunCommandAsProdSync(
 
 	client, err := ssh.Dial("tcp", host+":22", cfg)
 	if err != nil {
-		return nil, fmt.Errorf("ssh dial: %w", err)
+		return fmt.Errorf("ssh dial: %w", err)
 	}
 	defer client.Close()
 
 	session, err := client.NewSession()
 	if err != nil {
-		return nil, fmt.Errorf("new session: %w", err)
+		return fmt.Errorf("new session: %w", err)
 	}
 	defer session.Close()
 
@@ -204,35 +204,102 @@ func RunCommandAsProdSync(
 	stderr, _ := session.StderrPipe()
 	stdin, _ := session.StdinPipe()
 
-	var outBuf bytes.Buffer
+	var wg sync.WaitGroup
 
-	go copyStdinAndStderr(&outBuf, stdout, stderr, output)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		relayWithContext(ctx, stdout, stdoutSink)
+	}()
+	go func() {
+		defer wg.Done()
+		relayWithContext(ctx, stderr, stderrSink)
+	}()
 	go closeSessionOnContextDone(ctx, session)
 
 	if err := session.Start("bash -s"); err != nil {
-		return nil, err
+		return fmt.Errorf("start shell: %w", err)
 	}
 
 	if _, err := stdin.Write([]byte(script)); err != nil {
-		return nil, err
+		return fmt.Errorf("copy script: %w", err)
 	}
 
 	stdin.Close()
 
-	if err := session.Wait(); err != nil {
+	err = session.Wait()
+	wg.Wait()
+
+	if err != nil {
 		if ctx.Err() != nil {
-			return outBuf.Bytes(), ctx.Err()
+			return ctx.Err()
 		}
 
 		var exitErr *ssh.ExitError
 		if errors.As(err, &exitErr) {
-			return outBuf.Bytes(), fmt.Errorf("exit code %d", exitErr.ExitStatus())
+			return fmt.Errorf("exit code %d: %w", exitErr.ExitStatus(), exitErr)
 		}
 
-		return outBuf.Bytes(), err
+		return err
+	}
+
+	return nil
+}
+
+// RunCommandAsProdSync 在指定上下文 ctx 下，在远程服务器 host 上运行 commands 指定的指令，并在得到结果或错误时返回。
+// 返回的内容总是包括标准错误，仅当 output 为 true 时包括标准输出。
+//
+// 该函数以生产身份运行指令，请注意权限设置正确。
+func RunCommandAsProdSync(
+	ctx context.Context,
+	host string,
+	commands []string,
+	output bool,
+) ([]byte, error) {
+	var mu sync.Mutex
+	var outBuf bytes.Buffer
+
+	collect := func(b []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		outBuf.Write(b)
+	}
+
+	stdoutSink := collect
+
+	if !output {
+		stdoutSink = func([]byte) {}
+	}
+
+	err := runAsProd(ctx, host, commands, stdoutSink, collect)
+
+	return outBuf.Bytes(), err
+}
+
+// RunCommandAsProdStream 在指定上下文 ctx 下，在远程服务器 host 上运行 commands 指定的指令，运行过程中标准输出和标准错误的内容分别实时传递给 stdoutSink 和 stderrSink。
+// 函数在指令结束且输出全部传递完成后返回。
+//
+// 该函数以生产身份运行指令，请注意权限设置正确。
+func RunCommandAsProdStream(
+	ctx context.Context,
+	host string,
+	commands []string,
+	stdoutSink func([]byte),
+	stderrSink func([]byte),
+) error {
+	return runAsProd(ctx, host, commands, stdoutSink, stderrSink)
+}
+
+// ExitCode 返回远程指令以非零退出码结束时产生的错误 err 中的退出码。如果 err 不是此类错误，第二个返回值为 false
+func ExitCode(err error) (int, bool) {
+	var exitErr *ssh.ExitError
+
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
 	}
 
-	return outBuf.Bytes(), nil
+	return 0, false
 }
 
 func closeSessionOnContextDone(ctx context.Context, session *ssh.Session) {
@@ -256,31 +323,3 @@ func closeSessionOnContextDone(ctx context.Context, session *ssh.Session) {
 		log.Println("session closed by context done")
 	}
 }
-
-func copyStdinAndStderr(buf io.Writer, stdout io.Reader, stderr io.Reader, output bool) {
-	if output {
-		mw := io.MultiWriter(buf)
-		go func() {
-			_, err := io.Copy(mw, stdout)
-			if err != nil {
-				log.Println("cannot copy stdout to outBuf:", err)
-			}
-		}()
-		go func() {
-			_, err := io.Copy(mw, stderr)
-			if err != nil {
-				log.Println("cannot copy stderr to outBuf:", err)
-			}
-		}()
-	} else {
-		go func() {
-			_, _ = io.Copy(io.Discard, stdout)
-		}()
-		go func() {
-			_, err := io.Copy(buf, stderr)
-			if err != nil {
-				log.Println("cannot copy stderr to outBuf:", err)
-			}
-		}()
-	}
-}
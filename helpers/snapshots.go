@@ -0,0 +1,171 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/clients"
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	"github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// snapshotPollInterval 是等待快照创建完成时轮询快照状态的间隔
+const snapshotPollInterval = 5 * time.Second
+
+// describeDisk 返回实例 instanceId 上类型为 diskType（system 或 data）的云盘标识符及其大小（GiB）
+func describeDisk(ctx context.Context, instanceId string, diskType string) (string, int, error) {
+	describeDisksRequest := &client.DescribeDisksRequest{
+		RegionId:   tea.String(config.Cfg.Aliyun.RegionId),
+		InstanceId: tea.String(instanceId),
+		DiskType:   tea.String(diskType),
+	}
+
+	describeDisksResponse, err := clients.EcsClient.DescribeDisksWithContext(ctx, describeDisksRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	if describeDisksResponse.Body.Disks == nil || len(describeDisksResponse.Body.Disks.Disk) == 0 {
+		return "", 0, errors.New("no " + diskType + " disk found on instance " + instanceId)
+	}
+
+	disk := describeDisksResponse.Body.Disks.Disk[0]
+
+	return tea.StringValue(disk.DiskId), int(tea.Int32Value(disk.Size)), nil
+}
+
+// createSnapshot 为云盘 diskId 创建快照，并阻塞直到快照创建完成、创建失败或 ctx 结束
+func createSnapshot(ctx context.Context, diskId string, name string, description string) (string, error) {
+	createSnapshotRequest := &client.CreateSnapshotRequest{
+		DiskId:       tea.String(diskId),
+		SnapshotName: tea.String(name),
+		Description:  tea.String(description),
+	}
+
+	createSnapshotResponse, err := clients.EcsClient.CreateSnapshotWithContext(ctx, createSnapshotRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return "", err
+	}
+
+	snapshotId := tea.StringValue(createSnapshotResponse.Body.SnapshotId)
+
+	return snapshotId, waitSnapshotAccomplished(ctx, snapshotId)
+}
+
+// deleteSnapshot 删除快照 snapshotId
+func deleteSnapshot(ctx context.Context, snapshotId string) error {
+	deleteSnapshotRequest := &client.DeleteSnapshotRequest{
+		SnapshotId: tea.String(snapshotId),
+		Force:      tea.Bool(false),
+	}
+
+	_, err := clients.EcsClient.DeleteSnapshotWithContext(ctx, deleteSnapshotRequest, &dara.RuntimeOptions{})
+
+	return err
+}
+
+// waitSnapshotAccomplished 阻塞直到快照 snapshotId 创建完成、创建失败或 ctx 结束
+func waitSnapshotAccomplished(ctx context.Context, snapshotId string) error {
+	describeSnapshotsRequest := &client.DescribeSnapshotsRequest{
+		RegionId:    tea.String(config.Cfg.Aliyun.RegionId),
+		SnapshotIds: tea.String("[\"" + snapshotId + "\"]"),
+	}
+
+	for {
+		describeSnapshotsResponse, err := clients.EcsClient.DescribeSnapshotsWithContext(ctx, describeSnapshotsRequest, &dara.RuntimeOptions{})
+
+		if err != nil {
+			return err
+		}
+
+		if describeSnapshotsResponse.Body.Snapshots != nil && len(describeSnapshotsResponse.Body.Snapshots.Snapshot) > 0 {
+			switch tea.StringValue(describeSnapshotsResponse.Body.Snapshots.Snapshot[0].Status) {
+			case "accomplished":
+				return nil
+			case "failed":
+				return errors.New("snapshot " + snapshotId + " failed")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(snapshotPollInterval):
+		}
+	}
+}
+
+// CreateDataDiskSnapshot 为档案 profile 下实例 instanceId 的数据盘创建快照，等待快照创建完成后写入数据库，并按照 config.DeployConfig.SnapshotRetention 删除多余的旧快照。
+//
+// 调用者应当确保服务器已经停止，以保证快照中的存档完整。
+func CreateDataDiskSnapshot(ctx context.Context, profile string, instanceId string) (*store.Snapshot, error) {
+	diskId, diskSize, err := describeDisk(ctx, instanceId, "data")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// 实例删除时数据盘随之释放，必须等待快照创建完成
+	snapshotId, err := createSnapshot(ctx, diskId, profile+"-archive-"+time.Now().Format("20060102-150405"), "Archive of profile "+profile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &store.Snapshot{
+		SnapshotId: snapshotId,
+		Profile:    profile,
+		InstanceId: instanceId,
+		DiskId:     diskId,
+		DiskSize:   diskSize,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := store.InsertSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+
+	if err := PruneSnapshots(ctx, profile); err != nil {
+		log.Println("cannot prune snapshots:", err)
+	}
+
+	return snapshot, nil
+}
+
+// PruneSnapshots 删除档案 profile 超出 config.DeployConfig.SnapshotRetention 数量的旧快照
+func PruneSnapshots(ctx context.Context, profile string) error {
+	retention := config.Cfg.Deploy.SnapshotRetention
+
+	if retention == 0 {
+		return nil
+	}
+
+	snapshots, err := store.GetSnapshots(profile)
+
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) <= retention {
+		return nil
+	}
+
+	for _, snapshot := range snapshots[retention:] {
+		if err := deleteSnapshot(ctx, snapshot.SnapshotId); err != nil {
+			return err
+		}
+
+		if err := store.MarkSnapshotDeleted(snapshot.SnapshotId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
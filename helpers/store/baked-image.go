@@ -0,0 +1,82 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+const (
+	BakedImageStatusCreating  = "creating"
+	BakedImageStatusAvailable = "available"
+	BakedImageStatusFailed    = "failed"
+)
+
+type BakedImage struct {
+	ImageId     string     `json:"imageId"`
+	Fingerprint string     `json:"fingerprint"`
+	InstanceId  string     `json:"instanceId"`
+	SnapshotId  string     `json:"snapshotId"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	DeletedAt   *time.Time `json:"deletedAt"`
+}
+
+const bakedImageQ = "SELECT image_id, fingerprint, instance_id, snapshot_id, status, created_at, deleted_at FROM baked_images "
+
+// InsertBakedImage 记录一个正在制作的预制镜像
+func InsertBakedImage(imageId string, fingerprint string, instanceId string, snapshotId string) error {
+	_, err := db.Pool.Exec("INSERT INTO baked_images (image_id, fingerprint, instance_id, snapshot_id) VALUES (?, ?, ?, ?)", imageId, fingerprint, instanceId, snapshotId)
+	return err
+}
+
+// UpdateBakedImageStatus 更新预制镜像 imageId 的状态
+func UpdateBakedImageStatus(imageId string, status string) error {
+	_, err := db.Pool.Exec("UPDATE baked_images SET status = ? WHERE image_id = ?", status, imageId)
+	return err
+}
+
+// MarkBakedImageDeleted 将预制镜像 imageId 标记为已删除
+func MarkBakedImageDeleted(imageId string) error {
+	_, err := db.Pool.Exec("UPDATE baked_images SET deleted_at = CURRENT_TIMESTAMP WHERE image_id = ?", imageId)
+	return err
+}
+
+// GetBakedImage 获取指纹为 fingerprint 且状态为 status 的最新预制镜像。如果没有，返回 sql.ErrNoRows
+func GetBakedImage(fingerprint string, status string) (*BakedImage, error) {
+	var res BakedImage
+
+	err := db.Pool.QueryRow(bakedImageQ+"WHERE fingerprint = ? AND status = ? AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 1", fingerprint, status).
+		Scan(&res.ImageId, &res.Fingerprint, &res.InstanceId, &res.SnapshotId, &res.Status, &res.CreatedAt, &res.DeletedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetStaleBakedImages 获取所有指纹不为 fingerprint 或制作失败的未删除预制镜像
+func GetStaleBakedImages(fingerprint string) ([]*BakedImage, error) {
+	var result = make([]*BakedImage, 0, 1)
+
+	rows, err := db.Pool.Query(bakedImageQ+"WHERE deleted_at IS NULL AND (fingerprint != ? OR status = ?)", fingerprint, BakedImageStatusFailed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res BakedImage
+		err = rows.Scan(&res.ImageId, &res.Fingerprint, &res.InstanceId, &res.SnapshotId, &res.Status, &res.CreatedAt, &res.DeletedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
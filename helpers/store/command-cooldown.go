@@ -0,0 +1,59 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+// CommandCooldown 是指令的一个频率限制在某个对象上的剩余执行次数，参见 config.CommandLimitConfig
+type CommandCooldown struct {
+	LimitKey  string
+	Subject   string
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// GetCommandCooldowns 获取指令 commandType 在档案 profile 下的所有频率限制记录
+func GetCommandCooldowns(profile string, commandType consts.CommandType) ([]CommandCooldown, error) {
+	var result = make([]CommandCooldown, 0)
+
+	rows, err := db.Pool.Query("SELECT limit_key, subject, tokens, updated_at FROM command_cooldowns WHERE profile = ? AND command_type = ?", profile, commandType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res CommandCooldown
+
+		if err = rows.Scan(&res.LimitKey, &res.Subject, &res.Tokens, &res.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		result = append(result, res)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveCommandCooldown 写入指令 commandType 在档案 profile 下的一个频率限制记录
+func SaveCommandCooldown(profile string, commandType consts.CommandType, cooldown CommandCooldown) error {
+	_, err := db.Pool.Exec("INSERT INTO command_cooldowns (profile, command_type, limit_key, subject, tokens, updated_at) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE tokens = VALUES(tokens), updated_at = VALUES(updated_at)",
+		profile, commandType, cooldown.LimitKey, cooldown.Subject, cooldown.Tokens, cooldown.UpdatedAt)
+
+	return err
+}
+
+// DeleteCommandCooldowns 删除指令 commandType 在档案 profile 下的所有频率限制记录，返回被删除的记录数
+func DeleteCommandCooldowns(profile string, commandType consts.CommandType) (int64, error) {
+	res, err := db.Pool.Exec("DELETE FROM command_cooldowns WHERE profile = ? AND command_type = ?", profile, commandType)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
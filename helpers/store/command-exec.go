@@ -1,6 +1,9 @@
 package store
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/Subilan/go-aliyunmc/consts"
@@ -10,12 +13,45 @@ import (
 type CommandExec struct {
 	Id        int64              `json:"id"`
 	Type      consts.CommandType `json:"type"`
+	Profile   string             `json:"profile"`
 	By        *int64             `json:"by"`
 	Status    string             `json:"status"`
 	CreatedAt time.Time          `json:"createdAt"`
 	UpdatedAt time.Time          `json:"updatedAt"`
 	Auto      bool               `json:"auto"`
 	Comment   *string            `json:"comment"`
+	Args      CommandArgs        `json:"args"`
+}
+
+// CommandArgs 是一次指令执行的参数，在数据库中以 JSON 格式存储
+type CommandArgs map[string]string
+
+func (a CommandArgs) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+
+	marshalled, err := json.Marshal(a)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshalled), nil
+}
+
+func (a *CommandArgs) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+
+	return fmt.Errorf("cannot scan %T into CommandArgs", src)
 }
 
 type JoinedCommandExec struct {
@@ -23,13 +59,13 @@ type JoinedCommandExec struct {
 	Username *string `json:"username"`
 }
 
-const q = "SELECT id, `type`, `by`, `status`, created_at, updated_at, auto FROM command_exec "
+const q = "SELECT id, `type`, profile, `by`, `status`, created_at, updated_at, auto FROM command_exec "
 
-func GetLatestSuccessCommandExecByType(typ consts.CommandType) (*CommandExec, error) {
+func GetLatestSuccessCommandExecByType(profile string, typ consts.CommandType) (*CommandExec, error) {
 	var result CommandExec
 
-	err := db.Pool.QueryRow(q+"WHERE type = ? AND status = 'success' ORDER BY updated_at DESC LIMIT 1", typ).
-		Scan(&result.Id, &result.Type, &result.By, &result.Status, &result.CreatedAt, &result.UpdatedAt, &result.Auto)
+	err := db.Pool.QueryRow(q+"WHERE profile = ? AND type = ? AND status = 'success' ORDER BY updated_at DESC LIMIT 1", profile, typ).
+		Scan(&result.Id, &result.Type, &result.Profile, &result.By, &result.Status, &result.CreatedAt, &result.UpdatedAt, &result.Auto)
 
 	if err != nil {
 		return nil, err
@@ -38,10 +74,10 @@ func GetLatestSuccessCommandExecByType(typ consts.CommandType) (*CommandExec, er
 	return &result, nil
 }
 
-func GetExistingBackups() ([]*CommandExec, error) {
+func GetExistingBackups(profile string) ([]*CommandExec, error) {
 	var result = make([]*CommandExec, 0, 5)
 
-	rows, err := db.Pool.Query(q+"WHERE type = ? AND status = 'success' ORDER BY updated_at DESC LIMIT 5", consts.CmdTypeBackupWorlds)
+	rows, err := db.Pool.Query(q+"WHERE profile = ? AND type = ? AND status = 'success' ORDER BY updated_at DESC LIMIT 5", profile, consts.CmdTypeBackupWorlds)
 
 	if err != nil {
 		return nil, err
@@ -50,7 +86,7 @@ func GetExistingBackups() ([]*CommandExec, error) {
 	defer rows.Close()
 	for rows.Next() {
 		var res CommandExec
-		err = rows.Scan(&res.Id, &res.Type, &res.By, &res.Status, &res.CreatedAt, &res.UpdatedAt, &res.Auto)
+		err = rows.Scan(&res.Id, &res.Type, &res.Profile, &res.By, &res.Status, &res.CreatedAt, &res.UpdatedAt, &res.Auto)
 
 		if err != nil {
 			return nil, err
@@ -61,3 +97,28 @@ func GetExistingBackups() ([]*CommandExec, error) {
 
 	return result, nil
 }
+
+// CommandExecDetail 是一次指令执行的完整记录，包括输出、退出码以及开始和结束的时间
+type CommandExecDetail struct {
+	JoinedCommandExec
+	InstanceId *string    `json:"instanceId"`
+	Stdout     *string    `json:"stdout"`
+	Stderr     *string    `json:"stderr"`
+	ExitCode   *int       `json:"exitCode"`
+	StartedAt  *time.Time `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+// GetCommandExecDetail 获取档案 profile 中标识符为 id 的指令执行的完整记录
+func GetCommandExecDetail(profile string, id int64) (*CommandExecDetail, error) {
+	var r CommandExecDetail
+
+	err := db.Pool.QueryRow("SELECT c.id, c.type, c.profile, c.by, c.created_at, c.updated_at, c.status, c.auto, c.comment, c.args, u.username, c.instance_id, c.stdout, c.stderr, c.exit_code, c.started_at, c.finished_at FROM command_exec c LEFT JOIN users u ON c.by=u.id WHERE c.profile = ? AND c.id = ?", profile, id).
+		Scan(&r.Id, &r.Type, &r.Profile, &r.By, &r.CreatedAt, &r.UpdatedAt, &r.Status, &r.Auto, &r.Comment, &r.Args, &r.Username, &r.InstanceId, &r.Stdout, &r.Stderr, &r.ExitCode, &r.StartedAt, &r.FinishedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
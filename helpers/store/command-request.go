@@ -0,0 +1,139 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+const (
+	// CommandRequestPending 表示请求正在等待批准或投票
+	CommandRequestPending = "pending"
+	// CommandRequestApproved 表示请求已经通过，指令已经开始执行
+	CommandRequestApproved = "approved"
+	// CommandRequestRejected 表示请求被管理员或者投票否决
+	CommandRequestRejected = "rejected"
+	// CommandRequestExpired 表示请求在有效时间内没有得到结果
+	CommandRequestExpired = "expired"
+	// CommandRequestFailed 表示请求已经通过，但指令无法执行
+	CommandRequestFailed = "failed"
+)
+
+// CommandRequest 是用户提出的执行指令的请求，参见 config.CommandRequestConfig
+type CommandRequest struct {
+	Id          int64              `json:"id"`
+	Profile     string             `json:"profile"`
+	CommandType consts.CommandType `json:"commandType"`
+	Args        CommandArgs        `json:"args"`
+	Reason      string             `json:"reason"`
+	CreatedBy   int64              `json:"createdBy"`
+	Status      string             `json:"status"`
+	DecidedBy   *int64             `json:"decidedBy"`
+	Comment     *string            `json:"comment"`
+	TaskId      *string            `json:"taskId"`
+	ExpiresAt   time.Time          `json:"expiresAt"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+
+	// Yes 是赞成票数
+	Yes int `json:"yes"`
+
+	// No 是反对票数
+	No int `json:"no"`
+}
+
+const commandRequestQuery = "SELECT r.id, r.profile, r.command_type, r.args, r.reason, r.created_by, r.status, r.decided_by, r.comment, r.task_id, r.expires_at, r.created_at, r.updated_at, " +
+	"(SELECT COUNT(*) FROM command_request_votes v WHERE v.request_id = r.id AND v.approve = 1), " +
+	"(SELECT COUNT(*) FROM command_request_votes v WHERE v.request_id = r.id AND v.approve = 0) " +
+	"FROM command_requests r "
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCommandRequest(row scanner) (*CommandRequest, error) {
+	var r CommandRequest
+
+	err := row.Scan(&r.Id, &r.Profile, &r.CommandType, &r.Args, &r.Reason, &r.CreatedBy, &r.Status, &r.DecidedBy, &r.Comment, &r.TaskId, &r.ExpiresAt, &r.CreatedAt, &r.UpdatedAt, &r.Yes, &r.No)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// InsertCommandRequest 插入一个等待中的请求，返回请求的标识符
+func InsertCommandRequest(r *CommandRequest) (int64, error) {
+	res, err := db.Pool.Exec("INSERT INTO command_requests (profile, command_type, args, reason, created_by, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		r.Profile, r.CommandType, r.Args, r.Reason, r.CreatedBy, r.ExpiresAt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// GetCommandRequest 获取标识符为 id 的请求及其票数
+func GetCommandRequest(id int64) (*CommandRequest, error) {
+	return scanCommandRequest(db.Pool.QueryRow(commandRequestQuery+"WHERE r.id = ?", id))
+}
+
+// queryCommandRequests 获取满足条件 where 的请求及其票数
+func queryCommandRequests(where string, args ...any) ([]*CommandRequest, error) {
+	var result = make([]*CommandRequest, 0)
+
+	rows, err := db.Pool.Query(commandRequestQuery+where, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		r, err := scanCommandRequest(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// GetCommandRequests 分页获取档案 profile 的请求，按照创建时间倒序排列。status 为空表示不限制状态
+func GetCommandRequests(profile string, status string, limit int, offset int) ([]*CommandRequest, error) {
+	return queryCommandRequests("WHERE r.profile = ? AND (? = '' OR r.status = ?) ORDER BY r.created_at DESC LIMIT ? OFFSET ?", profile, status, status, limit, offset)
+}
+
+// GetPendingCommandRequests 获取所有档案中等待中的请求
+func GetPendingCommandRequests() ([]*CommandRequest, error) {
+	return queryCommandRequests("WHERE r.status = ?", CommandRequestPending)
+}
+
+// HasPendingCommandRequest 返回档案 profile 中是否存在请求执行指令 commandType 的等待中的请求
+func HasPendingCommandRequest(profile string, commandType consts.CommandType) (bool, error) {
+	var cnt int
+
+	err := db.Pool.QueryRow("SELECT COUNT(*) FROM command_requests WHERE profile = ? AND command_type = ? AND status = ?", profile, commandType, CommandRequestPending).Scan(&cnt)
+
+	return cnt > 0, err
+}
+
+// InsertCommandRequestVote 记录用户 userId 对请求 requestId 的投票。同一用户重复投票会导致重复键错误，参见 IsDuplicateEntryError
+func InsertCommandRequestVote(requestId int64, userId int64, approve bool) error {
+	_, err := db.Pool.Exec("INSERT INTO command_request_votes (request_id, user_id, approve) VALUES (?, ?, ?)", requestId, userId, approve)
+
+	return err
+}
+
+// UpdateCommandRequestResult 更新请求 id 的状态及结果
+func UpdateCommandRequestResult(id int64, status string, decidedBy *int64, comment *string, taskId *string) error {
+	_, err := db.Pool.Exec("UPDATE command_requests SET status = ?, decided_by = ?, comment = ?, task_id = ? WHERE id = ?", status, decidedBy, comment, taskId, id)
+
+	return err
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+// CommandSchedule 是一个通过接口添加的定时指令计划，字段含义参见 config.CommandScheduleConfig
+type CommandSchedule struct {
+	Name      string             `json:"name"`
+	Profile   string             `json:"profile"`
+	Cron      string             `json:"cron"`
+	Command   consts.CommandType `json:"command"`
+	Args      CommandArgs        `json:"args"`
+	Message   string             `json:"message"`
+	CreatedBy *int64             `json:"createdBy"`
+	CreatedAt *time.Time         `json:"createdAt"`
+}
+
+// InsertCommandSchedule 记录一个通过接口添加的定时指令计划
+func InsertCommandSchedule(schedule *CommandSchedule) error {
+	_, err := db.Pool.Exec("INSERT INTO command_schedules (name, profile, cron, command_type, args, message, created_by) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		schedule.Name, schedule.Profile, schedule.Cron, schedule.Command, schedule.Args, schedule.Message, schedule.CreatedBy)
+
+	return err
+}
+
+// GetCommandSchedules 获取所有通过接口添加的定时指令计划
+func GetCommandSchedules() ([]*CommandSchedule, error) {
+	var result = make([]*CommandSchedule, 0)
+
+	rows, err := db.Pool.Query("SELECT name, profile, cron, command_type, args, message, created_by, created_at FROM command_schedules ORDER BY created_at")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res CommandSchedule
+
+		err = rows.Scan(&res.Name, &res.Profile, &res.Cron, &res.Command, &res.Args, &res.Message, &res.CreatedBy, &res.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// DeleteCommandSchedule 删除名称为 name 的定时指令计划，返回计划是否存在
+func DeleteCommandSchedule(name string) (bool, error) {
+	res, err := db.Pool.Exec("DELETE FROM command_schedules WHERE name = ?", name)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+
+	return affected > 0, err
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+const (
+	// CrashIncidentRestarting 表示系统正在尝试重新开启服务器
+	CrashIncidentRestarting = "restarting"
+	// CrashIncidentRecovered 表示服务器已经重新上线
+	CrashIncidentRecovered = "recovered"
+	// CrashIncidentGaveUp 表示重启次数达到上限，系统放弃重启
+	CrashIncidentGaveUp = "gave_up"
+	// CrashIncidentAborted 表示重启期间实例停止运行、服务器被手动开启或者关闭，系统不再处理该故障
+	CrashIncidentAborted = "aborted"
+)
+
+// CrashIncident 是服务器的一次意外退出以及系统对其的处理，参见 monitors.CrashWatchdog
+type CrashIncident struct {
+	Id         int64      `json:"id"`
+	Profile    string     `json:"profile"`
+	InstanceId string     `json:"instanceId"`
+	DetectedAt time.Time  `json:"detectedAt"`
+	Report     *string    `json:"report"`
+	Attempts   int        `json:"attempts"`
+	Status     string     `json:"status"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+}
+
+// InsertCrashIncident 记录一次故障，并填入记录的 Id
+func InsertCrashIncident(incident *CrashIncident) error {
+	row, err := db.Pool.Exec("INSERT INTO crash_incidents (profile, instance_id, detected_at, report, attempts, status) VALUES (?, ?, ?, ?, ?, ?)",
+		incident.Profile, incident.InstanceId, incident.DetectedAt, incident.Report, incident.Attempts, incident.Status)
+
+	if err != nil {
+		return err
+	}
+
+	incident.Id, err = row.LastInsertId()
+
+	return err
+}
+
+// UpdateCrashIncident 更新故障的重启次数、状态和处理结束的时间
+func UpdateCrashIncident(incident *CrashIncident) error {
+	_, err := db.Pool.Exec("UPDATE crash_incidents SET attempts = ?, status = ?, resolved_at = ? WHERE id = ?",
+		incident.Attempts, incident.Status, incident.ResolvedAt, incident.Id)
+
+	return err
+}
+
+// GetCrashIncidents 分页获取档案 profile 的故障，按照发现时间倒序排列
+func GetCrashIncidents(profile string, limit int, offset int) ([]*CrashIncident, error) {
+	var result = make([]*CrashIncident, 0)
+
+	rows, err := db.Pool.Query("SELECT id, profile, instance_id, detected_at, report, attempts, status, resolved_at FROM crash_incidents WHERE profile = ? ORDER BY detected_at DESC LIMIT ? OFFSET ?",
+		profile, limit, offset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res CrashIncident
+
+		err = rows.Scan(&res.Id, &res.Profile, &res.InstanceId, &res.DetectedAt, &res.Report, &res.Attempts, &res.Status, &res.ResolvedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
@@ -22,10 +22,22 @@ type WhitelistItem struct {
 	Name string `json:"name"`
 }
 
+// IsWhitelisted 返回游戏名 gameId 是否在任意一个档案的白名单中
 func IsWhitelisted(gameId string) bool {
+	for _, profile := range config.Cfg.ProfileNames() {
+		if IsWhitelistedIn(profile, gameId) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWhitelistedIn 返回游戏名 gameId 是否在档案 profile 的白名单中
+func IsWhitelistedIn(profile string, gameId string) bool {
 	var whitelist []WhitelistItem
 
-	whitelistContent, err := os.ReadFile(config.Cfg.Monitor.Whitelist.CacheFile)
+	whitelistContent, err := os.ReadFile(config.Cfg.Monitor.Whitelist.ProfileCacheFile(profile))
 
 	if err != nil {
 		return false
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+// GameEvent 是从服务器日志中解析出的一个游戏事件，参见 monitors.GameLog
+type GameEvent struct {
+	Id      int64   `json:"id"`
+	Profile string  `json:"profile"`
+	Kind    string  `json:"kind"`
+	Player  *string `json:"player"`
+
+	// IP 是玩家进入服务器时的IP地址，只向管理员展示
+	IP *string `json:"ip,omitempty"`
+
+	Message    *string   `json:"message"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// GameEventFilter 是搜索游戏事件的条件，零值表示不限制
+type GameEventFilter struct {
+	// Kinds 是事件的类型
+	Kinds []string
+
+	// Player 是事件相关的玩家
+	Player string
+
+	// Keyword 是消息中包含的内容
+	Keyword string
+
+	// From 和 To 是事件发生的时间范围 [From, To)
+	From *time.Time
+	To   *time.Time
+}
+
+// InsertGameEvent 记录一个游戏事件，并填入记录的 Id
+func InsertGameEvent(e *GameEvent) error {
+	row, err := db.Pool.Exec("INSERT INTO game_events (profile, kind, player, ip, message, occurred_at) VALUES (?, ?, ?, ?, ?, ?)",
+		e.Profile, e.Kind, e.Player, e.IP, e.Message, e.OccurredAt)
+
+	if err != nil {
+		return err
+	}
+
+	e.Id, err = row.LastInsertId()
+
+	return err
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SearchGameEvents 分页搜索档案 profile 中满足条件 filter 的游戏事件，按照发生时间倒序排列
+func SearchGameEvents(profile string, filter GameEventFilter, limit int, offset int) ([]*GameEvent, error) {
+	var result = make([]*GameEvent, 0)
+
+	querySQL := "SELECT id, profile, kind, player, ip, message, occurred_at FROM game_events WHERE profile = ?"
+	params := []any{profile}
+
+	if len(filter.Kinds) > 0 {
+		querySQL += " AND kind IN (?" + strings.Repeat(", ?", len(filter.Kinds)-1) + ")"
+
+		for _, kind := range filter.Kinds {
+			params = append(params, kind)
+		}
+	}
+
+	if filter.Player != "" {
+		querySQL += " AND player = ?"
+		params = append(params, filter.Player)
+	}
+
+	if filter.Keyword != "" {
+		querySQL += " AND message LIKE ?"
+		params = append(params, "%"+escapeLike(filter.Keyword)+"%")
+	}
+
+	if filter.From != nil {
+		querySQL += " AND occurred_at >= ?"
+		params = append(params, *filter.From)
+	}
+
+	if filter.To != nil {
+		querySQL += " AND occurred_at < ?"
+		params = append(params, *filter.To)
+	}
+
+	querySQL += " ORDER BY occurred_at DESC, id DESC LIMIT ? OFFSET ?"
+	params = append(params, limit, offset)
+
+	rows, err := db.Pool.Query(querySQL, params...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res GameEvent
+
+		err = rows.Scan(&res.Id, &res.Profile, &res.Kind, &res.Player, &res.IP, &res.Message, &res.OccurredAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// DeleteGameEventsBefore 删除档案 profile 中 before 之前的游戏事件
+func DeleteGameEventsBefore(profile string, before time.Time) error {
+	_, err := db.Pool.Exec("DELETE FROM game_events WHERE profile = ? AND occurred_at < ?", profile, before)
+
+	return err
+}
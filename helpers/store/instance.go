@@ -9,6 +9,7 @@ import (
 
 type Instance struct {
 	InstanceId   string     `json:"instanceId"`
+	Profile      string     `json:"profile"`
 	InstanceType string     `json:"instanceType"`
 	RegionId     string     `json:"regionId"`
 	ZoneId       string     `json:"zoneId"`
@@ -17,13 +18,17 @@ type Instance struct {
 	Deployed     bool       `json:"deployed"`
 	Ip           *string    `json:"ip"`
 	VSwitchId    string     `json:"vswitchId"`
+	HibernatedAt *time.Time `json:"hibernatedAt"`
+	SnapshotId   *string    `json:"snapshotId"`
+	BakedImageId *string    `json:"bakedImageId"`
 }
 
-func getInstance(cond string) (*Instance, error) {
+func getInstance(cond string, args ...any) (*Instance, error) {
 	var result Instance
 
-	err := db.Pool.QueryRow("SELECT instance_id, instance_type, region_id, zone_id, deleted_at, created_at, ip, deployed, vswitch_id FROM instances "+cond).Scan(
+	err := db.Pool.QueryRow("SELECT instance_id, profile, instance_type, region_id, zone_id, deleted_at, created_at, ip, deployed, vswitch_id, hibernated_at, snapshot_id, baked_image_id FROM instances "+cond, args...).Scan(
 		&result.InstanceId,
+		&result.Profile,
 		&result.InstanceType,
 		&result.RegionId,
 		&result.ZoneId,
@@ -32,6 +37,9 @@ func getInstance(cond string) (*Instance, error) {
 		&result.Ip,
 		&result.Deployed,
 		&result.VSwitchId,
+		&result.HibernatedAt,
+		&result.SnapshotId,
+		&result.BakedImageId,
 	)
 
 	if err != nil {
@@ -41,10 +49,15 @@ func getInstance(cond string) (*Instance, error) {
 	return &result, nil
 }
 
-// GetIpAllocatedActiveInstance 从数据库获取当前的活动实例
+// GetActiveInstance 从数据库获取档案 profile 当前的活动实例，不论其是否分配了IP地址
+func GetActiveInstance(profile string) (*Instance, error) {
+	return getInstance("WHERE profile = ? AND deleted_at IS NULL", profile)
+}
+
+// GetIpAllocatedActiveInstance 从数据库获取档案 profile 当前的活动实例
 // 如果找不到实例，或者活动实例没有分配IP地址，返回 nil
-func GetIpAllocatedActiveInstance() (*Instance, error) {
-	result, err := getInstance("WHERE deleted_at IS NULL")
+func GetIpAllocatedActiveInstance(profile string) (*Instance, error) {
+	result, err := getInstance("WHERE profile = ? AND deleted_at IS NULL", profile)
 
 	if err != nil {
 		return nil, err
@@ -57,8 +70,8 @@ func GetIpAllocatedActiveInstance() (*Instance, error) {
 	return result, nil
 }
 
-func GetDeployedActiveInstance() (*Instance, error) {
-	result, err := getInstance("WHERE deleted_at IS NULL AND deployed = 1")
+func GetDeployedActiveInstance(profile string) (*Instance, error) {
+	result, err := getInstance("WHERE profile = ? AND deleted_at IS NULL AND deployed = 1", profile)
 
 	if err != nil {
 		return nil, err
@@ -71,6 +84,16 @@ func GetDeployedActiveInstance() (*Instance, error) {
 	return result, nil
 }
 
-func GetLatestInstance() (*Instance, error) {
-	return getInstance("ORDER BY created_at DESC LIMIT 1")
+func GetLatestInstance(profile string) (*Instance, error) {
+	return getInstance("WHERE profile = ? ORDER BY created_at DESC LIMIT 1", profile)
+}
+
+// GetHibernatedActiveInstance 从数据库获取档案 profile 当前处于休眠状态的活动实例
+func GetHibernatedActiveInstance(profile string) (*Instance, error) {
+	return getInstance("WHERE profile = ? AND deleted_at IS NULL AND hibernated_at IS NOT NULL", profile)
+}
+
+// GetInstanceById 根据实例标识符获取实例记录
+func GetInstanceById(instanceId string) (*Instance, error) {
+	return getInstance("WHERE instance_id = ?", instanceId)
 }
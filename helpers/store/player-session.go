@@ -0,0 +1,251 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+// PlayerSession 是玩家在某个档案的服务器中从进入到离开的一次会话
+type PlayerSession struct {
+	Id       int64      `json:"id"`
+	Profile  string     `json:"profile"`
+	GameId   string     `json:"gameId"`
+	UserId   *int64     `json:"userId"`
+	JoinedAt time.Time  `json:"joinedAt"`
+	LeftAt   *time.Time `json:"leftAt"`
+
+	// Duration 是会话的时长，单位秒。仍在进行的会话计算到当前时间
+	Duration int64 `json:"duration"`
+}
+
+// PlayerStats 是玩家在某个档案中的游玩统计
+type PlayerStats struct {
+	GameId string `json:"gameId"`
+
+	// UserId 是当前绑定了该游戏名的用户，没有绑定时为空
+	UserId *int64 `json:"userId"`
+
+	// Playtime 是统计范围内的总游玩时长，单位秒
+	Playtime int64 `json:"playtime"`
+
+	// Sessions 是统计范围内的会话数量
+	Sessions int `json:"sessions"`
+
+	// FirstJoinedAt 是统计范围内第一次进入服务器的时间
+	FirstJoinedAt time.Time `json:"firstJoinedAt"`
+
+	// LastSeenAt 是最后一次在服务器中的时间，仍然在线时为当前时间
+	LastSeenAt time.Time `json:"lastSeenAt"`
+
+	// Online 表示玩家当前是否在线
+	Online bool `json:"online"`
+}
+
+// DailyActivePlayers 是某一天在服务器中出现过的玩家数量
+type DailyActivePlayers struct {
+	// Date 是日期，格式为 2006-01-02，使用系统本地时区
+	Date    string `json:"date"`
+	Players int    `json:"players"`
+}
+
+// OpenPlayerSession 记录玩家 gameId 在时间 at 进入档案 profile 的服务器。如果存在绑定了该游戏名的用户，会话关联到该用户
+func OpenPlayerSession(profile string, gameId string, at time.Time) error {
+	_, err := db.Pool.Exec("INSERT INTO player_sessions (profile, game_id, user_id, joined_at, seen_at) VALUES (?, ?, (SELECT user_id FROM game_bounds WHERE game_id = ?), ?, ?)",
+		profile, gameId, gameId, at, at)
+
+	return err
+}
+
+// ClosePlayerSession 结束玩家 gameId 在档案 profile 中未结束的会话，离开时间为 at
+func ClosePlayerSession(profile string, gameId string, at time.Time) error {
+	_, err := db.Pool.Exec("UPDATE player_sessions SET left_at = ?, seen_at = ? WHERE profile = ? AND game_id = ? AND left_at IS NULL", at, at, profile, gameId)
+
+	return err
+}
+
+// TouchPlayerSessions 将档案 profile 中所有未结束的会话的最后确认在线时间更新为 at
+func TouchPlayerSessions(profile string, at time.Time) error {
+	_, err := db.Pool.Exec("UPDATE player_sessions SET seen_at = ? WHERE profile = ? AND left_at IS NULL", at, profile)
+
+	return err
+}
+
+// CloseStalePlayerSessions 以最后确认在线的时间结束档案 profile 中所有未结束的会话，用于系统启动时处理异常退出前遗留的会话
+func CloseStalePlayerSessions(profile string) error {
+	_, err := db.Pool.Exec("UPDATE player_sessions SET left_at = seen_at WHERE profile = ? AND left_at IS NULL", profile)
+
+	return err
+}
+
+// playerStatsQuery 按玩家统计会话。第一个参数是统计的开始时间，为 nil 表示不限制，早于开始时间的部分不计入游玩时长
+const playerStatsQuery = "SELECT s.game_id, MAX(b.user_id), " +
+	"SUM(TIMESTAMPDIFF(SECOND, GREATEST(s.joined_at, COALESCE(?, s.joined_at)), COALESCE(s.left_at, NOW()))), " +
+	"COUNT(*), MIN(s.joined_at), MAX(COALESCE(s.left_at, NOW())), MAX(s.left_at IS NULL) " +
+	"FROM player_sessions s LEFT JOIN game_bounds b ON b.game_id = s.game_id "
+
+func queryPlayerStats(since *time.Time, where string, args ...any) ([]*PlayerStats, error) {
+	var result = make([]*PlayerStats, 0)
+
+	rows, err := db.Pool.Query(playerStatsQuery+where, append([]any{since}, args...)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res PlayerStats
+
+		err = rows.Scan(&res.GameId, &res.UserId, &res.Playtime, &res.Sessions, &res.FirstJoinedAt, &res.LastSeenAt, &res.Online)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// GetPlayerStats 获取玩家 gameId 在档案 profile 中的游玩统计。玩家从未进入过服务器时返回 nil
+func GetPlayerStats(profile string, gameId string) (*PlayerStats, error) {
+	result, err := queryPlayerStats(nil, "WHERE s.profile = ? AND s.game_id = ? GROUP BY s.game_id", profile, gameId)
+
+	if err != nil || len(result) == 0 {
+		return nil, err
+	}
+
+	return result[0], nil
+}
+
+// GetPlayerStatsInProfiles 获取玩家 gameId 在每个档案中的游玩统计，键为档案名称
+func GetPlayerStatsInProfiles(gameId string) (map[string]*PlayerStats, error) {
+	var result = make(map[string]*PlayerStats)
+
+	rows, err := db.Pool.Query("SELECT profile FROM player_sessions WHERE game_id = ? GROUP BY profile", gameId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []string
+
+	for rows.Next() {
+		var profile string
+
+		if err := rows.Scan(&profile); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		stats, err := GetPlayerStats(profile, gameId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if stats != nil {
+			result[profile] = stats
+		}
+	}
+
+	return result, nil
+}
+
+// GetPlaytimeLeaderboard 获取档案 profile 中在 since 之后游玩时长最长的 limit 名玩家。since 为 nil 表示统计所有时间
+func GetPlaytimeLeaderboard(profile string, since *time.Time, limit int) ([]*PlayerStats, error) {
+	return queryPlayerStats(since, "WHERE s.profile = ? AND COALESCE(s.left_at, NOW()) > COALESCE(?, s.joined_at) GROUP BY s.game_id ORDER BY 3 DESC LIMIT ?", profile, since, limit)
+}
+
+// GetPlayerSessions 分页获取玩家 gameId 在档案 profile 中的会话，按照进入时间倒序排列
+func GetPlayerSessions(profile string, gameId string, limit int, offset int) ([]*PlayerSession, error) {
+	var result = make([]*PlayerSession, 0)
+
+	rows, err := db.Pool.Query("SELECT id, profile, game_id, user_id, joined_at, left_at, TIMESTAMPDIFF(SECOND, joined_at, COALESCE(left_at, NOW())) FROM player_sessions WHERE profile = ? AND game_id = ? ORDER BY joined_at DESC LIMIT ? OFFSET ?",
+		profile, gameId, limit, offset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res PlayerSession
+
+		err = rows.Scan(&res.Id, &res.Profile, &res.GameId, &res.UserId, &res.JoinedAt, &res.LeftAt, &res.Duration)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// GetDailyActivePlayers 获取档案 profile 最近 days 天（包括今天）中每天在服务器中出现过的玩家数量，按日期升序排列。跨越午夜的会话计入其经过的每一天
+func GetDailyActivePlayers(profile string, days int) ([]DailyActivePlayers, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	rows, err := db.Pool.Query("SELECT game_id, joined_at, COALESCE(left_at, NOW()) FROM player_sessions WHERE profile = ? AND COALESCE(left_at, NOW()) >= ?", profile, from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	players := make([]map[string]struct{}, days)
+
+	for i := range players {
+		players[i] = make(map[string]struct{})
+	}
+
+	for rows.Next() {
+		var gameId string
+		var joinedAt, leftAt time.Time
+
+		if err := rows.Scan(&gameId, &joinedAt, &leftAt); err != nil {
+			return nil, err
+		}
+
+		for i := range players {
+			dayStart := from.AddDate(0, 0, i)
+			dayEnd := dayStart.AddDate(0, 0, 1)
+
+			if joinedAt.Before(dayEnd) && !leftAt.Before(dayStart) {
+				players[i][gameId] = struct{}{}
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]DailyActivePlayers, days)
+
+	for i := range result {
+		result[i] = DailyActivePlayers{
+			Date:    from.AddDate(0, 0, i).Format(time.DateOnly),
+			Players: len(players[i]),
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+// Schedule 是一个通过接口添加的开放时段计划，字段含义参见 config.ScheduleConfig
+type Schedule struct {
+	Name      string     `json:"name"`
+	Profile   string     `json:"profile"`
+	Cron      string     `json:"cron"`
+	Duration  int        `json:"duration"`
+	LeadTime  int        `json:"leadTime"`
+	Warnings  []int      `json:"warnings"`
+	Protected bool       `json:"protected"`
+	CreatedBy *int64     `json:"createdBy"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// joinWarnings 将警告时间点列表转换为以逗号分隔的文本
+func joinWarnings(warnings []int) string {
+	parts := make([]string, 0, len(warnings))
+
+	for _, w := range warnings {
+		parts = append(parts, strconv.Itoa(w))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// splitWarnings 将以逗号分隔的文本转换为警告时间点列表
+func splitWarnings(s string) []int {
+	result := make([]int, 0)
+
+	for _, part := range strings.Split(s, ",") {
+		if w, err := strconv.Atoi(part); err == nil {
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
+
+// InsertSchedule 记录一个通过接口添加的计划
+func InsertSchedule(schedule *Schedule) error {
+	_, err := db.Pool.Exec("INSERT INTO schedules (name, profile, cron, duration, lead_time, warnings, protected, created_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		schedule.Name, schedule.Profile, schedule.Cron, schedule.Duration, schedule.LeadTime, joinWarnings(schedule.Warnings), schedule.Protected, schedule.CreatedBy)
+
+	return err
+}
+
+// GetSchedules 获取所有通过接口添加的计划
+func GetSchedules() ([]*Schedule, error) {
+	var result = make([]*Schedule, 0)
+
+	rows, err := db.Pool.Query("SELECT name, profile, cron, duration, lead_time, warnings, protected, created_by, created_at FROM schedules ORDER BY created_at")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res Schedule
+		var warnings string
+
+		err = rows.Scan(&res.Name, &res.Profile, &res.Cron, &res.Duration, &res.LeadTime, &warnings, &res.Protected, &res.CreatedBy, &res.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res.Warnings = splitWarnings(warnings)
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// DeleteSchedule 删除名称为 name 的计划及其跳过记录，返回计划是否存在
+func DeleteSchedule(name string) (bool, error) {
+	res, err := db.Pool.Exec("DELETE FROM schedules WHERE name = ?", name)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	_, err = db.Pool.Exec("DELETE FROM schedule_skips WHERE schedule_name = ?", name)
+
+	return affected > 0, err
+}
+
+// InsertScheduleSkip 记录计划 name 在 startsAt 开始的开放时段被跳过。重复跳过同一时段没有效果
+func InsertScheduleSkip(name string, startsAt time.Time, by *int64) error {
+	_, err := db.Pool.Exec("INSERT IGNORE INTO schedule_skips (schedule_name, starts_at, created_by) VALUES (?, ?, ?)", name, startsAt, by)
+
+	return err
+}
+
+// GetScheduleSkips 获取计划 name 在 from 之后开始的被跳过的开放时段的开始时间
+func GetScheduleSkips(name string, from time.Time) ([]time.Time, error) {
+	var result = make([]time.Time, 0)
+
+	rows, err := db.Pool.Query("SELECT starts_at FROM schedule_skips WHERE schedule_name = ? AND starts_at >= ?", name, from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var t time.Time
+
+		if err = rows.Scan(&t); err != nil {
+			return nil, err
+		}
+
+		result = append(result, t)
+	}
+
+	return result, rows.Err()
+}
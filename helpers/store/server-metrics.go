@@ -0,0 +1,110 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+// MetricsSample 是服务器和实例性能指标的一次采样，或者一段时间内采样的平均值。采集失败的指标为 nil，参见 monitors.Metrics
+type MetricsSample struct {
+	SampledAt time.Time `json:"sampledAt"`
+
+	TPS  *float64 `json:"tps"`
+	MSPT *float64 `json:"mspt"`
+
+	CPU       *float64 `json:"cpu"`
+	MemUsed   *int64   `json:"memUsed"`
+	MemTotal  *int64   `json:"memTotal"`
+	Load1     *float64 `json:"load1"`
+	Load5     *float64 `json:"load5"`
+	Load15    *float64 `json:"load15"`
+	DiskUsed  *int64   `json:"diskUsed"`
+	DiskTotal *int64   `json:"diskTotal"`
+}
+
+// metricsAnchor 是合并采样时划分时间段的起点
+var metricsAnchor = time.Unix(0, 0)
+
+// metricsAggregates 是合并采样时各指标的聚合方式。总量取最大值，其余取平均值
+const metricsAggregates = "AVG(tps), AVG(mspt), AVG(cpu), ROUND(AVG(mem_used)), MAX(mem_total), AVG(load1), AVG(load5), AVG(load15), ROUND(AVG(disk_used)), MAX(disk_total)"
+
+// InsertMetricsSample 记录档案 profile 的一次原始采样，resolution 是采样的间隔
+func InsertMetricsSample(profile string, resolution time.Duration, s *MetricsSample) error {
+	_, err := db.Pool.Exec("INSERT INTO server_metrics (profile, resolution, sampled_at, tps, mspt, cpu, mem_used, mem_total, load1, load5, load15, disk_used, disk_total) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		profile, int(resolution.Seconds()), s.SampledAt, s.TPS, s.MSPT, s.CPU, s.MemUsed, s.MemTotal, s.Load1, s.Load5, s.Load15, s.DiskUsed, s.DiskTotal)
+
+	return err
+}
+
+// GetMetrics 获取档案 profile 在 [from, to) 内的采样，按照从 from 开始、长度为 step 的时间段取平均值，按时间升序排列。没有采样的时间段不返回
+func GetMetrics(profile string, from time.Time, to time.Time, step time.Duration) ([]*MetricsSample, error) {
+	var result = make([]*MetricsSample, 0)
+
+	seconds := int64(step.Seconds())
+
+	rows, err := db.Pool.Query("SELECT bucket, "+metricsAggregates+" FROM "+
+		"(SELECT *, TIMESTAMPDIFF(SECOND, ?, sampled_at) DIV ? AS bucket FROM server_metrics WHERE profile = ? AND sampled_at >= ? AND sampled_at < ?) t "+
+		"GROUP BY bucket ORDER BY bucket",
+		from, seconds, profile, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res MetricsSample
+		var bucket int64
+
+		err = rows.Scan(&bucket, &res.TPS, &res.MSPT, &res.CPU, &res.MemUsed, &res.MemTotal, &res.Load1, &res.Load5, &res.Load15, &res.DiskUsed, &res.DiskTotal)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res.SampledAt = from.Add(time.Duration(bucket*seconds) * time.Second)
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// DownsampleMetrics 将档案 profile 中 before 之前、粒度小于 step 的采样合并为 step 粒度的平均值。before 会向前对齐到时间段的边界，以免一个时间段被合并两次
+func DownsampleMetrics(profile string, before time.Time, step time.Duration) error {
+	seconds := int64(step.Seconds())
+	before = metricsAnchor.Add(before.Sub(metricsAnchor) / step * step)
+
+	tx, err := db.Pool.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO server_metrics (profile, resolution, sampled_at, tps, mspt, cpu, mem_used, mem_total, load1, load5, load15, disk_used, disk_total) "+
+		"SELECT profile, ?, DATE_ADD(?, INTERVAL bucket * ? SECOND), "+metricsAggregates+" FROM "+
+		"(SELECT *, TIMESTAMPDIFF(SECOND, ?, sampled_at) DIV ? AS bucket FROM server_metrics WHERE profile = ? AND resolution < ? AND sampled_at < ?) t "+
+		"GROUP BY profile, bucket",
+		seconds, metricsAnchor, seconds, metricsAnchor, seconds, profile, seconds, before)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM server_metrics WHERE profile = ? AND resolution < ? AND sampled_at < ?", profile, seconds, before)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteMetricsBefore 删除档案 profile 中 before 之前的采样
+func DeleteMetricsBefore(profile string, before time.Time) error {
+	_, err := db.Pool.Exec("DELETE FROM server_metrics WHERE profile = ? AND sampled_at < ?", profile, before)
+
+	return err
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+// ServerStatusSample 是服务器状态的一次每分钟采样，参见 monitors.ServerStatus
+type ServerStatusSample struct {
+	SampledAt time.Time `json:"sampledAt"`
+	Running   bool      `json:"running"`
+
+	// PlayerCount 是在线人数，服务器不在线时为 nil
+	PlayerCount *int64 `json:"playerCount"`
+
+	// LatencyMs 是获取服务器状态的延迟，单位毫秒，服务器不在线时为 nil
+	LatencyMs *int64 `json:"latencyMs"`
+}
+
+// ServerStatusRollup 是一段时间内服务器状态采样的汇总
+type ServerStatusRollup struct {
+	// Start 是时间段的开始时间
+	Start time.Time `json:"start"`
+
+	// Samples 是时间段内的采样数量
+	Samples int `json:"samples"`
+
+	// Uptime 是时间段内服务器在线的采样所占的比例，取值范围为 0-1
+	Uptime float64 `json:"uptime"`
+
+	// AvgPlayers 和 MaxPlayers 是服务器在线时的平均和最多在线人数，时间段内服务器一直不在线时为 nil
+	AvgPlayers *float64 `json:"avgPlayers"`
+	MaxPlayers *int64   `json:"maxPlayers"`
+
+	// AvgLatencyMs 是服务器在线时的平均延迟，单位毫秒
+	AvgLatencyMs *float64 `json:"avgLatencyMs"`
+}
+
+// PlayerActivity 是一周中某一时段服务器在线时的平均在线人数
+type PlayerActivity struct {
+	// Weekday 是星期，0 表示星期日，与 time.Weekday 一致
+	Weekday int `json:"weekday"`
+
+	// Hour 是小时，使用系统本地时区
+	Hour int `json:"hour"`
+
+	// AvgPlayers 是该时段服务器在线时的平均在线人数
+	AvgPlayers float64 `json:"avgPlayers"`
+
+	// Samples 是该时段服务器在线的采样数量
+	Samples int `json:"samples"`
+}
+
+// InsertServerStatusSample 记录档案 profile 的一次状态采样。同一分钟内已经存在采样时覆盖原采样
+func InsertServerStatusSample(profile string, s *ServerStatusSample) error {
+	_, err := db.Pool.Exec("INSERT INTO server_status_samples (profile, sampled_at, running, player_count, latency_ms) VALUES (?, ?, ?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE running = VALUES(running), player_count = VALUES(player_count), latency_ms = VALUES(latency_ms)",
+		profile, s.SampledAt.Truncate(time.Minute), s.Running, s.PlayerCount, s.LatencyMs)
+
+	return err
+}
+
+// GetServerStatusRollups 获取档案 profile 在 [from, to) 内的状态采样，按照从 from 开始、长度为 step 的时间段汇总，按时间升序排列。没有采样的时间段不返回
+func GetServerStatusRollups(profile string, from time.Time, to time.Time, step time.Duration) ([]*ServerStatusRollup, error) {
+	var result = make([]*ServerStatusRollup, 0)
+
+	seconds := int64(step.Seconds())
+
+	rows, err := db.Pool.Query("SELECT bucket, COUNT(*), AVG(running), AVG(player_count), MAX(player_count), AVG(latency_ms) FROM "+
+		"(SELECT *, TIMESTAMPDIFF(SECOND, ?, sampled_at) DIV ? AS bucket FROM server_status_samples WHERE profile = ? AND sampled_at >= ? AND sampled_at < ?) t "+
+		"GROUP BY bucket ORDER BY bucket",
+		from, seconds, profile, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res ServerStatusRollup
+		var bucket int64
+
+		err = rows.Scan(&bucket, &res.Samples, &res.Uptime, &res.AvgPlayers, &res.MaxPlayers, &res.AvgLatencyMs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res.Start = from.Add(time.Duration(bucket*seconds) * time.Second)
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// GetPlayerActivity 获取档案 profile 在 [from, to) 内一周中每个小时服务器在线时的平均在线人数，按星期和小时升序排列。服务器从未在线的时段不返回
+func GetPlayerActivity(profile string, from time.Time, to time.Time) ([]*PlayerActivity, error) {
+	var result = make([]*PlayerActivity, 0)
+
+	rows, err := db.Pool.Query("SELECT DAYOFWEEK(sampled_at) - 1 AS weekday, HOUR(sampled_at) AS hour, AVG(player_count), COUNT(*) FROM server_status_samples "+
+		"WHERE profile = ? AND sampled_at >= ? AND sampled_at < ? AND running = 1 GROUP BY weekday, hour ORDER BY weekday, hour",
+		profile, from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res PlayerActivity
+
+		err = rows.Scan(&res.Weekday, &res.Hour, &res.AvgPlayers, &res.Samples)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// GetAveragePlayerCount 获取档案 profile 在 [from, to) 内服务器在线时的平均在线人数，可供开关服策略参考。期间服务器从未在线时返回 nil
+func GetAveragePlayerCount(profile string, from time.Time, to time.Time) (*float64, error) {
+	var avg *float64
+
+	err := db.Pool.QueryRow("SELECT AVG(player_count) FROM server_status_samples WHERE profile = ? AND sampled_at >= ? AND sampled_at < ? AND running = 1",
+		profile, from, to).Scan(&avg)
+
+	return avg, err
+}
+
+// DeleteServerStatusSamplesBefore 删除档案 profile 中 before 之前的状态采样
+func DeleteServerStatusSamplesBefore(profile string, before time.Time) error {
+	_, err := db.Pool.Exec("DELETE FROM server_status_samples WHERE profile = ? AND sampled_at < ?", profile, before)
+
+	return err
+}
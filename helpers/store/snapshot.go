@@ -0,0 +1,90 @@
+package store
+
+import (
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+)
+
+type Snapshot struct {
+	SnapshotId string     `json:"snapshotId"`
+	Profile    string     `json:"profile"`
+	InstanceId string     `json:"instanceId"`
+	DiskId     string     `json:"diskId"`
+	DiskSize   int        `json:"diskSize"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	DeletedAt  *time.Time `json:"deletedAt"`
+}
+
+const snapshotQ = "SELECT snapshot_id, profile, instance_id, disk_id, disk_size, created_at, deleted_at FROM snapshots "
+
+func getSnapshots(cond string, args ...any) ([]*Snapshot, error) {
+	var result = make([]*Snapshot, 0, 5)
+
+	rows, err := db.Pool.Query(snapshotQ+cond, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var res Snapshot
+		err = rows.Scan(&res.SnapshotId, &res.Profile, &res.InstanceId, &res.DiskId, &res.DiskSize, &res.CreatedAt, &res.DeletedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &res)
+	}
+
+	return result, rows.Err()
+}
+
+// InsertSnapshot 记录一个新创建的快照
+func InsertSnapshot(snapshot *Snapshot) error {
+	_, err := db.Pool.Exec("INSERT INTO snapshots (snapshot_id, profile, instance_id, disk_id, disk_size) VALUES (?, ?, ?, ?, ?)",
+		snapshot.SnapshotId, snapshot.Profile, snapshot.InstanceId, snapshot.DiskId, snapshot.DiskSize)
+
+	return err
+}
+
+// GetSnapshots 获取档案 profile 所有未被删除的快照，按创建时间从新到旧排列
+func GetSnapshots(profile string) ([]*Snapshot, error) {
+	return getSnapshots("WHERE profile = ? AND deleted_at IS NULL ORDER BY created_at DESC", profile)
+}
+
+// GetLatestSnapshot 获取档案 profile 最新的未被删除的快照。如果没有快照，返回 sql.ErrNoRows
+func GetLatestSnapshot(profile string) (*Snapshot, error) {
+	var res Snapshot
+
+	err := db.Pool.QueryRow(snapshotQ+"WHERE profile = ? AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 1", profile).
+		Scan(&res.SnapshotId, &res.Profile, &res.InstanceId, &res.DiskId, &res.DiskSize, &res.CreatedAt, &res.DeletedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetSnapshotById 根据快照标识符获取快照记录
+func GetSnapshotById(snapshotId string) (*Snapshot, error) {
+	var res Snapshot
+
+	err := db.Pool.QueryRow(snapshotQ+"WHERE snapshot_id = ?", snapshotId).
+		Scan(&res.SnapshotId, &res.Profile, &res.InstanceId, &res.DiskId, &res.DiskSize, &res.CreatedAt, &res.DeletedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// MarkSnapshotDeleted 将快照 snapshotId 标记为已删除
+func MarkSnapshotDeleted(snapshotId string) error {
+	_, err := db.Pool.Exec("UPDATE snapshots SET deleted_at = CURRENT_TIMESTAMP WHERE snapshot_id = ?", snapshotId)
+	return err
+}
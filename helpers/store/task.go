@@ -11,7 +11,8 @@ import (
 type Task struct {
 	Id        string            `json:"id"`
 	Type      consts.TaskType   `json:"type"`
-	UserId    int               `json:"userId"`
+	UserId    *int64            `json:"userId"`
+	Profile   *string           `json:"profile"`
 	Status    consts.TaskStatus `json:"status"`
 	CreatedAt time.Time         `json:"createdAt"`
 	UpdatedAt *time.Time        `json:"updatedAt"`
@@ -19,10 +20,11 @@ type Task struct {
 
 type JoinedTask struct {
 	Task
-	Username string `json:"username"`
+	Username *string `json:"username"`
 }
 
-func InsertTask(taskType consts.TaskType, userId int64) (string, error) {
+// InsertTask 插入一条运行中的任务记录并返回任务的标识符。userId 为 nil 表示任务由系统自动发起
+func InsertTask(taskType consts.TaskType, profile string, userId *int64) (string, error) {
 	uuidS, err := uuid.NewRandom()
 
 	if err != nil {
@@ -31,7 +33,7 @@ func InsertTask(taskType consts.TaskType, userId int64) (string, error) {
 
 	taskId := uuidS.String()
 
-	_, err = db.Pool.Exec("INSERT INTO tasks (task_id, `type`, user_id) VALUES (?, ?, ?)", taskId, taskType, userId)
+	_, err = db.Pool.Exec("INSERT INTO tasks (task_id, `type`, profile, user_id) VALUES (?, ?, ?, ?)", taskId, taskType, profile, userId)
 
 	if err != nil {
 		return "", err
@@ -40,9 +42,9 @@ func InsertTask(taskType consts.TaskType, userId int64) (string, error) {
 	return taskId, nil
 }
 
-func GetRunningTaskCount(taskType consts.TaskType) (int, error) {
+func GetRunningTaskCount(taskType consts.TaskType, profile string) (int, error) {
 	var cnt int
-	err := db.Pool.QueryRow("SELECT COUNT(*) FROM tasks WHERE `type` = ? AND status = ?", taskType, consts.TaskStatusRunning).Scan(&cnt)
+	err := db.Pool.QueryRow("SELECT COUNT(*) FROM tasks WHERE `type` = ? AND profile = ? AND status = ?", taskType, profile, consts.TaskStatusRunning).Scan(&cnt)
 
 	if err != nil {
 		return 0, err
@@ -2,6 +2,8 @@ package store
 
 import (
 	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/db"
 )
 
 // Transaction represents the transactions table structure
@@ -14,3 +16,12 @@ type Transaction struct {
 	Remarks      *string   `json:"remarks,omitempty"`
 	BillingCycle string    `json:"billingCycle"`
 }
+
+// GetLatestBalance 获取最近一笔交易后的账户余额。没有交易记录时返回 sql.ErrNoRows
+func GetLatestBalance() (float64, error) {
+	var balance float64
+
+	err := db.Pool.QueryRow("SELECT balance FROM transactions ORDER BY time DESC LIMIT 1").Scan(&balance)
+
+	return balance, err
+}
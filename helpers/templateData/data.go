@@ -15,9 +15,13 @@ type DeployTemplateData struct {
 	JavaVersion     uint
 	DataDiskSize    int
 	ArchiveOSSPath  string
+	// RestoreFromSnapshot 表示实例的数据盘从归档快照创建，部署时直接挂载，无需格式化和从存储桶复制归档
+	RestoreFromSnapshot bool
+	// Baked 表示实例从预制镜像启动，部署时跳过软件源、Java 和 ossutil 的安装
+	Baked bool
 }
 
-func Deploy() DeployTemplateData {
+func Deploy(profile config.ProfileConfig) DeployTemplateData {
 	return DeployTemplateData{
 		Username:        "mc",
 		Password:        config.Cfg.Aliyun.Ecs.ProdPassword,
@@ -27,8 +31,8 @@ func Deploy() DeployTemplateData {
 		AccessKeyId:     config.Cfg.Aliyun.AccessKeyId,
 		AccessKeySecret: config.Cfg.Aliyun.AccessKeySecret,
 		JavaVersion:     config.Cfg.Deploy.JavaVersion,
-		DataDiskSize:    config.Cfg.Aliyun.Ecs.DataDisk.Size,
-		ArchiveOSSPath:  config.Cfg.Deploy.ArchiveOSSPath(),
+		DataDiskSize:    profile.DataDisk.Size,
+		ArchiveOSSPath:  config.Cfg.Deploy.OSSPath(profile.ArchivePath),
 	}
 }
 
@@ -38,10 +42,23 @@ type ArchiveTemplateData struct {
 	OSSRoot        string
 }
 
-func Archive() ArchiveTemplateData {
+func Archive(profile config.ProfileConfig) ArchiveTemplateData {
 	return ArchiveTemplateData{
-		ArchiveOSSPath: config.Cfg.Deploy.ArchiveOSSPath(),
-		BackupOSSPath:  config.Cfg.Deploy.BackupOSSPath(),
+		ArchiveOSSPath: config.Cfg.Deploy.OSSPath(profile.ArchivePath),
+		BackupOSSPath:  config.Cfg.Deploy.OSSPath(profile.BackupPath),
 		OSSRoot:        config.Cfg.Deploy.OSSRoot,
 	}
 }
+
+// CommandTemplateData 是渲染指令内容时使用的模板数据。Args 中的参数值已经按照指令的执行位置转义
+type CommandTemplateData struct {
+	ArchiveTemplateData
+	Args map[string]string
+}
+
+func Command(profile config.ProfileConfig, args map[string]string) CommandTemplateData {
+	return CommandTemplateData{
+		ArchiveTemplateData: Archive(profile),
+		Args:                args,
+	}
+}
@@ -25,12 +25,17 @@ import (
 	"github.com/Subilan/go-aliyunmc/handlers/bss"
 	"github.com/Subilan/go-aliyunmc/handlers/instances"
 	"github.com/Subilan/go-aliyunmc/handlers/oss_routes"
+	"github.com/Subilan/go-aliyunmc/handlers/profiles"
+	"github.com/Subilan/go-aliyunmc/handlers/requests"
+	"github.com/Subilan/go-aliyunmc/handlers/schedules"
 	"github.com/Subilan/go-aliyunmc/handlers/server"
 	"github.com/Subilan/go-aliyunmc/handlers/simple"
 	"github.com/Subilan/go-aliyunmc/handlers/tasks"
 	"github.com/Subilan/go-aliyunmc/handlers/users"
+	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/commands"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/dns"
 	"github.com/Subilan/go-aliyunmc/helpers/mid"
 	"github.com/Subilan/go-aliyunmc/monitors"
 	"github.com/gin-contrib/cors"
@@ -39,7 +44,8 @@ import (
 )
 
 func bindRoutes(r *gin.Engine) {
-	i := r.Group("/instance")
+	i := r.Group("/instance/:profile")
+	i.Use(mid.Profile())
 	ij := i.Group("")
 	ij.Use(mid.JWTAuth())
 	ia := ij.Group("")
@@ -55,6 +61,9 @@ func bindRoutes(r *gin.Engine) {
 	ij.GET("/create-and-deploy", mid.Whitelist(), instances.HandleCreateAndDeployInstance())
 	ia.GET("/create-preferred", instances.HandleCreatePreferredInstance())
 	ia.GET("/deploy", instances.HandleDeployInstance())
+	ia.GET("/hibernate", instances.HandleHibernateInstance())
+	ia.GET("/migrate", instances.HandleMigrateInstance())
+	ij.GET("/resume", mid.Whitelist(), instances.HandleResumeInstance())
 	ia.DELETE("/:instanceId", instances.HandleDeleteInstance())
 	ia.DELETE("", instances.HandleDeleteInstance())
 
@@ -70,6 +79,7 @@ func bindRoutes(r *gin.Engine) {
 	uj.PATCH("/game-bound", users.HandleUpdateBindGameId())
 	uj.GET("/game-bound", users.HandleGetSelfGameBound())
 	uj.DELETE("/game-bound", users.HandleDeleteSelfGameBound())
+	uj.GET("/stats", users.HandleGetSelfPlayerStats())
 	uj.PATCH("/:userId", users.HandleUserUpdate())
 	uj.DELETE("/:userId", users.HandleUserDelete())
 
@@ -92,31 +102,68 @@ func bindRoutes(r *gin.Engine) {
 	tj.GET("", tasks.HandleGetActiveTaskByType())
 	ta.GET("/cancel/:taskId", tasks.HandleCancelTask())
 
-	s := r.Group("/server")
+	s := r.Group("/server/:profile")
+	s.Use(mid.Profile())
 	s.GET("/info", server.HandleGetServerInfo())
 	sj := s.Group("")
 	sj.Use(mid.JWTAuth())
 	sj.GET("/exec", server.HandleServerExecute())
 	sj.GET("/query", server.HandleServerQuery())
+	sj.GET("/commands", server.HandleGetCommands())
 	sj.GET("/backups", server.HandleGetBackupInfo())
+	sj.GET("/snapshots", server.HandleGetSnapshots())
 	sj.GET("/latest-success-backup", server.HandleGetLatestSuccessBackup())
 	sj.GET("/latest-success-archive", server.HandleGetLatestSuccessArchive())
 	sj.GET("/exec/s", server.HandleGetCommandExecs())
 	sj.GET("/exec-overview", server.HandleGetCommandExecOverview())
+	sj.GET("/metrics", server.HandleGetMetrics())
+	sj.GET("/status/history", server.HandleGetStatusHistory())
+	sj.GET("/status/activity", server.HandleGetPlayerActivity())
+	sj.GET("/game-events", server.HandleGetGameEvents())
+	sj.GET("/players/leaderboard", server.HandleGetPlayerLeaderboard())
+	sj.GET("/players/daily", server.HandleGetDailyActivePlayers())
+	sj.GET("/players/:gameId", server.HandleGetPlayerStats())
+	sj.GET("/players/:gameId/sessions", server.HandleGetPlayerSessions())
+	sa := sj.Group("")
+	sa.Use(mid.Role(consts.UserRoleAdmin))
+	sa.DELETE("/cooldowns/:commandType", server.HandleResetCommandCooldown())
+	sa.GET("/exec/:execId", server.HandleGetCommandExec())
+	sa.GET("/console", server.HandleConsoleStream())
+	sa.POST("/console", server.HandleConsoleExecute())
+	sa.GET("/crashes", server.HandleGetCrashIncidents())
+	sj.POST("/requests", mid.Whitelist(), requests.HandleCreateRequest())
+	sj.GET("/requests/s", requests.HandleGetRequests())
+	sj.POST("/requests/:requestId/vote", requests.HandleVoteRequest())
+	sa.POST("/requests/:requestId/decision", requests.HandleDecideRequest())
+
+	sc := r.Group("/schedule")
+	sc.Use(mid.JWTAuth(), mid.Role(consts.UserRoleAdmin))
+	sc.GET("/s", schedules.HandleGetSchedules())
+	sc.POST("", schedules.HandleCreateSchedule())
+	sc.DELETE("/:name", schedules.HandleDeleteSchedule())
+	sc.GET("/commands", schedules.HandleGetCommandSchedules())
+	sc.POST("/commands", schedules.HandleCreateCommandSchedule())
+	sc.DELETE("/commands/:name", schedules.HandleDeleteCommandSchedule())
+	sc.POST("/:name/skip", schedules.HandleSkipOccurrence())
 
 	bj := r.Group("/bss")
 	bj.Use(mid.JWTAuth())
 	bj.GET("/transactions", bss.HandleGetTransactions())
 	bj.GET("/overview", bss.HandleGetOverview())
 
-	oj := r.Group("/oss")
-	oj.Use(mid.JWTAuth())
+	oj := r.Group("/oss/:profile")
+	oj.Use(mid.Profile(), mid.JWTAuth())
 	oj.GET("/list", oss_routes.ListObjects())
 
+	r.GET("/profiles", profiles.HandleGetProfiles())
 	r.GET("/ping", simple.HandleGenerate200())
 	r.GET("/", simple.HandleVersion())
 	r.GET("/stream", mid.JWTAuth(), handlers.HandleBeginStream())
 	r.GET("/stream/simple-public", handlers.HandleBeginSimplePublicStream())
+
+	if config.Cfg.Exporter.Enabled {
+		r.GET("/metrics", handlers.HandleMetrics())
+	}
 	r.GET("/__docs/*any", func(c *gin.Context) {
 		htmlContent, err := scalar.ApiReferenceHTML(&scalar.Options{
 			SpecURL: "./docs/swagger.json",
@@ -134,29 +181,57 @@ func bindRoutes(r *gin.Engine) {
 }
 
 func runMonitors() {
-	var quitActiveInstance = make(chan bool)
-	var quitServerStatus = make(chan bool)
-	var quitPublicIP = make(chan bool)
-	var quitBackup = make(chan bool)
-	var quitInstanceCharge = make(chan bool)
-	var quitEmptyServer = make(chan bool)
 	var quitBssSync = make(chan bool)
-	var quitWhitelist = make(chan bool)
 
-	var ip string
-
-	_ = db.Pool.QueryRow("SELECT ip FROM instances WHERE deleted_at IS NULL").Scan(&ip)
+	monitors.Init()
+	monitors.ScheduleOpenFunc = instances.OpenServer
+	monitors.MigrateFunc = func(profile string) (string, error) {
+		return instances.MigrateInstance(profile, nil)
+	}
 
-	monitors.RestoreInstanceIp(ip)
+	for _, profile := range config.Cfg.ProfileNames() {
+		var quitActiveInstance = make(chan bool)
+		var quitServerStatus = make(chan bool)
+		var quitPublicIP = make(chan bool)
+		var quitBackup = make(chan bool)
+		var quitInstanceCharge = make(chan bool)
+		var quitEmptyServer = make(chan bool)
+		var quitWhitelist = make(chan bool)
+		var quitSpotInterruption = make(chan bool)
+		var quitHibernation = make(chan bool)
+		var quitScheduler = make(chan bool)
+		var quitDNS = make(chan bool)
+		var quitMigration = make(chan bool)
+		var quitCommandScheduler = make(chan bool)
+		var quitMetrics = make(chan bool)
+		var quitCrashWatchdog = make(chan bool)
+		var quitGameLog = make(chan bool)
+
+		var ip string
+
+		_ = db.Pool.QueryRow("SELECT ip FROM instances WHERE profile = ? AND deleted_at IS NULL", profile).Scan(&ip)
+
+		monitors.RestoreInstanceIp(profile, ip)
+
+		go monitors.ActiveInstance(profile, quitActiveInstance)
+		go monitors.PublicIP(profile, quitPublicIP)
+		go monitors.ServerStatus(profile, quitServerStatus)
+		go monitors.Backup(profile, quitBackup)
+		go monitors.InstanceCharge(profile, quitInstanceCharge)
+		go monitors.EmptyServer(profile, quitEmptyServer)
+		go monitors.Whitelist(profile, quitWhitelist)
+		go monitors.SpotInterruption(profile, quitSpotInterruption)
+		go monitors.Hibernation(profile, quitHibernation)
+		go monitors.Scheduler(profile, quitScheduler)
+		go monitors.DNS(profile, quitDNS)
+		go monitors.Migration(profile, quitMigration)
+		go monitors.CommandScheduler(profile, quitCommandScheduler)
+		go monitors.Metrics(profile, quitMetrics)
+		go monitors.CrashWatchdog(profile, quitCrashWatchdog)
+		go monitors.GameLog(profile, quitGameLog)
+	}
 
-	go monitors.ActiveInstance(quitActiveInstance)
-	go monitors.PublicIP(quitPublicIP)
-	go monitors.ServerStatus(quitServerStatus)
-	go monitors.Backup(quitBackup)
-	go monitors.InstanceCharge(quitInstanceCharge)
-	go monitors.EmptyServer(quitEmptyServer)
 	go monitors.BssSync(quitBssSync)
-	go monitors.Whitelist(quitWhitelist)
 }
 
 // mainLogWriter 是指向 main.log 日志文件的日志 writer
@@ -217,27 +292,41 @@ func main() {
 
 	config.Load("config.toml")
 
-	log.Print("Loading global ECS client...")
+	if config.Cfg.Aliyun.UseFakeProvider() {
+		log.Print("Using in-memory fake provider, no real Aliyun resource will be touched")
 
-	clients.EcsClient, err = clients.ShouldCreateEcsClient()
+		clients.UseFakeProvider()
+	} else {
+		log.Print("Loading global ECS client...")
 
-	if err != nil {
-		log.Fatalln("Error creating ECS client:", err)
-	}
+		clients.EcsClient, err = clients.ShouldCreateEcsClient()
 
-	clients.VpcClient, err = clients.ShouldCreateVpcClient()
+		if err != nil {
+			log.Fatalln("Error creating ECS client:", err)
+		}
 
-	if err != nil {
-		log.Fatalln("Error creating VPC client:", err)
-	}
+		clients.VpcClient, err = clients.ShouldCreateVpcClient()
 
-	clients.BssClient, err = clients.ShouldCreateBssClient()
+		if err != nil {
+			log.Fatalln("Error creating VPC client:", err)
+		}
 
-	if err != nil {
-		log.Fatalln("Error creating BSS client:", err)
+		clients.BssClient, err = clients.ShouldCreateBssClient()
+
+		if err != nil {
+			log.Fatalln("Error creating BSS client:", err)
+		}
+
+		clients.OssClient = clients.GetOssClient()
 	}
 
-	clients.OssClient = clients.GetOssClient()
+	if config.Cfg.Dns.Enabled() {
+		log.Print("Initializing DNS provider...")
+
+		if err := dns.Init(); err != nil {
+			log.Fatalln("Error initializing DNS provider:", err)
+		}
+	}
 
 	log.Print("Initializing database pool...")
 
@@ -251,6 +340,18 @@ func main() {
 
 	commands.Load()
 
+	if config.Cfg.Deploy.BakeImage {
+		// 配置中的 packages 或 java_version 可能已经变化，删除已失效的预制镜像
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), helpers.BakeImageTimeout)
+			defer cancel()
+
+			if err := helpers.PruneStaleBakedImages(ctx); err != nil {
+				log.Println("cannot prune stale baked images:", err)
+			}
+		}()
+	}
+
 	log.Println("Starting monitors...")
 
 	runMonitors()
@@ -271,8 +372,14 @@ func main() {
 
 	engine.Use(cors.New(config.Cfg.Base.GetGinCorsConfig()))
 
+	if config.Cfg.Exporter.Enabled {
+		engine.Use(mid.Metrics())
+	}
+
 	instances.StartDeployInstanceTaskStatusBroker()
 
+	requests.Resume()
+
 	bindRoutes(engine)
 
 	if config.Cfg.Base.Autotls.Enabled {
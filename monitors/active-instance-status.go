@@ -15,88 +15,124 @@ import (
 	"github.com/Subilan/go-aliyunmc/consts"
 	"github.com/Subilan/go-aliyunmc/events"
 	"github.com/Subilan/go-aliyunmc/events/stream"
-	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
 	"github.com/alibabacloud-go/tea/tea"
 )
 
-var instanceStatusBroker = broker.New[consts.InstanceStatus]()
-var isInstancePresentBroker = broker.New[bool]()
+// activeInstanceState 是一个档案的活动实例状态
+type activeInstanceState struct {
+	statusBroker  *broker.Broker[consts.InstanceStatus]
+	presentBroker *broker.Broker[bool]
 
-var instanceStatus consts.InstanceStatus
-var instanceStatusMu sync.RWMutex
-var isInstancePresent atomic.Bool
+	status   consts.InstanceStatus
+	statusMu sync.RWMutex
+	present  atomic.Bool
+}
 
-func SubscribeInstanceStatus() <-chan consts.InstanceStatus {
-	return instanceStatusBroker.Subscribe()
+func newActiveInstanceState() *activeInstanceState {
+	return &activeInstanceState{
+		statusBroker:  broker.New[consts.InstanceStatus](),
+		presentBroker: broker.New[bool](),
+	}
 }
 
-func SnapshotInstanceStatus() consts.InstanceStatus {
-	instanceStatusMu.RLock()
-	defer instanceStatusMu.RUnlock()
+// SubscribeInstanceStatus 订阅档案 profile 的活动实例状态更新。如果档案不存在，返回 nil
+func SubscribeInstanceStatus(profile string) <-chan consts.InstanceStatus {
+	state := stateOf(profile)
+
+	if state == nil {
+		return nil
+	}
 
-	return instanceStatus
+	return state.activeInstance.statusBroker.Subscribe()
 }
 
-func syncInstanceStatusWithUser(logger *log.Logger) {
-	instanceStatusUpdate := instanceStatusBroker.Subscribe()
+// SnapshotInstanceStatus 返回档案 profile 截止目前最新的活动实例状态
+func SnapshotInstanceStatus(profile string) consts.InstanceStatus {
+	state := stateOf(profile)
+
+	if state == nil {
+		return consts.InstanceInvalid
+	}
+
+	state.activeInstance.statusMu.RLock()
+	defer state.activeInstance.statusMu.RUnlock()
+
+	return state.activeInstance.status
+}
+
+func syncInstanceStatusWithUser(profile string, s *activeInstanceState, logger *log.Logger) {
+	instanceStatusUpdate := s.statusBroker.Subscribe()
 	for newInstanceStatus := range instanceStatusUpdate {
-		event := events.Instance(events.InstanceEventActiveStatusUpdate, newInstanceStatus, true)
+		event := events.Instance(profile, events.InstanceEventActiveStatusUpdate, newInstanceStatus, true)
 		err := stream.BroadcastAndSave(event)
 
 		if err != nil {
 			logger.Println("Error broadcast and save event", err)
+			prom.MonitorErrors.Inc("active-instance", profile)
 		}
 	}
 }
 
-func syncInstanceExternalDeletionWithUser(logger *log.Logger) {
-	isInstancePresentUpdate := isInstancePresentBroker.Subscribe()
+func syncInstanceExternalDeletionWithUser(profile string, s *activeInstanceState, logger *log.Logger) {
+	isInstancePresentUpdate := s.presentBroker.Subscribe()
 	for newIsInstancePresent := range isInstancePresentUpdate {
 		if !newIsInstancePresent {
-			event := events.Instance(events.InstanceEventNotify, events.InstanceNotificationDeleted, true)
+			event := events.Instance(profile, events.InstanceEventNotify, events.InstanceNotificationDeleted, true)
 			err := stream.BroadcastAndSave(event)
 
 			if err != nil {
 				logger.Println("Error broadcast and save event", err)
+				prom.MonitorErrors.Inc("active-instance", profile)
 			}
 		}
 	}
 }
 
-func setInstanceStatus(status consts.InstanceStatus) {
-	instanceStatusMu.Lock()
-	instanceStatus = status
-	instanceStatusMu.Unlock()
+func (s *activeInstanceState) setStatus(status consts.InstanceStatus) {
+	s.statusMu.Lock()
+	s.status = status
+	s.statusMu.Unlock()
 
-	instanceStatusBroker.Publish(status)
+	s.statusBroker.Publish(status)
 
 	if status == consts.InstanceInvalid {
-		if isInstancePresent.Load() == true {
-			isInstancePresent.Store(false)
-			isInstancePresentBroker.Publish(false)
+		if s.present.Load() == true {
+			s.present.Store(false)
+			s.presentBroker.Publish(false)
 		}
 	} else {
-		if isInstancePresent.Load() == false {
-			isInstancePresent.Store(true)
-			isInstancePresentBroker.Publish(true)
+		if s.present.Load() == false {
+			s.present.Store(true)
+			s.presentBroker.Publish(true)
 		}
 	}
 }
 
-func ActiveInstance(quit chan bool) {
-	logger := filelog.NewLogger("active-instance", "ActiveInstance")
+func (s *activeInstanceState) getStatus() consts.InstanceStatus {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+
+	return s.status
+}
+
+// ActiveInstance 监控档案 profile 的活动实例状态
+func ActiveInstance(profile string, quit chan bool) {
+	logger := profileLogger("active-instance", "ActiveInstance", profile)
 	logger.Println("starting...")
 
 	cfg := config.Cfg.Monitor.ActiveInstance
+	s := stateOf(profile).activeInstance
 
 	ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
 
-	go instanceStatusBroker.Start()
-	go isInstancePresentBroker.Start()
-	go syncInstanceExternalDeletionWithUser(logger)
-	go syncInstanceStatusWithUser(logger)
+	go s.statusBroker.Start()
+	go s.presentBroker.Start()
+	go syncInstanceExternalDeletionWithUser(profile, s, logger)
+	go syncInstanceStatusWithUser(profile, s, logger)
 
 	for {
 		select {
@@ -107,17 +143,18 @@ func ActiveInstance(quit chan bool) {
 
 				var activeInstanceId string
 
-				err := db.Pool.QueryRowContext(ctx, "SELECT instance_id FROM instances WHERE deleted_at IS NULL").Scan(&activeInstanceId)
+				err := db.Pool.QueryRowContext(ctx, "SELECT instance_id FROM instances WHERE profile = ? AND deleted_at IS NULL", profile).Scan(&activeInstanceId)
 
 				if err != nil {
 					if errors.Is(err, sql.ErrNoRows) {
-						if instanceStatus != consts.InstanceInvalid {
-							setInstanceStatus(consts.InstanceInvalid)
+						if s.getStatus() != consts.InstanceInvalid {
+							s.setStatus(consts.InstanceInvalid)
 						}
 						return
 					}
 
 					logger.Printf("Error querying active instance status: %v\n", err)
+					prom.MonitorErrors.Inc("active-instance", profile)
 					return
 				}
 
@@ -126,26 +163,27 @@ func ActiveInstance(quit chan bool) {
 					InstanceId: tea.StringSlice([]string{activeInstanceId}),
 				}
 
-				describeInstanceStatusResponse, err := clients.EcsClient.DescribeInstanceStatus(describeInstanceStatusRequest)
+				describeInstanceStatusResponse, err := clients.EcsClient.DescribeInstanceStatusWithContext(ctx, describeInstanceStatusRequest, &dara.RuntimeOptions{})
 
 				if err != nil {
-					if instanceStatus != consts.InstanceUnableToGet {
-						setInstanceStatus(consts.InstanceUnableToGet)
+					if s.getStatus() != consts.InstanceUnableToGet {
+						s.setStatus(consts.InstanceUnableToGet)
 					}
 					logger.Printf("Error describing active instance status: %v\n", err)
+					prom.MonitorErrors.Inc("active-instance", profile)
 					return
 				}
 
 				if len(describeInstanceStatusResponse.Body.InstanceStatuses.InstanceStatus) > 0 {
 					newInstanceStatus := consts.InstanceStatus(tea.StringValue(describeInstanceStatusResponse.Body.InstanceStatuses.InstanceStatus[0].Status))
 
-					if instanceStatus != newInstanceStatus {
-						setInstanceStatus(newInstanceStatus)
-						logger.Printf("Updated active instance status to %s\n", instanceStatus)
+					if s.getStatus() != newInstanceStatus {
+						s.setStatus(newInstanceStatus)
+						logger.Printf("Updated active instance status to %s\n", newInstanceStatus)
 					}
 				} else {
 					// 请求成功了但没有找到符合要求的实例，说明实例被外部删除
-					setInstanceStatus(consts.InstanceInvalid)
+					s.setStatus(consts.InstanceInvalid)
 
 					logger.Println("Active instance is externally deleted, updating database.")
 
@@ -153,6 +191,7 @@ func ActiveInstance(quit chan bool) {
 
 					if err != nil {
 						logger.Printf("Error updating active instance status: %v\n", err)
+						prom.MonitorErrors.Inc("active-instance", profile)
 						return
 					}
 				}
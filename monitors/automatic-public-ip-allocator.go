@@ -13,106 +13,152 @@ import (
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/events"
 	"github.com/Subilan/go-aliyunmc/events/stream"
-	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/rcon"
 	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
 )
 
-var instanceIpRestored bool
+// publicIpState 是一个档案的活动实例IP地址状态
+type publicIpState struct {
+	restored bool
 
-var instanceIp string
-var instanceIpMu sync.RWMutex
+	ip   string
+	ipMu sync.RWMutex
 
-var instanceIpBroker = broker.New[string]()
+	broker *broker.Broker[string]
+}
+
+func newPublicIpState() *publicIpState {
+	return &publicIpState{broker: broker.New[string]()}
+}
 
-func RestoreInstanceIp(ip string) {
-	if instanceIpRestored {
+// RestoreInstanceIp 在系统启动时恢复档案 profile 的活动实例IP地址。每个档案只能恢复一次。
+func RestoreInstanceIp(profile string, ip string) {
+	state := stateOf(profile)
+
+	if state == nil {
+		log.Fatalln("restoring instance ip of unknown profile", profile)
+	}
+
+	s := state.publicIp
+
+	if s.restored {
 		log.Fatalln("double restoring instance ip is not permitted")
 	}
 
-	instanceIpMu.Lock()
-	instanceIp = ip
-	instanceIpMu.Unlock()
-	instanceIpRestored = true
+	s.ipMu.Lock()
+	s.ip = ip
+	s.ipMu.Unlock()
+	s.restored = true
+}
+
+// SnapshotInstanceIp 返回档案 profile 截止目前最新的活动实例IP地址。如果档案不存在或者IP地址未分配，返回空字符串
+func SnapshotInstanceIp(profile string) string {
+	state := stateOf(profile)
+
+	if state == nil {
+		return ""
+	}
+
+	state.publicIp.ipMu.RLock()
+	defer state.publicIp.ipMu.RUnlock()
+
+	return state.publicIp.ip
 }
 
-func SnapshotInstanceIp() string {
-	instanceIpMu.RLock()
-	defer instanceIpMu.RUnlock()
+// set 更新活动实例IP地址并推送给订阅者。IP地址变化时，关闭原IP地址上的 RCON 会话
+func (s *publicIpState) set(ip string) {
+	s.ipMu.Lock()
+	previous := s.ip
+	s.ip = ip
+	s.ipMu.Unlock()
 
-	return instanceIp
+	if previous != "" && previous != ip {
+		rcon.Close(previous)
+	}
+
+	s.broker.Publish(ip)
 }
 
-func syncIpWithUser(logger *log.Logger) {
-	instanceIpUpdate := instanceIpBroker.Subscribe()
+func syncIpWithUser(profile string, s *publicIpState, logger *log.Logger) {
+	instanceIpUpdate := s.broker.Subscribe()
 	for ip := range instanceIpUpdate {
-		event := events.Instance(events.InstanceEventActiveIpUpdate, ip, true)
+		event := events.Instance(profile, events.InstanceEventActiveIpUpdate, ip, true)
 		err := stream.BroadcastAndSave(event)
 
 		if err != nil {
 			logger.Println("cannot broadcast and save event:", err)
+			prom.MonitorErrors.Inc("public-ip", profile)
 		}
 	}
 }
 
-func PublicIP(quit chan bool) {
-	cfg := config.Cfg.Monitor.PublicIP
-	logger := filelog.NewLogger("public-ip", "PublicIP")
-	logger.Println("starting...")
+// allocatePublicIp 为档案 profile 中尚未分配公网IP地址的活动实例分配公网IP地址，并将新地址保存到 s 和数据库中
+func allocatePublicIp(profile string, s *publicIpState, logger *log.Logger) {
+	var activeInstanceId string
 
-	ticker := time.NewTicker(cfg.IntervalDuration())
+	ctx, cancel := context.WithTimeout(context.Background(), config.Cfg.Monitor.PublicIP.TimeoutDuration())
+	defer cancel()
 
-	go instanceIpBroker.Start()
-	go syncIpWithUser(logger)
+	err := db.Pool.QueryRowContext(ctx, "SELECT instance_id FROM instances WHERE profile = ? AND deleted_at IS NULL AND ip IS NULL AND hibernated_at IS NULL", profile).Scan(&activeInstanceId)
 
-	for {
-		select {
-		case <-ticker.C:
-			func() {
-				var activeInstanceId string
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
 
-				ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutDuration())
-				defer cancel()
+		logger.Printf("Cannot get active instance id: %v", err)
+		prom.MonitorErrors.Inc("public-ip", profile)
+		return
+	}
 
-				err := db.Pool.QueryRowContext(ctx, "SELECT instance_id FROM instances WHERE deleted_at IS NULL AND ip IS NULL").Scan(&activeInstanceId)
+	allocatePublicIpAddressRequest := &ecs20140526.AllocatePublicIpAddressRequest{
+		InstanceId: &activeInstanceId,
+	}
 
-				if err != nil {
-					if errors.Is(err, sql.ErrNoRows) {
-						return
-					}
+	allocatePublicIpAddressResponse, err := clients.EcsClient.AllocatePublicIpAddressWithContext(ctx, allocatePublicIpAddressRequest, &dara.RuntimeOptions{})
 
-					logger.Printf("Cannot get active instance id: %v", err)
-					return
-				}
+	if err != nil {
+		logger.Printf("Cannot allocate public ip: %v", err)
+		prom.MonitorErrors.Inc("public-ip", profile)
+		return
+	}
 
-				allocatePublicIpAddressRequest := &ecs20140526.AllocatePublicIpAddressRequest{
-					InstanceId: &activeInstanceId,
-				}
+	ip := *allocatePublicIpAddressResponse.Body.IpAddress
 
-				allocatePublicIpAddressResponse, err := clients.EcsClient.AllocatePublicIpAddress(allocatePublicIpAddressRequest)
+	s.set(ip)
 
-				if err != nil {
-					logger.Printf("Cannot allocate public ip: %v", err)
-					return
-				}
+	_, err = db.Pool.ExecContext(ctx, "UPDATE instances SET ip = ? WHERE instance_id = ?", ip, activeInstanceId)
 
-				ip := *allocatePublicIpAddressResponse.Body.IpAddress
+	if err != nil {
+		logger.Printf("Cannot update public ip: %v", err)
+		prom.MonitorErrors.Inc("public-ip", profile)
+		return
+	}
 
-				instanceIpMu.Lock()
-				instanceIp = ip
-				instanceIpMu.Unlock()
+	logger.Printf("Successfully allocated public ip address: %v for instance %v", ip, activeInstanceId)
+}
+
+// PublicIP 为档案 profile 的活动实例自动分配公网IP地址
+func PublicIP(profile string, quit chan bool) {
+	cfg := config.Cfg.Monitor.PublicIP
+	logger := profileLogger("public-ip", "PublicIP", profile)
+	logger.Println("starting...")
 
-				instanceIpBroker.Publish(instanceIp)
+	s := stateOf(profile).publicIp
 
-				_, err = db.Pool.ExecContext(ctx, "UPDATE instances SET ip = ? WHERE instance_id = ?", ip, activeInstanceId)
+	ticker := time.NewTicker(cfg.IntervalDuration())
+	defer ticker.Stop()
 
-				if err != nil {
-					logger.Printf("Cannot update public ip: %v", err)
-					return
-				}
+	go s.broker.Start()
+	go syncIpWithUser(profile, s, logger)
 
-				logger.Printf("Successfully allocated public ip address: %v for instance %v", ip, activeInstanceId)
-			}()
+	for {
+		select {
+		case <-ticker.C:
+			allocatePublicIp(profile, s, logger)
 
 		case <-quit:
 			return
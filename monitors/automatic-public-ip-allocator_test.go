@@ -0,0 +1,149 @@
+package monitors
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Subilan/go-aliyunmc/clients"
+	"github.com/Subilan/go-aliyunmc/clients/fake"
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/db/dbtest"
+	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/tea"
+	vpc20160428 "github.com/alibabacloud-go/vpc-20160428/v6/client"
+)
+
+func TestMain(m *testing.M) {
+	// 监控器的日志写入工作目录下的 logs 目录
+	dir, err := os.MkdirTemp("", "monitors")
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		log.Fatalln(err)
+	}
+
+	code := m.Run()
+
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newFakeInstance 在模拟实现 p 中创建一个实例，返回实例标识符
+func newFakeInstance(t *testing.T, p *fake.Provider) string {
+	t.Helper()
+
+	ctx := context.Background()
+
+	vsw, err := p.DescribeVSwitchesWithContext(ctx, &vpc20160428.DescribeVSwitchesRequest{ZoneId: tea.String("cn-test-a")}, nil)
+
+	if err != nil {
+		t.Fatalf("DescribeVSwitches() error = %v", err)
+	}
+
+	resp, err := p.CreateInstanceWithContext(ctx, &ecs20140526.CreateInstanceRequest{
+		ZoneId:       tea.String("cn-test-a"),
+		VSwitchId:    vsw.Body.VSwitches.VSwitch[0].VSwitchId,
+		InstanceType: tea.String("ecs.fake.c2m4"),
+		ImageId:      tea.String("debian_12"),
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("CreateInstance() error = %v", err)
+	}
+
+	return tea.StringValue(resp.Body.InstanceId)
+}
+
+// setupPublicIp 初始化默认档案的监控状态，并以模拟实现和内存数据库替换全局客户端和 db.Pool。
+// 查询尚未分配IP地址的活动实例时返回 instanceId，为空时没有结果
+func setupPublicIp(t *testing.T, p *fake.Provider, instanceId string) (*publicIpState, *dbtest.DB) {
+	t.Helper()
+
+	config.Cfg.Profiles = []config.ProfileConfig{{Name: config.DefaultProfileName}}
+	config.Cfg.Monitor.PublicIP = config.PublicIP{Interval: 3600, Timeout: 5}
+
+	Init()
+
+	s := stateOf(config.DefaultProfileName).publicIp
+	go s.broker.Start()
+
+	d := &dbtest.DB{Query: func(query string, _ []driver.Value) (*dbtest.Rows, error) {
+		if instanceId == "" || !strings.Contains(query, "FROM instances") {
+			return nil, nil
+		}
+
+		return &dbtest.Rows{Columns: []string{"instance_id"}, Values: [][]driver.Value{{instanceId}}}, nil
+	}}
+
+	db.Pool = dbtest.Open(d)
+	clients.EcsClient = p
+
+	return s, d
+}
+
+func TestAllocatePublicIp(t *testing.T) {
+	p := fake.New("cn-test", "")
+	instanceId := newFakeInstance(t, p)
+	s, d := setupPublicIp(t, p, instanceId)
+
+	allocatePublicIp(config.DefaultProfileName, s, log.New(os.Stdout, "", 0))
+
+	if got := SnapshotInstanceIp(config.DefaultProfileName); got != fake.UnroutablePublicIp {
+		t.Errorf("SnapshotInstanceIp() = %q, want %q", got, fake.UnroutablePublicIp)
+	}
+
+	updates := d.Execs("UPDATE instances SET ip")
+
+	if len(updates) != 1 {
+		t.Fatalf("executed %d ip updates, want 1", len(updates))
+	}
+
+	if args := updates[0].Args; len(args) != 2 || args[0] != fake.UnroutablePublicIp || args[1] != instanceId {
+		t.Errorf("ip update args = %v, want [%s %s]", args, fake.UnroutablePublicIp, instanceId)
+	}
+}
+
+func TestAllocatePublicIpWithoutActiveInstance(t *testing.T) {
+	p := fake.New("cn-test", "")
+	s, d := setupPublicIp(t, p, "")
+
+	allocatePublicIp(config.DefaultProfileName, s, log.New(os.Stdout, "", 0))
+
+	if got := SnapshotInstanceIp(config.DefaultProfileName); got != "" {
+		t.Errorf("SnapshotInstanceIp() = %q, want empty", got)
+	}
+
+	if n := len(d.Execs("UPDATE")); n != 0 {
+		t.Errorf("executed %d updates, want 0", n)
+	}
+}
+
+func TestAllocatePublicIpAllocatedAlready(t *testing.T) {
+	p := fake.New("cn-test", "")
+	instanceId := newFakeInstance(t, p)
+
+	// 实例已经分配过IP地址，但数据库中尚未记录
+	if _, err := p.AllocatePublicIpAddressWithContext(context.Background(), &ecs20140526.AllocatePublicIpAddressRequest{InstanceId: tea.String(instanceId)}, nil); err != nil {
+		t.Fatalf("AllocatePublicIpAddress() error = %v", err)
+	}
+
+	s, d := setupPublicIp(t, p, instanceId)
+
+	allocatePublicIp(config.DefaultProfileName, s, log.New(os.Stdout, "", 0))
+
+	if got := SnapshotInstanceIp(config.DefaultProfileName); got != "" {
+		t.Errorf("SnapshotInstanceIp() = %q, want empty", got)
+	}
+
+	if n := len(d.Execs("UPDATE")); n != 0 {
+		t.Errorf("executed %d updates, want 0", n)
+	}
+}
@@ -6,18 +6,19 @@ import (
 
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/consts"
-	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 )
 
-func Backup(quit chan bool) {
+// Backup 定期备份档案 profile 的服务器
+func Backup(profile string, quit chan bool) {
 	cfg := config.Cfg.Monitor.Backup
 	var backupInterval = cfg.IntervalDuration()
 	var retryInterval = cfg.RetryIntervalDuration()
 	var backupTimeout = cfg.TimeoutDuration()
 
-	logger := filelog.NewLogger("backup", "Backup")
+	logger := profileLogger("backup", "Backup", profile)
 
 	cmd := commands.MustGetCommand(consts.CmdTypeBackupWorlds)
 
@@ -34,7 +35,7 @@ func Backup(quit chan bool) {
 				ctx, cancel := context.WithTimeout(context.Background(), backupTimeout)
 				defer cancel()
 
-				activeInstance, err := store.GetDeployedActiveInstance()
+				activeInstance, err := store.GetDeployedActiveInstance(profile)
 
 				if err != nil {
 					logger.Println("no instance found, retry in", retryInterval)
@@ -42,10 +43,11 @@ func Backup(quit chan bool) {
 					return
 				}
 
-				_, err = cmd.RunWithoutCooldown(ctx, *activeInstance.Ip, nil, nil)
+				_, err = cmd.RunWithoutCooldown(ctx, activeInstance, nil, nil)
 
 				if err != nil {
 					logger.Println("error:", err)
+					prom.MonitorErrors.Inc("backup", profile)
 					logger.Println("retry in", retryInterval)
 					ticker.Reset(retryInterval)
 					return
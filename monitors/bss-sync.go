@@ -10,12 +10,13 @@ import (
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	bss20171214 "github.com/alibabacloud-go/bssopenapi-20171214/v6/client"
 	"github.com/alibabacloud-go/tea/dara"
 	"github.com/alibabacloud-go/tea/tea"
 )
 
-func fetchAllTransactions(ctx context.Context, client *bss20171214.Client, createTimeStart time.Time) ([]*bss20171214.QueryAccountTransactionDetailsResponseBodyDataAccountTransactionsListAccountTransactionsList, error) {
+func fetchAllTransactions(ctx context.Context, client clients.BssProvider, createTimeStart time.Time) ([]*bss20171214.QueryAccountTransactionDetailsResponseBodyDataAccountTransactionsListAccountTransactionsList, error) {
 	var allItems []*bss20171214.QueryAccountTransactionDetailsResponseBodyDataAccountTransactionsListAccountTransactionsList
 	var nextToken string
 	var total int32
@@ -73,6 +74,7 @@ func BssSync(quit chan bool) {
 					latestTransactionTime = config.Cfg.Monitor.BssSync.InitialTime
 				} else {
 					logger.Println("warn: unexpected error during latest transaction time query:", err)
+					prom.MonitorErrors.Inc("bss-sync", "")
 					return
 				}
 			}
@@ -81,6 +83,7 @@ func BssSync(quit chan bool) {
 
 			if err != nil {
 				logger.Println("warn: cannot fetch transactions: ", err)
+				prom.MonitorErrors.Inc("bss-sync", "")
 				return
 			}
 
@@ -105,6 +108,7 @@ VALUES (?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE amount=amount`, *transactio
 
 				if err != nil {
 					logger.Println("warn: cannot insert transactions: ", err, "skipping")
+					prom.MonitorErrors.Inc("bss-sync", "")
 				}
 
 				rowsAffected, _ := result.RowsAffected()
@@ -0,0 +1,211 @@
+package monitors
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/cron"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// commandScheduleCheckInterval 是 CommandScheduler 检查定时指令计划的间隔
+const commandScheduleCheckInterval = 30 * time.Second
+
+// CommandSchedule 是一个解析后的定时指令计划，来源于配置文件或者通过接口添加
+type CommandSchedule struct {
+	store.CommandSchedule
+
+	// FromConfig 表示该计划是否来源于配置文件。来源于配置文件的计划不能通过接口删除
+	FromConfig bool `json:"fromConfig"`
+
+	expr *cron.Expr
+}
+
+// NextRuns 返回该计划在 from 之后的前 n 个运行时间
+func (s *CommandSchedule) NextRuns(from time.Time, n int) []time.Time {
+	result := make([]time.Time, 0, n)
+	t := from
+
+	for len(result) < n {
+		t = s.expr.Next(t)
+
+		if t.IsZero() {
+			break
+		}
+
+		result = append(result, t)
+	}
+
+	return result
+}
+
+// GetCommandSchedules 返回档案 profile 的所有定时指令计划，包括配置文件中的计划和通过接口添加的计划。profile 为空时返回所有档案的计划
+func GetCommandSchedules(profile string) ([]*CommandSchedule, error) {
+	var result []*CommandSchedule
+
+	for _, sc := range config.Cfg.CommandSchedules {
+		if profile != "" && sc.Profile != profile {
+			continue
+		}
+
+		expr, err := cron.Parse(sc.Cron)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &CommandSchedule{
+			CommandSchedule: store.CommandSchedule{
+				Name:    sc.Name,
+				Profile: sc.Profile,
+				Cron:    sc.Cron,
+				Command: consts.CommandType(sc.Command),
+				Args:    sc.Args,
+				Message: sc.Message,
+			},
+			FromConfig: true,
+			expr:       expr,
+		})
+	}
+
+	added, err := store.GetCommandSchedules()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range added {
+		if profile != "" && s.Profile != profile {
+			continue
+		}
+
+		expr, err := cron.Parse(s.Cron)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &CommandSchedule{CommandSchedule: *s, expr: expr})
+	}
+
+	return result, nil
+}
+
+// GetCommandSchedule 返回名称为 name 的定时指令计划。如果计划不存在，返回 nil
+func GetCommandSchedule(name string) (*CommandSchedule, error) {
+	schedules, err := GetCommandSchedules("")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range schedules {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// runCommandSchedule 在档案 profile 的活动实例上运行计划 s。没有已部署的活动实例或者指令的前置条件不满足时跳过
+func runCommandSchedule(profile string, s *CommandSchedule, logger *log.Logger) {
+	inst, err := store.GetDeployedActiveInstance(profile)
+
+	if err != nil {
+		logger.Printf("command schedule %s skipped: no deployed active instance", s.Name)
+		return
+	}
+
+	comment := "schedule " + s.Name
+
+	if s.Message != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := commands.BroadcastScheduledMessage(ctx, inst, s.Message, comment); err != nil {
+			logger.Printf("command schedule %s: cannot broadcast message: %s", s.Name, err.Error())
+			prom.MonitorErrors.Inc("command-scheduler", profile)
+		}
+
+		return
+	}
+
+	cmd, ok := commands.ShouldGetCommand(s.Command)
+
+	if !ok {
+		logger.Printf("command schedule %s skipped: command %s not found", s.Name, s.Command)
+		return
+	}
+
+	if cmd.Prerequisite != nil && !cmd.Prerequisite(inst) {
+		logger.Printf("command schedule %s skipped: prerequisite %s not satisfied", s.Name, cmd.PrerequisiteName)
+		return
+	}
+
+	ctx, cancel := cmd.DefaultContext()
+	defer cancel()
+
+	logger.Printf("command schedule %s: running %s", s.Name, s.Command)
+
+	_, err = cmd.RunWithoutCooldown(ctx, inst, nil, &commands.CommandRunOption{
+		Args:    s.Args,
+		Comment: comment,
+	})
+
+	if err != nil {
+		logger.Printf("command schedule %s: cannot run %s: %s", s.Name, s.Command, err.Error())
+		prom.MonitorErrors.Inc("command-scheduler", profile)
+		return
+	}
+
+	logger.Printf("command schedule %s: %s finished", s.Name, s.Command)
+}
+
+// checkCommandSchedules 运行档案 profile 中在 (from, to] 内到期的定时指令计划，每个计划最多运行一次
+func checkCommandSchedules(profile string, from time.Time, to time.Time, logger *log.Logger) {
+	schedules, err := GetCommandSchedules(profile)
+
+	if err != nil {
+		logger.Println("cannot get command schedules:", err)
+		prom.MonitorErrors.Inc("command-scheduler", profile)
+		return
+	}
+
+	for _, s := range schedules {
+		next := s.expr.Next(from)
+
+		if next.IsZero() || next.After(to) {
+			continue
+		}
+
+		go runCommandSchedule(profile, s, logger)
+	}
+}
+
+// CommandScheduler 按照档案 profile 的定时指令计划，在 cron 表达式触发时运行指令或者在服务器内广播消息。
+// 系统停止期间错过的运行不会补上。每次运行都以自动执行的身份记录在 command_exec 中
+func CommandScheduler(profile string, quit chan bool) {
+	logger := profileLogger("command-scheduler", "CommandScheduler", profile)
+	logger.Println("starting...")
+
+	ticker := time.NewTicker(commandScheduleCheckInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			checkCommandSchedules(profile, last, now, logger)
+			last = now
+		case <-quit:
+			return
+		}
+	}
+}
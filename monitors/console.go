@@ -0,0 +1,230 @@
+package monitors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/remote"
+)
+
+// consoleLogPath 是服务器日志在实例上的路径，与 archive.tmpl.sh 中的服务器目录一致
+const consoleLogPath = "/home/mc/server/archive/logs/latest.log"
+
+// consoleHistoryLines 是控制台开始跟踪日志时先输出的历史行数
+const consoleHistoryLines = 100
+
+// consoleRetryInterval 是控制台与实例的连接断开或者实例没有IP地址时，重新连接前等待的时间，同时是检查IP地址变化的间隔
+const consoleRetryInterval = 5 * time.Second
+
+// consoleBufferSize 是每个控制台订阅者的缓冲行数。订阅者来不及接收时，超出的行会被丢弃
+const consoleBufferSize = 256
+
+const (
+	// ConsoleLineOutput 表示服务器日志中的一行
+	ConsoleLineOutput = "output"
+	// ConsoleLineError 表示跟踪日志的指令在标准错误中输出的一行，例如日志文件不存在
+	ConsoleLineError = "error"
+	// ConsoleLineSystem 表示系统产生的提示，例如连接断开或重新连接
+	ConsoleLineSystem = "system"
+)
+
+// ConsoleLine 是控制台推送给订阅者的一行内容
+type ConsoleLine struct {
+	Kind    string    `json:"kind"`
+	Content string    `json:"content"`
+	Time    time.Time `json:"time"`
+}
+
+// consoleState 是一个档案的控制台状态。第一个订阅者加入时开始跟踪日志，最后一个订阅者离开时停止
+type consoleState struct {
+	mu     sync.Mutex
+	subs   map[chan ConsoleLine]struct{}
+	cancel context.CancelFunc
+	logger *log.Logger
+}
+
+func newConsoleState(profile string) *consoleState {
+	return &consoleState{
+		subs:   make(map[chan ConsoleLine]struct{}),
+		logger: profileLogger("console", "Console", profile),
+	}
+}
+
+func (s *consoleState) publish(kind string, content string) {
+	line := ConsoleLine{Kind: kind, Content: content, Time: time.Now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// lineWriter 将分段到达的输出按行切分后交给 emit，不完整的行留待下一段输出
+type lineWriter struct {
+	buf  []byte
+	emit func(line string)
+}
+
+func (w *lineWriter) write(p []byte) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+
+		if i < 0 {
+			return
+		}
+
+		w.emit(string(bytes.TrimRight(w.buf[:i], "\r")))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// SubscribeConsole 订阅档案 profile 的服务器日志。返回接收日志的管道以及取消订阅的函数。如果档案不存在，返回 nil
+func SubscribeConsole(profile string) (<-chan ConsoleLine, func()) {
+	state := stateOf(profile)
+
+	if state == nil {
+		return nil, nil
+	}
+
+	s := state.console
+	ch := make(chan ConsoleLine, consoleBufferSize)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.subs) == 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		go followServerLog(ctx, profile, consoleHistoryLines, s.publish, s.logger)
+	}
+
+	s.subs[ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.subs, ch)
+
+		if len(s.subs) == 0 && s.cancel != nil {
+			s.cancel()
+			s.cancel = nil
+		}
+	}
+}
+
+// CountConsoleSubscribers 返回档案 profile 的控制台当前的订阅者数量
+func CountConsoleSubscribers(profile string) int {
+	state := stateOf(profile)
+
+	if state == nil {
+		return 0
+	}
+
+	state.console.mu.Lock()
+	defer state.console.mu.Unlock()
+
+	return len(state.console.subs)
+}
+
+// followServerLog 在档案 profile 的活动实例上跟踪服务器日志，将每一行交给 publish，直到 ctx 被取消。首次连接或者连接到新地址时先输出 history 行历史。
+// 连接断开时自动重新连接；活动实例的IP地址变化时，断开原地址并连接到新地址。连接状态的变化以 ConsoleLineSystem 交给 publish
+func followServerLog(ctx context.Context, profile string, history int, publish func(kind string, content string), logger *log.Logger) {
+	var lastIp string
+
+	for ctx.Err() == nil {
+		ip := SnapshotInstanceIp(profile)
+
+		if ip == "" {
+			if lastIp != "" {
+				publish(ConsoleLineSystem, "实例没有IP地址，等待实例就绪")
+				lastIp = ""
+			}
+
+			select {
+			case <-time.After(consoleRetryInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// 首次连接或者连接到新地址时输出历史行，重新连接到同一地址时只输出新的行
+		lines := 0
+
+		if ip != lastIp {
+			lines = history
+			publish(ConsoleLineSystem, "已连接到 "+ip)
+		} else {
+			publish(ConsoleLineSystem, "已重新连接")
+		}
+
+		lastIp = ip
+
+		err := followServerLogAt(ctx, profile, ip, lines, publish)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			logger.Printf("log tail on %s ended: %s", ip, err.Error())
+			prom.MonitorErrors.Inc("console", profile)
+			publish(ConsoleLineSystem, "连接已断开："+err.Error())
+		} else {
+			publish(ConsoleLineSystem, "连接已断开")
+		}
+
+		select {
+		case <-time.After(consoleRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// followServerLogAt 在地址 ip 上跟踪服务器日志，先输出 history 行历史。档案 profile 的活动实例IP地址变化时返回
+func followServerLogAt(ctx context.Context, profile string, ip string, history int, publish func(kind string, content string)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(consoleRetryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if SnapshotInstanceIp(profile) != ip {
+					cancel()
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	stdout := &lineWriter{emit: func(line string) { publish(ConsoleLineOutput, line) }}
+	stderr := &lineWriter{emit: func(line string) { publish(ConsoleLineError, line) }}
+
+	err := remote.RunCommandAsProdStream(ctx, ip, []string{fmt.Sprintf("tail -n %d -F %s", history, consoleLogPath)}, stdout.write, stderr.write)
+
+	if ctx.Err() != nil && SnapshotInstanceIp(profile) != ip {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,259 @@
+package monitors
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/remote"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// crashReportDir 是服务器崩溃报告在实例上的目录，与 consoleLogPath 位于同一服务器目录
+const crashReportDir = "/home/mc/server/archive/crash-reports"
+
+// maxCrashReportBytes 是收集的崩溃报告的最大长度，超出的部分被截断
+const maxCrashReportBytes = 64 * 1024
+
+// crashReportTimeout 是收集崩溃报告的超时时间
+const crashReportTimeout = time.Minute
+
+// collectCrashReport 在地址 ip 上收集服务器在 since 之后生成的最新崩溃报告。找不到崩溃报告时，收集服务器日志的最后 lines 行
+func collectCrashReport(ip string, since time.Time, lines int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), crashReportTimeout)
+	defer cancel()
+
+	output, err := remote.RunCommandAsProdSync(ctx, ip, []string{
+		fmt.Sprintf("f=$(find %s -maxdepth 1 -name '*.txt' -newermt '@%d' 2>/dev/null | sort | tail -n 1)", crashReportDir, since.Unix()),
+		fmt.Sprintf(`if [ -n "$f" ]; then echo "$f"; head -c %d "$f"; else tail -n %d %s; fi`, maxCrashReportBytes, lines, consoleLogPath),
+	}, true)
+
+	return string(output), err
+}
+
+// publishCrashIncident 向用户推送故障的最新状态。崩溃报告可能包含敏感信息，不随推送发送
+func publishCrashIncident(incident *store.CrashIncident) {
+	payload := *incident
+	payload.Report = nil
+
+	// 故障已经保存在独立的表中，推送不再另行保存
+	stream.Broadcast(events.Server(incident.Profile, events.ServerEventCrashIncidentUpdate, payload))
+}
+
+// resolveCrashIncident 以状态 status 结束故障 incident 的处理
+func resolveCrashIncident(incident *store.CrashIncident, status string, logger *log.Logger) {
+	now := time.Now()
+	incident.Status = status
+	incident.ResolvedAt = &now
+
+	if err := store.UpdateCrashIncident(incident); err != nil {
+		logger.Println("cannot update crash incident:", err)
+		prom.MonitorErrors.Inc("crash-watchdog", incident.Profile)
+	}
+
+	publishCrashIncident(incident)
+}
+
+// restartBlocked 返回在发现故障的时间 detectedAt 之后，是否不再需要由系统重新开启档案 profile 的服务器：
+// 活动实例已经变化或者停止运行、服务器已经重新上线，或者有人要求关闭服务器
+func restartBlocked(profile string, inst *store.Instance, detectedAt time.Time) bool {
+	return SnapshotInstanceStatus(profile) != consts.InstanceRunning ||
+		SnapshotInstanceIp(profile) != *inst.Ip ||
+		SnapshotIsServerRunning(profile) ||
+		commands.LastStopRequestedAt(profile).After(detectedAt)
+}
+
+// waitServerOnline 等待档案 profile 的服务器在 timeout 内上线，返回服务器是否上线。quit 被关闭时立即返回
+func waitServerOnline(profile string, timeout time.Duration, quit chan bool) (online bool, quitting bool) {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(config.Cfg.Monitor.ServerStatus.IntervalDuration())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if SnapshotIsServerRunning(profile) {
+				return true, false
+			}
+		case <-deadline:
+			return false, false
+		case <-quit:
+			return false, true
+		}
+	}
+}
+
+// handleCrash 处理档案 profile 的服务器的一次意外退出：收集崩溃报告，记录故障，并按照指数退避的间隔尝试重新开启服务器。
+// onlineSince 是服务器上一次上线的时间，attempts 是此前连续重启的次数，返回处理后连续重启的次数。quit 被关闭时 quitting 为 true
+func handleCrash(profile string, cfg config.CrashWatchdog, onlineSince time.Time, attempts int, quit chan bool, logger *log.Logger) (int, bool) {
+	inst, err := store.GetIpAllocatedActiveInstance(profile)
+
+	if err != nil {
+		logger.Println("cannot get active instance:", err)
+		prom.MonitorErrors.Inc("crash-watchdog", profile)
+		return 0, false
+	}
+
+	incident := &store.CrashIncident{
+		Profile:    profile,
+		InstanceId: inst.InstanceId,
+		DetectedAt: time.Now(),
+		Attempts:   attempts,
+		Status:     store.CrashIncidentRestarting,
+	}
+
+	report, err := collectCrashReport(*inst.Ip, onlineSince, cfg.LogLinesOrDefault())
+
+	if err != nil {
+		logger.Println("cannot collect crash report:", err)
+		prom.MonitorErrors.Inc("crash-watchdog", profile)
+	}
+
+	if report != "" {
+		incident.Report = &report
+	}
+
+	if err := store.InsertCrashIncident(incident); err != nil {
+		logger.Println("cannot insert crash incident:", err)
+		prom.MonitorErrors.Inc("crash-watchdog", profile)
+	}
+
+	logger.Printf("server exited unexpectedly (incident %d, %d previous attempts)", incident.Id, attempts)
+	publishCrashIncident(incident)
+
+	startServerCmd := commands.MustGetCommand(consts.CmdTypeStartServer)
+
+	for {
+		if incident.Attempts >= cfg.MaxAttemptsOrDefault() {
+			logger.Printf("giving up restarting server after %d attempts", incident.Attempts)
+			resolveCrashIncident(incident, store.CrashIncidentGaveUp, logger)
+			return 0, false
+		}
+
+		select {
+		case <-time.After(cfg.BackoffDuration(incident.Attempts + 1)):
+		case <-quit:
+			return 0, true
+		}
+
+		if restartBlocked(profile, inst, incident.DetectedAt) {
+			logger.Println("restart no longer needed, aborting")
+			resolveCrashIncident(incident, store.CrashIncidentAborted, logger)
+			return 0, false
+		}
+
+		incident.Attempts++
+
+		if err := store.UpdateCrashIncident(incident); err != nil {
+			logger.Println("cannot update crash incident:", err)
+			prom.MonitorErrors.Inc("crash-watchdog", profile)
+		}
+
+		publishCrashIncident(incident)
+
+		ctx, cancel := startServerCmd.DefaultContext()
+		_, err := startServerCmd.RunWithoutCooldown(ctx, inst, nil, &commands.CommandRunOption{
+			Comment: fmt.Sprintf("crash incident %d, attempt %d", incident.Id, incident.Attempts),
+		})
+		cancel()
+
+		if err != nil {
+			logger.Printf("cannot start server (attempt %d): %s", incident.Attempts, err.Error())
+			prom.MonitorErrors.Inc("crash-watchdog", profile)
+			continue
+		}
+
+		online, quitting := waitServerOnline(profile, cfg.StartupTimeoutDuration(), quit)
+
+		if quitting {
+			return 0, true
+		}
+
+		if online {
+			logger.Printf("server recovered after %d attempts", incident.Attempts)
+			resolveCrashIncident(incident, store.CrashIncidentRecovered, logger)
+			return incident.Attempts, false
+		}
+
+		logger.Printf("server did not come online after attempt %d", incident.Attempts)
+	}
+}
+
+// CrashWatchdog 监控档案 profile 的服务器是否在实例运行期间意外退出。
+//
+// 服务器从在线变为不在线时，如果实例仍在运行，且服务器上线以来没有人要求关闭服务器（参见 commands.LastStopRequestedAt），则视为意外退出，
+// 交由 handleCrash 收集崩溃报告并自动重启。服务器重新上线后保持在线超过 config.CrashWatchdog.StableAfter，连续重启的次数才会清零，
+// 以免服务器反复崩溃时无限重启。
+func CrashWatchdog(profile string, quit chan bool) {
+	cfg := config.Cfg.Monitor.CrashWatchdog
+
+	if !cfg.Enabled {
+		return
+	}
+
+	logger := profileLogger("crash-watchdog", "CrashWatchdog", profile)
+	logger.Println("starting...")
+
+	ticker := time.NewTicker(config.Cfg.Monitor.ServerStatus.IntervalDuration())
+	defer ticker.Stop()
+
+	var wasRunning bool
+	var onlineSince time.Time
+	var attempts int
+
+	for {
+		select {
+		case now := <-ticker.C:
+			running := SnapshotIsServerRunning(profile)
+
+			if running {
+				if !wasRunning {
+					onlineSince = now
+				}
+
+				if attempts > 0 && now.Sub(onlineSince) >= cfg.StableAfterDuration() {
+					attempts = 0
+				}
+
+				wasRunning = true
+				continue
+			}
+
+			if !wasRunning {
+				continue
+			}
+
+			wasRunning = false
+
+			// 实例停止运行导致的服务器下线不视为崩溃
+			if SnapshotInstanceStatus(profile) != consts.InstanceRunning || SnapshotInstanceIp(profile) == "" {
+				attempts = 0
+				continue
+			}
+
+			if commands.LastStopRequestedAt(profile).After(onlineSince) {
+				logger.Println("server stopped as requested")
+				attempts = 0
+				continue
+			}
+
+			var quitting bool
+
+			attempts, quitting = handleCrash(profile, cfg, onlineSince, attempts, quit, logger)
+
+			if quitting {
+				return
+			}
+
+		case <-quit:
+			return
+		}
+	}
+}
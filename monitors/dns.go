@@ -0,0 +1,144 @@
+package monitors
+
+import (
+	"context"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/dns"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+)
+
+// dnsTimeout 是一次更新解析记录的超时时间
+const dnsTimeout = 30 * time.Second
+
+// activeInstanceIp 从数据库获取档案 profile 活动实例的公网IP地址。没有活动实例或者IP地址未分配时，返回空字符串
+func activeInstanceIp(profile string) string {
+	var ip *string
+
+	_ = db.Pool.QueryRow("SELECT ip FROM instances WHERE profile = ? AND deleted_at IS NULL", profile).Scan(&ip)
+
+	if ip == nil {
+		return ""
+	}
+
+	return *ip
+}
+
+// applyDnsRecords 使档案 profile 的解析记录指向 ip。ip 为空时，如果配置了 config.DnsConfig.ParkIp 则指向该地址，否则删除解析记录
+func applyDnsRecords(ctx context.Context, profile config.ProfileConfig, ip string) error {
+	cfg := config.Cfg.Dns
+
+	if ip == "" {
+		ip = cfg.ParkIp
+	}
+
+	if ip == "" {
+		if err := dns.Current.Remove(ctx, profile.DnsName, dns.RecordTypeA); err != nil {
+			return err
+		}
+
+		if cfg.Srv {
+			return dns.Current.Remove(ctx, dns.SrvPrefix+profile.DnsName, dns.RecordTypeSRV)
+		}
+
+		return nil
+	}
+
+	err := dns.Current.Upsert(ctx, dns.Record{Name: profile.DnsName, Type: dns.RecordTypeA, Value: ip, TTL: cfg.GetTTL()})
+
+	if err != nil {
+		return err
+	}
+
+	if cfg.Srv {
+		return dns.Current.Upsert(ctx, dns.Record{
+			Name:  dns.SrvPrefix + profile.DnsName,
+			Type:  dns.RecordTypeSRV,
+			Value: dns.SrvValue(profile.GetGamePort(), cfg.Address(profile)),
+			TTL:   cfg.GetTTL(),
+		})
+	}
+
+	return nil
+}
+
+// DNS 在档案 profile 活动实例的公网IP地址变化时更新档案的解析记录，使玩家可以通过固定的域名连接服务器。
+// 实例被删除或休眠后，解析记录被指向 config.DnsConfig.ParkIp 或被删除。更新失败时按 config.DnsConfig.RetryInterval 重试。
+// 如果没有启用解析记录管理，或档案没有配置 config.ProfileConfig.DnsName，该监控器直接退出。
+func DNS(profile string, quit chan bool) {
+	cfg := config.Cfg.Dns
+	p, _ := config.Cfg.GetProfile(profile)
+
+	if dns.Current == nil || p.DnsName == "" {
+		return
+	}
+
+	logger := profileLogger("dns", "DNS", profile)
+	logger.Println("starting...")
+
+	state := stateOf(profile)
+	ipUpdate := state.publicIp.broker.Subscribe()
+	presentUpdate := state.activeInstance.presentBroker.Subscribe()
+
+	var (
+		desired = activeInstanceIp(profile)
+		applied bool
+	)
+
+	apply := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+		defer cancel()
+
+		if err := applyDnsRecords(ctx, p, desired); err != nil {
+			logger.Printf("cannot update records of %s to %q: %v", cfg.Address(p), desired, err)
+			prom.MonitorErrors.Inc("dns", profile)
+			applied = false
+			return
+		}
+
+		logger.Printf("records of %s updated to %q", cfg.Address(p), desired)
+		applied = true
+	}
+
+	apply()
+
+	retryTicker := time.NewTicker(cfg.RetryIntervalDuration())
+	defer retryTicker.Stop()
+
+	for {
+		select {
+		case ip := <-ipUpdate:
+			if ip == desired && applied {
+				continue
+			}
+
+			desired = ip
+			apply()
+
+		case present := <-presentUpdate:
+			// 实例被删除后，内存中的IP地址不会被清空，因此以数据库中的记录为准
+			ip := ""
+
+			if present {
+				ip = activeInstanceIp(profile)
+			}
+
+			if ip == desired && applied {
+				continue
+			}
+
+			desired = ip
+			apply()
+
+		case <-retryTicker.C:
+			if !applied {
+				apply()
+			}
+
+		case <-quit:
+			return
+		}
+	}
+}
@@ -7,9 +7,9 @@ import (
 
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/consts"
-	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers"
 	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 )
 
@@ -21,8 +21,9 @@ const (
 	emptyServerStateDeleting
 )
 
-func safeDeleteServer(logger *log.Logger) {
-	activeInstance, err := store.GetDeployedActiveInstance()
+// safeDeleteServer 停止档案 profile 的服务器、归档并删除实例，comment 是记录在指令执行记录中的原因
+func safeDeleteServer(profile string, comment string, logger *log.Logger) {
+	activeInstance, err := store.GetDeployedActiveInstance(profile)
 
 	if err != nil {
 		logger.Println("instance not found, skipping")
@@ -32,24 +33,47 @@ func safeDeleteServer(logger *log.Logger) {
 	ctx, cancel := context.WithTimeout(context.Background(), consts.StopAndArchiveTimeout)
 	defer cancel()
 
-	if err := commands.StopAndArchiveServer(ctx, *activeInstance.Ip, nil, "The server has been empty for too long."); err != nil {
+	if err := commands.StopAndArchiveServer(ctx, activeInstance, nil, comment); err != nil {
 		logger.Println("cannot stop and archive server:", err)
+		prom.MonitorErrors.Inc("empty-server", profile)
 		return
 	}
 
 	logger.Println("stop and archive server successfully")
 
-	if err := helpers.DeleteInstance(ctx, activeInstance.InstanceId, true); err != nil {
+	if err := helpers.DeleteInstance(ctx, profile, activeInstance.InstanceId, true); err != nil {
 		logger.Println("cannot delete instance:", err)
+		prom.MonitorErrors.Inc("empty-server", profile)
 		return
 	}
 
 	logger.Println("delete instance successfully")
 }
 
-func EmptyServer(quit chan bool) {
+func safeHibernateServer(profile string, logger *log.Logger) {
+	activeInstance, err := store.GetDeployedActiveInstance(profile)
+
+	if err != nil {
+		logger.Println("instance not found, skipping")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.StopAndArchiveTimeout)
+	defer cancel()
+
+	if err := HibernateInstance(ctx, profile, activeInstance, nil); err != nil {
+		logger.Println("cannot hibernate instance:", err)
+		prom.MonitorErrors.Inc("empty-server", profile)
+		return
+	}
+
+	logger.Println("hibernate instance successfully")
+}
+
+// EmptyServer 在档案 profile 的服务器空转超时后，停止服务器、归档并删除实例。如果超时发生在受保护的开放时段内（参见 config.ScheduleConfig），则重新开始计时
+func EmptyServer(profile string, quit chan bool) {
 	cfg := config.Cfg.Monitor.EmptyServer
-	logger := filelog.NewLogger("empty-server", "EmptyServer")
+	logger := profileLogger("empty-server", "EmptyServer", profile)
 	logger.Println("starting...")
 
 	var emptyTimeout = cfg.EmptyTimeoutDuration()
@@ -59,7 +83,9 @@ func EmptyServer(quit chan bool) {
 		timer *time.Timer
 	)
 
-	playerCountUpdate := playerCountBroker.Subscribe()
+	s := stateOf(profile).serverStatus
+
+	playerCountUpdate := s.playerCountBroker.Subscribe()
 
 	for {
 		select {
@@ -93,18 +119,33 @@ func EmptyServer(quit chan bool) {
 			return nil
 		}():
 			// edge case
-			if playerCount.Load() > 0 {
+			if s.playerCount.Load() > 0 {
 				state = emptyServerStateIdle
 				timer = nil
 				logger.Println("timer fired but players exist, abort")
 				continue
 			}
 
+			// 处于受保护的开放时段内时不关闭服务器，重新开始计时
+			if protected, err := InProtectedWindow(profile, time.Now()); err != nil {
+				logger.Println("cannot check protected window:", err)
+				prom.MonitorErrors.Inc("empty-server", profile)
+			} else if protected {
+				timer = time.NewTimer(emptyTimeout)
+				logger.Println("timer fired but in protected opening hours, restarting empty-server timer")
+				continue
+			}
+
 			state = emptyServerStateDeleting
 			timer = nil
 
-			logger.Println("empty timeout reached, deleting server")
-			safeDeleteServer(logger)
+			if cfg.Mode == config.EmptyServerModeHibernate {
+				logger.Println("empty timeout reached, hibernating server")
+				safeHibernateServer(profile, logger)
+			} else {
+				logger.Println("empty timeout reached, deleting server")
+				safeDeleteServer(profile, "The server has been empty for too long.", logger)
+			}
 
 			state = emptyServerStateIdle
 
@@ -0,0 +1,103 @@
+package monitors
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers/gamelog"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// gameLogCleanupInterval 是删除过期游戏事件的间隔
+const gameLogCleanupInterval = time.Hour
+
+// gameEventTypes 是各类游戏事件推送时使用的事件类型
+var gameEventTypes = map[string]events.ServerEventType{
+	gamelog.KindChat:        events.ServerEventGameChat,
+	gamelog.KindJoin:        events.ServerEventGameJoin,
+	gamelog.KindLeave:       events.ServerEventGameLeave,
+	gamelog.KindDeath:       events.ServerEventGameDeath,
+	gamelog.KindAdvancement: events.ServerEventGameAdvancement,
+	gamelog.KindWarning:     events.ServerEventGameWarning,
+}
+
+// optional 将空字符串转换为 nil
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+// recordGameEvent 保存从档案 profile 的服务器日志中解析出的游戏事件 e，并推送给用户
+func recordGameEvent(profile string, e *gamelog.Event, logger *log.Logger) {
+	record := &store.GameEvent{
+		Profile:    profile,
+		Kind:       e.Kind,
+		Player:     optional(e.Player),
+		IP:         optional(e.IP),
+		Message:    optional(e.Message),
+		OccurredAt: time.Now(),
+	}
+
+	if err := store.InsertGameEvent(record); err != nil {
+		logger.Println("cannot insert game event:", err)
+		prom.MonitorErrors.Inc("game-log", profile)
+	}
+
+	payload := *record
+	payload.IP = nil
+
+	// 游戏事件已经保存在独立的表中，推送不再另行保存
+	stream.Broadcast(events.Server(profile, gameEventTypes[e.Kind], payload))
+}
+
+// GameLog 持续跟踪档案 profile 的活动实例上的服务器日志，按照服务器类型将新的日志行解析为游戏事件，保存并推送给用户，参见 gamelog.Parser。
+// 只处理开始跟踪后新写入的行，系统未运行期间的日志不会被补齐
+func GameLog(profile string, quit chan bool) {
+	cfg := config.Cfg.Monitor.GameLog
+
+	if !cfg.Enabled {
+		return
+	}
+
+	logger := profileLogger("game-log", "GameLog", profile)
+	logger.Println("starting...")
+
+	profileCfg, _ := config.Cfg.GetProfile(profile)
+	parser := gamelog.ParserFor(profileCfg.Server.Flavor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go followServerLog(ctx, profile, 0, func(kind string, content string) {
+		if kind != ConsoleLineOutput {
+			return
+		}
+
+		if e, ok := parser.Parse(content); ok {
+			recordGameEvent(profile, e, logger)
+		}
+	}, logger)
+
+	ticker := time.NewTicker(gameLogCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := store.DeleteGameEventsBefore(profile, now.Add(-cfg.RetentionDuration())); err != nil {
+				logger.Println("cannot delete expired game events:", err)
+				prom.MonitorErrors.Inc("game-log", profile)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
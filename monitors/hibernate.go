@@ -0,0 +1,246 @@
+package monitors
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/clients"
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/remote"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// hibernationCheckInterval 是 Hibernation 检查休眠实例的间隔
+const hibernationCheckInterval = 10 * time.Minute
+
+// waitInterval 是休眠和唤醒过程中轮询服务器或实例状态的间隔
+const waitInterval = 3 * time.Second
+
+// HibernateInstance 使档案 profile 的活动实例 inst 休眠：停止服务器（如果在线），然后以节省停机模式（StopCharging）停止实例。
+// 休眠的实例保留云盘，不再收取计算资源费用，但其公网IP地址会被释放，唤醒时需要重新获取，参见 ResumeInstance。
+func HibernateInstance(ctx context.Context, profile string, inst *store.Instance, by *int64) error {
+	stopServerCmd := commands.MustGetCommand(consts.CmdTypeStopServer)
+
+	if commands.IsServerOnline(inst) {
+		_, err := stopServerCmd.RunWithoutCooldown(ctx, inst, by, &commands.CommandRunOption{Comment: "During hibernate instance procedure"})
+
+		if err != nil {
+			return err
+		}
+
+		// 等待服务器保存存档并退出，再停止实例
+		for commands.IsServerOnline(inst) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(waitInterval):
+			}
+		}
+	}
+
+	stopInstanceRequest := &ecs20140526.StopInstanceRequest{
+		InstanceId:  tea.String(inst.InstanceId),
+		StoppedMode: tea.String("StopCharging"),
+		ForceStop:   tea.Bool(false),
+	}
+
+	_, err := clients.EcsClient.StopInstanceWithContext(ctx, stopInstanceRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Pool.ExecContext(ctx, "UPDATE instances SET ip = NULL, hibernated_at = CURRENT_TIMESTAMP WHERE instance_id = ?", inst.InstanceId)
+
+	if err != nil {
+		return err
+	}
+
+	if state := stateOf(profile); state != nil {
+		state.publicIp.set("")
+	}
+
+	event := events.Instance(profile, events.InstanceEventNotify, events.InstanceNotificationHibernated, true)
+	err = stream.BroadcastAndSave(event)
+
+	if err != nil {
+		log.Println("cannot broadcast and save event:", err)
+		prom.MonitorErrors.Inc("hibernation", profile)
+	}
+
+	return nil
+}
+
+// describeInstancePublicIp 返回实例 instanceId 当前的公网IP地址。如果实例没有公网IP地址，返回空字符串
+func describeInstancePublicIp(ctx context.Context, instanceId string) (string, error) {
+	describeInstancesRequest := &ecs20140526.DescribeInstancesRequest{
+		RegionId:    tea.String(config.Cfg.Aliyun.RegionId),
+		InstanceIds: tea.String("[\"" + instanceId + "\"]"),
+	}
+
+	describeInstancesResponse, err := clients.EcsClient.DescribeInstancesWithContext(ctx, describeInstancesRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, instance := range describeInstancesResponse.Body.Instances.Instance {
+		if instance.PublicIpAddress != nil && len(instance.PublicIpAddress.IpAddress) > 0 {
+			return tea.StringValue(instance.PublicIpAddress.IpAddress[0]), nil
+		}
+	}
+
+	return "", nil
+}
+
+// ResumeInstance 唤醒档案 profile 处于休眠状态的活动实例：启动实例，等待实例运行并获取新的公网IP地址，最后等待实例可以通过 SSH 连接。
+// 唤醒后的实例保持已部署状态，可以直接运行 start_server 等指令，无需重新部署。该函数会阻塞直到唤醒完成或 ctx 结束，step 用于报告过程中的各个步骤，可以为 nil。
+func ResumeInstance(ctx context.Context, profile string, step func(string)) (*store.Instance, error) {
+	if step == nil {
+		step = func(string) {}
+	}
+
+	inst, err := store.GetHibernatedActiveInstance(profile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if SnapshotInstanceStatus(profile) != consts.InstanceRunning {
+		_, err = clients.EcsClient.StartInstanceWithContext(ctx, &ecs20140526.StartInstanceRequest{InstanceId: tea.String(inst.InstanceId)}, &dara.RuntimeOptions{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		step("requested instance start")
+	}
+
+	for SnapshotInstanceStatus(profile) != consts.InstanceRunning {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+
+	step("instance is running, acquiring public ip")
+
+	ip, err := describeInstancePublicIp(ctx, inst.InstanceId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ip == "" {
+		allocatePublicIpAddressResponse, err := clients.EcsClient.AllocatePublicIpAddressWithContext(ctx, &ecs20140526.AllocatePublicIpAddressRequest{InstanceId: tea.String(inst.InstanceId)}, &dara.RuntimeOptions{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		ip = tea.StringValue(allocatePublicIpAddressResponse.Body.IpAddress)
+	}
+
+	_, err = db.Pool.ExecContext(ctx, "UPDATE instances SET ip = ?, hibernated_at = NULL WHERE instance_id = ?", ip, inst.InstanceId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if state := stateOf(profile); state != nil {
+		state.publicIp.set(ip)
+	}
+
+	step("waiting for instance to be initialized")
+
+	for !remote.TryDialRoot(ip, 5*time.Second) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+
+	event := events.Instance(profile, events.InstanceEventNotify, events.InstanceNotificationResumed, true)
+	err = stream.BroadcastAndSave(event)
+
+	if err != nil {
+		log.Println("cannot broadcast and save event:", err)
+		prom.MonitorErrors.Inc("hibernation", profile)
+	}
+
+	return store.GetDeployedActiveInstance(profile)
+}
+
+// Hibernation 检查档案 profile 处于休眠状态的活动实例。如果实例休眠时间超过了 config.EmptyServer.HibernateMaxDays，则唤醒实例、归档并删除，以免长期保留云盘产生费用。
+func Hibernation(profile string, quit chan bool) {
+	cfg := config.Cfg.Monitor.EmptyServer
+	logger := profileLogger("hibernation", "Hibernation", profile)
+	logger.Println("starting...")
+
+	ticker := time.NewTicker(hibernationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if cfg.HibernateMaxDays == 0 {
+				continue
+			}
+
+			inst, err := store.GetHibernatedActiveInstance(profile)
+
+			if err != nil || inst.HibernatedAt == nil {
+				continue
+			}
+
+			if time.Since(*inst.HibernatedAt) < cfg.HibernateMaxDuration() {
+				continue
+			}
+
+			logger.Printf("instance %s has been hibernated since %s, archiving and deleting", inst.InstanceId, inst.HibernatedAt)
+
+			func() {
+				ctx, cancel := context.WithTimeout(context.Background(), consts.StopAndArchiveTimeout)
+				defer cancel()
+
+				resumed, err := ResumeInstance(ctx, profile, func(s string) { logger.Println(s) })
+
+				if err != nil {
+					logger.Println("cannot resume instance:", err)
+					prom.MonitorErrors.Inc("hibernation", profile)
+					return
+				}
+
+				if err := commands.StopAndArchiveServer(ctx, resumed, nil, "The instance has been hibernated for too long."); err != nil {
+					logger.Println("cannot stop and archive server:", err)
+					prom.MonitorErrors.Inc("hibernation", profile)
+					return
+				}
+
+				if err := helpers.DeleteInstance(ctx, profile, resumed.InstanceId, true); err != nil {
+					logger.Println("cannot delete instance:", err)
+					prom.MonitorErrors.Inc("hibernation", profile)
+					return
+				}
+
+				logger.Println("archived and deleted hibernated instance successfully")
+			}()
+
+		case <-quit:
+			return
+		}
+	}
+}
@@ -13,7 +13,7 @@ import (
 
 	"github.com/Subilan/go-aliyunmc/clients"
 	"github.com/Subilan/go-aliyunmc/config"
-	"github.com/Subilan/go-aliyunmc/filelog"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
 	"github.com/alibabacloud-go/tea/dara"
 	"github.com/alibabacloud-go/tea/tea"
@@ -33,13 +33,49 @@ type PreferredInstanceFileContent struct {
 	UpdatedAt  time.Time               `json:"updatedAt"`
 }
 
-// GetInstanceCharge 尝试获取地域下符合要求的所有实例类型，并获取该实例类型在抢占式实例中的每小时预估价格。
-func GetInstanceCharge(ctx context.Context, logger *log.Logger) ([]AvailableInstanceItem, error) {
+// GetSpotTradePrice 获取档案 profile 在可用区 zoneId 内以实例类型 instanceType 创建抢占式实例的每小时预估交易价格，磁盘配置与创建实例时一致
+func GetSpotTradePrice(ctx context.Context, profile config.ProfileConfig, zoneId string, instanceType string) (float32, error) {
+	ecsConfig := config.Cfg.GetAliyunEcsConfig()
+	dataDisk := *profile.DataDisk
+
+	describePriceRequest := &ecs20140526.DescribePriceRequest{
+		RegionId:                tea.String(config.Cfg.Aliyun.RegionId),
+		ZoneId:                  tea.String(zoneId),
+		ResourceType:            tea.String("instance"),
+		InstanceType:            tea.String(instanceType),
+		InternetChargeType:      tea.String("PayByTraffic"),
+		InternetMaxBandwidthOut: tea.Int32(int32(ecsConfig.InternetMaxBandwidthOut)),
+		SystemDisk: &ecs20140526.DescribePriceRequestSystemDisk{
+			Category: tea.String(ecsConfig.SystemDisk.Category),
+			Size:     tea.Int32(int32(ecsConfig.SystemDisk.Size)),
+		},
+		DataDisk: []*ecs20140526.DescribePriceRequestDataDisk{
+			{
+				Category: tea.String(dataDisk.Category),
+				Size:     tea.Int64(int64(dataDisk.Size)),
+			},
+		},
+		SpotStrategy: tea.String("SpotAsPriceGo"),
+		SpotDuration: tea.Int32(1),
+	}
+
+	describePriceResponse, err := clients.EcsClient.DescribePriceWithContext(ctx, describePriceRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return *describePriceResponse.Body.PriceInfo.Price.TradePrice, nil
+}
+
+// GetInstanceCharge 尝试获取地域下符合档案 profile 要求的所有实例类型，并获取该实例类型在抢占式实例中的每小时预估价格。
+func GetInstanceCharge(ctx context.Context, profile config.ProfileConfig, logger *log.Logger) ([]AvailableInstanceItem, error) {
 	ecsConfig := config.Cfg.GetAliyunEcsConfig()
 	regionId := config.Cfg.Aliyun.RegionId
+	dataDisk := *profile.DataDisk
 
-	memChoices := config.Cfg.Monitor.InstanceCharge.MemChoices
-	cpuCoreCountChoices := config.Cfg.Monitor.InstanceCharge.CpuCoreCountChoices
+	memChoices := profile.MemChoices
+	cpuCoreCountChoices := profile.CpuCoreCountChoices
 
 	var result = make([]AvailableInstanceItem, 0, 10)
 
@@ -52,7 +88,7 @@ func GetInstanceCharge(ctx context.Context, logger *log.Logger) ([]AvailableInst
 				SpotDuration:        tea.Int32(1),
 				DestinationResource: tea.String("InstanceType"),
 				SystemDiskCategory:  &ecsConfig.SystemDisk.Category,
-				DataDiskCategory:    &ecsConfig.DataDisk.Category,
+				DataDiskCategory:    &dataDisk.Category,
 				Cores:               tea.Int32(int32(cpu)),
 				Memory:              tea.Float32(float32(mem)),
 				ResourceType:        tea.String("instance"),
@@ -73,35 +109,12 @@ func GetInstanceCharge(ctx context.Context, logger *log.Logger) ([]AvailableInst
 							continue
 						}
 
-						var tradePrice float32 = -1
-
-						describePriceRequest := &ecs20140526.DescribePriceRequest{
-							RegionId:                &regionId,
-							ZoneId:                  availZone.ZoneId,
-							ResourceType:            tea.String("instance"),
-							InstanceType:            resource.Value,
-							InternetChargeType:      tea.String("PayByTraffic"),
-							InternetMaxBandwidthOut: tea.Int32(int32(ecsConfig.InternetMaxBandwidthOut)),
-							SystemDisk: &ecs20140526.DescribePriceRequestSystemDisk{
-								Category: tea.String(ecsConfig.SystemDisk.Category),
-								Size:     tea.Int32(int32(ecsConfig.SystemDisk.Size)),
-							},
-							DataDisk: []*ecs20140526.DescribePriceRequestDataDisk{
-								{
-									Category: tea.String(ecsConfig.DataDisk.Category),
-									Size:     tea.Int64(int64(ecsConfig.DataDisk.Size)),
-								},
-							},
-							SpotStrategy: tea.String("SpotAsPriceGo"),
-							SpotDuration: tea.Int32(1),
-						}
-
-						describePriceResponse, err := clients.EcsClient.DescribePriceWithContext(ctx, describePriceRequest, &dara.RuntimeOptions{})
+						tradePrice, err := GetSpotTradePrice(ctx, profile, *availZone.ZoneId, *resource.Value)
 
 						if err != nil {
 							logger.Printf("describe price error: %s", err.Error())
-						} else {
-							tradePrice = *describePriceResponse.Body.PriceInfo.Price.TradePrice
+							prom.MonitorErrors.Inc("instance-charge", profile.Name)
+							tradePrice = -1
 						}
 
 						filters := config.Cfg.Monitor.InstanceCharge.Filters
@@ -145,31 +158,84 @@ func GetInstanceCharge(ctx context.Context, logger *log.Logger) ([]AvailableInst
 	return result, nil
 }
 
-var preferredInstanceChargePresent atomic.Bool
-var preferredInstanceCharge AvailableInstanceItem
-var preferredInstanceChargeMu sync.Mutex
-var preferredInstanceChargeCandidates []AvailableInstanceItem
-var preferredInstanceChargeCandidatesMu sync.Mutex
+// instanceChargeState 是一个档案的最佳实例类型及可用区状态
+type instanceChargeState struct {
+	present      atomic.Bool
+	preferred    AvailableInstanceItem
+	preferredMu  sync.Mutex
+	candidates   []AvailableInstanceItem
+	candidatesMu sync.Mutex
+}
+
+func (s *instanceChargeState) setPreferred(item AvailableInstanceItem) {
+	s.preferredMu.Lock()
+	s.preferred = item
+	s.preferredMu.Unlock()
+}
+
+func (s *instanceChargeState) getPreferred() AvailableInstanceItem {
+	s.preferredMu.Lock()
+	defer s.preferredMu.Unlock()
+	return s.preferred
+}
+
+func (s *instanceChargeState) setCandidates(candidates []AvailableInstanceItem) {
+	s.candidatesMu.Lock()
+	s.candidates = candidates
+	s.candidatesMu.Unlock()
+}
+
+func (s *instanceChargeState) getCandidates() []AvailableInstanceItem {
+	s.candidatesMu.Lock()
+	defer s.candidatesMu.Unlock()
+	return s.candidates
+}
+
+// SnapshotPreferredInstanceChargePresent 返回系统是否为档案 profile 记录了有效的最佳实例类型及可用区
+func SnapshotPreferredInstanceChargePresent(profile string) bool {
+	state := stateOf(profile)
+
+	if state == nil {
+		return false
+	}
+
+	return state.instanceCharge.present.Load()
+}
+
+// SnapshotPreferredInstanceCharge 返回当前为档案 profile 获取的最佳实例类型及可用区。在调用此函数之前，除非确信，应当调用 SnapshotPreferredInstanceChargePresent 检查该信息是否存在
+func SnapshotPreferredInstanceCharge(profile string) AvailableInstanceItem {
+	state := stateOf(profile)
+
+	if state == nil {
+		return AvailableInstanceItem{}
+	}
 
-// SnapshotPreferredInstanceChargePresent 返回系统是否记录了有效的最佳实例类型及可用区
-func SnapshotPreferredInstanceChargePresent() bool {
-	return preferredInstanceChargePresent.Load()
+	return state.instanceCharge.getPreferred()
 }
 
-// SnapshotPreferredInstanceCharge 返回当前获取的最佳实例类型及可用区。在调用此函数之前，除非确信，应当调用 SnapshotPreferredInstanceChargePresent 检查该信息是否存在
-func SnapshotPreferredInstanceCharge() AvailableInstanceItem {
-	preferredInstanceChargeMu.Lock()
-	defer preferredInstanceChargeMu.Unlock()
-	return preferredInstanceCharge
+// SnapshotPreferredInstanceChargeCandidates 返回当前为档案 profile 获取的候选实例类型及可用区，按价格从低到高排列，不包含最佳实例本身
+func SnapshotPreferredInstanceChargeCandidates(profile string) []AvailableInstanceItem {
+	state := stateOf(profile)
+
+	if state == nil {
+		return nil
+	}
+
+	return state.instanceCharge.getCandidates()
 }
 
-func InstanceCharge(quit chan bool) {
+// InstanceCharge 定期为档案 profile 获取最佳实例类型及可用区
+func InstanceCharge(profile string, quit chan bool) {
 	cfg := config.Cfg.Monitor.InstanceCharge
 	ticker := time.NewTicker(cfg.IntervalDuration())
-	logger := filelog.NewLogger("instance-charge", "InstanceCharge")
+	logger := profileLogger("instance-charge", "InstanceCharge", profile)
 	logger.Println("starting...")
 
-	cacheFileContent, err := os.ReadFile(cfg.CacheFile)
+	profileCfg, _ := config.Cfg.GetProfile(profile)
+	s := stateOf(profile).instanceCharge
+	cacheFile := cfg.ProfileCacheFile(profile)
+
+	cacheFileContent, err := os.ReadFile(cacheFile)
 	if err != nil {
 		logger.Println("read cache file error:", err)
 	} else {
@@ -177,17 +243,14 @@ func InstanceCharge(quit chan bool) {
 		err = json.Unmarshal(cacheFileContent, &cacheFileData)
 		if err != nil {
 			logger.Println("unmarshal cache file error:", err)
+			prom.MonitorErrors.Inc("instance-charge", profile)
 		} else {
 
 			// TODO: add validation for in-file struct
-			preferredInstanceChargePresent.Store(true)
-			preferredInstanceChargeMu.Lock()
-			preferredInstanceCharge = cacheFileData.AvailableInstanceItem
-			preferredInstanceChargeMu.Unlock()
-			preferredInstanceChargeCandidatesMu.Lock()
-			preferredInstanceChargeCandidates = cacheFileData.Candidates
-			preferredInstanceChargeCandidatesMu.Unlock()
-			logger.Printf("using cache from file, preferred = %v, candidate length = %d", preferredInstanceCharge, len(preferredInstanceChargeCandidates))
+			s.present.Store(true)
+			s.setPreferred(cacheFileData.AvailableInstanceItem)
+			s.setCandidates(cacheFileData.Candidates)
+			logger.Printf("using cache from file, preferred = %v, candidate length = %d", cacheFileData.AvailableInstanceItem, len(cacheFileData.Candidates))
 		}
 	}
 
@@ -198,21 +261,20 @@ func InstanceCharge(quit chan bool) {
 			ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutDuration())
 			defer cancel()
 
-			result, err := GetInstanceCharge(ctx, logger)
+			result, err := GetInstanceCharge(ctx, profileCfg, logger)
 
 			if err != nil {
 				logger.Println("cannot get instance charge", err)
+				prom.MonitorErrors.Inc("instance-charge", profile)
 				return
 			}
 
 			if len(result) == 0 {
 				logger.Println("warn: no preferred instance found with filter. set to empty.")
-				preferredInstanceChargeMu.Lock()
-				preferredInstanceCharge = AvailableInstanceItem{}
-				preferredInstanceChargeMu.Unlock()
+				s.setPreferred(AvailableInstanceItem{})
 
-				if preferredInstanceChargePresent.Load() == true {
-					preferredInstanceChargePresent.Store(false)
+				if s.present.Load() == true {
+					s.present.Store(false)
 				}
 
 				logger.Println("next refresh in", cfg.RetryIntervalDuration())
@@ -227,25 +289,22 @@ func InstanceCharge(quit chan bool) {
 				candidates = append(candidates, result[i])
 			}
 
-			if target.InstanceType != preferredInstanceCharge.InstanceType || target.ZoneId != preferredInstanceCharge.ZoneId {
-				preferredInstanceChargeMu.Lock()
-				preferredInstanceCharge = target
-				preferredInstanceChargeMu.Unlock()
-				preferredInstanceChargeCandidatesMu.Lock()
-				preferredInstanceChargeCandidates = candidates
-				preferredInstanceChargeCandidatesMu.Unlock()
+			if preferred := s.getPreferred(); target.InstanceType != preferred.InstanceType || target.ZoneId != preferred.ZoneId {
+				s.setPreferred(target)
+				s.setCandidates(candidates)
 
-				if preferredInstanceChargePresent.Load() == false {
-					preferredInstanceChargePresent.Store(true)
+				if s.present.Load() == false {
+					s.present.Store(true)
 				}
 
 				logger.Printf("updated preferred instance, %v", target)
 
 				marshalled, _ := json.Marshal(PreferredInstanceFileContent{AvailableInstanceItem: target, Candidates: candidates, UpdatedAt: time.Now()})
-				err = os.WriteFile(cfg.CacheFile, marshalled, 0600)
+				err = os.WriteFile(cacheFile, marshalled, 0600)
 
 				if err != nil {
 					logger.Println("cannot write to cache file", err)
+					prom.MonitorErrors.Inc("instance-charge", profile)
 				} else {
 					logger.Println("updated cache file")
 				}
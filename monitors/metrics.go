@@ -0,0 +1,153 @@
+package monitors
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers/metrics"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/rcon"
+	"github.com/Subilan/go-aliyunmc/helpers/remote"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// metricsCompactInterval 是合并和清理旧采样的间隔
+const metricsCompactInterval = time.Hour
+
+// sampleTick 通过 RCON 采集档案 profile 的服务器在地址 ip 上的 tick 性能，并填入 sample。服务器类型不支持时不做任何事情
+func sampleTick(ctx context.Context, profile config.ProfileConfig, ip string, sample *store.MetricsSample) error {
+	commands := metrics.TickCommands(profile.Server.Flavor)
+
+	if commands == nil {
+		return nil
+	}
+
+	responses, err := rcon.Run(ctx, ip, profile.GetGameRconPort(), profile.Server.RconPassword, commands...)
+
+	if err != nil {
+		return err
+	}
+
+	tick, err := metrics.ParseTick(profile.Server.Flavor, responses)
+
+	if err != nil {
+		return err
+	}
+
+	sample.TPS, sample.MSPT = &tick.TPS, &tick.MSPT
+
+	return nil
+}
+
+// sampleHost 通过 SSH 采集地址 ip 上实例的资源使用情况，并填入 sample
+func sampleHost(ctx context.Context, ip string, sample *store.MetricsSample) error {
+	output, err := remote.RunCommandAsProdSync(ctx, ip, metrics.HostCommands, true)
+
+	if err != nil {
+		return err
+	}
+
+	host, err := metrics.ParseHost(string(output))
+
+	if err != nil {
+		return err
+	}
+
+	sample.CPU = &host.CPU
+	sample.MemUsed, sample.MemTotal = &host.MemUsed, &host.MemTotal
+	sample.Load1, sample.Load5, sample.Load15 = &host.Load1, &host.Load5, &host.Load15
+	sample.DiskUsed, sample.DiskTotal = &host.DiskUsed, &host.DiskTotal
+
+	return nil
+}
+
+// collectMetrics 采集档案 profile 的一次采样，保存并推送给用户。实例没有运行时不采样，服务器不在线时只采集实例的指标
+func collectMetrics(profile config.ProfileConfig, cfg config.Metrics, logger *log.Logger) {
+	ip := SnapshotInstanceIp(profile.Name)
+
+	if SnapshotInstanceStatus(profile.Name) != consts.InstanceRunning || ip == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutDuration())
+	defer cancel()
+
+	sample := &store.MetricsSample{SampledAt: time.Now()}
+
+	if SnapshotIsServerRunning(profile.Name) {
+		if err := sampleTick(ctx, profile, ip, sample); err != nil {
+			logger.Println("cannot sample tick:", err)
+			prom.MonitorErrors.Inc("metrics", profile.Name)
+		}
+	}
+
+	if err := sampleHost(ctx, ip, sample); err != nil {
+		logger.Println("cannot sample host:", err)
+		prom.MonitorErrors.Inc("metrics", profile.Name)
+	}
+
+	if sample.TPS == nil && sample.CPU == nil {
+		return
+	}
+
+	if err := store.InsertMetricsSample(profile.Name, cfg.IntervalDuration(), sample); err != nil {
+		logger.Println("cannot insert metrics sample:", err)
+		prom.MonitorErrors.Inc("metrics", profile.Name)
+	}
+
+	// 采样已经保存在独立的表中，推送不再另行保存
+	stream.Broadcast(events.Server(profile.Name, events.ServerEventMetricsUpdate, sample))
+}
+
+// compactMetrics 合并超过原始粒度保留时间的采样，并删除超过保留时间的采样
+func compactMetrics(profile string, cfg config.Metrics, logger *log.Logger) {
+	now := time.Now()
+
+	if err := store.DownsampleMetrics(profile, now.Add(-cfg.DownsampleAfterDuration()), cfg.DownsampleStepDuration()); err != nil {
+		logger.Println("cannot downsample metrics:", err)
+		prom.MonitorErrors.Inc("metrics", profile)
+	}
+
+	if err := store.DeleteMetricsBefore(profile, now.Add(-cfg.RetentionDuration())); err != nil {
+		logger.Println("cannot delete expired metrics:", err)
+		prom.MonitorErrors.Inc("metrics", profile)
+	}
+}
+
+// Metrics 定期采集档案 profile 的服务器 tick 性能以及实例的 CPU、内存、负载和磁盘使用情况，并定期合并和清理旧的采样
+func Metrics(profile string, quit chan bool) {
+	cfg := config.Cfg.Monitor.Metrics
+
+	if !cfg.Enabled {
+		return
+	}
+
+	logger := profileLogger("metrics", "Metrics", profile)
+	logger.Println("starting...")
+
+	profileCfg, _ := config.Cfg.GetProfile(profile)
+
+	ticker := time.NewTicker(cfg.IntervalDuration())
+	defer ticker.Stop()
+
+	compactTicker := time.NewTicker(metricsCompactInterval)
+	defer compactTicker.Stop()
+
+	compactMetrics(profile, cfg, logger)
+
+	for {
+		select {
+		case <-ticker.C:
+			collectMetrics(profileCfg, cfg, logger)
+		case <-compactTicker.C:
+			compactMetrics(profile, cfg, logger)
+		case <-quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package monitors
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/helpers/cron"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// migrationPriceTimeout 是查询活动实例当前交易价格的超时时间
+const migrationPriceTimeout = 15 * time.Second
+
+// MigrateFunc 用于将档案 profile 的活动实例迁移到更便宜的实例类型，返回迁移任务的标识符。
+// 该流程位于 handlers/instances 中，因此需要在启动监控器之前注入。为 nil 时 Migration 不做任何事情。
+var MigrateFunc func(profile string) (string, error)
+
+// inMigrationWindow 返回 t 是否处于以 window 为开始时间、持续 length 的迁移时段内
+func inMigrationWindow(window *cron.Expr, length time.Duration, t time.Time) bool {
+	if window == nil {
+		return false
+	}
+
+	start := window.Next(t.Add(-length))
+
+	return !start.IsZero() && !start.After(t)
+}
+
+// shouldMigrate 返回档案 profile 的活动实例当前是否应该迁移到最佳实例类型
+func shouldMigrate(profile string, profileCfg config.ProfileConfig, window *cron.Expr, logger *log.Logger) bool {
+	cfg := config.Cfg.Monitor.Migration
+
+	if !SnapshotPreferredInstanceChargePresent(profile) || SnapshotInstanceStatus(profile) != consts.InstanceRunning {
+		return false
+	}
+
+	inst, err := store.GetDeployedActiveInstance(profile)
+
+	if err != nil {
+		return false
+	}
+
+	preferred := SnapshotPreferredInstanceCharge(profile)
+
+	if preferred.InstanceType == inst.InstanceType && preferred.ZoneId == inst.ZoneId {
+		return false
+	}
+
+	empty := cfg.WhenEmpty && stateOf(profile).serverStatus.playerCount.Load() == 0
+	inWindow := inMigrationWindow(window, cfg.WindowLength(), time.Now())
+
+	if !empty && !inWindow {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrationPriceTimeout)
+	defer cancel()
+
+	currentPrice, err := GetSpotTradePrice(ctx, profileCfg, inst.ZoneId, inst.InstanceType)
+
+	if err != nil {
+		logger.Println("cannot get trade price of active instance:", err)
+		prom.MonitorErrors.Inc("migration", profile)
+		return false
+	}
+
+	if currentPrice-preferred.TradePrice < cfg.Margin {
+		return false
+	}
+
+	logger.Printf("active instance %s (%s) costs %.4f, preferred %s (%s) costs %.4f, empty=%v, in window=%v", inst.InstanceType, inst.ZoneId, currentPrice, preferred.InstanceType, preferred.ZoneId, preferred.TradePrice, empty, inWindow)
+
+	return true
+}
+
+// Migration 定期检查档案 profile 的活动实例，当其交易价格比最佳实例高出 config.Migration.Margin 以上，并且服务器无玩家在线或者处于迁移时段内时，将其迁移到更便宜的实例类型。
+// 一次迁移开始后，在 config.Migration.Cooldown 内不会再次迁移。
+func Migration(profile string, quit chan bool) {
+	cfg := config.Cfg.Monitor.Migration
+
+	if !cfg.Enabled {
+		return
+	}
+
+	logger := profileLogger("migration", "Migration", profile)
+	logger.Println("starting...")
+
+	profileCfg, _ := config.Cfg.GetProfile(profile)
+
+	var window *cron.Expr
+
+	if cfg.Window != "" {
+		// 已经在加载配置时检查过
+		window, _ = cron.Parse(cfg.Window)
+	}
+
+	ticker := time.NewTicker(cfg.IntervalDuration())
+	defer ticker.Stop()
+
+	var lastAttempt time.Time
+
+	for {
+		select {
+		case <-ticker.C:
+			if MigrateFunc == nil || time.Since(lastAttempt) < cfg.CooldownDuration() {
+				continue
+			}
+
+			if !shouldMigrate(profile, profileCfg, window, logger) {
+				continue
+			}
+
+			lastAttempt = time.Now()
+
+			taskId, err := MigrateFunc(profile)
+
+			if err != nil {
+				logger.Println("cannot start migration:", err)
+				prom.MonitorErrors.Inc("migration", profile)
+				continue
+			}
+
+			logger.Println("migration started, task", taskId)
+
+		case <-quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package monitors
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// playerSessionTouchInterval 是更新未结束会话最后确认在线时间的间隔，也是系统异常退出时会话时长的最大误差
+const playerSessionTouchInterval = time.Minute
+
+// recordPlayerSessions 根据档案 profile 在线玩家列表的变化记录玩家的会话：新出现的玩家开始会话，消失的玩家结束会话，参见 store.PlayerSession
+func recordPlayerSessions(profile string, s *serverStatusState, logger *log.Logger) {
+	if err := store.CloseStalePlayerSessions(profile); err != nil {
+		logger.Println("cannot close stale player sessions:", err)
+		prom.MonitorErrors.Inc("server-status", profile)
+	}
+
+	onlinePlayersUpdate := s.onlinePlayersBroker.Subscribe()
+	ticker := time.NewTicker(playerSessionTouchInterval)
+	defer ticker.Stop()
+
+	var previous []string
+
+	for {
+		select {
+		case players, ok := <-onlinePlayersUpdate:
+			if !ok {
+				return
+			}
+
+			now := time.Now()
+
+			for _, player := range players {
+				if slices.Contains(previous, player) {
+					continue
+				}
+
+				if err := store.OpenPlayerSession(profile, player, now); err != nil {
+					logger.Printf("cannot open session of player %s: %s", player, err.Error())
+					prom.MonitorErrors.Inc("server-status", profile)
+				}
+			}
+
+			for _, player := range previous {
+				if slices.Contains(players, player) {
+					continue
+				}
+
+				if err := store.ClosePlayerSession(profile, player, now); err != nil {
+					logger.Printf("cannot close session of player %s: %s", player, err.Error())
+					prom.MonitorErrors.Inc("server-status", profile)
+				}
+			}
+
+			previous = players
+
+		case now := <-ticker.C:
+			if len(previous) == 0 {
+				continue
+			}
+
+			if err := store.TouchPlayerSessions(profile, now); err != nil {
+				logger.Println("cannot touch player sessions:", err)
+				prom.MonitorErrors.Inc("server-status", profile)
+			}
+		}
+	}
+}
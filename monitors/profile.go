@@ -0,0 +1,51 @@
+package monitors
+
+import (
+	"log"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/filelog"
+)
+
+// profileState 保存了一个档案的全部监控状态。每个档案拥有独立的状态和广播器，互不影响。
+type profileState struct {
+	name           string
+	activeInstance *activeInstanceState
+	publicIp       *publicIpState
+	serverStatus   *serverStatusState
+	instanceCharge *instanceChargeState
+	whitelist      *whitelistState
+	console        *consoleState
+}
+
+// profileStates 记录所有档案的监控状态，键为档案名称。该字典只在 Init 中写入，之后只读。
+var profileStates = make(map[string]*profileState)
+
+// Init 为配置中的每个档案初始化监控状态。该函数必须在任何监控器启动之前调用。
+func Init() {
+	for _, p := range config.Cfg.Profiles {
+		profileStates[p.Name] = &profileState{
+			name:           p.Name,
+			activeInstance: newActiveInstanceState(),
+			publicIp:       newPublicIpState(),
+			serverStatus:   newServerStatusState(),
+			instanceCharge: &instanceChargeState{},
+			whitelist:      &whitelistState{},
+			console:        newConsoleState(p.Name),
+		}
+	}
+}
+
+// stateOf 返回档案 profile 的监控状态。如果档案不存在，返回 nil
+func stateOf(profile string) *profileState {
+	return profileStates[profile]
+}
+
+// profileLogger 返回档案 profile 下监控器使用的日志记录器。默认档案沿用原日志文件，其它档案的日志写入以档案名称为后缀的独立文件。
+func profileLogger(filename string, prefixName string, profile string) *log.Logger {
+	if profile == config.DefaultProfileName {
+		return filelog.NewLogger(filename, prefixName)
+	}
+
+	return filelog.NewLogger(filename+"-"+profile, prefixName+"/"+profile)
+}
@@ -0,0 +1,379 @@
+package monitors
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/cron"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// scheduleCheckInterval 是 Scheduler 检查开放时段的间隔
+const scheduleCheckInterval = 30 * time.Second
+
+// scheduleGrace 是计划动作错过预定时间后仍然会被执行的最长时间，例如系统恰好在开放时段结束时重启
+const scheduleGrace = 5 * time.Minute
+
+// ScheduleOpenFunc 在开放时段即将开始时被调用，用于为档案 profile 创建并部署实例、开启服务器。
+// 该流程位于 handlers/instances 中，因此需要在启动监控器之前注入。为 nil 时 Scheduler 只负责关闭服务器。
+var ScheduleOpenFunc func(profile string) error
+
+// Schedule 是一个解析后的开放时段计划，来源于配置文件或者通过接口添加
+type Schedule struct {
+	store.Schedule
+
+	// FromConfig 表示该计划是否来源于配置文件。来源于配置文件的计划不能通过接口删除
+	FromConfig bool `json:"fromConfig"`
+
+	expr *cron.Expr
+}
+
+// ScheduleOccurrence 表示计划的一次开放时段
+type ScheduleOccurrence struct {
+	Schedule string `json:"schedule"`
+	Profile  string `json:"profile"`
+
+	// OpensAt 是开始创建并部署实例的时间，即 StartsAt 之前 LeadTime 分钟
+	OpensAt  time.Time `json:"opensAt"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+
+	// Skipped 表示该时段是否已被跳过
+	Skipped bool `json:"skipped"`
+}
+
+func (s *Schedule) duration() time.Duration {
+	return time.Duration(s.Duration) * time.Minute
+}
+
+func (s *Schedule) leadTime() time.Duration {
+	return time.Duration(s.LeadTime) * time.Minute
+}
+
+// Occurrences 返回该计划在 from 之后结束的前 n 个开放时段
+func (s *Schedule) Occurrences(from time.Time, n int) ([]ScheduleOccurrence, error) {
+	skips, err := store.GetScheduleSkips(s.Name, from.Add(-s.duration()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ScheduleOccurrence, 0, n)
+	t := from.Add(-s.duration())
+
+	for len(result) < n {
+		t = s.expr.Next(t)
+
+		if t.IsZero() {
+			break
+		}
+
+		result = append(result, ScheduleOccurrence{
+			Schedule: s.Name,
+			Profile:  s.Profile,
+			OpensAt:  t.Add(-s.leadTime()),
+			StartsAt: t,
+			EndsAt:   t.Add(s.duration()),
+			Skipped: slices.ContainsFunc(skips, func(skip time.Time) bool {
+				return skip.Equal(t)
+			}),
+		})
+	}
+
+	return result, nil
+}
+
+// occurrencesUntil 返回该计划在 from 之后结束、且在 until 之前开始创建实例的所有开放时段
+func (s *Schedule) occurrencesUntil(from time.Time, until time.Time) ([]ScheduleOccurrence, error) {
+	var result []ScheduleOccurrence
+
+	// 开放时段的时长至少为一分钟，因此一天内的时段数量有限，按批次获取即可
+	for n := 8; ; n *= 2 {
+		occurrences, err := s.Occurrences(from, n)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = result[:0]
+
+		for _, o := range occurrences {
+			if o.OpensAt.After(until) {
+				return result, nil
+			}
+
+			result = append(result, o)
+		}
+
+		if len(occurrences) < n {
+			return result, nil
+		}
+	}
+}
+
+// GetSchedules 返回档案 profile 的所有计划，包括配置文件中的计划和通过接口添加的计划。profile 为空时返回所有档案的计划
+func GetSchedules(profile string) ([]*Schedule, error) {
+	var result []*Schedule
+
+	for _, sc := range config.Cfg.Schedules {
+		if profile != "" && sc.Profile != profile {
+			continue
+		}
+
+		expr, err := cron.Parse(sc.Cron)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &Schedule{
+			Schedule: store.Schedule{
+				Name:      sc.Name,
+				Profile:   sc.Profile,
+				Cron:      sc.Cron,
+				Duration:  sc.Duration,
+				LeadTime:  sc.LeadTime,
+				Warnings:  sc.Warnings,
+				Protected: sc.Protected,
+			},
+			FromConfig: true,
+			expr:       expr,
+		})
+	}
+
+	added, err := store.GetSchedules()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range added {
+		if profile != "" && s.Profile != profile {
+			continue
+		}
+
+		expr, err := cron.Parse(s.Cron)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &Schedule{Schedule: *s, expr: expr})
+	}
+
+	return result, nil
+}
+
+// GetSchedule 返回名称为 name 的计划。如果计划不存在，返回 nil
+func GetSchedule(name string) (*Schedule, error) {
+	schedules, err := GetSchedules("")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range schedules {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// InProtectedWindow 返回时间 t 是否处于档案 profile 某个受保护的开放时段内（包括开放时段开始前创建实例的时间）。被跳过的时段不受保护
+func InProtectedWindow(profile string, t time.Time) (bool, error) {
+	schedules, err := GetSchedules(profile)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range schedules {
+		if !s.Protected {
+			continue
+		}
+
+		occurrences, err := s.occurrencesUntil(t, t)
+
+		if err != nil {
+			return false, err
+		}
+
+		for _, o := range occurrences {
+			if !o.Skipped {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// scheduleActionKind 是计划动作的类型
+type scheduleActionKind int
+
+const (
+	scheduleActionOpen scheduleActionKind = iota
+	scheduleActionWarn
+	scheduleActionClose
+)
+
+// scheduleAction 标识一个开放时段上的一个计划动作，用于避免同一动作被重复执行
+type scheduleAction struct {
+	schedule string
+	startsAt int64
+	kind     scheduleActionKind
+	minutes  int
+}
+
+func openScheduledServer(profile string, o ScheduleOccurrence, logger *log.Logger) {
+	if ScheduleOpenFunc == nil {
+		return
+	}
+
+	logger.Printf("schedule %s starts at %s, opening server", o.Schedule, o.StartsAt.Format(time.DateTime))
+
+	if err := ScheduleOpenFunc(profile); err != nil {
+		logger.Println("cannot open server:", err)
+		prom.MonitorErrors.Inc("scheduler", profile)
+		return
+	}
+
+	logger.Println("open server successfully")
+}
+
+func warnScheduledClose(profile string, minutes int, logger *log.Logger) {
+	inst, err := store.GetDeployedActiveInstance(profile)
+
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := commands.WarnScheduledClose(ctx, inst, minutes); err != nil {
+		logger.Println("cannot warn players in game:", err)
+		prom.MonitorErrors.Inc("scheduler", profile)
+	}
+}
+
+// checkSchedules 检查档案 profile 的所有开放时段，执行到期的计划动作。done 记录已经执行过的动作及其所在时段的结束时间
+func checkSchedules(profile string, done map[scheduleAction]time.Time, logger *log.Logger) {
+	now := time.Now()
+
+	schedules, err := GetSchedules(profile)
+
+	if err != nil {
+		logger.Println("cannot get schedules:", err)
+		prom.MonitorErrors.Inc("scheduler", profile)
+		return
+	}
+
+	var active, ended []ScheduleOccurrence
+	warnings := make(map[string][]int)
+
+	for _, s := range schedules {
+		occurrences, err := s.occurrencesUntil(now.Add(-scheduleGrace), now)
+
+		if err != nil {
+			logger.Println("cannot get occurrences of schedule", s.Name, ":", err)
+			prom.MonitorErrors.Inc("scheduler", profile)
+			return
+		}
+
+		for _, o := range occurrences {
+			if o.Skipped {
+				continue
+			}
+
+			if now.Before(o.EndsAt) {
+				active = append(active, o)
+			} else {
+				ended = append(ended, o)
+			}
+		}
+
+		warnings[s.Name] = s.Warnings
+	}
+
+	// mark 标记动作 a 已经执行，返回在此之前是否未执行
+	mark := func(a scheduleAction, o ScheduleOccurrence) bool {
+		if _, ok := done[a]; ok {
+			return false
+		}
+
+		done[a] = o.EndsAt
+		return true
+	}
+
+	for _, o := range active {
+		if mark(scheduleAction{o.Schedule, o.StartsAt.Unix(), scheduleActionOpen, 0}, o) {
+			go openScheduledServer(profile, o, logger)
+		}
+
+		// 与其它更晚结束的开放时段重叠时，服务器不会在此时段结束时关闭，不需要警告
+		if slices.ContainsFunc(active, func(other ScheduleOccurrence) bool { return other.EndsAt.After(o.EndsAt) }) {
+			continue
+		}
+
+		for _, minutes := range warnings[o.Schedule] {
+			at := o.EndsAt.Add(-time.Duration(minutes) * time.Minute)
+
+			if now.Before(at) || now.Sub(at) > scheduleGrace {
+				continue
+			}
+
+			if mark(scheduleAction{o.Schedule, o.StartsAt.Unix(), scheduleActionWarn, minutes}, o) {
+				go warnScheduledClose(profile, minutes, logger)
+			}
+		}
+	}
+
+	for _, o := range ended {
+		if !mark(scheduleAction{o.Schedule, o.StartsAt.Unix(), scheduleActionClose, 0}, o) {
+			continue
+		}
+
+		// 与其它仍在进行的开放时段重叠时，不关闭服务器
+		if len(active) > 0 {
+			logger.Printf("schedule %s ended but schedule %s is still active, keeping server", o.Schedule, active[0].Schedule)
+			continue
+		}
+
+		logger.Printf("schedule %s ended at %s, closing server", o.Schedule, o.EndsAt.Format(time.DateTime))
+		go safeDeleteServer(profile, "The scheduled opening hours have ended.", logger)
+	}
+
+	for a, endsAt := range done {
+		if now.Sub(endsAt) > 2*scheduleGrace {
+			delete(done, a)
+		}
+	}
+}
+
+// Scheduler 按照档案 profile 的开放时段计划，在开放时段开始前创建并部署实例、开启服务器，在结束前警告玩家，结束时停止服务器、归档并删除实例
+func Scheduler(profile string, quit chan bool) {
+	logger := profileLogger("scheduler", "Scheduler", profile)
+	logger.Println("starting...")
+
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	done := make(map[scheduleAction]time.Time)
+
+	for {
+		select {
+		case <-ticker.C:
+			checkSchedules(profile, done, logger)
+		case <-quit:
+			return
+		}
+	}
+}
@@ -13,48 +13,93 @@ import (
 	"github.com/Subilan/go-aliyunmc/consts"
 	"github.com/Subilan/go-aliyunmc/events"
 	"github.com/Subilan/go-aliyunmc/events/stream"
-	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	"github.com/mcstatus-io/mcutil/v4/query"
 	"github.com/mcstatus-io/mcutil/v4/response"
 	"github.com/mcstatus-io/mcutil/v4/status"
 )
 
-var isServerRunningBroker = broker.New[bool]()
-var onlinePlayersBroker = broker.New[[]string]()
-var playerCountBroker = broker.New[int64]()
+// serverStatusState 是一个档案的服务器状态
+type serverStatusState struct {
+	isServerRunningBroker *broker.Broker[bool]
+	onlinePlayersBroker   *broker.Broker[[]string]
+	playerCountBroker     *broker.Broker[int64]
 
-var isServerRunning atomic.Bool
-var playerCount atomic.Int64
+	isServerRunning atomic.Bool
+	playerCount     atomic.Int64
 
-var serverStatus *response.StatusModern
-var serverStatusMu sync.RWMutex
-var onlinePlayers = make([]string, 0, 20)
-var onlinePlayersMu sync.RWMutex
+	serverStatus    *response.StatusModern
+	serverStatusMu  sync.RWMutex
+	onlinePlayers   []string
+	onlinePlayersMu sync.RWMutex
+}
+
+func newServerStatusState() *serverStatusState {
+	s := &serverStatusState{
+		isServerRunningBroker: broker.New[bool](),
+		onlinePlayersBroker:   broker.New[[]string](),
+		playerCountBroker:     broker.New[int64](),
+		onlinePlayers:         make([]string, 0, 20),
+	}
 
-// SnapshotServerStatus 返回截止目前最新的服务器状态
-func SnapshotServerStatus() *response.StatusModern {
-	serverStatusMu.RLock()
-	defer serverStatusMu.RUnlock()
+	s.playerCount.Store(-1) // default value, server not online
 
-	return serverStatus
+	return s
 }
 
-// SnapshotOnlinePlayers 返回截止目前最新的玩家列表
-func SnapshotOnlinePlayers() []string {
-	onlinePlayersMu.RLock()
-	defer onlinePlayersMu.RUnlock()
+// SnapshotServerStatus 返回档案 profile 截止目前最新的服务器状态
+func SnapshotServerStatus(profile string) *response.StatusModern {
+	state := stateOf(profile)
 
-	return onlinePlayers
+	if state == nil {
+		return nil
+	}
+
+	state.serverStatus.serverStatusMu.RLock()
+	defer state.serverStatus.serverStatusMu.RUnlock()
+
+	return state.serverStatus.serverStatus
 }
 
-// SnapshotIsServerRunning 返回截止目前最新的服务器运行状态
-func SnapshotIsServerRunning() bool {
-	return isServerRunning.Load()
+// SnapshotOnlinePlayers 返回档案 profile 截止目前最新的玩家列表
+func SnapshotOnlinePlayers(profile string) []string {
+	state := stateOf(profile)
+
+	if state == nil {
+		return []string{}
+	}
+
+	state.serverStatus.onlinePlayersMu.RLock()
+	defer state.serverStatus.onlinePlayersMu.RUnlock()
+
+	return state.serverStatus.onlinePlayers
 }
 
-func syncServerStatusWithUser() {
-	isServerRunningUpdate := isServerRunningBroker.Subscribe()
+// SnapshotPlayerCount 返回档案 profile 截止目前最新的在线人数。服务器不在线时为 -1
+func SnapshotPlayerCount(profile string) int64 {
+	state := stateOf(profile)
+
+	if state == nil {
+		return -1
+	}
+
+	return state.serverStatus.playerCount.Load()
+}
+
+// SnapshotIsServerRunning 返回档案 profile 截止目前最新的服务器运行状态
+func SnapshotIsServerRunning(profile string) bool {
+	state := stateOf(profile)
+
+	if state == nil {
+		return false
+	}
+
+	return state.serverStatus.isServerRunning.Load()
+}
+
+func syncServerStatusWithUser(profile string, s *serverStatusState) {
+	isServerRunningUpdate := s.isServerRunningBroker.Subscribe()
 	for newIsServerRunning := range isServerRunningUpdate {
 		var data any
 
@@ -64,81 +109,89 @@ func syncServerStatusWithUser() {
 			data = events.ServerNotificationClosed
 		}
 
-		event := events.Server(events.ServerEventNotify, data, true)
+		event := events.Server(profile, events.ServerEventNotify, data, true)
 		err := stream.BroadcastAndSave(event)
 
 		if err != nil {
 			log.Println("cannot broadcast server event:", err)
+			prom.MonitorErrors.Inc("server-status", profile)
 		}
 	}
 }
 
-func syncOnlineCountWithUser() {
-	playerCountUpdate := playerCountBroker.Subscribe()
+// syncOnlineCountWithUser 向用户推送在线人数的变化。在线人数的历史由 recordStatusSamples 记录，推送不再另行保存
+func syncOnlineCountWithUser(profile string, s *serverStatusState) {
+	playerCountUpdate := s.playerCountBroker.Subscribe()
 	for onlineCount := range playerCountUpdate {
-		event := events.Server(events.ServerEventOnlineCountUpdate, onlineCount, true)
-		err := stream.BroadcastAndSave(event)
-
-		if err != nil {
-			log.Println("cannot broadcast server event:", err)
-		}
+		stream.Broadcast(events.Server(profile, events.ServerEventOnlineCountUpdate, onlineCount, true))
 	}
 }
 
-func syncOnlinePlayersWithUser() {
-	onlinePlayersUpdate := onlinePlayersBroker.Subscribe()
+func syncOnlinePlayersWithUser(profile string, s *serverStatusState) {
+	onlinePlayersUpdate := s.onlinePlayersBroker.Subscribe()
 
 	for newOnlinePlayers := range onlinePlayersUpdate {
 		marshalled, err := json.Marshal(newOnlinePlayers)
 
 		if err != nil {
 			log.Println("cannot marshal online players:", err)
+			prom.MonitorErrors.Inc("server-status", profile)
 			return
 		}
 
-		event := events.Server(events.ServerEventOnlinePlayersUpdate, string(marshalled), true)
+		event := events.Server(profile, events.ServerEventOnlinePlayersUpdate, string(marshalled), true)
 		err = stream.BroadcastAndSave(event)
 
 		if err != nil {
 			log.Println("cannot broadcast server event:", err)
+			prom.MonitorErrors.Inc("server-status", profile)
 		}
 	}
 }
 
-func setServerStatus(status bool) {
-	isServerRunning.Store(status)
-	isServerRunningBroker.Publish(status)
+func (s *serverStatusState) setServerStatus(status bool) {
+	s.isServerRunning.Store(status)
+	s.isServerRunningBroker.Publish(status)
 
 	if !status {
 		// 服务器关闭后，玩家数量记作-1，区分于空服务器状态
-		playerCount.Store(-1)
-		playerCountBroker.Publish(-1)
+		s.playerCount.Store(-1)
+		s.playerCountBroker.Publish(-1)
 
-		onlinePlayersMu.Lock()
-		onlinePlayers = []string{}
-		onlinePlayersMu.Unlock()
+		s.onlinePlayersMu.Lock()
+		s.onlinePlayers = []string{}
+		s.onlinePlayersMu.Unlock()
 
-		onlinePlayersBroker.Publish(onlinePlayers)
+		s.onlinePlayersBroker.Publish([]string{})
 	}
 }
 
-func ServerStatus(quit chan bool) {
-	var err error
+func (s *serverStatusState) setOnlinePlayers(players []string) {
+	s.onlinePlayersMu.Lock()
+	s.onlinePlayers = players
+	s.onlinePlayersMu.Unlock()
+	s.onlinePlayersBroker.Publish(players)
+}
 
+// ServerStatus 监控档案 profile 的服务器状态、在线人数和在线玩家列表
+func ServerStatus(profile string, quit chan bool) {
 	cfg := config.Cfg.Monitor.ServerStatus
 	ticker := time.NewTicker(cfg.IntervalDuration())
 
-	logger := filelog.NewLogger("server-status", "ServerStatus")
+	logger := profileLogger("server-status", "ServerStatus", profile)
 	logger.Println("starting...")
 
-	playerCount.Store(-1) // default value, server not online
+	profileCfg, _ := config.Cfg.GetProfile(profile)
+	s := stateOf(profile).serverStatus
 
-	go isServerRunningBroker.Start()
-	go onlinePlayersBroker.Start()
-	go playerCountBroker.Start()
-	go syncServerStatusWithUser()
-	go syncOnlineCountWithUser()
-	go syncOnlinePlayersWithUser()
+	go s.isServerRunningBroker.Start()
+	go s.onlinePlayersBroker.Start()
+	go s.playerCountBroker.Start()
+	go syncServerStatusWithUser(profile, s)
+	go syncOnlineCountWithUser(profile, s)
+	go syncOnlinePlayersWithUser(profile, s)
+	go recordPlayerSessions(profile, s, logger)
+	go recordStatusSamples(profile, logger)
 
 	for {
 		select {
@@ -147,23 +200,25 @@ func ServerStatus(quit chan bool) {
 				ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutDuration())
 				defer cancel()
 
-				currentInstanceStatus := SnapshotInstanceStatus()
-				currentInstanceIp := SnapshotInstanceIp()
+				currentInstanceStatus := SnapshotInstanceStatus(profile)
+				currentInstanceIp := SnapshotInstanceIp(profile)
 
 				if currentInstanceStatus != consts.InstanceRunning || currentInstanceIp == "" {
-					if isServerRunning.Load() == true {
-						setServerStatus(false)
+					if s.isServerRunning.Load() == true {
+						s.setServerStatus(false)
 					}
 					return
 				}
 
-				serverStatusMu.Lock()
-				serverStatus, err = status.Modern(ctx, currentInstanceIp, config.Cfg.GetGamePort())
-				serverStatusMu.Unlock()
+				serverStatus, err := status.Modern(ctx, currentInstanceIp, profileCfg.GetGamePort())
+
+				s.serverStatusMu.Lock()
+				s.serverStatus = serverStatus
+				s.serverStatusMu.Unlock()
 
 				if err != nil {
-					if isServerRunning.Load() == true {
-						setServerStatus(false)
+					if s.isServerRunning.Load() == true {
+						s.setServerStatus(false)
 					}
 					return
 				}
@@ -171,35 +226,29 @@ func ServerStatus(quit chan bool) {
 				if serverStatus.Players.Online == nil {
 					log.Println("warn: unexpected online player count being nil")
 				} else {
-					if playerCount.Load() != *serverStatus.Players.Online {
-						playerCount.Store(*serverStatus.Players.Online)
-						playerCountBroker.Publish(playerCount.Load())
+					if s.playerCount.Load() != *serverStatus.Players.Online {
+						s.playerCount.Store(*serverStatus.Players.Online)
+						s.playerCountBroker.Publish(s.playerCount.Load())
 					}
 
-					if playerCount.Load() > 0 {
-						queryFull, err := query.Full(ctx, currentInstanceIp, config.Cfg.GetGamePort())
+					if s.playerCount.Load() > 0 {
+						queryFull, err := query.Full(ctx, currentInstanceIp, profileCfg.GetGamePort())
 
 						if err != nil {
 							log.Println("cannot query full:", err)
+							prom.MonitorErrors.Inc("server-status", profile)
 						} else {
-							// rlock here?
-							if !helpers.SameStringSlice(onlinePlayers, queryFull.Players) {
-								onlinePlayersMu.Lock()
-								onlinePlayers = queryFull.Players
-								onlinePlayersMu.Unlock()
-								onlinePlayersBroker.Publish(onlinePlayers)
+							if !helpers.SameStringSlice(SnapshotOnlinePlayers(profile), queryFull.Players) {
+								s.setOnlinePlayers(queryFull.Players)
 							}
 						}
-					} else if len(onlinePlayers) > 0 {
-						onlinePlayersMu.Lock()
-						onlinePlayers = []string{}
-						onlinePlayersMu.Unlock()
-						onlinePlayersBroker.Publish(onlinePlayers)
+					} else if len(SnapshotOnlinePlayers(profile)) > 0 {
+						s.setOnlinePlayers([]string{})
 					}
 				}
 
-				if isServerRunning.Load() == false {
-					setServerStatus(true)
+				if s.isServerRunning.Load() == false {
+					s.setServerStatus(true)
 				}
 			}()
 
@@ -0,0 +1,168 @@
+package monitors
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/clients"
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/consts"
+	"github.com/Subilan/go-aliyunmc/events"
+	"github.com/Subilan/go-aliyunmc/events/stream"
+	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+	ecs20140526 "github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// SpotInterruptionNotice 是 events.InstanceEventSpotInterruption 事件的载荷
+type SpotInterruptionNotice struct {
+	InstanceId string `json:"instanceId"`
+	EventId    string `json:"eventId"`
+	EventType  string `json:"eventType"`
+	// NotBefore 是实例预计被回收的时间，格式与阿里云接口返回值一致
+	NotBefore string `json:"notBefore"`
+	// Action 是系统针对该通知执行的紧急操作，参见 config.SpotInterruption
+	Action string `json:"action"`
+}
+
+// describeSpotInterruptionNotices 查询实例 instanceId 尚未执行的系统事件，返回其中被视为抢占式实例中断通知的部分
+func describeSpotInterruptionNotices(ctx context.Context, instanceId string) ([]SpotInterruptionNotice, error) {
+	cfg := config.Cfg.Monitor.SpotInterruption
+
+	describeInstanceHistoryEventsRequest := &ecs20140526.DescribeInstanceHistoryEventsRequest{
+		RegionId:                 tea.String(config.Cfg.Aliyun.RegionId),
+		InstanceId:               tea.String(instanceId),
+		InstanceEventCycleStatus: tea.StringSlice([]string{"Scheduled", "Executing", "Inquiring"}),
+	}
+
+	describeInstanceHistoryEventsResponse, err := clients.EcsClient.DescribeInstanceHistoryEventsWithContext(ctx, describeInstanceHistoryEventsRequest, &dara.RuntimeOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result = make([]SpotInterruptionNotice, 0, 1)
+
+	if describeInstanceHistoryEventsResponse.Body.InstanceSystemEventSet == nil {
+		return result, nil
+	}
+
+	for _, event := range describeInstanceHistoryEventsResponse.Body.InstanceSystemEventSet.InstanceSystemEventType {
+		if event.EventType == nil || !slices.Contains(cfg.EventTypes, tea.StringValue(event.EventType.Name)) {
+			continue
+		}
+
+		result = append(result, SpotInterruptionNotice{
+			InstanceId: instanceId,
+			EventId:    tea.StringValue(event.EventId),
+			EventType:  tea.StringValue(event.EventType.Name),
+			NotBefore:  tea.StringValue(event.NotBefore),
+			Action:     cfg.Action,
+		})
+	}
+
+	return result, nil
+}
+
+// handleSpotInterruption 响应一次中断通知：向网页端和游戏内的玩家发出警告，然后执行紧急备份或归档
+func handleSpotInterruption(profile string, inst *store.Instance, notice SpotInterruptionNotice, logger *log.Logger) {
+	event := events.Instance(profile, events.InstanceEventSpotInterruption, notice, true)
+	err := stream.BroadcastAndSave(event)
+
+	if err != nil {
+		logger.Println("cannot broadcast and save event:", err)
+		prom.MonitorErrors.Inc("spot-interruption", profile)
+	}
+
+	warnCmd := commands.MustGetCommand(consts.CmdTypeWarnSpotInterruption)
+
+	func() {
+		ctx, cancel := warnCmd.DefaultContext()
+		defer cancel()
+
+		if _, err := warnCmd.RunWithoutCooldown(ctx, inst, nil, nil); err != nil {
+			logger.Println("cannot warn players in game:", err)
+			prom.MonitorErrors.Inc("spot-interruption", profile)
+		}
+	}()
+
+	comment := "Spot instance interruption notice " + notice.EventId
+
+	switch notice.Action {
+	case config.SpotInterruptionActionArchive:
+		ctx, cancel := context.WithTimeout(context.Background(), consts.StopAndArchiveTimeout)
+		defer cancel()
+
+		err = commands.StopAndArchiveServer(ctx, inst, nil, comment)
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), config.Cfg.Monitor.Backup.TimeoutDuration())
+		defer cancel()
+
+		_, err = commands.MustGetCommand(consts.CmdTypeBackupWorlds).RunWithoutCooldown(ctx, inst, nil, &commands.CommandRunOption{Comment: comment})
+	}
+
+	if err != nil {
+		logger.Printf("emergency %s failed: %v", notice.Action, err)
+		prom.MonitorErrors.Inc("spot-interruption", profile)
+		return
+	}
+
+	logger.Printf("emergency %s finished", notice.Action)
+}
+
+// SpotInterruption 监控档案 profile 的活动实例是否收到抢占式实例中断通知。收到通知后，立即警告玩家并执行紧急备份或归档，而不是等到实例被回收后才由 ActiveInstance 发现。
+func SpotInterruption(profile string, quit chan bool) {
+	cfg := config.Cfg.Monitor.SpotInterruption
+	logger := profileLogger("spot-interruption", "SpotInterruption", profile)
+	logger.Println("starting...")
+
+	ticker := time.NewTicker(cfg.IntervalDuration())
+	defer ticker.Stop()
+
+	// handled 记录已经响应过的事件，避免同一通知被重复处理
+	handled := make(map[string]bool)
+
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				activeInstance, err := store.GetDeployedActiveInstance(profile)
+
+				if err != nil {
+					return
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutDuration())
+				defer cancel()
+
+				notices, err := describeSpotInterruptionNotices(ctx, activeInstance.InstanceId)
+
+				if err != nil {
+					logger.Println("cannot describe instance history events:", err)
+					prom.MonitorErrors.Inc("spot-interruption", profile)
+					return
+				}
+
+				for _, notice := range notices {
+					if handled[notice.EventId] {
+						continue
+					}
+
+					handled[notice.EventId] = true
+
+					logger.Printf("received spot interruption notice %s (%s) for instance %s, not before %s", notice.EventId, notice.EventType, notice.InstanceId, notice.NotBefore)
+
+					handleSpotInterruption(profile, activeInstance, notice, logger)
+				}
+			}()
+
+		case <-quit:
+			return
+		}
+	}
+}
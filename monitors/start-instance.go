@@ -1,29 +1,33 @@
 package monitors
 
 import (
+	"context"
 	"time"
 
 	"github.com/Subilan/go-aliyunmc/clients"
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/consts"
-	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers/db"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	"github.com/alibabacloud-go/ecs-20140526/v7/client"
+	"github.com/alibabacloud-go/tea/dara"
 	"github.com/alibabacloud-go/tea/tea"
 )
 
-func StartActiveInstanceWhenReady() {
+// StartActiveInstanceWhenReady 等待档案 profile 的活动实例就绪后将其启动
+func StartActiveInstanceWhenReady(profile string) {
 	var err error
 
-	logger := filelog.NewLogger("start-active-instance-when-ready", "StartActiveInstanceWhenReady")
+	logger := profileLogger("start-active-instance-when-ready", "StartActiveInstanceWhenReady", profile)
 	cfg := config.Cfg.Monitor.StartInstance
 
 	var instanceId string
 
-	err = db.Pool.QueryRow("SELECT instance_id FROM instances WHERE deleted_at IS NULL").Scan(&instanceId)
+	err = db.Pool.QueryRow("SELECT instance_id FROM instances WHERE profile = ? AND deleted_at IS NULL", profile).Scan(&instanceId)
 
 	if err != nil {
 		logger.Println("Error getting instance id:", err)
+		prom.MonitorErrors.Inc("start-active-instance-when-ready", profile)
 		return
 	}
 
@@ -33,15 +37,16 @@ func StartActiveInstanceWhenReady() {
 	for {
 		select {
 		case <-ticker.C:
-			if SnapshotInstanceStatus() != consts.InstanceStopped || SnapshotInstanceIp() == "" {
+			if SnapshotInstanceStatus(profile) != consts.InstanceStopped || SnapshotInstanceIp(profile) == "" {
 				logger.Println("instance not ready, retry in", cfg.IntervalDuration())
 				continue
 			}
 
-			_, err = clients.EcsClient.StartInstance(&client.StartInstanceRequest{InstanceId: tea.String(instanceId)})
+			_, err = clients.EcsClient.StartInstanceWithContext(context.Background(), &client.StartInstanceRequest{InstanceId: tea.String(instanceId)}, &dara.RuntimeOptions{})
 
 			if err != nil {
 				logger.Println("cannot start instance in StartActiveInstanceWhenReady monitor")
+				prom.MonitorErrors.Inc("start-active-instance-when-ready", profile)
 			}
 
 			logger.Println("successfully triggered instance start")
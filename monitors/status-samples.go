@@ -0,0 +1,63 @@
+package monitors
+
+import (
+	"log"
+	"time"
+
+	"github.com/Subilan/go-aliyunmc/config"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
+	"github.com/Subilan/go-aliyunmc/helpers/store"
+)
+
+// statusSampleInterval 是记录服务器状态采样的间隔
+const statusSampleInterval = time.Minute
+
+// statusSampleCleanupInterval 是删除过期状态采样的间隔
+const statusSampleCleanupInterval = time.Hour
+
+// sampleServerStatus 返回档案 profile 当前的服务器状态采样
+func sampleServerStatus(profile string, at time.Time) *store.ServerStatusSample {
+	sample := &store.ServerStatusSample{SampledAt: at, Running: SnapshotIsServerRunning(profile)}
+
+	if !sample.Running {
+		return sample
+	}
+
+	if count := SnapshotPlayerCount(profile); count >= 0 {
+		sample.PlayerCount = &count
+	}
+
+	if status := SnapshotServerStatus(profile); status != nil {
+		latency := status.Latency.Milliseconds()
+		sample.LatencyMs = &latency
+	}
+
+	return sample
+}
+
+// recordStatusSamples 每分钟记录一次档案 profile 的在线人数、运行状态和延迟，并定期删除超过保留时间的采样，参见 store.ServerStatusSample
+func recordStatusSamples(profile string, logger *log.Logger) {
+	retention := config.Cfg.Monitor.ServerStatus.SampleRetentionDuration()
+
+	ticker := time.NewTicker(statusSampleInterval)
+	defer ticker.Stop()
+
+	cleanupTicker := time.NewTicker(statusSampleCleanupInterval)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := store.InsertServerStatusSample(profile, sampleServerStatus(profile, now)); err != nil {
+				logger.Println("cannot insert server status sample:", err)
+				prom.MonitorErrors.Inc("server-status", profile)
+			}
+
+		case now := <-cleanupTicker.C:
+			if err := store.DeleteServerStatusSamplesBefore(profile, now.Add(-retention)); err != nil {
+				logger.Println("cannot delete expired server status samples:", err)
+				prom.MonitorErrors.Inc("server-status", profile)
+			}
+		}
+	}
+}
@@ -9,37 +9,45 @@ import (
 
 	"github.com/Subilan/go-aliyunmc/config"
 	"github.com/Subilan/go-aliyunmc/consts"
-	"github.com/Subilan/go-aliyunmc/filelog"
 	"github.com/Subilan/go-aliyunmc/helpers/commands"
+	"github.com/Subilan/go-aliyunmc/helpers/prom"
 	"github.com/Subilan/go-aliyunmc/helpers/store"
 )
 
-var whitelist []store.WhitelistItem
+// whitelistState 是一个档案的白名单状态
+type whitelistState struct {
+	whitelist []store.WhitelistItem
+}
 
-func Whitelist(quit chan bool) {
-	logger := filelog.NewLogger("whitelist", "Whitelist")
+// Whitelist 定期从档案 profile 的服务器获取白名单并写入该档案的缓存文件
+func Whitelist(profile string, quit chan bool) {
+	logger := profileLogger("whitelist", "Whitelist", profile)
 	logger.Println("starting...")
 	ticker := time.NewTicker(config.Cfg.Monitor.Whitelist.IntervalDuration())
 
 	cmd := commands.MustGetCommand(consts.CmdTypeGetWhitelist)
 
-	cacheFileContent, err := os.ReadFile(config.Cfg.Monitor.Whitelist.CacheFile)
+	s := stateOf(profile).whitelist
+	cacheFile := config.Cfg.Monitor.Whitelist.ProfileCacheFile(profile)
+
+	cacheFileContent, err := os.ReadFile(cacheFile)
 
 	if err != nil {
 		logger.Println("cannot read whitelist cache file")
 	} else {
-		err = json.Unmarshal(cacheFileContent, &whitelist)
+		err = json.Unmarshal(cacheFileContent, &s.whitelist)
 
 		if err != nil {
 			logger.Println("cannot parse whitelist cache file")
+			prom.MonitorErrors.Inc("whitelist", profile)
 		} else {
-			logger.Printf("loaded whitelist cache file with %d records", len(whitelist))
+			logger.Printf("loaded whitelist cache file with %d records", len(s.whitelist))
 		}
 	}
 
 	for {
 		func() {
-			activeInstance, err := store.GetDeployedActiveInstance()
+			activeInstance, err := store.GetDeployedActiveInstance(profile)
 
 			if err != nil {
 				return
@@ -49,10 +57,11 @@ func Whitelist(quit chan bool) {
 			defer cancel()
 			logger.Println("refreshing...")
 
-			output, err := cmd.RunWithoutCooldown(ctx, *activeInstance.Ip, nil, nil)
+			output, err := cmd.RunWithoutCooldown(ctx, activeInstance, nil, nil)
 
 			if err != nil {
 				logger.Println("cannot get whitelist: " + err.Error())
+				prom.MonitorErrors.Inc("whitelist", profile)
 				return
 			}
 
@@ -60,11 +69,14 @@ func Whitelist(quit chan bool) {
 
 			if err := json.Unmarshal([]byte(output), &result); err != nil {
 				logger.Println("cannot unmarshal whitelist: " + err.Error())
+				prom.MonitorErrors.Inc("whitelist", profile)
 				return
 			}
 
 			isSame := false
 
+			whitelist := s.whitelist
+
 			if len(result) == len(whitelist) {
 				isSame = true
 
@@ -92,12 +104,13 @@ func Whitelist(quit chan bool) {
 			if !isSame {
 				logger.Println("whitelist has changed")
 
-				whitelist = result
+				s.whitelist = result
 				logger.Println("updating cache file")
-				err = os.WriteFile(config.Cfg.Monitor.Whitelist.CacheFile, []byte(output), 0644)
+				err = os.WriteFile(cacheFile, []byte(output), 0644)
 
 				if err != nil {
 					logger.Println("cannot write whitelist: " + err.Error())
+					prom.MonitorErrors.Inc("whitelist", profile)
 				} else {
 					logger.Println("ok")
 				}
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS baked_images
+(
+    -- 镜像的标识符，由阿里云返回，在这里也用作主键
+    image_id    VARCHAR(50) PRIMARY KEY,
+
+    -- 镜像内容的指纹，由基础镜像、需要安装的包和 Java 版本决定。与当前配置指纹不同的镜像已失效
+    fingerprint VARCHAR(16) NOT NULL,
+
+    -- 制作镜像所用的实例
+    instance_id VARCHAR(50) NOT NULL,
+
+    -- 镜像所依赖的系统盘快照，镜像删除后一并删除
+    snapshot_id VARCHAR(50) NOT NULL,
+
+    -- 镜像的状态，取值为 creating、available 或 failed
+    status      VARCHAR(20) NOT NULL DEFAULT 'creating',
+
+    -- 镜像被创建的时间
+    created_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- 镜像被删除的时间。如果镜像没有被删除，为空
+    deleted_at  TIMESTAMP            DEFAULT NULL,
+
+    INDEX `idx_fingerprint_deleted_at` (`fingerprint`, `deleted_at`)
+);
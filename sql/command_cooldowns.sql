@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS command_cooldowns
+(
+    -- 指令所属的档案
+    profile      VARCHAR(20)  NOT NULL,
+
+    -- 指令类型
+    command_type VARCHAR(32)  NOT NULL,
+
+    -- 频率限制的标识符，参见 config.CommandLimitConfig.Key
+    limit_key    VARCHAR(64)  NOT NULL,
+
+    -- 限制作用的对象。全局限制为空，用户限制为用户ID，权限等级限制为权限等级
+    subject      VARCHAR(20)  NOT NULL DEFAULT '',
+
+    -- 在 updated_at 时剩余的执行次数，可以为小数
+    tokens       DOUBLE       NOT NULL,
+
+    -- 剩余执行次数被更新的时间
+    updated_at   DATETIME(3)  NOT NULL,
+
+    PRIMARY KEY (`profile`, `command_type`, `limit_key`, `subject`)
+);
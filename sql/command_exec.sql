@@ -1,12 +1,20 @@
 CREATE TABLE IF NOT EXISTS command_exec
 (
-    `id`         INT AUTO_INCREMENT PRIMARY KEY,
-    `type`       VARCHAR(20) NOT NULL COMMENT '指令类型',
-    `by`         INT COMMENT '执行者',
-    `auto`       TINYINT(1)  NOT NULL DEFAULT 0 COMMENT '是否为自动执行',
-    `created_at` TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    `updated_at` TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    `status`     VARCHAR(20) NOT NULL COMMENT '执行状态',
-    `comment`    TEXT COMMENT '注释',
+    `id`          INT AUTO_INCREMENT PRIMARY KEY,
+    `type`        VARCHAR(32)  NOT NULL COMMENT '指令类型',
+    `profile`     VARCHAR(20)  NOT NULL DEFAULT 'default' COMMENT '执行指令的档案',
+    `by`          INT COMMENT '执行者',
+    `auto`        TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '是否为自动执行',
+    `created_at`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at`  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `status`      VARCHAR(20)  NOT NULL COMMENT '执行状态',
+    `comment`     TEXT COMMENT '注释',
+    `args`        TEXT COMMENT '指令参数，JSON格式',
+    `instance_id` VARCHAR(50) COMMENT '执行指令的实例ID',
+    `stdout`      MEDIUMTEXT COMMENT '标准输出，超出长度时只保留最后的部分',
+    `stderr`      MEDIUMTEXT COMMENT '标准错误，超出长度时只保留最后的部分',
+    `exit_code`   INT COMMENT '退出码，仅在Shell中执行时记录',
+    `started_at`  TIMESTAMP(3) NULL COMMENT '开始执行的时间',
+    `finished_at` TIMESTAMP(3) NULL COMMENT '执行结束的时间',
     FOREIGN KEY (`by`) REFERENCES `users` (`id`)
 )
\ No newline at end of file
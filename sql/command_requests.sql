@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS command_requests
+(
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+
+    -- 请求执行指令的档案
+    profile      VARCHAR(20)  NOT NULL,
+
+    -- 请求执行的指令类型
+    command_type VARCHAR(32)  NOT NULL,
+
+    -- 指令参数，JSON格式
+    args         TEXT,
+
+    -- 请求的理由
+    reason       VARCHAR(200) NOT NULL DEFAULT '',
+
+    -- 提出请求的用户，指令以该用户的身份执行
+    created_by   INT          NOT NULL,
+
+    -- 请求的状态，可以为pending、approved、rejected、expired或failed
+    status       VARCHAR(20)  NOT NULL DEFAULT 'pending',
+
+    -- 批准或否决请求的管理员。由投票得到结果时为空
+    decided_by   INT                   DEFAULT NULL,
+
+    -- 结果的说明，例如管理员的备注或者指令无法执行的原因
+    comment      TEXT,
+
+    -- 请求通过后执行指令的任务标识符
+    task_id      VARCHAR(36)           DEFAULT NULL,
+
+    -- 请求失效的时间
+    expires_at   TIMESTAMP    NOT NULL,
+
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (`created_by`) REFERENCES `users` (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS command_request_votes
+(
+    -- 投票的请求
+    request_id INT        NOT NULL,
+
+    -- 投票的用户
+    user_id    INT        NOT NULL,
+
+    -- 是否赞成
+    approve    TINYINT(1) NOT NULL,
+
+    created_at TIMESTAMP  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (`request_id`, `user_id`),
+    FOREIGN KEY (`request_id`) REFERENCES `command_requests` (`id`) ON DELETE CASCADE
+);
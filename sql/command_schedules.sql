@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS command_schedules
+(
+    -- 计划的名称，同时是计划的标识符，不能与配置文件中的定时指令计划重名
+    name         VARCHAR(20)  PRIMARY KEY,
+
+    -- 计划作用的档案
+    profile      VARCHAR(20)  NOT NULL,
+
+    -- 运行时间的 cron 表达式
+    cron         VARCHAR(100) NOT NULL,
+
+    -- 要运行的指令类型，与 message 二选一
+    command_type VARCHAR(32)  NOT NULL DEFAULT '',
+
+    -- 运行指令时传入的参数，JSON格式
+    args         TEXT,
+
+    -- 在服务器内广播的消息，与 command_type 二选一
+    message      VARCHAR(200) NOT NULL DEFAULT '',
+
+    -- 添加计划的用户
+    created_by   INT                   DEFAULT NULL,
+
+    -- 计划被添加的时间
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS crash_incidents
+(
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+    -- 故障所属的档案
+    profile     VARCHAR(20) NOT NULL,
+
+    -- 服务器退出时的活动实例
+    instance_id VARCHAR(50) NOT NULL,
+
+    -- 发现服务器意外退出的时间
+    detected_at DATETIME    NOT NULL,
+
+    -- 崩溃报告或者服务器退出前最后的日志
+    report      MEDIUMTEXT           DEFAULT NULL,
+
+    -- 已经尝试重启的次数
+    attempts    INT         NOT NULL DEFAULT 0,
+
+    -- 故障的处理状态，取值为 restarting、recovered、gave_up 或 aborted
+    status      VARCHAR(12) NOT NULL,
+
+    -- 故障处理结束的时间
+    resolved_at DATETIME             DEFAULT NULL,
+
+    INDEX `idx_profile_detected_at` (`profile`, `detected_at`)
+);
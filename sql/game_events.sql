@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS game_events
+(
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+    -- 事件所属的档案
+    profile     VARCHAR(20) NOT NULL,
+
+    -- 事件的类型，取值为 chat、join、leave、death、advancement 或 warning，参见 gamelog.KindChat 等
+    kind        VARCHAR(12) NOT NULL,
+
+    -- 事件相关的玩家，服务器警告可能为空
+    player      VARCHAR(16)          DEFAULT NULL,
+
+    -- 玩家进入服务器时的IP地址
+    ip          VARCHAR(45)          DEFAULT NULL,
+
+    -- 聊天内容、死亡信息、进度名称或者警告内容
+    message     TEXT                 DEFAULT NULL,
+
+    -- 系统读取到该行日志的时间
+    occurred_at DATETIME    NOT NULL,
+
+    INDEX `idx_profile_occurred_at` (`profile`, `occurred_at`),
+    INDEX `idx_profile_kind_occurred_at` (`profile`, `kind`, `occurred_at`),
+    INDEX `idx_profile_player` (`profile`, `player`)
+);
@@ -3,6 +3,9 @@ CREATE TABLE IF NOT EXISTS instances
     -- 实例的标识符，由阿里云返回，在这里也用作主键
     instance_id   VARCHAR(50) PRIMARY KEY,
 
+    -- 实例所属的档案
+    profile       VARCHAR(20) NOT NULL DEFAULT 'default',
+
     -- 实例类型
     instance_type VARCHAR(20) NOT NULL,
 
@@ -25,5 +28,16 @@ CREATE TABLE IF NOT EXISTS instances
     created_at    TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
 
     -- 是否已部署
-    deployed      TINYINT(1)  NOT NULL DEFAULT 0
+    deployed      TINYINT(1)  NOT NULL DEFAULT 0,
+
+    -- 实例进入休眠（节省停机）的时间。如果实例没有休眠，为空
+    hibernated_at TIMESTAMP            DEFAULT NULL,
+
+    -- 实例数据盘的源快照。如果数据盘不是从快照创建的，为空
+    snapshot_id   VARCHAR(50)          DEFAULT NULL,
+
+    -- 实例启动所用的预制镜像。如果实例从基础镜像启动，为空
+    baked_image_id VARCHAR(50)         DEFAULT NULL,
+
+    INDEX `idx_profile_deleted_at` (`profile`, `deleted_at`)
 );
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- 为引入档案之前创建的数据库添加档案相关的列。已有的实例、指令执行记录和任务均归入默认档案 default。
+-- 新建的数据库直接使用 sql 目录下的建表语句即可，无需执行本脚本。
+
+ALTER TABLE instances
+    ADD COLUMN profile VARCHAR(20) NOT NULL DEFAULT 'default' AFTER instance_id,
+    ADD INDEX `idx_profile_deleted_at` (`profile`, `deleted_at`);
+
+ALTER TABLE command_exec
+    ADD COLUMN `profile` VARCHAR(20) NOT NULL DEFAULT 'default' COMMENT '执行指令的档案' AFTER `type`;
+
+ALTER TABLE `tasks`
+    ADD COLUMN `profile` VARCHAR(20) COMMENT '任务关联的档案' AFTER `user_id`;
+
+UPDATE `tasks`
+SET `profile` = 'default'
+WHERE `profile` IS NULL;
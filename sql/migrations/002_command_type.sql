@@ -0,0 +1,5 @@
+-- 加宽指令执行记录中的指令类型列，以容纳 warn_spot_interruption 等超过20个字符的指令类型。
+-- 长度与 commands.toml 中 type 的长度限制一致。
+
+ALTER TABLE command_exec
+    MODIFY COLUMN `type` VARCHAR(32) NOT NULL COMMENT '指令类型';
@@ -0,0 +1,4 @@
+-- 为引入休眠之前创建的数据库添加实例的休眠时间。已有的实例均视为没有休眠。
+
+ALTER TABLE instances
+    ADD COLUMN hibernated_at TIMESTAMP DEFAULT NULL AFTER deployed;
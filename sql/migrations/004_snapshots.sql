@@ -0,0 +1,5 @@
+-- 为引入快照归档之前创建的数据库添加实例数据盘的源快照。已有实例的数据盘均视为不是从快照创建的。
+-- snapshots 表是新增的表，直接执行 sql 目录下的建表语句即可。
+
+ALTER TABLE instances
+    ADD COLUMN snapshot_id VARCHAR(50) DEFAULT NULL AFTER hibernated_at;
@@ -0,0 +1,5 @@
+-- 为引入预制镜像之前创建的数据库添加实例启动所用的预制镜像。已有的实例均视为从基础镜像启动。
+-- baked_images 表是新增的表，直接执行 sql 目录下的建表语句即可。
+
+ALTER TABLE instances
+    ADD COLUMN baked_image_id VARCHAR(50) DEFAULT NULL AFTER snapshot_id;
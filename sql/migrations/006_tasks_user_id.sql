@@ -0,0 +1,4 @@
+-- 允许任务不关联用户。定时开放和自动迁移等由系统自动发起的任务没有发起用户。
+
+ALTER TABLE `tasks`
+    MODIFY COLUMN `user_id` INT DEFAULT NULL COMMENT '发起任务的用户，由系统自动发起时为空';
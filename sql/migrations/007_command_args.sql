@@ -0,0 +1,4 @@
+-- 为引入指令参数之前创建的数据库添加指令执行记录的参数。已有的记录均视为没有参数。
+
+ALTER TABLE command_exec
+    ADD COLUMN `args` TEXT COMMENT '指令参数，JSON格式' AFTER `comment`;
@@ -0,0 +1,9 @@
+-- 为记录指令输出之前创建的数据库添加指令执行记录的实例、输出、退出码和执行时间。已有的记录均视为没有这些信息。
+
+ALTER TABLE command_exec
+    ADD COLUMN `instance_id` VARCHAR(50) COMMENT '执行指令的实例ID' AFTER `args`,
+    ADD COLUMN `stdout`      MEDIUMTEXT COMMENT '标准输出，超出长度时只保留最后的部分' AFTER `instance_id`,
+    ADD COLUMN `stderr`      MEDIUMTEXT COMMENT '标准错误，超出长度时只保留最后的部分' AFTER `stdout`,
+    ADD COLUMN `exit_code`   INT COMMENT '退出码，仅在Shell中执行时记录' AFTER `stderr`,
+    ADD COLUMN `started_at`  TIMESTAMP(3) NULL COMMENT '开始执行的时间' AFTER `exit_code`,
+    ADD COLUMN `finished_at` TIMESTAMP(3) NULL COMMENT '执行结束的时间' AFTER `started_at`;
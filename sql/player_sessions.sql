@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS player_sessions
+(
+    id        BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+    -- 会话所在的档案
+    profile   VARCHAR(20) NOT NULL,
+
+    -- 玩家的游戏名
+    game_id   VARCHAR(20) NOT NULL,
+
+    -- 玩家进入服务器时绑定了该游戏名的用户，没有绑定时为空
+    user_id   INT                  DEFAULT NULL,
+
+    -- 玩家进入服务器的时间
+    joined_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- 最后一次确认玩家仍然在线的时间。系统异常退出时，未结束的会话在下次启动时以此时间结束
+    seen_at   TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- 玩家离开服务器的时间，为空表示仍然在线
+    left_at   TIMESTAMP   NULL     DEFAULT NULL,
+
+    FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE SET NULL,
+    INDEX `idx_profile_game_id` (`profile`, `game_id`),
+    INDEX `idx_profile_joined_at` (`profile`, `joined_at`)
+);
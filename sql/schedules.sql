@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS schedules
+(
+    -- 计划的名称，同时是计划的标识符，不能与配置文件中的计划重名
+    name       VARCHAR(20) PRIMARY KEY,
+
+    -- 计划作用的档案
+    profile    VARCHAR(20)  NOT NULL,
+
+    -- 开放时段开始时间的 cron 表达式
+    cron       VARCHAR(100) NOT NULL,
+
+    -- 开放时段的时长，单位分钟
+    duration   INT          NOT NULL,
+
+    -- 提前创建并部署实例的时间，单位分钟
+    lead_time  INT          NOT NULL DEFAULT 0,
+
+    -- 开放时段结束前警告玩家的时间点，单位分钟，以逗号分隔
+    warnings   VARCHAR(100) NOT NULL DEFAULT '',
+
+    -- 开放时段内是否阻止空转关闭
+    protected  TINYINT(1)   NOT NULL DEFAULT 0,
+
+    -- 添加计划的用户
+    created_by INT                   DEFAULT NULL,
+
+    -- 计划被添加的时间
+    created_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS schedule_skips
+(
+    -- 被跳过的计划名称
+    schedule_name VARCHAR(20) NOT NULL,
+
+    -- 被跳过的开放时段的开始时间
+    starts_at     TIMESTAMP   NOT NULL,
+
+    -- 跳过该时段的用户
+    created_by    INT                  DEFAULT NULL,
+
+    -- 记录被插入的时间
+    created_at    TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (`schedule_name`, `starts_at`)
+);
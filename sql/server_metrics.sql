@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS server_metrics
+(
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+
+    -- 采样所属的档案
+    profile    VARCHAR(20) NOT NULL,
+
+    -- 采样的粒度，单位秒。原始采样为采样间隔，合并后的采样为合并的粒度
+    resolution INT         NOT NULL,
+
+    -- 采样的时间。合并后的采样为所在时间段的开始时间
+    sampled_at DATETIME    NOT NULL,
+
+    -- 服务器的 TPS 和 MSPT，服务器不在线或者不支持采集时为空
+    tps        DOUBLE               DEFAULT NULL,
+    mspt       DOUBLE               DEFAULT NULL,
+
+    -- 实例的 CPU 使用率（0-100）、内存（字节）、平均负载和数据盘空间（字节），采集失败时为空
+    cpu        DOUBLE               DEFAULT NULL,
+    mem_used   BIGINT               DEFAULT NULL,
+    mem_total  BIGINT               DEFAULT NULL,
+    load1      DOUBLE               DEFAULT NULL,
+    load5      DOUBLE               DEFAULT NULL,
+    load15     DOUBLE               DEFAULT NULL,
+    disk_used  BIGINT               DEFAULT NULL,
+    disk_total BIGINT               DEFAULT NULL,
+
+    INDEX `idx_profile_sampled_at` (`profile`, `sampled_at`)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS server_status_samples
+(
+    -- 采样所属的档案
+    profile      VARCHAR(20) NOT NULL,
+
+    -- 采样的时间，对齐到整分钟
+    sampled_at   DATETIME    NOT NULL,
+
+    -- 服务器是否在线
+    running      TINYINT(1)  NOT NULL,
+
+    -- 在线人数，服务器不在线时为空
+    player_count INT                  DEFAULT NULL,
+
+    -- 获取服务器状态的延迟，单位毫秒，服务器不在线时为空
+    latency_ms   INT                  DEFAULT NULL,
+
+    PRIMARY KEY (`profile`, `sampled_at`)
+);
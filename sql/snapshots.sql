@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS snapshots
+(
+    -- 快照的标识符，由阿里云返回，在这里也用作主键
+    snapshot_id VARCHAR(50) PRIMARY KEY,
+
+    -- 快照所属的档案
+    profile     VARCHAR(20) NOT NULL,
+
+    -- 创建快照时数据盘所在的实例
+    instance_id VARCHAR(50) NOT NULL,
+
+    -- 快照的源数据盘
+    disk_id     VARCHAR(50) NOT NULL,
+
+    -- 源数据盘的大小，单位 GiB。从快照创建的数据盘不能小于此大小
+    disk_size   INT         NOT NULL,
+
+    -- 快照被创建的时间
+    created_at  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- 快照被删除的时间。如果快照没有被删除，为空
+    deleted_at  TIMESTAMP            DEFAULT NULL,
+
+    INDEX `idx_profile_deleted_at` (`profile`, `deleted_at`)
+);
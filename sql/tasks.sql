@@ -2,7 +2,8 @@ CREATE TABLE IF NOT EXISTS `tasks`
 (
     `task_id`    VARCHAR(36) PRIMARY KEY,
     `type`       VARCHAR(20) NOT NULL COMMENT '任务类型',
-    `user_id`    INT         NOT NULL,
+    `user_id`    INT                  DEFAULT NULL COMMENT '发起任务的用户，由系统自动发起时为空',
+    `profile`    VARCHAR(20) COMMENT '任务关联的档案',
     `status`     VARCHAR(20) NOT NULL DEFAULT 'running' COMMENT '任务状态',
     `created_at` TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
     `updated_at` TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,